@@ -0,0 +1,83 @@
+// Package addr converts between traversals and their address strings, such
+// as aws_instance.web[0].id or var.tags["Name"], so every feature formats
+// and parses addresses the same way.
+package addr
+
+import (
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// String returns the address of a traversal. Attribute steps are written
+// with dots, index steps with brackets holding the key as an HCL literal,
+// and splat steps as [*].
+func String(traversal hcl.Traversal) string {
+	var b []byte
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			b = append(b, step.Name...)
+		case hcl.TraverseAttr:
+			b = append(b, '.')
+			b = append(b, step.Name...)
+		case hcl.TraverseIndex:
+			b = append(b, '[')
+			b = append(b, key(step.Key)...)
+			b = append(b, ']')
+		case hcl.TraverseSplat:
+			b = append(b, "[*]"...)
+		}
+	}
+	return string(b)
+}
+
+func key(val cty.Value) string {
+	switch {
+	case val.IsNull():
+		return "null"
+	case !val.IsKnown():
+		return "unknown"
+	case val.Type() == cty.Number:
+		return val.AsBigFloat().Text('f', -1)
+	default:
+		return string(hclwrite.TokensForValue(val).Bytes())
+	}
+}
+
+// Parse parses an address, as written by String, into a traversal.
+func Parse(address string) (hcl.Traversal, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(address), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parse address %q: %v", address, diags.Errs())
+	}
+	traversal, ok := traversal(expr)
+	if !ok || len(traversal) == 0 {
+		return nil, fmt.Errorf("parse address %q: not a traversal", address)
+	}
+	return traversal, nil
+}
+
+// traversal flattens expressions made up only of traversals and splats
+// into a single traversal. The traversal of an expression used as the
+// source of a splat is relative to each element, and so starts empty.
+func traversal(expr hclsyntax.Expression) (hcl.Traversal, bool) {
+	switch e := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		return e.Traversal, true
+	case *hclsyntax.RelativeTraversalExpr:
+		source, ok := traversal(e.Source)
+		return append(source, e.Traversal...), ok
+	case *hclsyntax.SplatExpr:
+		source, ok := traversal(e.Source)
+		each, eachOK := traversal(e.Each)
+		return append(append(source, hcl.TraverseSplat{}), each...), ok && eachOK
+	case *hclsyntax.AnonSymbolExpr:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
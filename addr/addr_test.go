@@ -0,0 +1,33 @@
+package addr
+
+import (
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, address := range []string{
+		"var.name",
+		"aws_instance.web[0].id",
+		`var.tags["Name"]`,
+		`local.map["a \"quoted\" key"].value`,
+		"data.aws_ami.ubuntu[12].tags",
+		"aws_instance.web[*].id",
+	} {
+		traversal, err := Parse(address)
+		if err != nil {
+			t.Errorf("parse %s: %v", address, err)
+			continue
+		}
+		if got := String(traversal); got != address {
+			t.Errorf("expected %s, got %s", address, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, address := range []string{"", "var.", "1abc", "var.x + 1"} {
+		if _, err := Parse(address); err == nil {
+			t.Errorf("expected %q to fail", address)
+		}
+	}
+}
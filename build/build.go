@@ -0,0 +1,101 @@
+// Package build generates formatted HCL from Go through a fluent API, for
+// programs that create configuration rather than convert it:
+//
+//	src, err := build.NewFile().
+//		Block("resource", "aws_s3_bucket", "logs").
+//		Attr("bucket", cty.StringVal("logs")).
+//		Ref("acl", "var.acl").
+//		End().
+//		Bytes()
+package build
+
+import (
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// File is an HCL file being built.
+type File struct {
+	*Body
+
+	file *hclwrite.File
+	err  error
+}
+
+// NewFile returns an empty file.
+func NewFile() *File {
+	f := &File{file: hclwrite.NewEmptyFile()}
+	f.Body = &Body{body: f.file.Body(), file: f}
+	return f
+}
+
+// Bytes returns the formatted source of the file, or the first error
+// encountered while building it.
+func (f *File) Bytes() ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return hclwrite.Format(f.file.Bytes()), nil
+}
+
+// Body is the body of a file or block. Its methods return a body so calls
+// can be chained; End returns to the enclosing body.
+type Body struct {
+	body   *hclwrite.Body
+	file   *File
+	parent *Body
+}
+
+// Attr sets the attribute name to a literal value.
+func (b *Body) Attr(name string, value cty.Value) *Body {
+	b.body.SetAttributeValue(name, value)
+	return b
+}
+
+// Ref sets the attribute name to a reference such as var.region or
+// aws_instance.web[0].id.
+func (b *Body) Ref(name string, ref string) *Body {
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(ref), "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		b.fail(fmt.Errorf("parse reference %q for %s: %v", ref, name, diags.Errs()))
+		return b
+	}
+	b.body.SetAttributeTraversal(name, traversal)
+	return b
+}
+
+// Block appends a block to the body and returns the body of the new block.
+func (b *Body) Block(typeName string, labels ...string) *Body {
+	block := b.body.AppendNewBlock(typeName, labels)
+	return &Body{body: block.Body(), file: b.file, parent: b}
+}
+
+// End returns the body enclosing the block. The body of a file has no
+// enclosing body, so it returns itself.
+func (b *Body) End() *Body {
+	if b.parent == nil {
+		return b
+	}
+	return b.parent
+}
+
+// File returns the file the body belongs to.
+func (b *Body) File() *File {
+	return b.file
+}
+
+// Bytes is a shorthand for File().Bytes(), so a chain of calls can end at
+// any depth.
+func (b *Body) Bytes() ([]byte, error) {
+	return b.file.Bytes()
+}
+
+func (b *Body) fail(err error) {
+	if b.file.err == nil {
+		b.file.err = err
+	}
+}
@@ -0,0 +1,46 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestBuild(t *testing.T) {
+	src, err := NewFile().
+		Attr("region", cty.StringVal("us-east-1")).
+		Block("resource", "aws_s3_bucket", "logs").
+		Attr("bucket", cty.StringVal("logs")).
+		Ref("acl", "var.acl").
+		Block("versioning").
+		Attr("enabled", cty.True).
+		End().
+		End().
+		Block("variable", "acl").
+		Bytes()
+	if err != nil {
+		t.Fatal("build:", err)
+	}
+
+	expected := `region = "us-east-1"
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  acl    = var.acl
+  versioning {
+    enabled = true
+  }
+}
+variable "acl" {
+}
+`
+	if string(src) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, src)
+	}
+}
+
+func TestBuildInvalidReference(t *testing.T) {
+	_, err := NewFile().Block("output", "x").Ref("value", "1 + 2").Bytes()
+	if err == nil {
+		t.Error("an invalid reference should return an error")
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ckndave/hclparser/convert"
+	"github.com/ckndave/hclparser/terraform"
+)
+
+// runConvert converts a file, or stdin when none is given, writing the JSON
+// to stdout and the line info to the file or file descriptor given by the
+// -lines or -lines-fd flags, the inferred type of each attribute to the
+// file given by -types, and the type map to the file given by -type-map.
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		schemas    string
+		files      resultFiles
+		terragrunt bool
+	)
+
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	flags.StringVar(&schemas, "provider-schemas", "", "file holding the output of terraform providers schema -json, used as the schema")
+	flags.StringVar(&files.lines, "lines", "", "file to write the line info to")
+	flags.IntVar(&files.linesFD, "lines-fd", -1, "file descriptor to write the line info to")
+	flags.StringVar(&files.types, "types", "", "file to write the inferred type of each attribute's expression to")
+	flags.StringVar(&files.typeMap, "type-map", "", "file to write the type of each attribute's value to, in the structure of the line info")
+	flags.BoolVar(&terragrunt, "terragrunt", false, "resolve the includes, locals and dependencies of a terragrunt.hcl, given relative to -fs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("at most one file may be converted")
+	}
+	if files.lines != "" && files.linesFD >= 0 {
+		return errors.New("-lines and -lines-fd can't be used together")
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+	options.InferTypes = files.types != ""
+	files.encoded = options.Encoder != nil
+	if files.typeMap != "" {
+		options.IncludeTypes = convert.TypesMap
+	}
+
+	if terragrunt {
+		if options.FS == nil || flags.NArg() == 0 {
+			return errors.New("-terragrunt needs -fs and a file within it")
+		}
+		result, err := convert.Terragrunt(flags.Arg(0), options)
+		if err != nil {
+			return err
+		}
+		return writeResult(result, files, stdout, stderr)
+	}
+
+	filename, src, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+
+	var source terraform.SchemaSource
+	if schemas != "" {
+		f, err := os.Open(schemas)
+		if err != nil {
+			return fmt.Errorf("open provider schemas: %w", err)
+		}
+		source, err = terraform.NewDumpSource(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("read provider schemas: %w", err)
+		}
+	}
+
+	result, err := convertTimeout(src, filename, source, options)
+	if err != nil {
+		return err
+	}
+	return writeResult(result, files, stdout, stderr)
+}
+
+// resultFiles are the files the parts of a result other than its JSON are
+// written to, each left unwritten when empty. encoded is whether the
+// result was written by an encoder, so is written as it is rather than
+// ending with a newline.
+type resultFiles struct {
+	lines   string
+	linesFD int
+	types   string
+	typeMap string
+	encoded bool
+}
+
+// writeResult writes the diagnostics of a result to stderr, its JSON to
+// stdout, and the rest of it to the files given.
+func writeResult(result *convert.Result, files resultFiles, stdout, stderr io.Writer) error {
+	for _, diag := range result.Diagnostics {
+		fmt.Fprintln(stderr, diag.Error())
+	}
+
+	finish := withNewline
+	if files.encoded {
+		finish = func(b []byte) []byte { return b }
+	}
+	if _, err := stdout.Write(finish(result.JSON)); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	switch {
+	case files.lines != "":
+		if err := ioutil.WriteFile(files.lines, finish(result.Lines), 0644); err != nil {
+			return fmt.Errorf("write line info: %w", err)
+		}
+	case files.linesFD >= 0:
+		f := os.NewFile(uintptr(files.linesFD), "lines")
+		if f == nil {
+			return fmt.Errorf("invalid file descriptor %d", files.linesFD)
+		}
+		defer f.Close()
+		if _, err := f.Write(finish(result.Lines)); err != nil {
+			return fmt.Errorf("write line info: %w", err)
+		}
+	}
+	if files.types != "" {
+		b, err := json.Marshal(result.Types)
+		if err != nil {
+			return fmt.Errorf("marshal types: %w", err)
+		}
+		if err := ioutil.WriteFile(files.types, append(b, '\n'), 0644); err != nil {
+			return fmt.Errorf("write types: %w", err)
+		}
+	}
+	if files.typeMap != "" {
+		if err := ioutil.WriteFile(files.typeMap, finish(result.TypeMap), 0644); err != nil {
+			return fmt.Errorf("write type map: %w", err)
+		}
+	}
+	return nil
+}
+
+// withNewline returns b ending with a newline, adding one unless it
+// already does, as output converted with Options.Canonical does.
+func withNewline(b []byte) []byte {
+	if bytes.HasSuffix(b, []byte("\n")) {
+		return b
+	}
+	return append(b, '\n')
+}
+
+// readInput reads the named file, or stdin when the name is empty or "-".
+func readInput(name string, stdin io.Reader) (string, []byte, error) {
+	if name == "" || name == "-" {
+		src, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return "", nil, fmt.Errorf("read stdin: %w", err)
+		}
+		return "STDIN", src, nil
+	}
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("read file: %w", err)
+	}
+	return name, src, nil
+}
+
+// convertTimeout converts src, using the provider schemas from source when
+// it's not nil, giving up after Options.FileTimeout.
+func convertTimeout(src []byte, filename string, source terraform.SchemaSource, options convert.Options) (*convert.Result, error) {
+	type outcome struct {
+		result *convert.Result
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		var o outcome
+		if source != nil {
+			o.result, o.err = terraform.Convert(src, filename, source, options)
+		} else {
+			o.result, o.err = convert.Convert(src, filename, options)
+		}
+		done <- o
+	}()
+
+	var timeout <-chan time.Time
+	if options.FileTimeout > 0 {
+		timeout = time.After(options.FileTimeout)
+	}
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-timeout:
+		return nil, &convert.TimeoutError{Timeout: options.FileTimeout}
+	}
+}
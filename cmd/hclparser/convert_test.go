@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertCommand(t *testing.T) {
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "lines.json")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader(`name = format("%s-1", var.name)
+password = "hunter2"
+`)
+	args := []string{
+		"-simplify",
+		"-var", `var={"name":"web"}`,
+		"-redact", "password",
+		"-lines", lines,
+	}
+	if err := runConvert(args, stdin, &stdout, &stderr); err != nil {
+		t.Fatal("convert:", err, stderr.String())
+	}
+
+	expected := `{"name":"web-1","password":"(redacted)"}` + "\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %s, got %s", expected, stdout.String())
+	}
+
+	lineInfo, err := ioutil.ReadFile(lines)
+	if err != nil {
+		t.Fatal("read line info:", err)
+	}
+	if !bytes.Contains(lineInfo, []byte(`"password":{`)) {
+		t.Errorf("expected line info for password, got %s", lineInfo)
+	}
+}
+
+func TestConvertCommandTypes(t *testing.T) {
+	types := filepath.Join(t.TempDir(), "types.json")
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("port = var.port + 1\n")
+	if err := runConvert([]string{"-types", types}, stdin, &stdout, &stderr); err != nil {
+		t.Fatal("convert:", err, stderr.String())
+	}
+	b, err := ioutil.ReadFile(types)
+	if err != nil {
+		t.Fatal("read types:", err)
+	}
+	if !bytes.Contains(b, []byte(`"attribute":"port","type":"number","constraint":"number"`)) {
+		t.Errorf("expected port to be a number, got %s", b)
+	}
+}
+
+func TestConvertCommandErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-lines", "a", "-lines-fd", "3"},
+		{"-now", "yesterday"},
+		{"-var", "novalue"},
+		{"a.tf", "b.tf"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if err := runConvert(args, strings.NewReader(""), &stdout, &stderr); err == nil {
+			t.Errorf("expected %q to fail", args)
+		}
+	}
+}
+
+func TestSelftestCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := runSelftest([]string{"-simplify"}, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatal("selftest:", err, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "ok    terraform.tf\n") {
+		t.Errorf("expected terraform.tf to pass, got %s", stdout.String())
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte("variable \"region\" {}\nlocals {\n  r = var.region\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := runSearch([]string{"-workspace", dir, "-reference", "var.*"}, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatal("search:", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"path": "locals.r"`) {
+		t.Errorf("expected the reference from locals.r, got %s", stdout.String())
+	}
+	if err := runSearch([]string{"-workspace", dir}, strings.NewReader(""), &stdout, &stderr); err == nil {
+		t.Error("expected an empty query to fail")
+	}
+}
+
+func TestGraphCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("resource \"aws_instance\" \"web\" {\n  ami = var.ami\n}\n")
+	if err := runGraph([]string{"-format", "json"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatal("graph:", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"aws_instance.web": [`+"\n"+`    "var.ami"`) {
+		t.Errorf("expected aws_instance.web to refer to var.ami, got %s", stdout.String())
+	}
+	if err := runGraph([]string{"-format", "svg"}, strings.NewReader(""), &stdout, &stderr); err == nil {
+		t.Error("expected an unsupported format to fail")
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.tf"), filepath.Join(dir, "b.tf")
+	if err := ioutil.WriteFile(a, []byte("x = 1\ny = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(b, []byte("y = 2\nx = 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := runDiff([]string{a, b}, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatal("diff:", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"kind": "changed",`+"\n"+`    "path": "x"`) || strings.Contains(stdout.String(), `"path": "y"`) {
+		t.Errorf("expected only x to have changed, got %s", stdout.String())
+	}
+}
+
+func TestQueryCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("resource \"aws_instance\" \"web\" {\n  ami = \"ami-1\"\n}\n")
+	if err := runQuery([]string{"resource.aws_instance.*.ami"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatal("query:", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"value": "ami-1"`) || !strings.Contains(stdout.String(), `"line": 2`) {
+		t.Errorf("expected ami-1 on line 2, got %s", stdout.String())
+	}
+	if err := runQuery(nil, strings.NewReader(""), &stdout, &stderr); err == nil {
+		t.Error("expected a missing query to fail")
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("region = \"us-east-1\"\n")
+	if err := runExport(nil, stdin, &stdout, &stderr); err != nil {
+		t.Fatal("export:", err, stderr.String())
+	}
+	for _, want := range []string{`"file": "STDIN"`, `"region": "us-east-1"`, `"lines": {`, `"tool": "hclparser"`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %s in %s", want, stdout.String())
+		}
+	}
+}
+
+func TestConvertCommandEncoder(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := runConvert([]string{"-encoder", "msgpack"}, strings.NewReader("a = 1\n"), &stdout, &stderr); err != nil {
+		t.Fatal("convert:", err, stderr.String())
+	}
+	// A map of a to 1, without a trailing newline.
+	if got := stdout.Bytes(); !bytes.Equal(got, []byte{0x81, 0xa1, 'a', 0x01}) {
+		t.Errorf("expected MessagePack output, got %x", got)
+	}
+	if err := runConvert([]string{"-encoder", "xml"}, strings.NewReader("a = 1\n"), &stdout, &stderr); err == nil {
+		t.Error("expected an unsupported encoder to fail")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ckndave/hclparser/workspace"
+)
+
+// defaultSocket is the name of the daemon's socket within the workspace,
+// when -socket isn't given.
+const defaultSocket = ".hclparser.sock"
+
+// runDaemon keeps an index of a workspace up to date, serving queries over
+// a unix socket until it fails.
+func runDaemon(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		dir      string
+		socket   string
+		interval time.Duration
+	)
+
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	flags.StringVar(&dir, "workspace", ".", "directory to index")
+	flags.StringVar(&socket, "socket", "", "unix socket to serve queries on; "+defaultSocket+" in the workspace when empty")
+	flags.DurationVar(&interval, "interval", 2*time.Second, "how often to check the workspace for changes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+	if socket == "" {
+		socket = filepath.Join(dir, defaultSocket)
+	}
+
+	ix := workspace.New(dir, options)
+	if _, err := ix.Refresh(); err != nil {
+		return err
+	}
+	listener, err := workspace.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watched := make(chan error, 1)
+	go func() {
+		watched <- ix.Watch(ctx, interval, func(paths []string) {
+			fmt.Fprintf(stderr, "reindexed %s\n", strings.Join(paths, ", "))
+		})
+		listener.Close()
+	}()
+
+	fmt.Fprintf(stderr, "indexed %d files, listening on %s\n", len(ix.Paths()), socket)
+	err = http.Serve(listener, workspace.Handler(ix))
+	select {
+	case watchErr := <-watched:
+		if !errors.Is(watchErr, context.Canceled) {
+			return watchErr
+		}
+	default:
+	}
+	return err
+}
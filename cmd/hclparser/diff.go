@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+// runDiff writes the attributes and blocks which differ between two files,
+// matching blocks by their labels rather than where they are in each file.
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("expected two files to diff")
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+
+	a, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", flags.Arg(0), err)
+	}
+	b, err := ioutil.ReadFile(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", flags.Arg(1), err)
+	}
+	changes, err := convert.Diff(a, b, options)
+	if err != nil {
+		return err
+	}
+	out := json.NewEncoder(stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(changes)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+// runExport converts a file, or stdin when none is given, writing it as
+// the input document for policy engines such as Open Policy Agent.
+func runExport(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("at most one file may be exported")
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+
+	filename, src, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	input, err := convert.ExportPolicyInput(src, filename, options)
+	if err != nil {
+		return err
+	}
+	out := json.NewEncoder(stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(input)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ckndave/hclparser/convert"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// runGraph writes the references between the blocks of a file, or stdin
+// when none is given, in the format given by -format.
+func runGraph(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var format string
+
+	flags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&format, "format", "dot", "format of the graph: dot, or json for an adjacency list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("at most one file may be graphed")
+	}
+	if format != "dot" && format != "json" {
+		return fmt.Errorf("unsupported format %q", format)
+	}
+
+	filename, src, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return fmt.Errorf("parse config: %v", diags.Errs())
+	}
+
+	graph, err := convert.Graph(file)
+	if err != nil {
+		return err
+	}
+	if format == "dot" {
+		_, err = stdout.Write(graph.DOT())
+		return err
+	}
+	out := json.NewEncoder(stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(graph)
+}
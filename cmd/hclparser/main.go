@@ -0,0 +1,80 @@
+// Command hclparser converts HCL files to JSON.
+//
+// Usage:
+//
+//	hclparser <command> [flags] [file]
+//
+// The commands are:
+//
+//	convert   convert a file, or stdin, to JSON
+//	daemon    keep an index of a workspace, serving queries over a socket
+//	diff      write the attributes and blocks which differ between two files
+//	export    write a file as the input document for policy engines such as OPA
+//	graph     write the references between the blocks of a file
+//	preview   report what simplifying a file would evaluate
+//	query     write the values of a file selected by a query, with their positions
+//	scaffold  write a skeleton block for a resource type from its provider schema
+//	search    find resources, references and values in a workspace
+//	serve     serve conversions over HTTP
+//	selftest  check this build converts the embedded samples correctly
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command runs a subcommand with its arguments, reading input from stdin
+// and writing output to stdout.
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+var commands = map[string]command{
+	"convert":  runConvert,
+	"daemon":   runDaemon,
+	"diff":     runDiff,
+	"export":   runExport,
+	"graph":    runGraph,
+	"preview":  runPreview,
+	"query":    runQuery,
+	"scaffold": runScaffold,
+	"search":   runSearch,
+	"serve":    runServe,
+	"selftest": runSelftest,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "hclparser: unknown command %q\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err := cmd(os.Args[2:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "hclparser %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: hclparser <command> [flags] [file]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  convert   convert a file, or stdin, to JSON")
+	fmt.Fprintln(w, "  daemon    keep an index of a workspace, serving queries over a socket")
+	fmt.Fprintln(w, "  diff      write the attributes and blocks which differ between two files")
+	fmt.Fprintln(w, "  export    write a file as the input document for policy engines such as OPA")
+	fmt.Fprintln(w, "  graph     write the references between the blocks of a file")
+	fmt.Fprintln(w, "  preview   report what simplifying a file would evaluate")
+	fmt.Fprintln(w, "  query     write the values of a file selected by a query, with their positions")
+	fmt.Fprintln(w, "  scaffold  write a skeleton block for a resource type from its provider schema")
+	fmt.Fprintln(w, "  search    find resources, references and values in a workspace")
+	fmt.Fprintln(w, "  serve     serve conversions over HTTP")
+	fmt.Fprintln(w, "  selftest  check this build converts the embedded samples correctly")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ckndave/hclparser/convert"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// stringList is a flag which may be given more than once, or as a comma
+// separated list.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, strings.Split(value, ",")...)
+	return nil
+}
+
+// variables is a flag setting Options.Variables from name=JSON pairs, such
+// as var={"region":"eu-west-1"}.
+type variables map[string]cty.Value
+
+func (v variables) String() string { return "" }
+
+func (v variables) Set(value string) error {
+	eq := strings.Index(value, "=")
+	if eq < 1 {
+		return errors.New("expected name=JSON")
+	}
+	name, raw := value[:eq], []byte(value[eq+1:])
+	typ, err := ctyjson.ImpliedType(raw)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", name, err)
+	}
+	val, err := ctyjson.Unmarshal(raw, typ)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", name, err)
+	}
+	v[name] = val
+	return nil
+}
+
+// optionFlags holds the flags mapping to convert.Options.
+type optionFlags struct {
+	fields  convert.Options
+	vars    variables
+	allow   stringList
+	redact  stringList
+	now     string
+	seed    int64
+	fsRoot  string
+	dialect string
+	mode    string
+	syntax  string
+	types   string
+	version int
+	format  string
+	catalog string
+	encoder string
+}
+
+// newOptionFlags registers the flags for every field of convert.Options.
+func newOptionFlags(flags *flag.FlagSet) *optionFlags {
+	o := &optionFlags{vars: make(variables)}
+	flags.BoolVar(&o.fields.Simplify, "simplify", false, "evaluate expressions which don't need unknown variables or functions")
+	flags.Var(o.vars, "var", "make a variable available when simplifying, as `name=JSON`; may be repeated")
+	flags.StringVar(&o.dialect, "dialect", "", "flavour of HCL, such as terraform")
+	flags.Var(&o.allow, "allow-function", "allow evaluating a function outside of the dialect's safe set; may be repeated")
+	flags.StringVar(&o.now, "now", "", "RFC 3339 time used as the current time")
+	flags.Int64Var(&o.seed, "rand-seed", 0, "seed for the random source used by functions such as uuid, instead of crypto/rand")
+	flags.StringVar(&o.fsRoot, "fs", "", "directory file functions may read from")
+	flags.IntVar(&o.fields.MaxIncludeDepth, "max-include-depth", 0, "limit on how deeply includes are resolved")
+	flags.DurationVar(&o.fields.FileTimeout, "file-timeout", 0, "limit on how long converting the file may take")
+	flags.BoolVar(&o.fields.InjectDefaults, "inject-defaults", false, "add missing attributes which have a default in the schema")
+	flags.BoolVar(&o.fields.CanonicalExpressions, "canonical-expressions", false, "record the canonical form of expressions in the line info")
+	flags.BoolVar(&o.fields.PreserveOrder, "preserve-order", false, "write keys in source order rather than sorted")
+	flags.BoolVar(&o.fields.Canonical, "canonical", false, "write byte-stable output that can be hashed and compared")
+	flags.StringVar(&o.fields.Indent, "indent", "", "pretty-print the output, indenting by this string, such as two spaces")
+	flags.BoolVar(&o.fields.DisableHTMLEscaping, "disable-html-escaping", false, "write <, > and & in strings as they are")
+	flags.BoolVar(&o.fields.CombinedOutput, "combined-output", false, "write each value with its position, as {\"value\": ..., \"range\": ...}")
+	flags.BoolVar(&o.fields.PreserveHeredocs, "preserve-heredocs", false, "write heredocs as objects recording their delimiter and indentation")
+	flags.BoolVar(&o.fields.StructuredFor, "structured-for", false, "write for expressions as objects holding their variables and expressions")
+	flags.BoolVar(&o.fields.StructuredConditionals, "structured-conditionals", false, "write conditional expressions as objects holding the condition and results")
+	flags.BoolVar(&o.fields.StructuredFunctions, "structured-functions", false, "write function calls as objects holding the function name and arguments")
+	flags.BoolVar(&o.fields.CompactLines, "compact-lines", false, "write the line info in the compact encoding")
+	flags.StringVar(&o.format, "line-format", "", "structure of the line info: empty for nested, or flat for an index keyed by JSON pointer")
+	flags.BoolVar(&o.fields.AnnotateTypes, "annotate-types", false, "record the type the schema declares for each attribute in the line info")
+	flags.StringVar(&o.types, "include-types", "", "write the type of each attribute's value: inline in the output, or map for a type map")
+	flags.BoolVar(&o.fields.AnnotateOrigins, "annotate-origins", false, "record whether each value is a literal, evaluated or computed at plan time in the line info")
+	flags.BoolVar(&o.fields.IncludeByteOffsets, "include-byte-offsets", false, "add byte offsets to the line info")
+	flags.StringVar(&o.fields.LineKeyPrefix, "line-key-prefix", "", "prefix for the keys of positions in the line info, such as $, so they can't collide with attribute names")
+	flags.IntVar(&o.version, "output-schema-version", 0, "version of the output structure")
+	flags.StringVar(&o.mode, "expression-mode", "", "how expressions are written: empty for ${...} strings, or ast")
+	flags.Var(&o.redact, "redact", "redact the values of attributes matching this name or pattern, such as *.password; may be repeated")
+	flags.BoolVar(&o.fields.Skeleton, "skeleton", false, "convert only blocks, labels and attribute names, writing every value as null")
+	flags.BoolVar(&o.fields.AllowErrors, "allow-errors", false, "convert what can be parsed from files with syntax errors")
+	flags.StringVar(&o.syntax, "input-syntax", "", "syntax of the input, native or json; detected when empty")
+	flags.BoolVar(&o.fields.Provenance, "provenance", false, "add a provenance header to the output")
+	flags.StringVar(&o.encoder, "encoder", "", "write the output and line info in a binary format: msgpack or cbor")
+	flags.StringVar(&o.catalog, "catalog", "", "JSON file of message templates by ID, used to render diagnostics")
+	return o
+}
+
+// options returns the options given by the parsed flags.
+func (o *optionFlags) options() (convert.Options, error) {
+	options := o.fields
+	if len(o.vars) > 0 {
+		options.Variables = o.vars
+	}
+	options.Dialect = convert.Dialect(o.dialect)
+	options.AllowFunctions = o.allow
+	if o.now != "" {
+		t, err := time.Parse(time.RFC3339, o.now)
+		if err != nil {
+			return options, fmt.Errorf("parse -now: %w", err)
+		}
+		options.Clock = func() time.Time { return t }
+	}
+	if o.seed != 0 {
+		options.RandSource = rand.New(rand.NewSource(o.seed))
+	}
+	if o.fsRoot != "" {
+		options.FS = os.DirFS(o.fsRoot)
+	}
+	options.OutputSchemaVersion = convert.OutputSchemaVersion(o.version)
+	options.ExpressionMode = convert.ExpressionMode(o.mode)
+	options.LineFormat = convert.LineFormat(o.format)
+	options.Redact = o.redact
+	options.InputSyntax = convert.InputSyntax(o.syntax)
+	options.IncludeTypes = convert.TypeLayout(o.types)
+	switch o.encoder {
+	case "":
+	case "msgpack":
+		options.Encoder = convert.MsgpackEncoder
+	case "cbor":
+		options.Encoder = convert.CBOREncoder
+	default:
+		return options, fmt.Errorf("unsupported encoder %q", o.encoder)
+	}
+	if o.catalog != "" {
+		b, err := ioutil.ReadFile(o.catalog)
+		if err != nil {
+			return options, fmt.Errorf("read catalog: %w", err)
+		}
+		if err := json.Unmarshal(b, &options.Catalog); err != nil {
+			return options, fmt.Errorf("decode catalog: %w", err)
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ckndave/hclparser/convert"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// runPreview writes a report of what simplifying a file, or stdin when none
+// is given, would evaluate with the given options, without evaluating
+// anything.
+func runPreview(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("at most one file may be previewed")
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+
+	filename, src, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return fmt.Errorf("parse config: %v", diags.Errs())
+	}
+
+	entries, err := convert.Preview(file, options)
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []convert.PreviewEntry{}
+	}
+	out := json.NewEncoder(stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(entries)
+}
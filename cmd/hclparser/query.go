@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+// runQuery converts a file, or stdin when none is given, and writes the
+// values selected by a query such as resource.aws_instance.*.ami, with
+// their positions.
+func runQuery(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return errors.New("expected a query and at most one file")
+	}
+
+	filename, src, err := readInput(flags.Arg(1), stdin)
+	if err != nil {
+		return err
+	}
+	result, err := convert.Convert(src, filename, convert.Options{})
+	if err != nil {
+		return err
+	}
+	matches, err := convert.Query(result.JSON, result.Lines, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	out := json.NewEncoder(stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(matches)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ckndave/hclparser/terraform"
+)
+
+// runScaffold writes a skeleton block for a resource or data source type,
+// using the provider schemas from -provider-schemas.
+func runScaffold(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		schemas string
+		source  string
+		name    string
+		data    bool
+	)
+
+	flags := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&schemas, "provider-schemas", "", "file holding the output of terraform providers schema -json")
+	flags.StringVar(&source, "provider", "", "source of the provider, such as hashicorp/aws; by default the hashicorp provider named by the type's prefix")
+	flags.StringVar(&name, "name", "example", "name of the block")
+	flags.BoolVar(&data, "data", false, "scaffold a data source rather than a resource")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one resource type, such as aws_s3_bucket")
+	}
+	if schemas == "" {
+		return errors.New("-provider-schemas is required")
+	}
+	typeName := flags.Arg(0)
+
+	f, err := os.Open(schemas)
+	if err != nil {
+		return fmt.Errorf("open provider schemas: %w", err)
+	}
+	dump, err := terraform.NewDumpSource(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("read provider schemas: %w", err)
+	}
+
+	if source == "" {
+		source = "hashicorp/" + strings.SplitN(typeName, "_", 2)[0]
+	}
+	if strings.Count(source, "/") == 1 {
+		source = terraform.DefaultRegistryHost + "/" + source
+	}
+	src, err := terraform.Scaffold(terraform.Provider{Source: source}, dump, typeName, name, data)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(src)
+	return err
+}
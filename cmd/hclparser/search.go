@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net"
+	"path/filepath"
+
+	"github.com/ckndave/hclparser/workspace"
+)
+
+// runSearch writes what a query finds in a workspace as JSON, asking the
+// daemon when one is listening and indexing the workspace itself otherwise.
+func runSearch(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		dir    string
+		socket string
+		query  workspace.Query
+	)
+
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	flags.StringVar(&dir, "workspace", ".", "directory to search")
+	flags.StringVar(&socket, "socket", "", "unix socket of a daemon to ask; "+defaultSocket+" in the workspace when empty")
+	flags.StringVar(&query.Type, "type", "", "find resources of this type, such as aws_instance or data.aws_ami")
+	flags.StringVar(&query.Reference, "reference", "", "find references matching this pattern, such as var.db_*")
+	flags.StringVar(&query.Value, "value", "", "find values matching this regular expression")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if socket == "" {
+		socket = filepath.Join(dir, defaultSocket)
+	}
+
+	var matches []workspace.Match
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		matches, err = workspace.Dial(socket).Search(context.Background(), query)
+		if err != nil {
+			return err
+		}
+	} else {
+		options, err := optionFlags.options()
+		if err != nil {
+			return err
+		}
+		ix := workspace.New(dir, options)
+		if _, err := ix.Refresh(); err != nil {
+			return err
+		}
+		if matches, err = ix.Search(query); err != nil {
+			return err
+		}
+	}
+
+	if matches == nil {
+		matches = []workspace.Match{}
+	}
+	out := json.NewEncoder(stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(matches)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ckndave/hclparser/selftest"
+)
+
+// runSelftest converts the embedded samples with the given options,
+// reporting each and failing if any breaks an invariant.
+func runSelftest(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+
+	report, err := selftest.Run(options)
+	if err != nil {
+		return err
+	}
+	for _, result := range report.Results {
+		if len(result.Failures) == 0 {
+			fmt.Fprintf(stdout, "ok    %s\n", result.Sample)
+			continue
+		}
+		fmt.Fprintf(stdout, "FAIL  %s\n", result.Sample)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(stdout, "      %s\n", failure)
+		}
+	}
+	if failed := report.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d samples failed", len(failed), len(report.Results))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ckndave/hclparser/server"
+)
+
+// runServe serves conversions over HTTP until the server fails.
+func runServe(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		addr        string
+		overrides   stringList
+		webhooks    stringList
+		artifactDir string
+		config      server.Config
+	)
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optionFlags := newOptionFlags(flags)
+	flags.StringVar(&addr, "addr", ":8080", "address to listen on")
+	flags.Var(&overrides, "allow-override", "request option, such as dialect, which requests may set; may be repeated")
+	flags.IntVar(&config.MaxConcurrent, "max-concurrent", 0, "limit on conversions running at once")
+	flags.Int64Var(&config.MaxRequestBytes, "max-request-bytes", 0, "limit on the size of request bodies")
+	flags.Var(&webhooks, "webhook", "URL sent an event when a conversion completes; may be repeated")
+	flags.StringVar(&artifactDir, "artifact-dir", "", "directory to store converted JSON in, with its path given in webhook events")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options, err := optionFlags.options()
+	if err != nil {
+		return err
+	}
+
+	config.Options, config.Overrides, config.Webhooks = options, overrides, webhooks
+	if artifactDir != "" {
+		config.Artifacts = server.DirStore(artifactDir)
+	}
+	s := server.New(config)
+	fmt.Fprintf(stderr, "listening on %s\n", addr)
+	return http.ListenAndServe(addr, s)
+}
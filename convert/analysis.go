@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// attribute is an attribute found while walking a file, along with the path
+// to it and the address of the top-level block which owns it.
+type attribute struct {
+	path  []string
+	owner string
+	attr  *hclsyntax.Attribute
+}
+
+func (a attribute) pathString() string {
+	return strings.Join(a.path, ".")
+}
+
+// fileBody returns the syntax body of a parsed file.
+func fileBody(file *hcl.File) (*hclsyntax.Body, error) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("convert file body to body type")
+	}
+	return body, nil
+}
+
+// attributes returns every attribute in body, including those of nested
+// blocks, in source order.
+func attributes(body *hclsyntax.Body) []attribute {
+	var attrs []attribute
+	for _, attr := range sortedAttributes(body) {
+		attrs = append(attrs, attribute{path: []string{attr.Name}, owner: attr.Name, attr: attr})
+	}
+	for _, block := range body.Blocks {
+		path := append([]string{block.Type}, block.Labels...)
+		owner := blockAddress(block)
+		attrs = appendBlockAttributes(attrs, block, path, owner)
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		return attrs[i].attr.SrcRange.Start.Byte < attrs[j].attr.SrcRange.Start.Byte
+	})
+	return attrs
+}
+
+func appendBlockAttributes(attrs []attribute, block *hclsyntax.Block, path []string, owner string) []attribute {
+	for _, attr := range sortedAttributes(block.Body) {
+		attrOwner := owner
+		if block.Type == "locals" && len(path) == 1 {
+			attrOwner = "local." + attr.Name
+		}
+		attrPath := append(append([]string{}, path...), attr.Name)
+		attrs = append(attrs, attribute{path: attrPath, owner: attrOwner, attr: attr})
+	}
+	for _, nested := range block.Body.Blocks {
+		nestedPath := append(append(append([]string{}, path...), nested.Type), nested.Labels...)
+		attrs = appendBlockAttributes(attrs, nested, nestedPath, owner)
+	}
+	return attrs
+}
+
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	return attrs
+}
+
+// blockAddress returns the address by which a top-level Terraform block is
+// referenced, such as aws_instance.web or data.aws_ami.ubuntu. Blocks which
+// can't be referenced are addressed by their type and labels.
+func blockAddress(block *hclsyntax.Block) string {
+	switch {
+	case block.Type == "resource" && len(block.Labels) == 2:
+		return block.Labels[0] + "." + block.Labels[1]
+	case block.Type == "data" && len(block.Labels) == 2:
+		return "data." + block.Labels[0] + "." + block.Labels[1]
+	case block.Type == "module" && len(block.Labels) == 1:
+		return "module." + block.Labels[0]
+	case block.Type == "variable" && len(block.Labels) == 1:
+		return "var." + block.Labels[0]
+	default:
+		return strings.Join(append([]string{block.Type}, block.Labels...), ".")
+	}
+}
+
+// referenceAddress returns the address of the object a traversal refers to,
+// or false if it refers to something which isn't declared in configuration,
+// such as count.index or path.module.
+func referenceAddress(traversal hcl.Traversal) (string, bool) {
+	names := traversalNames(traversal)
+	if len(names) == 0 {
+		return "", false
+	}
+	switch names[0] {
+	case "count", "each", "self", "path", "terraform":
+		return "", false
+	case "var", "local", "module":
+		if len(names) < 2 {
+			return "", false
+		}
+		return names[0] + "." + names[1], true
+	case "data":
+		if len(names) < 3 {
+			return "", false
+		}
+		return strings.Join(names[:3], "."), true
+	default:
+		if len(names) < 2 {
+			return "", false
+		}
+		return names[0] + "." + names[1], true
+	}
+}
+
+// traversalNames returns the leading attribute names of a traversal,
+// stopping at the first index step.
+func traversalNames(traversal hcl.Traversal) []string {
+	var names []string
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			names = append(names, step.Name)
+		case hcl.TraverseAttr:
+			names = append(names, step.Name)
+		default:
+			return names
+		}
+	}
+	return names
+}
+
+// references returns the addresses referred to by an expression.
+func references(expr hclsyntax.Expression) []string {
+	var addrs []string
+	for _, traversal := range expr.Variables() {
+		if addr, ok := referenceAddress(traversal); ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
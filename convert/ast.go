@@ -0,0 +1,191 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/ckndave/hclparser/addr"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ExpressionMode selects how expressions which can't be converted to plain
+// values are written in the output.
+type ExpressionMode string
+
+const (
+	// ExpressionModeWrapped writes the expression's source wrapped in
+	// ${...}, as in "${var.name}".
+	ExpressionModeWrapped ExpressionMode = ""
+
+	// ExpressionModeAST writes the expression's syntax tree, as produced
+	// by ExpressionTree, so tools don't need to parse wrapped strings.
+	ExpressionModeAST ExpressionMode = "ast"
+)
+
+// ExpressionTree returns the syntax tree of an expression as JSON-friendly
+// values. Every node is an object whose "node" names its kind, such as
+// "call" or "traversal", alongside its operands. Parentheses are dropped,
+// as the tree already gives the order of evaluation.
+func ExpressionTree(expr hclsyntax.Expression) map[string]interface{} {
+	trees := func(exprs []hclsyntax.Expression) []interface{} {
+		list := make([]interface{}, len(exprs))
+		for i, e := range exprs {
+			list[i] = ExpressionTree(e)
+		}
+		return list
+	}
+	optional := func(e hclsyntax.Expression) interface{} {
+		if e == nil {
+			return nil
+		}
+		return ExpressionTree(e)
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		return literalNode(e.Val)
+	case *hclsyntax.ParenthesesExpr:
+		return ExpressionTree(e.Expression)
+	case *hclsyntax.TemplateWrapExpr:
+		return ExpressionTree(e.Wrapped)
+	case *hclsyntax.ScopeTraversalExpr:
+		return map[string]interface{}{
+			"node":    "traversal",
+			"address": addr.String(e.Traversal),
+			"steps":   traversalSteps(e.Traversal),
+		}
+	case *hclsyntax.RelativeTraversalExpr:
+		return map[string]interface{}{
+			"node":   "get",
+			"source": ExpressionTree(e.Source),
+			"steps":  traversalSteps(e.Traversal),
+		}
+	case *hclsyntax.FunctionCallExpr:
+		return map[string]interface{}{
+			"node":         "call",
+			"name":         e.Name,
+			"args":         trees(e.Args),
+			"expand_final": e.ExpandFinal,
+		}
+	case *hclsyntax.BinaryOpExpr:
+		return map[string]interface{}{
+			"node": "binary",
+			"op":   operatorSymbols[e.Op],
+			"lhs":  ExpressionTree(e.LHS),
+			"rhs":  ExpressionTree(e.RHS),
+		}
+	case *hclsyntax.UnaryOpExpr:
+		return map[string]interface{}{
+			"node":    "unary",
+			"op":      operatorSymbols[e.Op],
+			"operand": ExpressionTree(e.Val),
+		}
+	case *hclsyntax.ConditionalExpr:
+		return map[string]interface{}{
+			"node":      "conditional",
+			"condition": ExpressionTree(e.Condition),
+			"true":      ExpressionTree(e.TrueResult),
+			"false":     ExpressionTree(e.FalseResult),
+		}
+	case *hclsyntax.TemplateExpr:
+		if e.IsStringLiteral() {
+			v, _ := e.Value(nil)
+			return literalNode(v)
+		}
+		return map[string]interface{}{
+			"node":  "template",
+			"parts": trees(e.Parts),
+		}
+	case *hclsyntax.TemplateJoinExpr:
+		return map[string]interface{}{
+			"node":  "join",
+			"tuple": ExpressionTree(e.Tuple),
+		}
+	case *hclsyntax.TupleConsExpr:
+		return map[string]interface{}{
+			"node":     "tuple",
+			"elements": trees(e.Exprs),
+		}
+	case *hclsyntax.ObjectConsExpr:
+		items := make([]interface{}, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = map[string]interface{}{
+				"key":   ExpressionTree(item.KeyExpr),
+				"value": ExpressionTree(item.ValueExpr),
+			}
+		}
+		return map[string]interface{}{
+			"node":  "object",
+			"items": items,
+		}
+	case *hclsyntax.ObjectConsKeyExpr:
+		if name := hcl.ExprAsKeyword(e.Wrapped); name != "" && !e.ForceNonLiteral {
+			return literalNode(cty.StringVal(name))
+		}
+		return ExpressionTree(e.Wrapped)
+	case *hclsyntax.ForExpr:
+		return map[string]interface{}{
+			"node":       "for",
+			"key_var":    e.KeyVar,
+			"value_var":  e.ValVar,
+			"collection": ExpressionTree(e.CollExpr),
+			"condition":  optional(e.CondExpr),
+			"key":        optional(e.KeyExpr),
+			"value":      ExpressionTree(e.ValExpr),
+			"group":      e.Group,
+		}
+	case *hclsyntax.IndexExpr:
+		return map[string]interface{}{
+			"node":       "index",
+			"collection": ExpressionTree(e.Collection),
+			"key":        ExpressionTree(e.Key),
+		}
+	case *hclsyntax.SplatExpr:
+		return map[string]interface{}{
+			"node":   "splat",
+			"source": ExpressionTree(e.Source),
+			"each":   ExpressionTree(e.Each),
+		}
+	case *hclsyntax.AnonSymbolExpr:
+		return map[string]interface{}{"node": "splat_item"}
+	default:
+		return map[string]interface{}{"node": "unknown", "type": fmt.Sprintf("%T", expr)}
+	}
+}
+
+func literalNode(val cty.Value) map[string]interface{} {
+	node := map[string]interface{}{"node": "literal"}
+	if val.IsWhollyKnown() {
+		node["value"] = ctyjson.SimpleJSONValue{Value: val}
+	}
+	return node
+}
+
+// traversalSteps describes each step of a traversal after its root.
+func traversalSteps(traversal hcl.Traversal) []interface{} {
+	steps := make([]interface{}, 0, len(traversal))
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			steps = append(steps, map[string]interface{}{"type": "root", "name": step.Name})
+		case hcl.TraverseAttr:
+			steps = append(steps, map[string]interface{}{"type": "attr", "name": step.Name})
+		case hcl.TraverseIndex:
+			steps = append(steps, map[string]interface{}{"type": "index", "key": ctyjson.SimpleJSONValue{Value: step.Key}})
+		case hcl.TraverseSplat:
+			steps = append(steps, map[string]interface{}{"type": "splat"})
+		}
+	}
+	return steps
+}
+
+// opaque returns the output for an expression which can't be converted to
+// a plain value, following Options.ExpressionMode.
+func (c *converter) opaque(expr hclsyntax.Expression) interface{} {
+	if c.options.ExpressionMode == ExpressionModeAST {
+		return ExpressionTree(expr)
+	}
+	return c.wrapExpr(expr)
+}
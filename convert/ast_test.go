@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExpressionModeAST(t *testing.T) {
+	input := []byte(`
+a = upper(var.name)
+b = var.list[0] + 1
+c = "${local.prefix}-web"
+d = "plain"
+e = 1 + 2
+f = [for s in var.items : s if s != ""]
+`)
+	result, err := Convert(input, "main.tf", Options{ExpressionMode: ExpressionModeAST})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"a": {"node": "call", "name": "upper", "expand_final": false, "args": [
+		{"node": "traversal", "address": "var.name", "steps": [
+			{"type": "root", "name": "var"}, {"type": "attr", "name": "name"}
+		]}
+	]},
+	"b": {"node": "binary", "op": "+",
+		"lhs": {"node": "traversal", "address": "var.list[0]", "steps": [
+			{"type": "root", "name": "var"}, {"type": "attr", "name": "list"}, {"type": "index", "key": 0}
+		]},
+		"rhs": {"node": "literal", "value": 1}
+	},
+	"c": {"node": "template", "parts": [
+		{"node": "traversal", "address": "local.prefix", "steps": [
+			{"type": "root", "name": "local"}, {"type": "attr", "name": "prefix"}
+		]},
+		{"node": "literal", "value": "-web"}
+	]},
+	"d": "plain",
+	"e": 3,
+	"f": {"node": "for", "key_var": "", "value_var": "s", "group": false, "key": null,
+		"collection": {"node": "traversal", "address": "var.items", "steps": [
+			{"type": "root", "name": "var"}, {"type": "attr", "name": "items"}
+		]},
+		"condition": {"node": "binary", "op": "!=",
+			"lhs": {"node": "traversal", "address": "s", "steps": [{"type": "root", "name": "s"}]},
+			"rhs": {"node": "literal", "value": ""}
+		},
+		"value": {"node": "traversal", "address": "s", "steps": [{"type": "root", "name": "s"}]}
+	}
+}`))
+
+	wrapped, err := Convert(input, "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if (Options{}).Fingerprint() == (Options{ExpressionMode: ExpressionModeAST}).Fingerprint() {
+		t.Error("expression mode should change the fingerprint")
+	}
+	sameJSON(t, wrapped.JSON, []byte(`{
+	"a": "${upper(var.name)}",
+	"b": "${var.list[0] + 1}",
+	"c": "${local.prefix}-web",
+	"d": "plain",
+	"e": 3,
+	"f": "${[for s in var.items : s if s != \"\"]}"
+}`))
+}
+
+func sameJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatal("unmarshal:", err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatal("unmarshal:", err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ErrAttributeNotFound is returned by Attribute when no attribute exists at
+// the path.
+var ErrAttributeNotFound = errors.New("attribute not found")
+
+// Attribute returns the converted value and range of the single attribute
+// at path, such as terraform.required_version or
+// resource.aws_instance.web.ami, without converting the rest of the file.
+// Blocks on the path are matched by type followed by their labels, and the
+// first attribute found is returned when several blocks match.
+func Attribute(bytes []byte, path string) (interface{}, hcl.Range, error) {
+	file, diags := hclsyntax.ParseConfig(bytes, "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, hcl.Range{}, fmt.Errorf("parse config: %v", diags.Errs())
+	}
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, hcl.Range{}, err
+	}
+
+	attr := findAttribute(body, strings.Split(path, "."))
+	if attr == nil {
+		return nil, hcl.Range{}, fmt.Errorf("%w: %s", ErrAttributeNotFound, path)
+	}
+
+	c := converter{bytes: file.Bytes}
+	value, _, err := c.convertExpression(attr.Expr)
+	if err != nil {
+		return nil, hcl.Range{}, fmt.Errorf("convert expression: %w", err)
+	}
+	return value, attr.SrcRange, nil
+}
+
+// findAttribute walks body along path, stopping at the first match.
+func findAttribute(body *hclsyntax.Body, path []string) *hclsyntax.Attribute {
+	if len(path) == 1 {
+		return body.Attributes[path[0]]
+	}
+	for _, block := range body.Blocks {
+		rest := path[1:]
+		if block.Type != path[0] || len(rest) <= len(block.Labels) {
+			continue
+		}
+		matched := true
+		for i, label := range block.Labels {
+			if rest[i] != label {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if attr := findAttribute(block.Body, rest[len(block.Labels):]); attr != nil {
+			return attr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAttribute(t *testing.T) {
+	input := []byte(`
+terraform {
+	required_version = "1.0.0"
+}
+
+resource "aws_instance" "web" {
+	ami = var.ami
+
+	root_block_device {
+		volume_size = 20
+	}
+}`)
+
+	tests := []struct {
+		path     string
+		expected string
+		line     int
+	}{
+		{"terraform.required_version", `"1.0.0"`, 3},
+		{"resource.aws_instance.web.ami", `"${var.ami}"`, 7},
+		{"resource.aws_instance.web.root_block_device.volume_size", "20", 10},
+	}
+	for _, test := range tests {
+		value, rng, err := Attribute(input, test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			t.Errorf("%s: marshal: %v", test.path, err)
+			continue
+		}
+		if string(valueBytes) != test.expected || rng.Start.Line != test.line {
+			t.Errorf("%s: expected %s on line %d, got %s on line %d", test.path, test.expected, test.line, valueBytes, rng.Start.Line)
+		}
+	}
+
+	if _, _, err := Attribute(input, "resource.aws_instance.db.ami"); !errors.Is(err, ErrAttributeNotFound) {
+		t.Errorf("expected ErrAttributeNotFound, got %v", err)
+	}
+}
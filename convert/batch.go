@@ -0,0 +1,251 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestName is the name of the manifest written by Batch.
+const ManifestName = "manifest.json"
+
+// Manifest describes a batch conversion of a directory tree.
+type Manifest struct {
+	Source      string          `json:"source"`
+	Fingerprint string          `json:"fingerprint"`
+	Files       []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry describes the conversion of a single input file. Output and
+// Lines are relative to the output directory and are empty when the
+// conversion failed, in which case Error is set, along with its Code when
+// it has one.
+type ManifestEntry struct {
+	File        string  `json:"file"`
+	SHA256      string  `json:"sha256"`
+	Output      string  `json:"output,omitempty"`
+	Lines       string  `json:"lines,omitempty"`
+	Diagnostics int     `json:"diagnostics"`
+	Error       string  `json:"error,omitempty"`
+	Code        string  `json:"code,omitempty"`
+	DurationMS  float64 `json:"duration_ms"`
+}
+
+// Failed returns the entries for files which could not be converted.
+func (m *Manifest) Failed() []ManifestEntry {
+	var failed []ManifestEntry
+	for _, entry := range m.Files {
+		if entry.Error != "" {
+			failed = append(failed, entry)
+		}
+	}
+	return failed
+}
+
+// Batch converts every HCL and Terraform file under src, including .tf.json
+// files, writing the JSON and line info for each to the same relative path
+// under dst, along with a manifest describing the run. Outputs of an
+// earlier run into dst are skipped if dst is within src. A file failing to convert is recorded in the
+// manifest rather than stopping the run, including when it panics or takes
+// longer than Options.FileTimeout.
+func Batch(src, dst string, options Options) (*Manifest, error) {
+	files, err := sourceFiles(src)
+	if err != nil {
+		return nil, fmt.Errorf("list source files: %w", err)
+	}
+	files = skipOutputs(files, src, dst)
+
+	manifest := &Manifest{
+		Source:      src,
+		Fingerprint: options.Fingerprint(),
+		Files:       make([]ManifestEntry, 0, len(files)),
+	}
+	for _, rel := range files {
+		manifest.Files = append(manifest.Files, batchFile(src, dst, rel, options))
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dst, ManifestName), manifestBytes, 0644); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	if options.SigningKey != nil {
+		if err := writeSignature(dst, ManifestName, manifestBytes, "", options); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+func batchFile(src, dst, rel string, options Options) ManifestEntry {
+	start := time.Now()
+	entry := ManifestEntry{File: rel}
+	err := func() error {
+		bytes, err := ioutil.ReadFile(filepath.Join(src, rel))
+		if err != nil {
+			return fmt.Errorf("read file: %w", err)
+		}
+		sum := sha256.Sum256(bytes)
+		entry.SHA256 = hex.EncodeToString(sum[:])
+
+		result, err := convertIsolated(bytes, rel, options)
+		if err != nil {
+			return err
+		}
+		entry.Diagnostics = len(result.Diagnostics)
+
+		output, lines := rel+".json", rel+".lines.json"
+		if err := writeArtifact(dst, output, result.JSON); err != nil {
+			return err
+		}
+		if err := writeArtifact(dst, lines, result.Lines); err != nil {
+			return err
+		}
+		if options.SigningKey != nil {
+			if err := writeSignature(dst, output, result.JSON, entry.SHA256, options); err != nil {
+				return err
+			}
+			if err := writeSignature(dst, lines, result.Lines, entry.SHA256, options); err != nil {
+				return err
+			}
+		}
+		entry.Output, entry.Lines = output, lines
+		return nil
+	}()
+	if err != nil {
+		entry.Error = err.Error()
+		if code, ok := ErrorCode(err); ok {
+			entry.Code = code.ID
+		}
+	}
+	entry.DurationMS = float64(time.Since(start)) / float64(time.Millisecond)
+	return entry
+}
+
+// convertIsolated converts a single file, turning a panic into an error and
+// giving up once Options.FileTimeout has passed. A conversion which times
+// out keeps running in the background, but its result is discarded.
+func convertIsolated(bytes []byte, filename string, options Options) (*Result, error) {
+	type outcome struct {
+		result *Result
+		err    error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("conversion panicked: %v", r)}
+			}
+		}()
+		result, err := Convert(bytes, filename, options)
+		done <- outcome{result: result, err: err}
+	}()
+
+	var timeout <-chan time.Time
+	if options.FileTimeout > 0 {
+		timer := time.NewTimer(options.FileTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-timeout:
+		return nil, &TimeoutError{Timeout: options.FileTimeout}
+	}
+}
+
+func writeArtifact(dst, rel string, contents []byte) error {
+	path := filepath.Join(dst, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// skipOutputs removes the files which may be the output of an earlier batch
+// conversion from src to dst: those under dst when it is within src, and
+// when dst is src, the JSON of another source file, such as main.tf.json
+// next to main.tf.
+func skipOutputs(files []string, src, dst string) []string {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return files
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return files
+	}
+	out, err := filepath.Rel(absSrc, absDst)
+	if err != nil || out == ".." || strings.HasPrefix(out, ".."+string(filepath.Separator)) {
+		return files
+	}
+	out = filepath.ToSlash(out)
+	sources := make(map[string]bool, len(files))
+	for _, rel := range files {
+		sources[rel] = true
+	}
+	kept := make([]string, 0, len(files))
+	for _, rel := range files {
+		if out == "." && strings.HasSuffix(rel, ".json") && sources[strings.TrimSuffix(rel, ".json")] {
+			continue
+		}
+		if out != "." && strings.HasPrefix(rel, out+"/") {
+			continue
+		}
+		kept = append(kept, rel)
+	}
+	return kept
+}
+
+// sourceFiles returns the paths, relative to root, of the HCL and Terraform
+// files beneath it in lexical order.
+func sourceFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isSourceFile(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// isSourceFile reports whether path names an HCL or Terraform file, in
+// either syntax.
+func isSourceFile(path string) bool {
+	if strings.HasSuffix(path, ".tf.json") {
+		return true
+	}
+	switch filepath.Ext(path) {
+	case ".hcl", ".tf":
+		return true
+	default:
+		return false
+	}
+}
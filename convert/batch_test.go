@@ -0,0 +1,122 @@
+package convert
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "main.tf"), `x = 1`)
+	writeFile(t, filepath.Join(src, "nested", "broken.hcl"), `x = `)
+	writeFile(t, filepath.Join(src, "README.md"), `not hcl`)
+
+	manifest, err := Batch(src, dst, Options{})
+	if err != nil {
+		t.Fatal("batch:", err)
+	}
+
+	if len(manifest.Files) != 2 {
+		t.Fatalf("expected 2 files in the manifest, got %+v", manifest.Files)
+	}
+	if failed := manifest.Failed(); len(failed) != 1 || failed[0].File != "nested/broken.hcl" {
+		t.Errorf("expected nested/broken.hcl to fail, got %+v", failed)
+	}
+
+	entry := manifest.Files[0]
+	if entry.File != "main.tf" || entry.Output != "main.tf.json" || entry.SHA256 == "" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	output, err := ioutil.ReadFile(filepath.Join(dst, entry.Output))
+	if err != nil {
+		t.Fatal("read output:", err)
+	}
+	compareTest(t, output, `{
+	"x": 1
+}`)
+
+	var written Manifest
+	manifestBytes, err := ioutil.ReadFile(filepath.Join(dst, ManifestName))
+	if err != nil {
+		t.Fatal("read manifest:", err)
+	}
+	if err := json.Unmarshal(manifestBytes, &written); err != nil {
+		t.Fatal("unmarshal manifest:", err)
+	}
+	if len(written.Files) != 2 || written.Fingerprint != manifest.Fingerprint {
+		t.Errorf("written manifest doesn't match: %+v", written)
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal("mkdir:", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal("write file:", err)
+	}
+}
+
+func TestBatchJSONInput(t *testing.T) {
+	for _, out := range []string{"out", "."} {
+		src := t.TempDir()
+		writeFile(t, filepath.Join(src, "main.tf"), `x = 1`)
+		writeFile(t, filepath.Join(src, "vars.tf.json"), `{"y": 2}`)
+		dst := filepath.Join(src, out)
+
+		// the second run would pick up the output of the first
+		for run := 0; run < 2; run++ {
+			manifest, err := Batch(src, dst, Options{})
+			if err != nil {
+				t.Fatal("batch:", err)
+			}
+			var files []string
+			for _, entry := range manifest.Files {
+				files = append(files, entry.File)
+			}
+			if strings.Join(files, ",") != "main.tf,vars.tf.json" {
+				t.Errorf("dst %s, run %d: expected main.tf and vars.tf.json, got %v", dst, run, files)
+			}
+		}
+	}
+}
+
+func TestBatchFileTimeout(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "slow.hcl"), `id = uuid()`)
+	writeFile(t, filepath.Join(src, "fast.hcl"), `x = 1`)
+
+	block := make(chan struct{})
+	defer close(block)
+
+	options := Options{
+		Simplify:       true,
+		AllowFunctions: []string{"uuid"},
+		RandSource:     blockingReader(block),
+		FileTimeout:    50 * time.Millisecond,
+	}
+	manifest, err := Batch(src, dst, options)
+	if err != nil {
+		t.Fatal("batch:", err)
+	}
+
+	failed := manifest.Failed()
+	if len(failed) != 1 || failed[0].File != "slow.hcl" || !strings.Contains(failed[0].Error, "timed out") {
+		t.Errorf("expected slow.hcl to time out, got %+v", manifest.Files)
+	}
+}
+
+// blockingReader blocks every read until the channel is closed.
+type blockingReader chan struct{}
+
+func (r blockingReader) Read(p []byte) (int, error) {
+	<-r
+	return 0, io.EOF
+}
@@ -0,0 +1,155 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Fingerprint returns a stable hash of every option affecting the output of
+// a conversion, so caches can tell results converted with different
+// settings apart. Options holding functions or readers, such as Clock, only
+// contribute whether they are set.
+func (o Options) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "simplify=%t\n", o.Simplify)
+
+	names := make([]string, 0, len(o.Variables))
+	for name := range o.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := o.Variables[name]
+		typ, _ := ctyjson.MarshalType(value.Type())
+		val, _ := ctyjson.Marshal(value, value.Type())
+		fmt.Fprintf(h, "variable %q type=%s value=%s\n", name, typ, val)
+	}
+
+	fmt.Fprintf(h, "dialect=%s\n", o.Dialect)
+
+	allow := append([]string{}, o.AllowFunctions...)
+	sort.Strings(allow)
+	fmt.Fprintf(h, "allow=%q\n", allow)
+
+	fmt.Fprintf(h, "clock=%t\n", o.Clock != nil)
+	fmt.Fprintf(h, "rand=%t\n", o.RandSource != nil)
+	fmt.Fprintf(h, "fs=%t\n", o.FS != nil)
+	fmt.Fprintf(h, "maxincludedepth=%d\n", o.MaxIncludeDepth)
+
+	fmt.Fprint(h, "schema=")
+	o.Schema.writeFingerprint(h)
+	fmt.Fprintf(h, "\ninjectdefaults=%t\n", o.InjectDefaults)
+	fmt.Fprintf(h, "canonical=%t\n", o.CanonicalExpressions)
+	fmt.Fprintf(h, "preserveorder=%t\n", o.PreserveOrder)
+	fmt.Fprintf(h, "canonicaloutput=%t\n", o.Canonical)
+	fmt.Fprintf(h, "indent=%q\n", o.Indent)
+	fmt.Fprintf(h, "disablehtmlescaping=%t\n", o.DisableHTMLEscaping)
+	fmt.Fprintf(h, "combinedoutput=%t\n", o.CombinedOutput)
+	fmt.Fprintf(h, "compactlines=%t\n", o.CompactLines)
+	fmt.Fprintf(h, "lineformat=%s\n", o.LineFormat)
+	fmt.Fprintf(h, "outputschemaversion=%d\n", o.OutputSchemaVersion)
+	fmt.Fprintf(h, "bytes=%t\n", o.IncludeByteOffsets)
+	fmt.Fprintf(h, "linekeyprefix=%q\n", o.LineKeyPrefix)
+	fmt.Fprintf(h, "annotatetypes=%t\n", o.AnnotateTypes)
+	fmt.Fprintf(h, "annotateorigins=%t\n", o.AnnotateOrigins)
+	fmt.Fprintf(h, "infertypes=%t\n", o.InferTypes)
+	fmt.Fprintf(h, "includetypes=%s\n", o.IncludeTypes)
+	fmt.Fprintf(h, "expressionmode=%s\n", o.ExpressionMode)
+	fmt.Fprintf(h, "heredocs=%t\n", o.PreserveHeredocs)
+	fmt.Fprintf(h, "structuredfor=%t\n", o.StructuredFor)
+	fmt.Fprintf(h, "structuredconditionals=%t\n", o.StructuredConditionals)
+	fmt.Fprintf(h, "structuredfunctions=%t\n", o.StructuredFunctions)
+	transformers := make([]string, len(o.Transformers))
+	for i, transformer := range o.Transformers {
+		transformers[i] = transformer.Name()
+	}
+	fmt.Fprintf(h, "transformers=%q\n", transformers)
+	fmt.Fprintf(h, "redact=%q\n", o.Redact)
+	processors := make([]string, len(o.PostProcessors))
+	for i, processor := range o.PostProcessors {
+		processors[i] = processor.Name()
+	}
+	fmt.Fprintf(h, "postprocessors=%q\n", processors)
+	rules := make([]string, len(o.Rules))
+	for i, r := range o.Rules {
+		rules[i] = r.Name()
+	}
+	fmt.Fprintf(h, "rules=%q\n", rules)
+	var encoder string
+	if o.Encoder != nil {
+		encoder = o.Encoder.Name()
+	}
+	fmt.Fprintf(h, "encoder=%q\n", encoder)
+	fmt.Fprintf(h, "skeleton=%t\n", o.Skeleton)
+	fmt.Fprintf(h, "allowerrors=%t\n", o.AllowErrors)
+	fmt.Fprintf(h, "inputsyntax=%s\n", o.InputSyntax)
+	fmt.Fprintf(h, "provenance=%t\n", o.Provenance)
+	fmt.Fprint(h, "catalog=")
+	o.Catalog.writeFingerprint(h)
+	fmt.Fprintln(h)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Cache holds conversion results keyed by the input and the fingerprint of
+// the options used, so changing any conversion setting invalidates entries.
+// A Cache is safe for concurrent use. Results are shared between callers,
+// so they must not be modified; use Result.Clone to get a private copy.
+type Cache struct {
+	mu      sync.Mutex
+	results map[string]*Result
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	return &Cache{results: make(map[string]*Result)}
+}
+
+// Convert returns the cached result for the input and options if there is
+// one, and otherwise converts the input and caches the result.
+func (c *Cache) Convert(bytes []byte, filename string, options Options) (*Result, error) {
+	key := cacheKey(bytes, filename, options)
+	c.mu.Lock()
+	result, ok := c.results[key]
+	c.mu.Unlock()
+	if ok {
+		return result, nil
+	}
+
+	// Convert without holding the lock so unrelated inputs don't wait on
+	// each other. If another caller converted the same input meanwhile,
+	// keep theirs so every caller sees the same result.
+	result, err := Convert(bytes, filename, options)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.results[key]; ok {
+		return cached, nil
+	}
+	c.results[key] = result
+	return result, nil
+}
+
+// Len returns the number of cached results.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.results)
+}
+
+func cacheKey(bytes []byte, filename string, options Options) string {
+	h := sha256.New()
+	io.WriteString(h, filename)
+	h.Write([]byte{0})
+	h.Write(bytes)
+	return hex.EncodeToString(h.Sum(nil)) + ":" + options.Fingerprint()
+}
@@ -0,0 +1,115 @@
+package convert
+
+import (
+	"sync"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := Options{AllowFunctions: []string{"file", "uuid"}}
+	reordered := Options{AllowFunctions: []string{"uuid", "file"}}
+	if base.Fingerprint() != reordered.Fingerprint() {
+		t.Error("function order should not change the fingerprint")
+	}
+
+	simplify := base
+	simplify.Simplify = true
+	if base.Fingerprint() == simplify.Fingerprint() {
+		t.Error("enabling Simplify should change the fingerprint")
+	}
+}
+
+func TestCacheRules(t *testing.T) {
+	finding := func(rule string) Rules {
+		return RulesFunc(rule, func(path string, value interface{}, rng hcl.Range) []Finding {
+			return []Finding{{Rule: rule}}
+		})
+	}
+	first, second := Options{Rules: []Rules{finding("a")}}, Options{Rules: []Rules{finding("b")}}
+	if first.Fingerprint() == second.Fingerprint() {
+		t.Fatal("different rules should change the fingerprint")
+	}
+
+	cache := NewCache()
+	input := []byte(`x = 1`)
+	if _, err := cache.Convert(input, "a.hcl", first); err != nil {
+		t.Fatal("convert:", err)
+	}
+	result, err := cache.Convert(input, "a.hcl", second)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Findings) == 0 || result.Findings[0].Rule != "b" {
+		t.Errorf("expected the findings of the second rules, got %+v", result.Findings)
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache()
+	input := []byte(`x = 1 + 2`)
+
+	first, err := cache.Convert(input, "a.hcl", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	second, err := cache.Convert(input, "a.hcl", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if first != second {
+		t.Error("expected the cached result to be returned")
+	}
+
+	if _, err := cache.Convert(input, "a.hcl", Options{Simplify: true}); err != nil {
+		t.Fatal("convert:", err)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 cached results, got %d", cache.Len())
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	cache := NewCache()
+	inputs := [][]byte{[]byte(`x = 1 + 2`), []byte(`y = "a"`), []byte(`z = [1, 2]`)}
+	options := Options{Simplify: true, Variables: map[string]cty.Value{"v": cty.StringVal("a")}}
+
+	var wg sync.WaitGroup
+	results := make([]*Result, 30)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := cache.Convert(inputs[i%len(inputs)], "a.hcl", options)
+			if err != nil {
+				t.Error("convert:", err)
+				return
+			}
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Len() != len(inputs) {
+		t.Errorf("expected %d cached results, got %d", len(inputs), cache.Len())
+	}
+	for i, result := range results {
+		if result != results[i%len(inputs)] {
+			t.Errorf("result %d differs from the cached result for the same input", i)
+		}
+	}
+}
+
+func TestResultClone(t *testing.T) {
+	result, err := Convert([]byte(`x = 1`), "a.hcl", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	clone := result.Clone()
+	clone.JSON[0] = 'X'
+	if result.JSON[0] == 'X' {
+		t.Error("modifying the clone should not modify the original")
+	}
+}
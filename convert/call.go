@@ -0,0 +1,40 @@
+package convert
+
+import "github.com/hashicorp/hcl/v2/hclsyntax"
+
+// FunctionCallKey is the key of the object function calls are written as
+// when Options.StructuredFunctions is set and they aren't evaluated:
+//
+//	{"__call__": {"name": "file", "args": ["${path.module}/policy.json"]}}
+//
+// with "expand": true when the final argument is expanded with "...".
+const FunctionCallKey = "__call__"
+
+// callLines records the name of the function called and the position of
+// each argument in the line info of a call.
+func (c *converter) callLines(line lineObj, call *hclsyntax.FunctionCallExpr) {
+	line[c.key("function")] = call.Name
+	args := make([]interface{}, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = c.rangeLines(arg.Range())
+	}
+	line[c.key("arguments")] = args
+}
+
+// convertCall returns the structured form of a function call, with its
+// arguments converted like attribute values.
+func (c *converter) convertCall(call *hclsyntax.FunctionCallExpr) (interface{}, error) {
+	args := make([]interface{}, len(call.Args))
+	for i, arg := range call.Args {
+		value, _, err := c.convertExpression(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	fields := map[string]interface{}{"name": call.Name, "args": args}
+	if call.ExpandFinal {
+		fields["expand"] = true
+	}
+	return jsonObj{FunctionCallKey: fields}, nil
+}
@@ -0,0 +1,38 @@
+package convert
+
+import "testing"
+
+func TestFunctionCalls(t *testing.T) {
+	input := []byte(`policy = file("${path.module}/policy.json")
+args   = join(",", var.list...)
+`)
+
+	result, err := Convert(input, "main.tf", Options{StructuredFunctions: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"policy": {"__call__": {"name": "file", "args": ["${path.module}/policy.json"]}},
+	"args": {"__call__": {"name": "join", "args": [",", "${var.list}"], "expand": true}}
+}`))
+
+	lines := decodeLines(t, result.Lines)
+	policy := lines["policy"].(map[string]interface{})
+	if policy["function"] != "file" {
+		t.Errorf("expected the function in the line info, got %v", policy)
+	}
+	arg := policy["arguments"].([]interface{})[0].(map[string]interface{})
+	if arg["line"] != 1.0 || arg["startIndex"] != 15.0 || arg["endIndex"] != 43.0 {
+		t.Errorf("unexpected argument position %v", arg)
+	}
+
+	compact, err := CompactLines(result.Lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := ExpandLines(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, expanded, result.Lines)
+}
@@ -0,0 +1,127 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Category groups diagnostics, findings and errors by where they come from,
+// so they can be filtered and routed.
+type Category string
+
+const (
+	// CategorySyntax is for source which couldn't be parsed.
+	CategorySyntax Category = "syntax"
+	// CategorySchema is for source which doesn't match the schema.
+	CategorySchema Category = "schema"
+	// CategoryLint is for issues found by analysis and lint rules.
+	CategoryLint Category = "lint"
+	// CategoryLimit is for work stopped by a limit, such as a timeout.
+	CategoryLimit Category = "limit"
+)
+
+// DocsURL is the page documenting every code, with an anchor for each.
+const DocsURL = "https://github.com/ckndave/hclparser/blob/main/docs/diagnostics.md"
+
+// Code is the machine-readable identity of a diagnostic, finding or error.
+// Its ID is the message ID of diagnostics and the rule of findings.
+type Code struct {
+	ID       string   `json:"id"`
+	Category Category `json:"category"`
+	URL      string   `json:"url"`
+}
+
+// categories holds the category of every code by ID.
+var categories = map[string]Category{
+	"syntax-error": CategorySyntax,
+
+	"unsupported-argument":      CategorySchema,
+	"missing-required-argument": CategorySchema,
+	"unsupported-block-type":    CategorySchema,
+	"missing-required-block":    CategorySchema,
+	"unexpected-block-labels":   CategorySchema,
+	"deprecated-argument":       CategorySchema,
+	"incorrect-value-type":      CategorySchema,
+	"value-not-allowed":         CategorySchema,
+
+	"undeclared-reference":    CategoryLint,
+	"module-ref-missing":      CategoryLint,
+	"module-ref-mutable":      CategoryLint,
+	"module-version-missing":  CategoryLint,
+	"module-version-unpinned": CategoryLint,
+
+	"function-not-allowed":   CategoryLimit,
+	"include-cycle":          CategoryLimit,
+	"include-depth-exceeded": CategoryLimit,
+	"timeout":                CategoryLimit,
+	"request-too-large":      CategoryLimit,
+	"too-many-requests":      CategoryLimit,
+}
+
+// CodeFor returns the code with the given ID, reporting whether it's known.
+func CodeFor(id string) (Code, bool) {
+	category, ok := categories[id]
+	if !ok {
+		return Code{}, false
+	}
+	return Code{ID: id, Category: category, URL: DocsURL + "#" + id}, true
+}
+
+// Codes returns every known code, ordered by ID.
+func Codes() []Code {
+	ids := make([]string, 0, len(categories))
+	for id := range categories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	codes := make([]Code, len(ids))
+	for i, id := range ids {
+		codes[i], _ = CodeFor(id)
+	}
+	return codes
+}
+
+// Code returns the code of a diagnostic in the result, reporting whether
+// it has one.
+func (r *Result) Code(diag *hcl.Diagnostic) (Code, bool) {
+	m, ok := r.Messages[diag]
+	if !ok {
+		return Code{}, false
+	}
+	return CodeFor(m.ID)
+}
+
+// Code returns the code of the finding's rule.
+func (f Finding) Code() (Code, bool) {
+	return CodeFor(f.Rule)
+}
+
+// ErrorCode returns the code of an error, or of any error it wraps, which
+// has one, such as an IncludeError or TimeoutError.
+func ErrorCode(err error) (Code, bool) {
+	var coded interface{ Code() Code }
+	if !errors.As(err, &coded) {
+		return Code{}, false
+	}
+	return coded.Code(), true
+}
+
+// TimeoutError is returned when converting a file takes longer than
+// Options.FileTimeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("conversion timed out after %s", e.Timeout)
+}
+
+// Code returns the timeout code.
+func (e *TimeoutError) Code() Code {
+	code, _ := CodeFor("timeout")
+	return code
+}
@@ -0,0 +1,61 @@
+package convert
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodes(t *testing.T) {
+	schema := &Schema{Attributes: map[string]*AttributeSchema{"name": {}}}
+	result, err := Convert([]byte("nmae = \"web\"\nname = \"x\",\n"), "main.hcl", Options{Schema: schema, AllowErrors: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	categories := make(map[Category]bool)
+	for _, diag := range result.Diagnostics {
+		code, ok := result.Code(diag)
+		if !ok {
+			t.Errorf("expected a code for %v", diag)
+			continue
+		}
+		categories[code.Category] = true
+	}
+	if !categories[CategorySyntax] || !categories[CategorySchema] {
+		t.Errorf("expected syntax and schema diagnostics, got %v", categories)
+	}
+
+	code, ok := CodeFor("unsupported-argument")
+	if !ok || code.URL != DocsURL+"#unsupported-argument" {
+		t.Errorf("unexpected code %+v", code)
+	}
+
+	err = fmt.Errorf("convert: %w", &IncludeError{Chain: []string{"a", "b", "a"}, Cycle: true})
+	if code, ok := ErrorCode(err); !ok || code.ID != "include-cycle" || code.Category != CategoryLimit {
+		t.Errorf("unexpected code %+v for %v", code, err)
+	}
+	if _, ok := ErrorCode(fmt.Errorf("other")); ok {
+		t.Error("expected no code for a plain error")
+	}
+}
+
+// TestCodesDocumented checks every code has a section in the docs its URL
+// points to, and every message has a code.
+func TestCodesDocumented(t *testing.T) {
+	docs, err := ioutil.ReadFile(filepath.Join("..", "docs", "diagnostics.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range Codes() {
+		if !strings.Contains(string(docs), "\n## "+code.ID+"\n") {
+			t.Errorf("%s isn't documented", code.ID)
+		}
+	}
+	for id := range DefaultCatalog {
+		if _, ok := CodeFor(id); !ok && id != "did-you-mean" {
+			t.Errorf("message %s has no code", id)
+		}
+	}
+}
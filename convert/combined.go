@@ -0,0 +1,63 @@
+package convert
+
+// combined returns the output with the value of each entry of the line info
+// which has a position replaced by an object holding the value, combined in
+// turn, under "value" and its position under "range", as in the flat line
+// info. Values without a position, such as the objects grouping blocks by
+// their labels, are kept as they are. With ordered, the keys of objects
+// are in the order they appear in the source. prefix is
+// Options.LineKeyPrefix.
+func combined(output, lines interface{}, prefix string, ordered bool) (interface{}, error) {
+	var out, outLines interface{}
+	if err := decodedCopy(output, &out); err != nil {
+		return nil, err
+	}
+	if err := decodedCopy(lines, &outLines); err != nil {
+		return nil, err
+	}
+	return combineNode(out, outLines, prefix, ordered), nil
+}
+
+func combineNode(value, line interface{}, prefix string, ordered bool) interface{} {
+	l, _ := line.(map[string]interface{})
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if !ordered {
+			obj := make(map[string]interface{}, len(v))
+			for key, elem := range v {
+				obj[key] = combineNode(elem, l[key], prefix, ordered)
+			}
+			value = obj
+			break
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sortSourceOrder(keys, l, prefix)
+		obj := make(orderedObj, 0, len(keys))
+		for _, key := range keys {
+			obj = append(obj, orderedEntry{key: key, value: combineNode(v[key], l[key], prefix, ordered)})
+		}
+		value = obj
+	case []interface{}:
+		elemLines, isBlocks := line.([]interface{})
+		if !isBlocks {
+			elemLines, _ = l[prefix+"lines"].([]interface{})
+		}
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			var elemLine interface{}
+			if i < len(elemLines) {
+				elemLine = elemLines[i]
+			}
+			list[i] = combineNode(elem, elemLine, prefix, ordered)
+		}
+		value = list
+	}
+
+	if _, ok := l[prefix+"line"]; !ok {
+		return value
+	}
+	return map[string]interface{}{"value": value, "range": flatLine(l, prefix)}
+}
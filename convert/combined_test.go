@@ -0,0 +1,73 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCombinedOutput(t *testing.T) {
+	src := []byte(`zone = "a"
+ports = [80, 443]
+resource "aws_instance" "web" {
+  ami = "ami-1"
+}
+`)
+	result, err := Convert(src, "main.tf", Options{CombinedOutput: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+		"range": {"line": 1, "endLine": 6, "startCol": 1, "endCol": 1},
+		"value": {
+			"zone": {
+				"value": "a",
+				"range": {"line": 1, "endLine": 1, "startCol": 8, "endCol": 11, "key": {"line": 1, "endLine": 1, "startCol": 1, "endCol": 5}}
+			},
+			"ports": {
+				"value": [
+					{"value": 80, "range": {"line": 2, "endLine": 2, "startCol": 10, "endCol": 12}},
+					{"value": 443, "range": {"line": 2, "endLine": 2, "startCol": 14, "endCol": 17}}
+				],
+				"range": {"line": 2, "endLine": 2, "startCol": 9, "endCol": 18, "key": {"line": 2, "endLine": 2, "startCol": 1, "endCol": 6}}
+			},
+			"resource": [
+				{
+					"aws_instance": {
+						"web": {
+							"value": {
+								"ami": {
+									"value": "ami-1",
+									"range": {"line": 4, "endLine": 4, "startCol": 9, "endCol": 16, "key": {"line": 4, "endLine": 4, "startCol": 3, "endCol": 6}}
+								}
+							},
+							"range": {
+								"line": 3, "endLine": 5, "startCol": 31, "endCol": 2,
+								"key": {"line": 3, "endLine": 3, "startCol": 1, "endCol": 30},
+								"labels": [
+									{"line": 3, "endLine": 3, "startCol": 10, "endCol": 24},
+									{"line": 3, "endLine": 3, "startCol": 25, "endCol": 30}
+								]
+							}
+						}
+					}
+				}
+			]
+		}
+	}`))
+}
+
+func TestCombinedOutputOrder(t *testing.T) {
+	result, err := Convert([]byte("b = 1\na = 2\n"), "main.tf", Options{CombinedOutput: true, PreserveOrder: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if b, a := strings.Index(string(result.JSON), `"b"`), strings.Index(string(result.JSON), `"a"`); b < 0 || a < b {
+		t.Errorf("expected b before a, got %s", result.JSON)
+	}
+}
+
+func TestCombinedOutputInlineTypes(t *testing.T) {
+	if _, err := Convert([]byte("a = 1\n"), "main.tf", Options{CombinedOutput: true, IncludeTypes: TypesInline}); err == nil {
+		t.Error("expected inline types to be rejected")
+	}
+}
@@ -0,0 +1,356 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// CompactLinesVersion identifies the compact line info encoding.
+const CompactLinesVersion = 1
+
+// compactLines is the compact encoding of line info. Each entry of the line
+// info becomes an array of [line, startIndex, endIndex, endLine, file],
+// where file indexes Strings or is -1, followed by an object holding
+// anything else the entry had:
+//
+//	"t": the index in Strings of its type
+//	"k": the [line, startIndex, endIndex] of its key
+//	"c": its children, keyed by name
+//	"l": the entries for the elements of a tuple
+//	"s": true when the entry is synthetic
+//	"e": its canonical expression
+//	"n": the labels of a block, in OutputSchemaV2
+//	"r": the entries for the positions of a block's labels
+//	"b": its [startByte, endByte], with Options.IncludeByteOffsets
+//	"kb": the [startByte, endByte] of its key
+//	"y": its schemaType, with Options.AnnotateTypes
+//	"f": the index in Strings of the function called, for function calls
+//	"a": the entries for the arguments of a function call
+//	"o": the index in Strings of its origin, with Options.AnnotateOrigins
+//
+// Entries without a position, such as block labels, have a line of 0.
+type compactLines struct {
+	Version int           `json:"version"`
+	Strings []string      `json:"strings"`
+	Lines   []interface{} `json:"lines"`
+}
+
+// lineMetaKeys are the keys of a line info entry describing the entry
+// itself rather than its children.
+var lineMetaKeys = map[string]bool{
+	"line": true, "startIndex": true, "endIndex": true, "endLine": true, "file": true,
+	"type": true, "lines": true, "synthetic": true, "canonical": true, "labels": true,
+	"__key__line": true, "__key__startIndex": true, "__key__endIndex": true,
+	"startByte": true, "endByte": true, "__key__startByte": true, "__key__endByte": true,
+	"schemaType": true, "function": true, "arguments": true, "origin": true,
+	"labelRanges": true,
+}
+
+// CompactLines rewrites line info produced by Bytes or File in the compact
+// encoding used when Options.CompactLines is set, which is less than half
+// the size. ExpandLines reverses it.
+func CompactLines(lines []byte) ([]byte, error) {
+	var root map[string]interface{}
+	if err := decodeJSON(lines, &root); err != nil {
+		return nil, fmt.Errorf("parse line info: %w", err)
+	}
+
+	encoder := lineEncoder{indexes: make(map[string]int)}
+	compact := compactLines{Version: CompactLinesVersion, Lines: encoder.entry(root)}
+	compact.Strings = encoder.strings
+	if compact.Strings == nil {
+		compact.Strings = []string{}
+	}
+	return json.Marshal(compact)
+}
+
+type lineEncoder struct {
+	strings []string
+	indexes map[string]int
+}
+
+func (e *lineEncoder) intern(s string) int {
+	if i, ok := e.indexes[s]; ok {
+		return i
+	}
+	e.indexes[s] = len(e.strings)
+	e.strings = append(e.strings, s)
+	return len(e.strings) - 1
+}
+
+func (e *lineEncoder) entry(line map[string]interface{}) []interface{} {
+	file := -1
+	if name, ok := line["file"].(string); ok {
+		file = e.intern(name)
+	}
+	entry := []interface{}{
+		compactNumber(line["line"]),
+		compactNumber(line["startIndex"]),
+		compactNumber(line["endIndex"]),
+		compactNumber(line["endLine"]),
+		file,
+	}
+
+	extra := make(map[string]interface{})
+	if typ, ok := line["type"].(string); ok {
+		extra["t"] = e.intern(typ)
+	}
+	if _, ok := line["__key__line"]; ok {
+		extra["k"] = []interface{}{
+			compactNumber(line["__key__line"]),
+			compactNumber(line["__key__startIndex"]),
+			compactNumber(line["__key__endIndex"]),
+		}
+	}
+	if _, ok := line["startByte"]; ok {
+		extra["b"] = []interface{}{line["startByte"], line["endByte"]}
+	}
+	if _, ok := line["__key__startByte"]; ok {
+		extra["kb"] = []interface{}{line["__key__startByte"], line["__key__endByte"]}
+	}
+	if typ, ok := line["schemaType"]; ok {
+		extra["y"] = typ
+	}
+	if elems, ok := line["lines"].([]interface{}); ok {
+		extra["l"] = e.list(elems)
+	}
+	if function, ok := line["function"].(string); ok {
+		extra["f"] = e.intern(function)
+	}
+	if args, ok := line["arguments"].([]interface{}); ok {
+		extra["a"] = e.list(args)
+	}
+	if synthetic, ok := line["synthetic"].(bool); ok && synthetic {
+		extra["s"] = true
+	}
+	if origin, ok := line["origin"].(string); ok {
+		extra["o"] = e.intern(origin)
+	}
+	if canonical, ok := line["canonical"].(string); ok {
+		extra["e"] = canonical
+	}
+	if labels, ok := line["labels"].([]interface{}); ok {
+		extra["n"] = labels
+	}
+	if ranges, ok := line["labelRanges"].([]interface{}); ok {
+		extra["r"] = e.list(ranges)
+	}
+
+	// Children are encoded in key order, so strings are interned in the
+	// same order each time.
+	keys := make([]string, 0, len(line))
+	for key := range line {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make(map[string]interface{})
+	for _, key := range keys {
+		if lineMetaKeys[key] {
+			continue
+		}
+		switch v := line[key].(type) {
+		case map[string]interface{}:
+			children[key] = e.entry(v)
+		case []interface{}:
+			children[key] = e.list(v)
+		}
+	}
+	if len(children) > 0 {
+		extra["c"] = children
+	}
+
+	if len(extra) > 0 {
+		entry = append(entry, extra)
+	}
+	return entry
+}
+
+// list encodes a list of entries, which is told apart from an entry by not
+// starting with a number.
+func (e *lineEncoder) list(values []interface{}) []interface{} {
+	list := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if line, ok := value.(map[string]interface{}); ok {
+			list = append(list, e.entry(line))
+		}
+	}
+	return list
+}
+
+func compactNumber(value interface{}) interface{} {
+	if value == nil {
+		return 0
+	}
+	return value
+}
+
+// ExpandLines converts line info in the compact encoding back to the form
+// produced by Bytes or File.
+func ExpandLines(compact []byte) ([]byte, error) {
+	var parsed compactLines
+	if err := decodeJSON(compact, &parsed); err != nil {
+		return nil, fmt.Errorf("parse compact line info: %w", err)
+	}
+	if parsed.Version != CompactLinesVersion {
+		return nil, fmt.Errorf("unsupported compact line info version %d", parsed.Version)
+	}
+
+	decoder := lineDecoder{strings: parsed.Strings}
+	root, err := decoder.entry(parsed.Lines)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(root)
+}
+
+type lineDecoder struct {
+	strings []string
+}
+
+func (d *lineDecoder) str(value interface{}) (string, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return "", fmt.Errorf("expected a string index, got %v", value)
+	}
+	i, err := n.Int64()
+	if err != nil || i < 0 || int(i) >= len(d.strings) {
+		return "", fmt.Errorf("string index %v out of range", value)
+	}
+	return d.strings[i], nil
+}
+
+func (d *lineDecoder) entry(entry []interface{}) (map[string]interface{}, error) {
+	if len(entry) < 5 {
+		return nil, fmt.Errorf("line entry has %d fields, expected at least 5", len(entry))
+	}
+
+	line := make(map[string]interface{})
+	if entry[0] != json.Number("0") {
+		line["line"] = entry[0]
+		line["startIndex"] = entry[1]
+		line["endIndex"] = entry[2]
+		line["endLine"] = entry[3]
+	}
+	if entry[4] != json.Number("-1") {
+		file, err := d.str(entry[4])
+		if err != nil {
+			return nil, err
+		}
+		line["file"] = file
+	}
+	if len(entry) < 6 {
+		return line, nil
+	}
+
+	extra, ok := entry[5].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object of extra fields, got %v", entry[5])
+	}
+	if t, ok := extra["t"]; ok {
+		typ, err := d.str(t)
+		if err != nil {
+			return nil, err
+		}
+		line["type"] = typ
+	}
+	if k, ok := extra["k"].([]interface{}); ok && len(k) == 3 {
+		line["__key__line"] = k[0]
+		line["__key__startIndex"] = k[1]
+		line["__key__endIndex"] = k[2]
+	}
+	if b, ok := extra["b"].([]interface{}); ok && len(b) == 2 {
+		line["startByte"] = b[0]
+		line["endByte"] = b[1]
+	}
+	if kb, ok := extra["kb"].([]interface{}); ok && len(kb) == 2 {
+		line["__key__startByte"] = kb[0]
+		line["__key__endByte"] = kb[1]
+	}
+	if typ, ok := extra["y"]; ok {
+		line["schemaType"] = typ
+	}
+	if l, ok := extra["l"].([]interface{}); ok {
+		elems, err := d.list(l)
+		if err != nil {
+			return nil, err
+		}
+		line["lines"] = elems
+	}
+	if f, ok := extra["f"]; ok {
+		function, err := d.str(f)
+		if err != nil {
+			return nil, err
+		}
+		line["function"] = function
+	}
+	if a, ok := extra["a"].([]interface{}); ok {
+		args, err := d.list(a)
+		if err != nil {
+			return nil, err
+		}
+		line["arguments"] = args
+	}
+	if s, ok := extra["s"].(bool); ok && s {
+		line["synthetic"] = true
+	}
+	if o, ok := extra["o"]; ok {
+		origin, err := d.str(o)
+		if err != nil {
+			return nil, err
+		}
+		line["origin"] = origin
+	}
+	if e, ok := extra["e"].(string); ok {
+		line["canonical"] = e
+	}
+	if n, ok := extra["n"].([]interface{}); ok {
+		line["labels"] = n
+	}
+	if r, ok := extra["r"].([]interface{}); ok {
+		ranges, err := d.list(r)
+		if err != nil {
+			return nil, err
+		}
+		line["labelRanges"] = ranges
+	}
+	if children, ok := extra["c"].(map[string]interface{}); ok {
+		for key, value := range children {
+			child, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected an array for %s, got %v", key, value)
+			}
+			var err error
+			if len(child) > 0 && isNumber(child[0]) {
+				line[key], err = d.entry(child)
+			} else {
+				line[key], err = d.list(child)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return line, nil
+}
+
+func (d *lineDecoder) list(values []interface{}) ([]interface{}, error) {
+	list := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		entry, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a line entry, got %v", value)
+		}
+		line, err := d.entry(entry)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, line)
+	}
+	return list, nil
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := value.(json.Number)
+	return ok
+}
@@ -0,0 +1,52 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestCompactLines(t *testing.T) {
+	input := `
+variable "zones" {
+	default = ["a", "b"]
+}
+
+resource "aws_instance" "web" {
+	ami  = "ami-123"
+	tags = { Name = "web" }
+
+	ebs_block_device {
+		device_name = "/dev/sdb"
+	}
+	ebs_block_device {
+		device_name = "/dev/sdc"
+	}
+}`
+
+	options := Options{CanonicalExpressions: true}
+	_, lines, err := Bytes([]byte(input), "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	options.CompactLines = true
+	_, compact, err := Bytes([]byte(input), "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(compact)*2 > len(lines) {
+		t.Errorf("expected compact line info to be under half the size, got %d of %d bytes", len(compact), len(lines))
+	}
+
+	expanded, err := ExpandLines(compact)
+	if err != nil {
+		t.Fatal("expand lines:", err)
+	}
+	var expected, actual bytes.Buffer
+	json.Indent(&expected, lines, "", "  ")
+	json.Indent(&actual, expanded, "", "  ")
+	if expected.String() != actual.String() {
+		t.Errorf("expanding changed the line info:\nexpected %s\ngot %s", expected.String(), actual.String())
+	}
+}
@@ -0,0 +1,531 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExternalTool is another HCL to JSON converter, run as a command reading
+// a file's source on stdin and writing its JSON to stdout.
+type ExternalTool struct {
+	Name    string
+	Command []string
+}
+
+// HCL2JSON is github.com/tmccombs/hcl2json, which must be on the PATH.
+var HCL2JSON = ExternalTool{Name: "hcl2json", Command: []string{"hcl2json"}}
+
+// Compat converts every HCL and Terraform file under src with options and
+// with tool, reporting where the tool's output differs from this
+// converter's, which is the report's baseline, to quantify the differences
+// met when migrating from the tool. A file the tool fails on is recorded
+// in the report rather than stopping it, as is one taking longer than
+// Options.FileTimeout.
+func Compat(src string, tool ExternalTool, options Options) (*CorpusReport, error) {
+	if len(tool.Command) == 0 {
+		return nil, fmt.Errorf("no command given for %s", tool.Name)
+	}
+	files, err := sourceFiles(src)
+	if err != nil {
+		return nil, fmt.Errorf("list source files: %w", err)
+	}
+
+	report := &CorpusReport{
+		Source:   src,
+		Baseline: "hclparser",
+		Files:    make([]CorpusFile, 0, len(files)),
+		Changed:  make(map[string]int),
+	}
+	for _, rel := range files {
+		file, err := compatFile(src, rel, tool, options)
+		if err != nil {
+			return nil, err
+		}
+		if len(file.Differences) > 0 {
+			report.Changed[tool.Name]++
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+func compatFile(src, rel string, tool ExternalTool, options Options) (CorpusFile, error) {
+	file := CorpusFile{File: rel}
+	bytes, err := ioutil.ReadFile(filepath.Join(src, rel))
+	if err != nil {
+		return file, fmt.Errorf("read file: %w", err)
+	}
+	fail := func(name string, err error) {
+		if file.Errors == nil {
+			file.Errors = make(map[string]string)
+		}
+		file.Errors[name] = err.Error()
+	}
+
+	var ours, theirs map[string]string
+	result, err := convertIsolated(bytes, rel, options)
+	if err == nil {
+		ours, err = flattenOutput(result.JSON)
+	}
+	if err != nil {
+		fail("hclparser", err)
+	}
+	output, err := tool.run("", bytes, options)
+	if err == nil {
+		theirs, err = flattenOutput(output)
+	}
+	if err != nil {
+		fail(tool.Name, err)
+	}
+	if ours == nil || theirs == nil {
+		return file, nil
+	}
+
+	for _, path := range unionKeys(ours, theirs) {
+		if ours[path] != theirs[path] {
+			file.Differences = append(file.Differences, CorpusDifference{
+				Configuration: tool.Name,
+				Path:          path,
+				Baseline:      ours[path],
+				Value:         theirs[path],
+			})
+		}
+	}
+	return file, nil
+}
+
+// run runs the tool in dir, or the current directory if it's empty, with
+// src on its stdin if it's not nil.
+func (t ExternalTool) run(dir string, src []byte, options Options) ([]byte, error) {
+	ctx := context.Background()
+	if options.FileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.FileTimeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.Command[0], t.Command[1:]...)
+	cmd.Dir = dir
+	if src != nil {
+		cmd.Stdin = bytes.NewReader(src)
+	}
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("run %s: %w: %s", t.Name, err, msg)
+		}
+		return nil, fmt.Errorf("run %s: %w", t.Name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// TerraformShow runs terraform show -json on the plan saved at planFile,
+// relative to dir, which must hold the initialized configuration it was
+// planned from, returning its output for CompatTerraform.
+func TerraformShow(dir, planFile string, options Options) ([]byte, error) {
+	tool := ExternalTool{Name: "terraform", Command: []string{"terraform", "show", "-json", planFile}}
+	return tool.run(dir, nil, options)
+}
+
+// terraformConfig holds the parts of the configuration in the output of
+// terraform show -json which CompatTerraform compares.
+type terraformConfig struct {
+	ProviderConfig map[string]struct {
+		ModuleAddress string                 `json:"module_address"`
+		Expressions   map[string]interface{} `json:"expressions"`
+	} `json:"provider_config"`
+	RootModule struct {
+		Resources []struct {
+			Address           string                 `json:"address"`
+			Expressions       map[string]interface{} `json:"expressions"`
+			CountExpression   interface{}            `json:"count_expression"`
+			ForEachExpression interface{}            `json:"for_each_expression"`
+		} `json:"resources"`
+		ModuleCalls map[string]struct {
+			Source            string                 `json:"source"`
+			VersionConstraint string                 `json:"version_constraint"`
+			Expressions       map[string]interface{} `json:"expressions"`
+			CountExpression   interface{}            `json:"count_expression"`
+			ForEachExpression interface{}            `json:"for_each_expression"`
+		} `json:"module_calls"`
+		Variables map[string]map[string]interface{} `json:"variables"`
+		Outputs   map[string]struct {
+			Expression  interface{} `json:"expression"`
+			Sensitive   bool        `json:"sensitive"`
+			Description string      `json:"description"`
+		} `json:"outputs"`
+	} `json:"root_module"`
+}
+
+// CompatTerraform converts the directory dir with options, as ConvertDir
+// does, and reports where Terraform's view of its root module differs,
+// taken from the configuration in plan, the output of terraform show -json
+// for a plan of dir such as returned by TerraformShow. Resources, data
+// sources, variables, outputs, module calls and provider configurations
+// are compared, under their Terraform addresses. Terraform gives the value
+// of an attribute only if it is constant, so attributes holding
+// expressions match as long as both sides hold one. Locals, lifecycle,
+// provisioner, connection and dynamic blocks aren't part of Terraform's
+// view and aren't compared. The report holds the directory as a single
+// file named ".".
+func CompatTerraform(dir string, plan []byte, options Options) (*CorpusReport, error) {
+	if err := options.terraformComparable(); err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Configuration *terraformConfig `json:"configuration"`
+	}
+	if err := json.Unmarshal(plan, &parsed); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	if parsed.Configuration == nil {
+		return nil, fmt.Errorf("the plan has no configuration; terraform show -json must be given a saved plan")
+	}
+	result, err := ConvertDir(dir, options)
+	if err != nil {
+		return nil, err
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(result.JSON, &cfg); err != nil {
+		return nil, fmt.Errorf("parse output: %w", err)
+	}
+
+	d := terraformDiffer{}
+	d.compare(cfg, parsed.Configuration)
+	report := &CorpusReport{
+		Source:   dir,
+		Baseline: "hclparser",
+		Files:    []CorpusFile{{File: ".", Differences: d.differences}},
+		Changed:  make(map[string]int),
+	}
+	if len(d.differences) > 0 {
+		report.Changed["terraform"] = 1
+	}
+	return report, nil
+}
+
+// terraformComparable returns an error if o changes the layout of the JSON
+// so that it can't be compared with Terraform's.
+func (o Options) terraformComparable() error {
+	if o.CombinedOutput || o.IncludeTypes == TypesInline || o.Encoder != nil || len(o.PostProcessors) > 0 {
+		return fmt.Errorf("CombinedOutput, inline IncludeTypes, Encoder and PostProcessors are not supported when comparing with Terraform")
+	}
+	if o.ExpressionMode != ExpressionModeWrapped || o.StructuredFor || o.StructuredConditionals || o.StructuredFunctions || o.PreserveHeredocs {
+		return fmt.Errorf("only wrapped expressions are supported when comparing with Terraform")
+	}
+	if o.Skeleton {
+		return fmt.Errorf("Skeleton is not supported when comparing with Terraform")
+	}
+	if o.Dialect.profile() != nil {
+		return fmt.Errorf("the %s dialect is not supported when comparing with Terraform", o.Dialect)
+	}
+	if version, err := o.outputSchemaVersion(); err != nil || version != OutputSchemaV1 {
+		return fmt.Errorf("only OutputSchemaV1 is supported when comparing with Terraform")
+	}
+	return nil
+}
+
+// terraformDiffer collects the differences between converted JSON and
+// Terraform's view of the same configuration.
+type terraformDiffer struct {
+	differences []CorpusDifference
+}
+
+// resourceMetaArguments are the keys of resource bodies Terraform doesn't
+// give among their expressions. count and for_each are compared with
+// count_expression and for_each_expression instead.
+var resourceMetaArguments = []string{"provider", "depends_on", "lifecycle", "provisioner", "connection", "dynamic"}
+
+func (d *terraformDiffer) compare(cfg map[string]interface{}, tf *terraformConfig) {
+	resources := labelledBlocks(cfg, "resource", 2)
+	for address, body := range labelledBlocks(cfg, "data", 2) {
+		resources["data."+address] = body
+	}
+	theirs := make(map[string]interface{})
+	for _, resource := range tf.RootModule.Resources {
+		theirs[resource.Address] = withMetaExpressions(resource.Expressions, resource.CountExpression, resource.ForEachExpression)
+	}
+	for _, address := range unionBlockKeys(resources, theirs) {
+		if body, ok := resources[address]; ok {
+			deleteKeys(body, resourceMetaArguments...)
+		}
+		d.compareBlock(address, resources[address], theirs[address])
+	}
+
+	variables, theirs := labelledBlocks(cfg, "variable", 1), make(map[string]interface{})
+	for name, variable := range tf.RootModule.Variables {
+		theirs[name] = variable
+	}
+	for _, name := range unionBlockKeys(variables, theirs) {
+		d.compareFields("var."+name, variables[name], theirs[name], "default", "description", "sensitive")
+	}
+
+	outputs, theirs := labelledBlocks(cfg, "output", 1), make(map[string]interface{})
+	for name, output := range tf.RootModule.Outputs {
+		theirs[name] = map[string]interface{}{"value": output.Expression, "description": output.Description, "sensitive": output.Sensitive}
+	}
+	for _, name := range unionBlockKeys(outputs, theirs) {
+		path := "output." + name
+		d.compareFields(path, outputs[name], theirs[name], "description", "sensitive")
+		if fields, ok := theirs[name].(map[string]interface{}); ok && outputs[name] != nil {
+			d.compareBody(path, map[string]interface{}{"value": outputs[name]["value"]}, map[string]interface{}{"value": fields["value"]})
+		}
+	}
+
+	modules, theirs := labelledBlocks(cfg, "module", 1), make(map[string]interface{})
+	for name, call := range tf.RootModule.ModuleCalls {
+		body := withMetaExpressions(call.Expressions, call.CountExpression, call.ForEachExpression)
+		body["source"], body["version"] = call.Source, call.VersionConstraint
+		theirs[name] = body
+	}
+	for _, name := range unionBlockKeys(modules, theirs) {
+		path := "module." + name
+		d.compareFields(path, modules[name], theirs[name], "source", "version")
+		if body, ok := modules[name]; ok {
+			deleteKeys(body, "source", "version", "providers", "depends_on")
+		}
+		if body, ok := theirs[name].(map[string]interface{}); ok {
+			deleteKeys(body, "source", "version")
+		}
+		d.compareBlock(path, modules[name], theirs[name])
+	}
+
+	providers, theirs := make(map[string]map[string]interface{}), make(map[string]interface{})
+	// blocks are keyed by name and alias, as several configure one provider
+	blocks, _ := cfg["provider"].([]interface{})
+	for _, block := range blocks {
+		obj, _ := block.(map[string]interface{})
+		for name, value := range obj {
+			body, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if alias, ok := body["alias"].(string); ok {
+				name += "." + alias
+			}
+			deleteKeys(body, "alias", "version")
+			providers[name] = body
+		}
+	}
+	for key, provider := range tf.ProviderConfig {
+		// providers configured in child modules, or which are required
+		// but never configured, have no provider block in the root module
+		if provider.ModuleAddress != "" || (provider.Expressions == nil && providers[key] == nil) {
+			continue
+		}
+		theirs[key] = withMetaExpressions(provider.Expressions, nil, nil)
+	}
+	for _, key := range unionBlockKeys(providers, theirs) {
+		d.compareBlock("provider."+key, providers[key], theirs[key])
+	}
+}
+
+// compareBlock compares the body of a block with its expressions in
+// Terraform's view, either of which may be missing.
+func (d *terraformDiffer) compareBlock(path string, ours map[string]interface{}, theirs interface{}) {
+	body, ok := theirs.(map[string]interface{})
+	if ours == nil || !ok {
+		d.add(path, ours, ours != nil, theirs, ok)
+		return
+	}
+	d.compareBody(path, ours, body)
+}
+
+// compareFields compares the given keys of two objects holding plain
+// values rather than expressions, treating false as missing.
+func (d *terraformDiffer) compareFields(path string, ours map[string]interface{}, theirs interface{}, keys ...string) {
+	fields, ok := theirs.(map[string]interface{})
+	if ours == nil || !ok {
+		if ours == nil && !ok {
+			return
+		}
+		d.add(path, ours, ours != nil, theirs, ok)
+		return
+	}
+	for _, key := range keys {
+		o, hasOurs := ours[key]
+		t, hasTheirs := fields[key]
+		hasOurs = hasOurs && o != false && o != ""
+		hasTheirs = hasTheirs && t != false && t != ""
+		if hasOurs != hasTheirs || (hasOurs && jsonString(o) != jsonString(t)) {
+			d.add(path+"."+key, o, hasOurs, t, hasTheirs)
+		}
+	}
+}
+
+// compareBody compares the attributes and nested blocks of a converted
+// body with a body of Terraform expressions.
+func (d *terraformDiffer) compareBody(path string, ours, theirs map[string]interface{}) {
+	for _, key := range objectKeys(ours, theirs) {
+		o, hasOurs := ours[key]
+		t, hasTheirs := theirs[key]
+		keyPath := path + "." + key
+		switch {
+		case !hasOurs || !hasTheirs:
+			d.add(keyPath, o, hasOurs, t, hasTheirs)
+		case isTerraformExpression(t):
+			constant, isConstant := t.(map[string]interface{})["constant_value"]
+			if isConstant == containsTemplate(o) || (isConstant && jsonString(o) != jsonString(constant)) {
+				if isConstant {
+					t = constant
+				}
+				d.add(keyPath, o, true, t, true)
+			}
+		default:
+			d.compareBlocks(keyPath, o, t)
+		}
+	}
+}
+
+// compareBlocks compares the nested blocks of a type in a converted body,
+// always a list, with those in Terraform's view, which are a single object
+// for blocks nested at most once.
+func (d *terraformDiffer) compareBlocks(path string, ours, theirs interface{}) {
+	if body, ok := theirs.(map[string]interface{}); ok {
+		theirs = []interface{}{body}
+	}
+	o, ok := ours.([]interface{})
+	t, _ := theirs.([]interface{})
+	if !ok || len(o) != len(t) {
+		d.add(path, ours, true, theirs, true)
+		return
+	}
+	for i := range o {
+		ourBody, ok := o[i].(map[string]interface{})
+		theirBody, theirsOK := t[i].(map[string]interface{})
+		if !ok || !theirsOK {
+			d.add(path, ours, true, theirs, true)
+			return
+		}
+		d.compareBody(path+"."+strconv.Itoa(i), ourBody, theirBody)
+	}
+}
+
+func (d *terraformDiffer) add(path string, ours interface{}, hasOurs bool, theirs interface{}, hasTheirs bool) {
+	difference := CorpusDifference{Configuration: "terraform", Path: path}
+	if hasOurs {
+		difference.Baseline = jsonString(ours)
+	}
+	if hasTheirs {
+		difference.Value = jsonString(theirs)
+	}
+	d.differences = append(d.differences, difference)
+}
+
+// labelledBlocks returns the bodies of the blocks of type key in converted
+// JSON with the given number of labels, keyed by their dot-separated
+// labels.
+func labelledBlocks(cfg map[string]interface{}, key string, labels int) map[string]map[string]interface{} {
+	bodies := make(map[string]map[string]interface{})
+	var walk func(prefix string, value interface{}, depth int)
+	walk = func(prefix string, value interface{}, depth int) {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if depth == labels {
+			bodies[strings.TrimPrefix(prefix, ".")] = obj
+			return
+		}
+		for label, elem := range obj {
+			walk(prefix+"."+label, elem, depth+1)
+		}
+	}
+	blocks, _ := cfg[key].([]interface{})
+	for _, block := range blocks {
+		walk("", block, 0)
+	}
+	return bodies
+}
+
+// withMetaExpressions returns a copy of expressions with the count and
+// for_each expressions added, when set.
+func withMetaExpressions(expressions map[string]interface{}, count, forEach interface{}) map[string]interface{} {
+	body := make(map[string]interface{}, len(expressions)+2)
+	for key, expr := range expressions {
+		body[key] = expr
+	}
+	if count != nil {
+		body["count"] = count
+	}
+	if forEach != nil {
+		body["for_each"] = forEach
+	}
+	return body
+}
+
+// isTerraformExpression reports whether a value in Terraform's expressions
+// is a single expression, rather than nested blocks.
+func isTerraformExpression(value interface{}) bool {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key := range obj {
+		if key != "constant_value" && key != "references" {
+			return false
+		}
+	}
+	return true
+}
+
+// containsTemplate reports whether a converted value holds an
+// interpolation or directive, so isn't constant.
+func containsTemplate(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(v, "${") || strings.Contains(v, "%{")
+	case map[string]interface{}:
+		for key, elem := range v {
+			if containsTemplate(key) || containsTemplate(elem) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if containsTemplate(elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// objectKeys returns the keys of all the objects, sorted.
+func objectKeys(objs ...map[string]interface{}) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, obj := range objs {
+		for key := range obj {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unionBlockKeys(ours map[string]map[string]interface{}, theirs map[string]interface{}) []string {
+	keys := make(map[string]interface{}, len(ours))
+	for key := range ours {
+		keys[key] = nil
+	}
+	return objectKeys(keys, theirs)
+}
+
+func deleteKeys(obj map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		delete(obj, key)
+	}
+}
@@ -0,0 +1,147 @@
+package convert
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompat(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "same.hcl"), `x = 1`)
+	writeFile(t, filepath.Join(src, "block.tf"), `resource "a" "b" {}`)
+
+	// a stand-in for another converter, which always outputs the same
+	tool := ExternalTool{Name: "fake", Command: []string{"sh", "-c", `cat >/dev/null; echo '{"x": 1}'`}}
+	report, err := Compat(src, tool, Options{})
+	if err != nil {
+		t.Fatal("compat:", err)
+	}
+	if report.Changed["fake"] != 1 || len(report.Files) != 2 {
+		t.Fatalf("expected one divergent file, got %+v", report)
+	}
+	block := report.Files[0]
+	if block.File != "block.tf" || len(block.Differences) != 2 {
+		t.Fatalf("unexpected differences %+v", block)
+	}
+	if diff := block.Differences[0]; diff.Path != "resource.0.a.b" || diff.Baseline != "{}" || diff.Value != "" {
+		t.Errorf("unexpected difference %+v", diff)
+	}
+
+	broken := ExternalTool{Name: "broken", Command: []string{"sh", "-c", "echo oops >&2; exit 1"}}
+	report, err = Compat(src, broken, Options{})
+	if err != nil {
+		t.Fatal("compat:", err)
+	}
+	if msg := report.Files[1].Errors["broken"]; msg != "run broken: exit status 1: oops" {
+		t.Errorf("unexpected error %q", msg)
+	}
+}
+
+func TestCompatTerraform(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+variable "suffix" {
+  default = "123"
+}
+
+resource "aws_instance" "web" {
+  ami   = "ami-${var.suffix}"
+  count = 2
+  tags  = { Name = "web" }
+
+  ebs_block_device {
+    device_name = "sdb"
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  owners = ["self"]
+}
+
+module "vpc" {
+  source = "./vpc"
+  cidr   = "10.0.0.0/16"
+}
+
+output "ip" {
+  value     = aws_instance.web[0].private_ip
+  sensitive = true
+}`)
+
+	// as terraform show -json gives it, except that Terraform sees a
+	// different volume and doesn't see the data source
+	plan := `{
+		"format_version": "1.1",
+		"configuration": {
+			"provider_config": {
+				"aws": {"name": "aws", "full_name": "registry.terraform.io/hashicorp/aws", "expressions": {"region": {"constant_value": "us-east-1"}}},
+				"aws.west": {"name": "aws", "full_name": "registry.terraform.io/hashicorp/aws", "alias": "west", "expressions": {"region": {"constant_value": "us-west-2"}}},
+				"random": {"name": "random", "full_name": "registry.terraform.io/hashicorp/random"},
+				"vpc:aws": {"name": "aws", "module_address": "module.vpc", "expressions": {"region": {"constant_value": "eu-west-1"}}}
+			},
+			"root_module": {
+				"resources": [{
+					"address": "aws_instance.web",
+					"mode": "managed",
+					"type": "aws_instance",
+					"name": "web",
+					"provider_config_key": "aws",
+					"expressions": {
+						"ami": {"references": ["var.suffix"]},
+						"tags": {"constant_value": {"Name": "web"}},
+						"ebs_block_device": [{"device_name": {"constant_value": "sdc"}}]
+					},
+					"schema_version": 1,
+					"count_expression": {"constant_value": 2}
+				}],
+				"module_calls": {
+					"vpc": {"source": "./vpc", "expressions": {"cidr": {"constant_value": "10.0.0.0/16"}}, "module": {}}
+				},
+				"variables": {"suffix": {"default": "123"}},
+				"outputs": {"ip": {"sensitive": true, "expression": {"references": ["aws_instance.web[0].private_ip", "aws_instance.web[0]", "aws_instance.web"]}}}
+			}
+		}
+	}`
+	report, err := CompatTerraform(dir, []byte(plan), Options{})
+	if err != nil {
+		t.Fatal("compat:", err)
+	}
+	if report.Changed["terraform"] != 1 || len(report.Files) != 1 {
+		t.Fatalf("expected the directory to differ, got %+v", report)
+	}
+	expected := []CorpusDifference{
+		{Configuration: "terraform", Path: "aws_instance.web.ebs_block_device.0.device_name", Baseline: `"sdb"`, Value: `"sdc"`},
+		{Configuration: "terraform", Path: "data.aws_ami.ubuntu", Baseline: `{"owners":["self"]}`},
+	}
+	if diffs := report.Files[0].Differences; !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected differences %+v, got %+v", expected, diffs)
+	}
+
+	if _, err := CompatTerraform(dir, []byte(`{"format_version": "1.0", "values": {}}`), Options{}); err == nil {
+		t.Error("expected output without a configuration to fail")
+	}
+	for name, options := range map[string]Options{
+		"OutputSchemaV2":   {OutputSchemaVersion: OutputSchemaV2},
+		"Skeleton":         {Skeleton: true},
+		"PreserveHeredocs": {PreserveHeredocs: true},
+		"DialectNomad":     {Dialect: DialectNomad},
+	} {
+		if _, err := CompatTerraform(dir, []byte(plan), options); err == nil {
+			t.Errorf("expected %s to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package convert
+
+import "github.com/hashicorp/hcl/v2/hclsyntax"
+
+// ConditionalKey is the key of the object conditional expressions are
+// written as when Options.StructuredConditionals is set and they can't be
+// evaluated:
+//
+//	{"__cond__": {"condition": "${var.prod}", "true": 3, "false": 1}}
+const ConditionalKey = "__cond__"
+
+// convertConditional returns the structured form of a conditional
+// expression, with its expressions converted like attribute values.
+func (c *converter) convertConditional(expr *hclsyntax.ConditionalExpr) (interface{}, error) {
+	fields := make(map[string]interface{}, 3)
+	for name, e := range map[string]hclsyntax.Expression{
+		"condition": expr.Condition,
+		"true":      expr.TrueResult,
+		"false":     expr.FalseResult,
+	} {
+		value, _, err := c.convertExpression(e)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+	return jsonObj{ConditionalKey: fields}, nil
+}
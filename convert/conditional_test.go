@@ -0,0 +1,21 @@
+package convert
+
+import "testing"
+
+func TestStructuredConditionals(t *testing.T) {
+	input := []byte(`
+count = var.prod ? 3 : 1
+name  = var.name != "" ? var.name : "default"
+fixed = true ? "yes" : "no"
+`)
+
+	result, err := Convert(input, "main.tf", Options{StructuredConditionals: true, Simplify: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"count": {"__cond__": {"condition": "${var.prod}", "true": 3, "false": 1}},
+	"name": {"__cond__": {"condition": "${var.name != \"\"}", "true": "${var.name}", "false": "default"}},
+	"fixed": "yes"
+}`))
+}
@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"sort"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Constness describes how early an attribute's value can be known.
+type Constness string
+
+const (
+	// Constant values can be computed from the configuration alone.
+	Constant Constness = "constant"
+
+	// Variable values also depend on input variables, the workspace or the
+	// instance keys of count and for_each, all known before planning.
+	Variable Constness = "variable"
+
+	// Runtime values depend on data sources, resources, modules or impure
+	// functions, so can't be predicted statically.
+	Runtime Constness = "runtime"
+)
+
+var constnessOrder = map[Constness]int{Constant: 0, Variable: 1, Runtime: 2}
+
+// impureFunctionNames are the functions whose results can't be predicted.
+var impureFunctionNames = map[string]bool{
+	"timestamp": true,
+	"uuid":      true,
+	"bcrypt":    true,
+}
+
+// AttributeConstness reports how predictable an attribute's value is, and
+// the references it depends on.
+type AttributeConstness struct {
+	Path         string    `json:"path"`
+	Range        hcl.Range `json:"range"`
+	Constness    Constness `json:"constness"`
+	Dependencies []string  `json:"dependencies,omitempty"`
+}
+
+// Constants classifies every attribute in a Terraform file by whether its
+// value is constant, depends only on variables, or depends on values only
+// known at plan or apply time. Local values take on the classification of
+// the expressions they are defined by.
+func Constants(file *hcl.File) ([]AttributeConstness, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+	attrs := attributes(body)
+
+	locals := make(map[string]Constness)
+	report := make([]AttributeConstness, len(attrs))
+	for changed := true; changed; {
+		changed = false
+		for i, attr := range attrs {
+			constness, deps := classifyExpression(attr.attr.Expr, locals)
+			report[i] = AttributeConstness{
+				Path:         attr.pathString(),
+				Range:        attr.attr.SrcRange,
+				Constness:    constness,
+				Dependencies: deps,
+			}
+			if attr.path[0] == "locals" && locals[attr.owner] != constness {
+				locals[attr.owner] = constness
+				changed = true
+			}
+		}
+	}
+	return report, nil
+}
+
+func classifyExpression(expr hclsyntax.Expression, locals map[string]Constness) (Constness, []string) {
+	constness := Constant
+	raise := func(c Constness) {
+		if constnessOrder[c] > constnessOrder[constness] {
+			constness = c
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, traversal := range expr.Variables() {
+		names := traversalNames(traversal)
+		if len(names) == 0 {
+			continue
+		}
+		switch names[0] {
+		case "path":
+			continue
+		case "var", "terraform", "count", "each":
+			raise(Variable)
+		case "self":
+			raise(Runtime)
+		}
+		if addr, ok := referenceAddress(traversal); ok {
+			seen[addr] = true
+			if names[0] == "local" {
+				raise(locals[addr])
+			} else if names[0] != "var" {
+				raise(Runtime)
+			}
+		}
+	}
+
+	hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+		if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && impureFunctionNames[call.Name] {
+			raise(Runtime)
+		}
+		return nil
+	})
+
+	deps := make([]string, 0, len(seen))
+	for addr := range seen {
+		deps = append(deps, addr)
+	}
+	sort.Strings(deps)
+	return constness, deps
+}
@@ -0,0 +1,55 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestConstants(t *testing.T) {
+	input := `
+locals {
+	from_data = data.aws_ami.ubuntu.id
+	name = "${var.env}-web"
+}
+
+resource "aws_instance" "web" {
+	count = 2
+	ami = local.from_data
+	tags = {
+		Name = local.name
+		Index = count.index
+	}
+	created = timestamp()
+	module_path = "${path.module}/files"
+}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	report, err := Constants(file)
+	if err != nil {
+		t.Fatal("constants:", err)
+	}
+
+	expected := map[string]Constness{
+		"locals.from_data":                      Runtime,
+		"locals.name":                           Variable,
+		"resource.aws_instance.web.count":       Constant,
+		"resource.aws_instance.web.ami":         Runtime,
+		"resource.aws_instance.web.tags":        Variable,
+		"resource.aws_instance.web.created":     Runtime,
+		"resource.aws_instance.web.module_path": Constant,
+	}
+	if len(report) != len(expected) {
+		t.Fatalf("expected %d attributes, got %+v", len(expected), report)
+	}
+	for _, attr := range report {
+		if expected[attr.Path] != attr.Constness {
+			t.Errorf("expected %s to be %s, got %s", attr.Path, expected[attr.Path], attr.Constness)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package convert
+
+// consulProfile reshapes a Consul agent configuration into the JSON form of
+// the configuration, as in {"ports": {"http": 8500}, "services": [...]}.
+// Blocks Consul only takes one of are written as an object, and services
+// and checks as lists under their plural names.
+var consulProfile = &profile{
+	root: &shape{
+		names: map[string]string{"service": "services", "check": "checks"},
+		blocks: map[string]*blockShape{
+			"service":       {body: consulService},
+			"check":         {body: consulCheck},
+			"acl":           {single: true, body: consulACL},
+			"addresses":     {single: true},
+			"audit":         {single: true},
+			"auto_config":   {single: true},
+			"auto_encrypt":  {single: true},
+			"autopilot":     {single: true},
+			"connect":       {single: true, body: consulConnect},
+			"dns_config":    {single: true},
+			"gossip_lan":    {single: true},
+			"gossip_wan":    {single: true},
+			"limits":        {single: true},
+			"node_meta":     {single: true},
+			"performance":   {single: true},
+			"ports":         {single: true},
+			"raft_logstore": {single: true},
+			"rpc":           {single: true},
+			"telemetry":     {single: true},
+			"tls":           {single: true, body: consulTLS},
+			"ui_config":     {single: true, body: consulUI},
+		},
+	},
+}
+
+var (
+	consulCheck = &shape{
+		blocks: map[string]*blockShape{
+			"header": {single: true},
+		},
+	}
+
+	consulService = &shape{
+		names: map[string]string{"check": "checks"},
+		blocks: map[string]*blockShape{
+			"check": {body: consulCheck},
+			"connect": {single: true, body: &shape{
+				blocks: map[string]*blockShape{
+					"sidecar_service": {single: true, body: &shape{
+						blocks: map[string]*blockShape{"proxy": {single: true}},
+					}},
+				},
+			}},
+			"meta":    {single: true},
+			"proxy":   {single: true},
+			"weights": {single: true},
+		},
+	}
+
+	consulACL = &shape{
+		blocks: map[string]*blockShape{
+			"tokens": {single: true},
+		},
+	}
+
+	consulConnect = &shape{
+		blocks: map[string]*blockShape{
+			"ca_config": {single: true},
+		},
+	}
+
+	consulTLS = &shape{
+		blocks: map[string]*blockShape{
+			"defaults":     {single: true},
+			"grpc":         {single: true},
+			"https":        {single: true},
+			"internal_rpc": {single: true},
+		},
+	}
+
+	consulUI = &shape{
+		blocks: map[string]*blockShape{
+			"metrics_proxy": {single: true},
+		},
+	}
+)
@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"testing"
+)
+
+func TestConsulDialect(t *testing.T) {
+	src := []byte(`datacenter = "dc1"
+retry_join = ["10.0.0.1"]
+
+ports {
+  http = 8500
+  grpc = 8502
+}
+
+acl {
+  enabled = true
+  tokens {
+    agent = "secret"
+  }
+}
+
+service {
+  name = "web"
+  port = 80
+
+  check {
+    http     = "http://localhost/health"
+    interval = "10s"
+  }
+}
+
+service {
+  name = "db"
+}
+`)
+	out, lines, err := Bytes(src, "consul.hcl", Options{Dialect: DialectConsul})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{
+		"datacenter": "dc1",
+		"retry_join": ["10.0.0.1"],
+		"ports": {"http": 8500, "grpc": 8502},
+		"acl": {"enabled": true, "tokens": {"agent": "secret"}},
+		"services": [
+			{"name": "web", "port": 80, "checks": [{"http": "http://localhost/health", "interval": "10s"}]},
+			{"name": "db"}
+		]
+	}`))
+
+	l := decodeLines(t, lines)
+	services := l["services"].([]interface{})
+	if name := services[1].(map[string]interface{})["name"].(map[string]interface{}); flatInt(name["line"]) != 27 {
+		t.Errorf("expected the second service's name on line 27, got %v", name)
+	}
+}
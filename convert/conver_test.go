@@ -3,6 +3,7 @@ package convert
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -185,7 +186,7 @@ variable "region" {
 			"temp2": "hi there",
 			"test1": "hello",
 			"test2": 5,
-			"test3": "${1 + 2}",
+			"test3": 3,
 			"x": -10,
 			"y": "${-x}",
 			"z": "${-(1 + 4)}"
@@ -206,7 +207,7 @@ variable "region" {
 			"cond": "${test3 \u003e 2 ? 1: 0}",
 			"heredoc": "This is a heredoc template.\nIt references ${local.other.3}\n",
 			"heredoc2": "\t\tAnother heredoc, that\n\t\tdoesn't remove indentation\n\t\t${local.other.3}\n\t\t%{if true ? false : true}\"gotcha\"\\n%{else}4%{endif}\n",
-			"simple": "${4 - 2}"
+			"simple": 2
 		}
 	],
 	"variable": {
@@ -267,6 +268,72 @@ func TestSimplify(t *testing.T) {
 	compareTest(t, convertedBytes, expected)
 }
 
+func TestBinaryOpLiterals(t *testing.T) {
+	input := `locals {
+		sum = 2 + 3
+		eq = "a" == "a"
+		nested = (1 + 2) * 3
+		with_vars = x + 1
+	}`
+
+	expected := `{
+	"locals": [
+		{
+			"eq": true,
+			"nested": 9,
+			"sum": 5,
+			"with_vars": "${x + 1}"
+		}
+	]
+}`
+
+	convertedBytes, _, err := Bytes([]byte(input), "", Options{})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+
+	compareTest(t, convertedBytes, expected)
+}
+
+func TestTemplateConditionalChain(t *testing.T) {
+	input := `locals {
+		chain = "%{if a}one%{else}%{if b}two%{else}three%{endif}%{endif}"
+		nested = "%{if a}one%{else}x%{if b}two%{endif}%{endif}"
+	}`
+
+	expected := `{
+	"locals": [
+		{
+			"chain": "%{if a}one%{else}%{if b}two%{else}three%{endif}%{endif}",
+			"nested": "%{if a}one%{else}x%{if b}two%{endif}%{endif}"
+		}
+	]
+}`
+
+	convertedBytes, _, err := Bytes([]byte(input), "", Options{})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+
+	compareTest(t, convertedBytes, expected)
+}
+
+func TestTemplateConditionalChainRoundTrip(t *testing.T) {
+	input := `chain = "%{if a}one%{else}%{if b}two%{else}three%{endif}%{endif}"
+`
+	jsonBytes, lineBytes, err := Bytes([]byte(input), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	src, err := JSONToHCL(jsonBytes, lineBytes)
+	if err != nil {
+		t.Fatal("json to hcl:", err)
+	}
+	if string(src) != input {
+		t.Errorf("expected %q, got %q", input, src)
+	}
+}
+
 func TestEndOfFileExpr(t *testing.T) {
 	input := `inputs = merge(
 		{},
@@ -284,6 +351,181 @@ func TestEndOfFileExpr(t *testing.T) {
 	compareTest(t, convertedBytes, expected)
 }
 
+func TestExpressionLineRanges(t *testing.T) {
+	input := `doc = <<EOT
+hello
+EOT
+call = merge(
+  {},
+  var.extra
+)
+`
+	_, lineBytes, err := Bytes([]byte(input), "", Options{})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+	lines := decodeLines(t, lineBytes)
+
+	for key, want := range map[string][4]float64{
+		"doc":  {1, 7, 3, 4},
+		"call": {4, 8, 7, 2},
+	} {
+		line := lines[key].(map[string]interface{})
+		got := [4]float64{
+			line["line"].(float64),
+			line["startIndex"].(float64),
+			line["endLine"].(float64),
+			line["endIndex"].(float64),
+		}
+		if got != want {
+			t.Errorf("%s: expected line, startIndex, endLine, endIndex %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestLabelRanges(t *testing.T) {
+	input := `resource "aws_instance" "web" {
+  provisioner "local-exec" {}
+}
+`
+	_, lineBytes, err := Bytes([]byte(input), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+	lines := decodeLines(t, lineBytes)
+
+	web := lines["resource"].([]interface{})[0].(map[string]interface{})["aws_instance"].(map[string]interface{})["web"].(map[string]interface{})
+	provisioner := web["provisioner"].([]interface{})[0].(map[string]interface{})["local-exec"].(map[string]interface{})
+	for _, test := range []struct {
+		name string
+		line map[string]interface{}
+		want [][3]float64
+	}{
+		{"web", web, [][3]float64{{1, 10, 24}, {1, 25, 30}}},
+		{"provisioner", provisioner, [][3]float64{{2, 15, 27}}},
+	} {
+		ranges, _ := test.line["labelRanges"].([]interface{})
+		if len(ranges) != len(test.want) {
+			t.Fatalf("%s: expected %d label ranges, got %v", test.name, len(test.want), test.line["labelRanges"])
+		}
+		for i, rng := range ranges {
+			rng := rng.(map[string]interface{})
+			got := [3]float64{rng["line"].(float64), rng["startIndex"].(float64), rng["endIndex"].(float64)}
+			if got != test.want[i] {
+				t.Errorf("%s: expected label %d at line, startIndex, endIndex %v, got %v", test.name, i, test.want[i], got)
+			}
+		}
+	}
+}
+
+func TestIncludeByteOffsets(t *testing.T) {
+	input := `name = "web"
+resource "aws_instance" "web" {
+  tags = { a = 1 }
+}
+`
+	_, lineBytes, err := Bytes([]byte(input), "", Options{IncludeByteOffsets: true})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+	lines := decodeLines(t, lineBytes)
+
+	offsets := func(line interface{}, prefix string) [2]float64 {
+		l := line.(map[string]interface{})
+		return [2]float64{l[prefix+"startByte"].(float64), l[prefix+"endByte"].(float64)}
+	}
+	block := lines["resource"].([]interface{})[0].(map[string]interface{})["aws_instance"].(map[string]interface{})["web"]
+	tags := block.(map[string]interface{})["tags"]
+	for name, test := range map[string]struct {
+		got, want [2]float64
+	}{
+		"name":      {offsets(lines["name"], ""), [2]float64{7, 12}},
+		"name key":  {offsets(lines["name"], "__key__"), [2]float64{0, 4}},
+		"block":     {offsets(block, ""), [2]float64{43, 65}},
+		"block key": {offsets(block, "__key__"), [2]float64{13, 42}},
+		"tags":      {offsets(tags, ""), [2]float64{54, 63}},
+		"tags.a":    {offsets(tags.(map[string]interface{})["a"], ""), [2]float64{60, 61}},
+		"body":      {offsets(lines, ""), [2]float64{0, 66}},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: expected bytes %v, got %v", name, test.want, test.got)
+		}
+	}
+
+	compact, err := CompactLines(lineBytes)
+	if err != nil {
+		t.Fatal("compact lines:", err)
+	}
+	expanded, err := ExpandLines(compact)
+	if err != nil {
+		t.Fatal("expand lines:", err)
+	}
+	if !reflect.DeepEqual(decodeLines(t, expanded), lines) {
+		t.Errorf("byte offsets didn't survive the compact encoding:\n%s", expanded)
+	}
+
+	_, lineBytes, err = Bytes([]byte(input), "", Options{})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+	if _, ok := decodeLines(t, lineBytes)["name"].(map[string]interface{})["startByte"]; ok {
+		t.Error("byte offsets should only be included when asked for")
+	}
+}
+
+func TestLineKeyPrefix(t *testing.T) {
+	input := `resource "aws_instance" "web" {
+  line = 1
+  type = "t2.micro"
+}
+`
+	jsonBytes, lineBytes, err := Bytes([]byte(input), "", Options{LineKeyPrefix: "$", PreserveOrder: true})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+	compareTest(t, jsonBytes, `{
+	"resource": [
+		{
+			"aws_instance": {
+				"web": {
+					"line": 1,
+					"type": "t2.micro"
+				}
+			}
+		}
+	]
+}`)
+
+	lines := decodeLines(t, lineBytes)
+	block := lines["resource"].([]interface{})[0].(map[string]interface{})["aws_instance"].(map[string]interface{})["web"].(map[string]interface{})
+	if block["$type"] != "block" || block["$__key__line"] != float64(1) {
+		t.Errorf("expected the block's line info under prefixed keys, got %v", block)
+	}
+	attr, ok := block["line"].(map[string]interface{})
+	if !ok || attr["$line"] != float64(2) || attr["$__key__startIndex"] != float64(3) {
+		t.Errorf("expected the line attribute's own line info, got %v", block["line"])
+	}
+	if _, ok := lines["line"]; ok {
+		t.Error("expected no unprefixed keys")
+	}
+
+	flat, err := Convert([]byte(input), "", Options{LineKeyPrefix: "$", LineFormat: LineFormatFlat})
+	if err != nil {
+		t.Fatal("convert flat:", err)
+	}
+	var positions map[string]FlatLine
+	if err := json.Unmarshal(flat.Lines, &positions); err != nil {
+		t.Fatal("unmarshal flat lines:", err)
+	}
+	if got := positions["/resource/0/aws_instance/web/type"]; got.Line != 3 || got.Key == nil {
+		t.Errorf("expected the type attribute's position, got %+v", got)
+	}
+
+	if _, err := Convert([]byte(input), "", Options{LineKeyPrefix: "$", CompactLines: true}); err == nil {
+		t.Error("expected an error combining a key prefix with compact lines")
+	}
+}
+
 func TestBlocksWithAndWithoutLabels(t *testing.T) {
 	input := `
 	foo "baz" {
@@ -316,3 +558,12 @@ func compareTest(t *testing.T, input []byte, expected string) {
 		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, actual)
 	}
 }
+
+func decodeLines(t *testing.T, lineBytes []byte) map[string]interface{} {
+	t.Helper()
+	var lines map[string]interface{}
+	if err := json.Unmarshal(lineBytes, &lines); err != nil {
+		t.Fatal("unmarshal lines:", err)
+	}
+	return lines
+}
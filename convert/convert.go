@@ -1,9 +1,13 @@
 package convert
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"strings"
+	"time"
 
 	hcl "github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -14,6 +18,280 @@ import (
 
 type Options struct {
 	Simplify bool
+
+	// Variables are made available to expressions when simplifying, keyed
+	// by their root name such as "var" or "local".
+	Variables map[string]cty.Value
+
+	// Dialect selects the flavour of HCL being converted, which decides
+	// the functions known to be safe to evaluate when simplifying.
+	Dialect Dialect
+
+	// AllowFunctions lists functions outside of the dialect's known safe
+	// set which may be evaluated when simplifying. Calls to any other
+	// function are left wrapped and reported as a warning.
+	AllowFunctions []string
+
+	// Clock returns the current time for impure functions such as
+	// timestamp and for provenance headers. When nil, time.Now is used.
+	Clock func() time.Time
+
+	// RandSource provides the random bytes for impure functions such as
+	// uuid. When nil, crypto/rand is used. Conversions running concurrently
+	// with the same options share it, so it must then be safe for
+	// concurrent use.
+	RandSource io.Reader
+
+	// FS is the root that file functions such as file and templatefile
+	// read from. Paths outside of it are rejected, and when nil the file
+	// functions always fail.
+	FS fs.FS
+
+	// MaxIncludeDepth limits how deeply included files and module sources
+	// are resolved. When zero, DefaultMaxIncludeDepth is used.
+	MaxIncludeDepth int
+
+	// FileTimeout limits how long Batch and Files spend converting any
+	// one file. When zero, there is no limit.
+	FileTimeout time.Duration
+
+	// Workers limits how many files Files converts at once. When zero,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	// Schema describes the structure expected of the file.
+	Schema *Schema
+
+	// InjectDefaults adds attributes missing from the source which have a
+	// default in Schema, with their line info marked as synthetic.
+	InjectDefaults bool
+
+	// CanonicalExpressions records the canonical S-expression form of each
+	// attribute's expression in its line info, under "canonical".
+	CanonicalExpressions bool
+
+	// PreserveOrder writes the keys of objects in the JSON output in the
+	// order they appear in the source, rather than sorted.
+	PreserveOrder bool
+
+	// Canonical writes the JSON output and line info in a byte-stable
+	// form, so they can be hashed and compared: compact unless Indent is
+	// set, with keys sorted, or in source order with PreserveOrder, no
+	// escaping of HTML characters, numbers in plain decimal notation and a
+	// single trailing newline.
+	Canonical bool
+
+	// Indent pretty-prints the JSON output and line info, writing each
+	// element of an object or array on a line of its own, indented by
+	// Indent for each level of nesting, such as "  ". When empty, they're
+	// compact.
+	Indent string
+
+	// DisableHTMLEscaping writes <, > and & in strings as they are, rather
+	// than escaped as \u003c, \u003e and \u0026.
+	DisableHTMLEscaping bool
+
+	// CombinedOutput writes each value in the output which has a position
+	// as an object holding it under "value" and its position, as a
+	// FlatLine, under "range", so callers needn't match the output up with
+	// the line info. Objects grouping blocks by their labels have no
+	// position, so are written as they are. It can't be combined with
+	// inline types.
+	CombinedOutput bool
+
+	// CompactLines writes the line info in the compact encoding described
+	// by CompactLines rather than as nested objects.
+	CompactLines bool
+
+	// LineFormat selects the structure of the line info. LineFormatFlat
+	// includes byte offsets, and can't be combined with CompactLines.
+	LineFormat LineFormat
+
+	// OutputSchemaVersion selects the structure of the output. When zero,
+	// OutputSchemaV1 is used.
+	OutputSchemaVersion OutputSchemaVersion
+
+	// IncludeByteOffsets adds the byte offsets of the start and end of
+	// each entry in the line info, as startByte and endByte, and of its
+	// key, as __key__startByte and __key__endByte.
+	IncludeByteOffsets bool
+
+	// LineKeyPrefix is prepended to the keys the line info records
+	// positions and other details under, such as "line", "startIndex" and
+	// "__key__line", so they can't collide with the names of attributes
+	// and blocks. "$" is safe for any name written in native syntax. It
+	// can't be combined with CompactLines, and functions reading line
+	// info, such as FlattenLines and LookupPosition, expect no prefix.
+	LineKeyPrefix string
+
+	// AnnotateTypes records the type Schema declares for each attribute in
+	// its line info, under "schemaType" in the JSON encoding of cty types,
+	// such as "string" or ["list","string"].
+	AnnotateTypes bool
+
+	// AnnotateOrigins records how each value was produced in its line info,
+	// under "origin", as one of the Origin constants, so tooling can require
+	// that some attributes are written as literals. Arrays and objects take
+	// the least literal origin of their elements.
+	AnnotateOrigins bool
+
+	// InferTypes sets Result.Types to the type of every attribute's
+	// expression, as returned by ExpressionTypes. Types aren't inferred for
+	// files in JSON syntax.
+	InferTypes bool
+
+	// IncludeTypes writes the type of each attribute's value, either inline
+	// in the output or in Result.TypeMap, as selected by its TypeLayout.
+	// When empty, types aren't written.
+	IncludeTypes TypeLayout
+
+	// ExpressionMode selects how expressions which can't be converted to
+	// plain values are written. When empty, ExpressionModeWrapped is used.
+	ExpressionMode ExpressionMode
+
+	// PreserveHeredocs writes heredocs as objects keyed by HeredocKey,
+	// recording their delimiter and whether they're indented alongside
+	// their value, so they can be reproduced as they were written.
+	PreserveHeredocs bool
+
+	// StructuredFor writes for expressions which aren't evaluated as
+	// objects keyed by ForKey, holding their variables and expressions,
+	// instead of as wrapped expressions.
+	StructuredFor bool
+
+	// StructuredConditionals writes conditional expressions which aren't
+	// evaluated as objects keyed by ConditionalKey, holding the condition
+	// and both results, instead of as wrapped expressions.
+	StructuredConditionals bool
+
+	// StructuredFunctions writes function calls which aren't evaluated as
+	// objects keyed by FunctionCallKey, holding the function name and
+	// arguments, instead of as wrapped expressions. The line info of calls
+	// records the function and the position of each argument either way.
+	StructuredFunctions bool
+
+	// Transformers rewrite the value of each attribute, in order, after
+	// it's been converted.
+	Transformers []ValueTransformer
+
+	// Redact lists patterns of attributes whose values are replaced by
+	// RedactedValue, as described by Redact, such as "*.password" or
+	// "provider.*.secret_key". It's applied after Transformers.
+	Redact []string
+
+	// PostProcessors rewrite the marshalled JSON output, in order, once
+	// it's complete. The line info is left as it is, so it no longer
+	// matches the output if they move values.
+	PostProcessors []OutputProcessor
+
+	// Encoder re-encodes the output, line info and type map in another
+	// format once they're complete, such as MsgpackEncoder or CBOREncoder,
+	// so the result's JSON and Lines hold that format rather than JSON.
+	Encoder Encoder
+
+	// Rules check the value of each attribute and the body of each block
+	// as they're converted, with their findings in Result.Findings.
+	Rules []Rules
+
+	// Skeleton converts only the structure of a file: its blocks, their
+	// labels and the names of attributes, whose values are all null. No
+	// expressions are converted or evaluated, and schema defaults aren't
+	// injected, which makes it much faster for inventories of large
+	// configurations that don't need values.
+	Skeleton bool
+
+	// AllowErrors converts whatever could be parsed from a file with
+	// syntax errors instead of failing, returning the errors in the
+	// result's diagnostics.
+	AllowErrors bool
+
+	// InputSyntax selects whether the source is native HCL or JSON. When
+	// empty, it is detected from the file name and contents.
+	InputSyntax InputSyntax
+
+	// Provenance adds a header under ProvenanceKey recording the converter
+	// version, options fingerprint, input hash and time of conversion.
+	Provenance bool
+
+	// Catalog renders diagnostics, such as those from checking the schema,
+	// in place of DefaultCatalog, so they can be translated. The message
+	// each was rendered from is recorded in the result's Messages.
+	Catalog Catalog
+
+	// SigningKey, when set, is used by Batch to sign each artifact and the
+	// manifest it writes, with the signatures written alongside them with
+	// SignatureSuffix. It doesn't change the output, so isn't part of the
+	// options fingerprint.
+	SigningKey ed25519.PrivateKey
+}
+
+// Result holds the outcome of converting a single file.
+type Result struct {
+	JSON  []byte
+	Lines []byte
+
+	// Diagnostics holds the problems found during conversion, such as
+	// disallowed functions or blocks not described by the schema. They
+	// don't prevent the rest of the file from being converted.
+	Diagnostics hcl.Diagnostics
+
+	// Fixes suggests changes correcting common syntax errors, found when
+	// converting with AllowErrors. When they correct every error, the
+	// output is converted from the fixed source.
+	Fixes []Fix
+
+	// Messages holds the message each diagnostic raised by the converter
+	// was rendered from, so tools can match them by ID whatever language
+	// they're in.
+	Messages Messages
+
+	// Types holds the type of every attribute's expression, when converting
+	// with InferTypes.
+	Types []ExpressionType
+
+	// TypeMap holds the type of every attribute's value, in the structure
+	// of the line info, when converting with IncludeTypes set to TypesMap.
+	TypeMap []byte
+
+	// Findings holds the findings of Options.Rules, in source order.
+	Findings []Finding
+
+	// output holds the converted value JSON was marshalled from, and
+	// lineJSON the line info before any Encoder, for Struct and
+	// LinesStruct. Neither is modified once the result is returned.
+	output   interface{}
+	lineJSON []byte
+}
+
+// Clone returns a deep copy of the result, for callers that want to modify
+// a result shared with others, such as one returned by a Cache.
+func (r *Result) Clone() *Result {
+	clone := &Result{
+		JSON:     append([]byte(nil), r.JSON...),
+		Lines:    append([]byte(nil), r.Lines...),
+		Fixes:    append([]Fix(nil), r.Fixes...),
+		Types:    append([]ExpressionType(nil), r.Types...),
+		Findings: append([]Finding(nil), r.Findings...),
+		output:   r.output,
+		lineJSON: r.lineJSON,
+	}
+	if r.TypeMap != nil {
+		clone.TypeMap = append([]byte(nil), r.TypeMap...)
+	}
+	if r.Diagnostics != nil {
+		clone.Diagnostics = make(hcl.Diagnostics, len(r.Diagnostics))
+		for i, diag := range r.Diagnostics {
+			copied := *diag
+			clone.Diagnostics[i] = &copied
+			if m, ok := r.Messages[diag]; ok {
+				if clone.Messages == nil {
+					clone.Messages = make(Messages)
+				}
+				clone.Messages[&copied] = m
+			}
+		}
+	}
+	return clone
 }
 
 func String(filename string) (map[string]interface{}, error) {
@@ -40,37 +318,195 @@ func String(filename string) (map[string]interface{}, error) {
 // Bytes takes the contents of an HCL file, as bytes, and converts
 // them into a JSON representation of the HCL file.
 func Bytes(bytes []byte, filename string, options Options) ([]byte, []byte, error) {
-	file, diags := hclsyntax.ParseConfig(bytes, filename, hcl.Pos{Line: 1, Column: 1})
+	result, err := Convert(bytes, filename, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result.JSON, result.Lines, nil
+}
+
+// Convert is like Bytes, but also returns the diagnostics raised while
+// converting the file. Convert, Bytes and File are safe to call
+// concurrently, including with the same options.
+func Convert(bytes []byte, filename string, options Options) (*Result, error) {
+	file, diags := parseConfig(bytes, filename, options)
+	var fixes []Fix
 	if diags.HasErrors() {
-		return nil, nil, fmt.Errorf("parse config: %v", diags.Errs())
+		if !options.AllowErrors {
+			return nil, fmt.Errorf("parse config: %v", diags.Errs())
+		}
+		file, fixes = recoverFile(file, bytes, filename, options)
 	}
 
-	hclBytes, lineBytes, err := File(file, options)
+	result, err := convertResult(file, options)
 	if err != nil {
-		return nil, nil, fmt.Errorf("convert to HCL: %w", err)
+		return nil, fmt.Errorf("convert to HCL: %w", err)
+	}
+	result.Diagnostics = append(diags, result.Diagnostics...)
+	for _, diag := range diags {
+		if result.Messages == nil {
+			result.Messages = make(Messages)
+		}
+		result.Messages[diag] = Message{ID: "syntax-error"}
 	}
+	result.Fixes = fixes
 
-	return hclBytes, lineBytes, nil
+	return result, nil
 }
 
 // File takes an HCL file and converts it to its JSON representation.
 func File(file *hcl.File, options Options) ([]byte, []byte, error) {
-	convertedFile, lineObj, err := ConvertFile(file, options)
+	result, err := convertResult(file, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result.JSON, result.Lines, nil
+}
+
+func convertResult(file *hcl.File, options Options) (*Result, error) {
+	options, err := options.lineOptions()
+	if err != nil {
+		return nil, err
+	}
+	convertedFile, lineObj, c, err := convertFile(file, options)
+	if err != nil {
+		return nil, fmt.Errorf("convert file: %w", err)
+	}
+	result, err := encodeResult(convertedFile, lineObj, c.diags, c.messages, options)
+	if err != nil {
+		return nil, err
+	}
+	result.Findings = c.findings
+	if _, ok := file.Body.(*hclsyntax.Body); ok && options.InferTypes {
+		result.Types, err = ExpressionTypes(file, options)
+		if err != nil {
+			return nil, fmt.Errorf("infer types: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// lineOptions checks the options selecting the form of the line info,
+// returning them with any the line format implies.
+func (o Options) lineOptions() (Options, error) {
+	switch o.LineFormat {
+	case LineFormatNested:
+	case LineFormatFlat:
+		if o.CompactLines {
+			return o, fmt.Errorf("flat line info can't be compact")
+		}
+		o.IncludeByteOffsets = true
+	default:
+		return o, fmt.Errorf("unsupported line format %q", o.LineFormat)
+	}
+	if o.LineKeyPrefix != "" && o.CompactLines {
+		return o, fmt.Errorf("compact line info can't have a key prefix")
+	}
+	return o, nil
+}
+
+// encodeResult encodes converted output and line info in the version and
+// forms selected by options.
+func encodeResult(convertedFile jsonObj, lineObj lineObj, diags hcl.Diagnostics, messages Messages, options Options) (*Result, error) {
+	version, err := options.outputSchemaVersion()
 	if err != nil {
-		return nil, nil, fmt.Errorf("convert file: %w", err)
+		return nil, err
 	}
 
-	jsonBytes, err := json.Marshal(convertedFile)
+	var output, lineOutput interface{} = convertedFile, lineObj
+	if profile := options.Dialect.profile(); profile != nil {
+		if version != OutputSchemaV1 {
+			return nil, fmt.Errorf("the %s dialect can't be written in output schema version %d", options.Dialect, version)
+		}
+		output, lineOutput, err = profile.apply(convertedFile, lineObj, options.LineKeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("reshape %s output: %w", options.Dialect, err)
+		}
+	} else if version != OutputSchemaV1 {
+		output, lineOutput, err = migrateConverted(convertedFile, lineObj, version, options.LineKeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("migrate output: %w", err)
+		}
+	}
+	if options.CombinedOutput && options.IncludeTypes == TypesInline {
+		return nil, fmt.Errorf("inline types can't be written in combined output")
+	}
+	var typeMap interface{}
+	if options.IncludeTypes != "" {
+		output, lineOutput, typeMap, err = includeTypes(output, lineOutput, options.IncludeTypes, options.LineKeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("include types: %w", err)
+		}
+	}
+	if options.CombinedOutput {
+		output, err = combined(output, lineOutput, options.LineKeyPrefix, options.PreserveOrder)
+		if err != nil {
+			return nil, fmt.Errorf("combine output: %w", err)
+		}
+	} else if options.PreserveOrder {
+		output = sourceOrdered(output, lineOutput, options.LineKeyPrefix)
+	}
+	jsonBytes, err := json.Marshal(output)
 	if err != nil {
-		return nil, nil, fmt.Errorf("marshal json: %w", err)
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	jsonBytes, err = options.postProcess(jsonBytes)
+	if err != nil {
+		return nil, err
+	}
+	format, reformat := options.jsonFormat()
+	if reformat {
+		jsonBytes, err = formatJSON(jsonBytes, format)
+		if err != nil {
+			return nil, fmt.Errorf("format json: %w", err)
+		}
 	}
 
-	lineBytes, err := json.Marshal(lineObj)
+	lineBytes, err := json.Marshal(lineOutput)
 	if err != nil {
-		return nil, nil, fmt.Errorf("marshal json: %w", err)
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	if options.CompactLines {
+		lineBytes, err = CompactLines(lineBytes)
+		if err != nil {
+			return nil, fmt.Errorf("compact line info: %w", err)
+		}
+	}
+	if options.LineFormat == LineFormatFlat {
+		lineBytes, err = flattenLines(lineBytes, options.LineKeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("flatten line info: %w", err)
+		}
+	}
+	if reformat {
+		lineBytes, err = formatJSON(lineBytes, format)
+		if err != nil {
+			return nil, fmt.Errorf("format line info: %w", err)
+		}
 	}
 
-	return jsonBytes, lineBytes, nil
+	result := &Result{JSON: jsonBytes, Lines: lineBytes, Diagnostics: diags, Messages: messages, output: output, lineJSON: lineBytes}
+	if options.IncludeTypes == TypesMap {
+		if typeMap == nil {
+			typeMap = map[string]interface{}{}
+		}
+		result.TypeMap, err = json.Marshal(typeMap)
+		if err != nil {
+			return nil, fmt.Errorf("marshal type map: %w", err)
+		}
+		if reformat {
+			result.TypeMap, err = formatJSON(result.TypeMap, format)
+			if err != nil {
+				return nil, fmt.Errorf("format type map: %w", err)
+			}
+		}
+	}
+	if err := options.encode(result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 type jsonObj map[string]interface{}
@@ -79,37 +515,80 @@ type lineObj = map[string]interface{}
 type converter struct {
 	bytes   []byte
 	options Options
+	diags   hcl.Diagnostics
+	allowed map[string]bool
+	ctx     *hcl.EvalContext
+	schema  *Schema
+
+	// disallowed holds the name ranges of the calls allowEvaluation has
+	// reported, as it sees nested expressions again as they're converted.
+	disallowed map[hcl.Range]bool
+
+	// messages records the message each diagnostic was rendered from.
+	messages Messages
+
+	// path holds the types and labels of the blocks being converted.
+	path []string
+
+	// findings holds the findings of Options.Rules.
+	findings []Finding
+
+	// terragrunt is the Terragrunt configuration being converted, which
+	// its built-in functions are evaluated for, with DialectTerragrunt.
+	terragrunt *terragruntFile
 }
 
 func ConvertFile(file *hcl.File, options Options) (jsonObj, lineObj, error) {
-	body, ok := file.Body.(*hclsyntax.Body)
-	if !ok {
-		return nil, nil, fmt.Errorf("convert file body to body type")
-	}
+	out, line, _, err := convertFile(file, options)
+	return out, line, err
+}
 
+// convertFile converts a file, returning the converter with the
+// diagnostics, messages and findings raised along the way.
+func convertFile(file *hcl.File, options Options) (jsonObj, lineObj, *converter, error) {
 	c := converter{
 		bytes:   file.Bytes,
 		options: options,
+		schema:  options.Schema,
+	}
+	if options.Dialect == DialectTerragrunt && options.FS != nil {
+		name := file.Body.MissingItemRange().Filename
+		c.terragrunt = &terragruntFile{fsys: options.FS, path: name, original: name}
 	}
 
-	out, line, err := c.convertBody(body)
+	var (
+		out  jsonObj
+		line lineObj
+		err  error
+	)
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		out, line, err = c.convertBody(body)
+	} else {
+		rng := sourceRange(file.Bytes, file.Body.MissingItemRange().Filename)
+		out, line, err = c.convertJSONBody(file.Body, c.jsonSchema(file), rng)
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("convert body: %w", err)
+		return nil, nil, nil, fmt.Errorf("convert body: %w", err)
 	}
+	if options.Provenance {
+		out[ProvenanceKey] = newProvenance(file, options)
+	}
+	sortFindings(c.findings)
 
-	return out, line, nil
+	return out, line, &c, nil
 }
 
 func (c *converter) convertBody(body *hclsyntax.Body) (jsonObj, lineObj, error) {
 	cfg := make(jsonObj)
 	lcfg := make(jsonObj)
+	c.validateBody(body)
 
 	for _, block := range body.Blocks {
 		var (
 			bcfg  = make(jsonObj) // block resource config
 			blcfg = make(lineObj) // block resource line config
 		)
-		blcfg["type"] = "block"
+		blcfg[c.key("type")] = "block"
 
 		if err := c.convertBlock(block, bcfg, blcfg); err != nil {
 			return nil, nil, fmt.Errorf("convert block: %w", err)
@@ -133,38 +612,62 @@ func (c *converter) convertBody(body *hclsyntax.Body) (jsonObj, lineObj, error)
 
 	var err error
 	for key, value := range body.Attributes {
-		cfg[key], lcfg[key], err = c.convertExpression(value.Expr)
-		int, e := lcfg[key].(map[string]int)
-		if e {
-			int["__key__startIndex"] = value.NameRange.Start.Column
-			int["__key__endIndex"] = value.NameRange.End.Column
-			int["__key__line"] = value.NameRange.Start.Line
-		}
-		int1, e1 := lcfg[key].(map[string]map[string]interface{})
-		if e1 {
-			l := int1[key]
-			l["__key__startIndex"] = value.NameRange.Start.Column
-			l["__key__endIndex"] = value.NameRange.End.Column
-			l["__key__line"] = value.NameRange.Start.Line
-		}
-		int2, e2 := lcfg[key].(map[string]interface{})
-		if e2 {
-			int2["__key__startIndex"] = value.NameRange.Start.Column
-			int2["__key__endIndex"] = value.NameRange.End.Column
-			int2["__key__line"] = value.NameRange.Start.Line
-		}
+		cfg[key], lcfg[key], err = c.convertAttribute(value)
 		if err != nil {
-			return nil, nil, fmt.Errorf("convert expression: %w", err)
+			return nil, nil, err
 		}
 	}
-	lcfg["line"] = body.SrcRange.Start.Line
-	lcfg["startIndex"] = body.SrcRange.Start.Column
-	lcfg["endIndex"] = body.SrcRange.End.Column
-	lcfg["type"] = "block"
-	lcfg["endLine"] = body.SrcRange.End.Line
+	c.injectDefaults(cfg, lcfg)
+	lcfg[c.key("line")] = body.SrcRange.Start.Line
+	lcfg[c.key("startIndex")] = body.SrcRange.Start.Column
+	lcfg[c.key("endIndex")] = body.SrcRange.End.Column
+	lcfg[c.key("type")] = "block"
+	lcfg[c.key("endLine")] = body.SrcRange.End.Line
+	c.byteOffsets(lcfg, body.SrcRange)
 	return cfg, lcfg, nil
 }
 
+func (c *converter) convertAttribute(attr *hclsyntax.Attribute) (interface{}, interface{}, error) {
+	var value, line interface{}
+	var err error
+	if c.options.Skeleton {
+		line = c.rangeLines(attr.Expr.Range())
+	} else if value, line, err = c.convertExpression(attr.Expr); err != nil {
+		return nil, nil, fmt.Errorf("convert expression: %w", err)
+	}
+	// The canonical expression would give away hidden values, such as
+	// redacted ones, so is left out for them.
+	canonical := c.options.CanonicalExpressions
+	if canonical {
+		hidden, err := c.hidden(attr.Name, value)
+		if err != nil {
+			return nil, nil, err
+		}
+		canonical = !hidden
+	}
+	if !c.options.Skeleton {
+		value, err = c.transform(attr.Name, attr.Expr.Range(), value)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := c.check(attr.Name, attr.Expr.Range(), value); err != nil {
+		return nil, nil, err
+	}
+	if l, ok := line.(lineObj); ok {
+		l[c.key("__key__startIndex")] = attr.NameRange.Start.Column
+		l[c.key("__key__endIndex")] = attr.NameRange.End.Column
+		l[c.key("__key__line")] = attr.NameRange.Start.Line
+		c.keyByteOffsets(l, attr.NameRange)
+		c.annotateType(l, attr.Name)
+		c.recordType(l, attr.Expr)
+		if canonical {
+			l[c.key("canonical")] = SExpr(attr.Expr)
+		}
+	}
+	return value, line, nil
+}
+
 func (c *converter) rangeSource(r hcl.Range) string {
 	// for some reason the range doesn't include the ending paren, so
 	// check if the next character is an ending paren, and include it if it is.
@@ -175,6 +678,41 @@ func (c *converter) rangeSource(r hcl.Range) string {
 	return string(c.bytes[r.Start.Byte:end])
 }
 
+// key returns the name a line info entry records a position or other
+// detail under, such as "line", with Options.LineKeyPrefix.
+func (c *converter) key(name string) string {
+	return c.options.LineKeyPrefix + name
+}
+
+// rangeLines returns the line info of a range.
+func (c *converter) rangeLines(rng hcl.Range) lineObj {
+	line := lineObj{
+		c.key("line"):       rng.Start.Line,
+		c.key("startIndex"): rng.Start.Column,
+		c.key("endIndex"):   rng.End.Column,
+		c.key("endLine"):    rng.End.Line,
+	}
+	c.byteOffsets(line, rng)
+	return line
+}
+
+// byteOffsets records the byte offsets of rng in line, when
+// Options.IncludeByteOffsets is set.
+func (c *converter) byteOffsets(line lineObj, rng hcl.Range) {
+	if c.options.IncludeByteOffsets {
+		line[c.key("startByte")] = rng.Start.Byte
+		line[c.key("endByte")] = rng.End.Byte
+	}
+}
+
+// keyByteOffsets is like byteOffsets, for the range of the entry's key.
+func (c *converter) keyByteOffsets(line lineObj, rng hcl.Range) {
+	if c.options.IncludeByteOffsets {
+		line[c.key("__key__startByte")] = rng.Start.Byte
+		line[c.key("__key__endByte")] = rng.End.Byte
+	}
+}
+
 func (c *converter) convertBlock(block *hclsyntax.Block, cfg jsonObj, lcfg lineObj) error {
 	key := block.Type
 	for _, label := range block.Labels {
@@ -225,17 +763,34 @@ func (c *converter) convertBlock(block *hclsyntax.Block, cfg jsonObj, lcfg lineO
 		key = label
 	}
 
+	outer, outerPath := c.schema, c.path
+	c.schema = c.schema.block(block)
+	c.path = append(append(c.path[:len(c.path):len(c.path)], block.Type), block.Labels...)
 	value, blcfg, err := c.convertBody(block.Body)
-	blcfg["__key__startIndex"] = block.TypeRange.Start.Column // start_column
-	blcfg["__key__endIndex"] = block.TypeRange.End.Column
-	blcfg["__key__line"] = block.TypeRange.Start.Line
-	if len(block.LabelRanges) > 0 {
-		blcfg["__key__endIndex"] = block.LabelRanges[len(block.LabelRanges)-1].End.Column
+	if err == nil {
+		err = c.check("", block.Range(), value)
 	}
-
+	c.schema, c.path = outer, outerPath
 	if err != nil {
 		return fmt.Errorf("convert body: %w", err)
 	}
+	blcfg[c.key("__key__startIndex")] = block.TypeRange.Start.Column // start_column
+	blcfg[c.key("__key__endIndex")] = block.TypeRange.End.Column
+	blcfg[c.key("__key__line")] = block.TypeRange.Start.Line
+	keyRange := block.TypeRange
+	if len(block.LabelRanges) > 0 {
+		keyRange = hcl.RangeBetween(keyRange, block.LabelRanges[len(block.LabelRanges)-1])
+		blcfg[c.key("__key__endIndex")] = keyRange.End.Column
+	}
+	c.keyByteOffsets(blcfg, keyRange)
+	if len(block.LabelRanges) > 0 {
+		labels := make([]interface{}, len(block.LabelRanges))
+		for i, rng := range block.LabelRanges {
+			labels[i] = c.rangeLines(rng)
+		}
+		blcfg[c.key("labelRanges")] = labels
+	}
+
 	// resource config for blocks
 	if current, exists := cfg[key]; exists {
 		if list, ok := current.([]interface{}); ok {
@@ -277,19 +832,37 @@ func (c *converter) convertBlock(block *hclsyntax.Block, cfg jsonObj, lcfg lineO
 	return nil
 }
 
-func (c *converter) convertExpression(expr hclsyntax.Expression) (ret interface{}, line interface{}, err error) {
+func (c *converter) convertExpression(expr hclsyntax.Expression) (interface{}, interface{}, error) {
+	if c.options.PreserveHeredocs {
+		if delimiter, indented, ok := c.heredoc(expr); ok {
+			value, line, err := c.convertValue(expr)
+			if err != nil {
+				return nil, nil, err
+			}
+			return heredocObject(delimiter, indented, value), line, nil
+		}
+	}
+	return c.convertValue(expr)
+}
 
-	lineInfo := make(map[string]int)
-	lineInfo["line"] = expr.StartRange().Start.Line
-	lineInfo["startIndex"] = expr.StartRange().Start.Column
-	lineInfo["endIndex"] = expr.StartRange().End.Column
-	lineInfo["endLine"] = expr.StartRange().End.Line
+func (c *converter) convertValue(expr hclsyntax.Expression) (ret interface{}, line interface{}, err error) {
+
+	lineInfo := make(lineObj)
+	lineInfo[c.key("line")] = expr.Range().Start.Line
+	lineInfo[c.key("startIndex")] = expr.Range().Start.Column
+	lineInfo[c.key("endIndex")] = expr.Range().End.Column
+	lineInfo[c.key("endLine")] = expr.Range().End.Line
+	c.byteOffsets(lineInfo, expr.Range())
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok {
+		c.callLines(lineInfo, call)
+	}
 
 	line = lineInfo
 
-	if c.options.Simplify {
-		value, err := expr.Value(&evalContext)
-		if err == nil {
+	if c.options.Simplify && c.allowEvaluation(expr) {
+		value, err := expr.Value(c.evalContext())
+		if err == nil && value.IsWhollyKnown() {
+			c.annotateOrigin(line, evaluatedOrigin(expr))
 			return ctyjson.SimpleJSONValue{Value: value}, line, nil
 		}
 	}
@@ -297,24 +870,70 @@ func (c *converter) convertExpression(expr hclsyntax.Expression) (ret interface{
 	// assume it is hcl syntax (because, um, it is)
 	switch value := expr.(type) {
 	case *hclsyntax.LiteralValueExpr:
+		if !value.Val.IsWhollyKnown() {
+			// the parser's placeholder for an expression it couldn't parse,
+			// only seen when converting with AllowErrors
+			c.annotateOrigin(line, OriginComputed)
+			return nil, line, nil
+		}
+		c.annotateOrigin(line, OriginLiteral)
 		return ctyjson.SimpleJSONValue{Value: value.Val}, line, nil
 	// case *hclsyntax.UnaryOpExpr:
 	// 	return c.convertUnary(value)
+	case *hclsyntax.BinaryOpExpr:
+		ret = c.convertBinaryOp(value)
+		if _, evaluated := ret.(ctyjson.SimpleJSONValue); evaluated {
+			c.annotateOrigin(line, OriginConstant)
+		} else {
+			c.annotateOrigin(line, OriginComputed)
+		}
+		return ret, line, nil
 	case *hclsyntax.TemplateExpr:
+		if value.IsStringLiteral() {
+			c.annotateOrigin(line, OriginLiteral)
+		} else {
+			c.annotateOrigin(line, OriginComputed)
+		}
+		if c.options.ExpressionMode == ExpressionModeAST && !value.IsStringLiteral() {
+			return ExpressionTree(value), line, nil
+		}
 		ret, err = c.convertTemplate(value)
 		return
 	case *hclsyntax.TemplateWrapExpr:
 		return c.convertExpression(value.Wrapped)
+	case *hclsyntax.ForExpr:
+		c.annotateOrigin(line, OriginComputed)
+		if !c.options.StructuredFor {
+			return c.opaque(expr), line, nil
+		}
+		ret, err = c.convertFor(value)
+		return
+	case *hclsyntax.ConditionalExpr:
+		c.annotateOrigin(line, OriginComputed)
+		if !c.options.StructuredConditionals {
+			return c.opaque(expr), line, nil
+		}
+		ret, err = c.convertConditional(value)
+		return
+	case *hclsyntax.FunctionCallExpr:
+		c.annotateOrigin(line, OriginComputed)
+		if !c.options.StructuredFunctions {
+			return c.opaque(expr), line, nil
+		}
+		ret, err = c.convertCall(value)
+		return
 	case *hclsyntax.TupleConsExpr:
 		list := make([]interface{}, 0)
 		lines := make([]interface{}, 0)
 
 		lineInfo := make(map[string]interface{})
-		lineInfo["line"] = expr.StartRange().Start.Line
-		lineInfo["startIndex"] = expr.StartRange().Start.Column
-		lineInfo["endIndex"] = expr.StartRange().End.Column
-		lineInfo["endLine"] = expr.StartRange().End.Line
-		lineInfo["type"] = "array"
+		lineInfo[c.key("line")] = expr.Range().Start.Line
+		lineInfo[c.key("startIndex")] = expr.Range().Start.Column
+		lineInfo[c.key("endIndex")] = expr.Range().End.Column
+		lineInfo[c.key("endLine")] = expr.Range().End.Line
+		lineInfo[c.key("type")] = "array"
+		c.byteOffsets(lineInfo, expr.Range())
+		origin := OriginLiteral
 		for _, ex := range value.Exprs {
 			elem, line, err := c.convertExpression(ex)
 			if err != nil {
@@ -322,18 +941,22 @@ func (c *converter) convertExpression(expr hclsyntax.Expression) (ret interface{
 			}
 			list = append(list, elem)
 			lines = append(lines, line)
+			origin = leastLiteral(origin, c.lineOrigin(line))
 		}
-		lineInfo["lines"] = lines
+		lineInfo[c.key("lines")] = lines
+		c.annotateOrigin(lineInfo, origin)
 		line = lineInfo
 		return list, line, nil
 	case *hclsyntax.ObjectConsExpr:
 		m := make(jsonObj)
 		l := make(lineObj)
-		l["type"] = "object"
-		l["line"] = value.SrcRange.Start.Line
-		l["startIndex"] = value.SrcRange.Start.Column
-		l["endIndex"] = value.SrcRange.End.Column
-		l["endLine"] = value.SrcRange.End.Line
+		l[c.key("type")] = "object"
+		l[c.key("line")] = value.SrcRange.Start.Line
+		l[c.key("startIndex")] = value.SrcRange.Start.Column
+		l[c.key("endIndex")] = value.SrcRange.End.Column
+		l[c.key("endLine")] = value.SrcRange.End.Line
+		c.byteOffsets(l, value.SrcRange)
+		origin := OriginLiteral
 		for _, item := range value.Items {
 			key, err := c.convertKey(item.KeyExpr)
 			if err != nil {
@@ -343,10 +966,16 @@ func (c *converter) convertExpression(expr hclsyntax.Expression) (ret interface{
 			if err != nil {
 				return nil, line, err
 			}
+			origin = leastLiteral(origin, c.lineOrigin(l[key]))
+			if !isLiteralKey(item.KeyExpr) {
+				origin = OriginComputed
+			}
 		}
+		c.annotateOrigin(l, origin)
 		return m, l, nil
 	default:
-		return c.wrapExpr(expr), line, nil
+		c.annotateOrigin(line, OriginComputed)
+		return c.opaque(expr), line, nil
 	}
 }
 
@@ -364,6 +993,37 @@ func (c *converter) convertUnary(v *hclsyntax.UnaryOpExpr) (interface{}, error)
 	return ctyjson.SimpleJSONValue{Value: val}, nil
 }
 
+// convertBinaryOp evaluates operations such as 2 + 3 or "a" == "a" whose
+// operands are literals, as no evaluation context is needed for them.
+// Anything else is handled as an opaque expression.
+func (c *converter) convertBinaryOp(v *hclsyntax.BinaryOpExpr) interface{} {
+	if !isLiteralOperand(v.LHS) || !isLiteralOperand(v.RHS) {
+		return c.opaque(v)
+	}
+	val, diags := v.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		return c.opaque(v)
+	}
+	return ctyjson.SimpleJSONValue{Value: val}
+}
+
+// isLiteralOperand reports whether expr can be evaluated without any
+// variables or functions.
+func isLiteralOperand(expr hclsyntax.Expression) bool {
+	switch v := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		return true
+	case *hclsyntax.TemplateExpr:
+		return v.IsStringLiteral()
+	case *hclsyntax.ParenthesesExpr:
+		return isLiteralOperand(v.Expression)
+	case *hclsyntax.BinaryOpExpr:
+		return isLiteralOperand(v.LHS) && isLiteralOperand(v.RHS)
+	default:
+		return false
+	}
+}
+
 func (c *converter) convertTemplate(t *hclsyntax.TemplateExpr) (string, error) {
 	if t.IsStringLiteral() {
 		// safe because the value is just the string
@@ -424,10 +1084,16 @@ func (c *converter) convertTemplateConditional(expr *hclsyntax.ConditionalExpr)
 	builder.WriteString("}")
 	trueResult, err := c.convertStringPart(expr.TrueResult)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	builder.WriteString(trueResult)
+
+	// HCL templates have no else if, so a chain is written as nested
+	// %{if}s, as it was written in the source.
 	falseResult, err := c.convertStringPart(expr.FalseResult)
+	if err != nil {
+		return "", err
+	}
 	if len(falseResult) > 0 {
 		builder.WriteString("%{else}")
 		builder.WriteString(falseResult)
@@ -0,0 +1,180 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Configuration is a named set of options to convert a corpus with.
+type Configuration struct {
+	Name    string
+	Options Options
+}
+
+// CorpusReport describes how converting a corpus differs between
+// configurations. Every configuration is compared against the first, the
+// baseline. Changed counts the files each configuration converted
+// differently from the baseline.
+type CorpusReport struct {
+	Source   string         `json:"source"`
+	Baseline string         `json:"baseline"`
+	Files    []CorpusFile   `json:"files"`
+	Changed  map[string]int `json:"changed"`
+}
+
+// CorpusFile describes the differences found in a single file. Errors holds
+// the error for each configuration which failed to convert the file.
+type CorpusFile struct {
+	File        string             `json:"file"`
+	Errors      map[string]string  `json:"errors,omitempty"`
+	Differences []CorpusDifference `json:"differences,omitempty"`
+}
+
+// CorpusDifference is a value in the output for a configuration which
+// differs from the baseline. Baseline and Value hold the JSON of either
+// value, and are empty when the path is missing from that output.
+type CorpusDifference struct {
+	Configuration string `json:"configuration"`
+	Path          string `json:"path"`
+	Baseline      string `json:"baseline,omitempty"`
+	Value         string `json:"value,omitempty"`
+}
+
+// Corpus converts every HCL and Terraform file under src with each of the
+// configurations and reports where their output differs, to show the
+// impact of changing options such as Simplify or Dialect across many
+// files before doing so.
+func Corpus(src string, configurations []Configuration) (*CorpusReport, error) {
+	if len(configurations) < 2 {
+		return nil, fmt.Errorf("at least two configurations are needed to compare")
+	}
+
+	files, err := sourceFiles(src)
+	if err != nil {
+		return nil, fmt.Errorf("list source files: %w", err)
+	}
+
+	report := &CorpusReport{
+		Source:   src,
+		Baseline: configurations[0].Name,
+		Files:    make([]CorpusFile, 0, len(files)),
+		Changed:  make(map[string]int),
+	}
+	for _, rel := range files {
+		file, err := corpusFile(src, rel, configurations)
+		if err != nil {
+			return nil, err
+		}
+		changed := make(map[string]bool)
+		for _, diff := range file.Differences {
+			changed[diff.Configuration] = true
+		}
+		for name := range changed {
+			report.Changed[name]++
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+func corpusFile(src, rel string, configurations []Configuration) (CorpusFile, error) {
+	file := CorpusFile{File: rel}
+	bytes, err := ioutil.ReadFile(filepath.Join(src, rel))
+	if err != nil {
+		return file, fmt.Errorf("read file: %w", err)
+	}
+
+	outputs := make([]map[string]string, len(configurations))
+	for i, configuration := range configurations {
+		result, err := convertIsolated(bytes, rel, configuration.Options)
+		if err == nil {
+			outputs[i], err = flattenOutput(result.JSON)
+		}
+		if err != nil {
+			if file.Errors == nil {
+				file.Errors = make(map[string]string)
+			}
+			file.Errors[configuration.Name] = err.Error()
+		}
+	}
+
+	baseline := outputs[0]
+	if baseline == nil {
+		return file, nil
+	}
+	for i, output := range outputs[1:] {
+		if output == nil {
+			continue
+		}
+		name := configurations[i+1].Name
+		for _, path := range unionKeys(baseline, output) {
+			if baseline[path] != output[path] {
+				file.Differences = append(file.Differences, CorpusDifference{
+					Configuration: name,
+					Path:          path,
+					Baseline:      baseline[path],
+					Value:         output[path],
+				})
+			}
+		}
+	}
+	return file, nil
+}
+
+// flattenOutput returns the JSON of every leaf value in a converted file,
+// keyed by its dot-separated path.
+func flattenOutput(jsonBytes []byte) (map[string]string, error) {
+	var value interface{}
+	if err := json.Unmarshal(jsonBytes, &value); err != nil {
+		return nil, fmt.Errorf("parse output: %w", err)
+	}
+	leaves := make(map[string]string)
+	flattenValue("", value, leaves)
+	return leaves, nil
+}
+
+func flattenValue(path string, value interface{}, leaves map[string]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			for key, elem := range v {
+				flattenValue(join(key), elem, leaves)
+			}
+			return
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			for i, elem := range v {
+				flattenValue(join(strconv.Itoa(i)), elem, leaves)
+			}
+			return
+		}
+	}
+	leaf, _ := json.Marshal(value)
+	leaves[path] = string(leaf)
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,38 @@
+package convert
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCorpus(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "main.tf"), `x = max(1, 2)
+y = "a"`)
+	writeFile(t, filepath.Join(src, "plain.tf"), `z = true`)
+
+	report, err := Corpus(src, []Configuration{
+		{Name: "default"},
+		{Name: "simplify", Options: Options{Simplify: true}},
+	})
+	if err != nil {
+		t.Fatal("corpus:", err)
+	}
+
+	expected := []CorpusFile{
+		{
+			File: "main.tf",
+			Differences: []CorpusDifference{
+				{Configuration: "simplify", Path: "x", Baseline: `"${max(1, 2)}"`, Value: "2"},
+			},
+		},
+		{File: "plain.tf"},
+	}
+	if !reflect.DeepEqual(report.Files, expected) {
+		t.Errorf("expected %+v, got %+v", expected, report.Files)
+	}
+	if report.Changed["simplify"] != 1 {
+		t.Errorf("expected 1 changed file, got %d", report.Changed["simplify"])
+	}
+}
@@ -0,0 +1,93 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ConvertFileToCty converts a file like ConvertFile, but to a cty object
+// value with the types of the values in the file, so numbers stay numbers
+// and tuples stay tuples, for callers working with go-cty rather than
+// JSON. Blocks are tuples of objects, and expressions which can't be
+// converted to values are strings, as in the JSON.
+func ConvertFileToCty(file *hcl.File) (cty.Value, error) {
+	out, _, err := ConvertFile(file, Options{})
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyValue(out)
+}
+
+// ctyValue converts a value as produced by the converter to cty, keeping
+// the types of the values it holds from the file.
+func ctyValue(value interface{}) (cty.Value, error) {
+	switch value := value.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case ctyjson.SimpleJSONValue:
+		return value.Value, nil
+	case string:
+		return cty.StringVal(value), nil
+	case bool:
+		return cty.BoolVal(value), nil
+	case jsonObj:
+		return ctyObject(value)
+	case map[string]interface{}:
+		return ctyObject(value)
+	case []jsonObj:
+		elems := make([]interface{}, len(value))
+		for i, elem := range value {
+			elems[i] = elem
+		}
+		return ctyValue(elems)
+	case []interface{}:
+		if len(value) == 0 {
+			return cty.EmptyTupleVal, nil
+		}
+		elems := make([]cty.Value, len(value))
+		for i, elem := range value {
+			var err error
+			if elems[i], err = ctyValue(elem); err != nil {
+				return cty.NilVal, err
+			}
+		}
+		return cty.TupleVal(elems), nil
+	default:
+		// values decoded from JSON, such as json.Number
+		bytes, err := json.Marshal(value)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("marshal value: %w", err)
+		}
+		ty, err := ctyjson.ImpliedType(bytes)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("infer type: %w", err)
+		}
+		return ctyjson.Unmarshal(bytes, ty)
+	}
+}
+
+func ctyObject(obj map[string]interface{}) (cty.Value, error) {
+	if len(obj) == 0 {
+		return cty.EmptyObjectVal, nil
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make(map[string]cty.Value, len(obj))
+	for _, key := range keys {
+		value, err := ctyValue(obj[key])
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("convert %s: %w", key, err)
+		}
+		attrs[key] = value
+	}
+	return cty.ObjectVal(attrs), nil
+}
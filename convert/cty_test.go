@@ -0,0 +1,45 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestConvertFileToCty(t *testing.T) {
+	file, diags := hclsyntax.ParseConfig([]byte(`
+count   = 3
+enabled = true
+zones   = ["a", 1]
+tags    = { name = "web" }
+ami     = var.ami
+resource "aws_instance" "web" {
+	size = 20
+}
+`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	value, err := ConvertFileToCty(file)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	expected := cty.ObjectVal(map[string]cty.Value{
+		"count":   cty.NumberIntVal(3),
+		"enabled": cty.True,
+		"zones":   cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1)}),
+		"tags":    cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("web")}),
+		"ami":     cty.StringVal("${var.ami}"),
+		"resource": cty.TupleVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+			"aws_instance": cty.ObjectVal(map[string]cty.Value{
+				"web": cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(20)}),
+			}),
+		})}),
+	})
+	if !value.RawEquals(expected) {
+		t.Errorf("expected %#v, got %#v", expected, value)
+	}
+}
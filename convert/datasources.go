@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// DataSource describes a data block and everywhere its result is used.
+type DataSource struct {
+	Address    string                 `json:"address"`
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Provider   string                 `json:"provider"`
+	Config     map[string]interface{} `json:"config"`
+	Range      hcl.Range              `json:"range"`
+	References []Usage                `json:"references"`
+}
+
+// Usage is a reference made from within an attribute.
+type Usage struct {
+	Path  string    `json:"path"`
+	Range hcl.Range `json:"range"`
+}
+
+// DataSources returns every data block in a Terraform file, with its
+// provider, converted configuration, and the places it is referenced.
+func DataSources(file *hcl.File, options Options) ([]DataSource, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := converter{
+		bytes:   file.Bytes,
+		options: options,
+	}
+
+	var sources []DataSource
+	index := make(map[string]int)
+	for _, block := range body.Blocks {
+		if block.Type != "data" || len(block.Labels) != 2 {
+			continue
+		}
+		config, _, err := c.convertBody(block.Body)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s: %w", blockAddress(block), err)
+		}
+
+		index[blockAddress(block)] = len(sources)
+		sources = append(sources, DataSource{
+			Address:    blockAddress(block),
+			Type:       block.Labels[0],
+			Name:       block.Labels[1],
+			Provider:   providerOf(block),
+			Config:     config,
+			Range:      block.DefRange(),
+			References: []Usage{},
+		})
+	}
+
+	for _, attr := range attributes(body) {
+		for _, traversal := range attr.attr.Expr.Variables() {
+			addr, ok := referenceAddress(traversal)
+			if !ok {
+				continue
+			}
+			if i, ok := index[addr]; ok {
+				sources[i].References = append(sources[i].References, Usage{
+					Path:  attr.pathString(),
+					Range: traversal.SourceRange(),
+				})
+			}
+		}
+	}
+	return sources, nil
+}
+
+// providerOf returns the provider configuration a resource or data block
+// uses, taken from its provider argument (such as aws.west) or otherwise
+// implied by the prefix of its type.
+func providerOf(block *hclsyntax.Block) string {
+	if attr, ok := block.Body.Attributes["provider"]; ok {
+		traversal, diags := hcl.AbsTraversalForExpr(attr.Expr)
+		if !diags.HasErrors() {
+			return strings.Join(traversalNames(traversal), ".")
+		}
+	}
+	if len(block.Labels) == 0 {
+		return ""
+	}
+	return strings.SplitN(block.Labels[0], "_", 2)[0]
+}
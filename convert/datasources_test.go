@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestDataSources(t *testing.T) {
+	input := `
+data "aws_ami" "ubuntu" {
+	most_recent = true
+}
+
+data "google_client_config" "current" {
+	provider = google.beta
+}
+
+resource "aws_instance" "web" {
+	ami = data.aws_ami.ubuntu.id
+	tags = {
+		Source = data.aws_ami.ubuntu.name
+	}
+}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	sources, err := DataSources(file, Options{})
+	if err != nil {
+		t.Fatal("data sources:", err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 data sources, got %+v", sources)
+	}
+
+	ubuntu := sources[0]
+	if ubuntu.Address != "data.aws_ami.ubuntu" || ubuntu.Provider != "aws" {
+		t.Errorf("unexpected data source %+v", ubuntu)
+	}
+	if _, ok := ubuntu.Config["most_recent"]; !ok {
+		t.Errorf("expected the config to include most_recent, got %v", ubuntu.Config)
+	}
+	if len(ubuntu.References) != 2 {
+		t.Fatalf("expected 2 references, got %+v", ubuntu.References)
+	}
+	if ref := ubuntu.References[0]; ref.Path != "resource.aws_instance.web.ami" || ref.Range.Start.Line != 11 {
+		t.Errorf("unexpected reference %+v", ref)
+	}
+
+	if provider := sources[1].Provider; provider != "google.beta" {
+		t.Errorf("expected the google.beta provider, got %q", provider)
+	}
+}
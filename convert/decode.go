@@ -0,0 +1,261 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Decode converts a file and decodes it into target, a pointer to a struct
+// whose fields are tagged like those decoded by gohcl:
+//
+//	Name    string   `hcl:"name"`           // a required attribute
+//	Tags    []string `hcl:"tags,optional"`  // an optional attribute
+//	Disks   []Disk   `hcl:"disk,block"`     // blocks, or *Disk for at most one
+//	Kind    string   `hcl:"kind,label"`     // a label of the enclosing block
+//
+// Attribute values are decoded as encoding/json would decode their JSON.
+// Problems are returned as DecodeErrors positioned at the source of the
+// value concerned. Once decoded, each struct implementing Validator is
+// validated, with a FieldError positioned at the attribute it names.
+func Decode(bytes []byte, filename string, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a pointer to a struct, not %T", target)
+	}
+
+	result, err := Convert(bytes, filename, Options{})
+	if err != nil {
+		return err
+	}
+	var body, lines map[string]interface{}
+	if err := decodeJSON(result.JSON, &body); err != nil {
+		return fmt.Errorf("parse json: %w", err)
+	}
+	if err := decodeJSON(result.Lines, &lines); err != nil {
+		return fmt.Errorf("parse line info: %w", err)
+	}
+
+	d := decoder{filename: filename}
+	d.body(body, lines, rv.Elem(), nil)
+	if len(d.errs) > 0 {
+		return d.errs
+	}
+	return nil
+}
+
+// Validator is implemented by structs decoded by Decode which check their
+// own values once decoded.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is returned by a Validator to attribute a problem to one of
+// its attributes, by its name in the hcl tag, so it's positioned there.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Err.Error() }
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// DecodeError is a problem decoding a file, at the position of the value
+// concerned, with Path the dot-separated types, labels and names leading
+// to it.
+type DecodeError struct {
+	Filename string
+	Line     int
+	Column   int
+	Path     string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s:%d,%d: %s: %v", e.Filename, e.Line, e.Column, e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// DecodeErrors are the problems found decoding a file.
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type decoder struct {
+	filename string
+	errs     DecodeErrors
+}
+
+// fail records a problem at the position held in line info, which is that
+// of the key for attributes and blocks.
+func (d *decoder) fail(line interface{}, path []string, err error) {
+	l, _ := line.(map[string]interface{})
+	lineKey, columnKey := "line", "startIndex"
+	if _, ok := l["__key__line"]; ok {
+		lineKey, columnKey = "__key__line", "__key__startIndex"
+	}
+	lineNo, _ := lineNumber(l[lineKey])
+	column, _ := lineNumber(l[columnKey])
+	d.errs = append(d.errs, &DecodeError{
+		Filename: d.filename,
+		Line:     lineNo,
+		Column:   column,
+		Path:     strings.Join(path, "."),
+		Err:      err,
+	})
+}
+
+type hclField struct {
+	index int
+	name  string
+	kind  string
+}
+
+// hclFields returns the fields of a struct type with hcl tags.
+func hclFields(t reflect.Type) []hclField {
+	var fields []hclField
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("hcl")
+		if !ok {
+			continue
+		}
+		field := hclField{index: i, name: tag, kind: "attr"}
+		if comma := strings.IndexByte(tag, ','); comma >= 0 {
+			field.name, field.kind = tag[:comma], tag[comma+1:]
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// body decodes a body into a struct, with line the body's line info.
+func (d *decoder) body(body, line map[string]interface{}, rv reflect.Value, path []string) {
+	for _, field := range hclFields(rv.Type()) {
+		fieldPath := append(path[:len(path):len(path)], field.name)
+		value, present := body[field.name]
+		switch field.kind {
+		case "label":
+		case "block":
+			if present {
+				d.blocks(value, line[field.name], rv.Field(field.index), fieldPath)
+			}
+		case "attr", "optional":
+			if !present {
+				if field.kind == "attr" {
+					d.fail(line, path, fmt.Errorf("missing required argument %q", field.name))
+				}
+				continue
+			}
+			b, err := json.Marshal(value)
+			if err == nil {
+				err = json.Unmarshal(b, rv.Field(field.index).Addr().Interface())
+			}
+			if err != nil {
+				d.fail(line[field.name], fieldPath, err)
+			}
+		default:
+			d.fail(line, fieldPath, fmt.Errorf("unsupported hcl tag kind %q", field.kind))
+		}
+	}
+
+	validator, ok := rv.Addr().Interface().(Validator)
+	if !ok {
+		return
+	}
+	if err := validator.Validate(); err != nil {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			if fieldLine, ok := line[fieldErr.Field]; ok {
+				d.fail(fieldLine, append(path[:len(path):len(path)], fieldErr.Field), fieldErr.Err)
+				return
+			}
+		}
+		d.fail(line, path, err)
+	}
+}
+
+// blocks decodes the blocks of one type into a field of type T, *T, []T
+// or []*T, where T is a struct.
+func (d *decoder) blocks(value, line interface{}, field reflect.Value, path []string) {
+	values, _ := value.([]interface{})
+	lines, _ := line.([]interface{})
+	if len(values) != len(lines) {
+		d.fail(nil, path, errors.New("blocks don't match their line info"))
+		return
+	}
+
+	elemType := field.Type()
+	if elemType.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+	}
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		d.fail(nil, path, fmt.Errorf("blocks can't be decoded into %s", field.Type()))
+		return
+	}
+	var labels []hclField
+	for _, f := range hclFields(structType) {
+		if f.kind == "label" {
+			labels = append(labels, f)
+		}
+	}
+
+	decoded := 0
+	for i := range values {
+		body, _ := values[i].(map[string]interface{})
+		bodyLine, _ := lines[i].(map[string]interface{})
+		d.labelled(body, bodyLine, labels, nil, func(labelValues []string, body, line map[string]interface{}) {
+			block := reflect.New(structType)
+			for j, label := range labels {
+				block.Elem().Field(label.index).SetString(labelValues[j])
+			}
+			d.body(body, line, block.Elem(), append(path[:len(path):len(path)], labelValues...))
+
+			if elemType.Kind() != reflect.Ptr {
+				block = block.Elem()
+			}
+			switch {
+			case field.Kind() == reflect.Slice:
+				field.Set(reflect.Append(field, block))
+			case decoded > 0:
+				d.fail(line, path, errors.New("duplicate block"))
+			default:
+				field.Set(block)
+			}
+			decoded++
+		})
+	}
+}
+
+// labelled descends through the objects holding a block's labels, calling
+// fn with the labels and body of each block found.
+func (d *decoder) labelled(body, line map[string]interface{}, labels []hclField, values []string, fn func([]string, map[string]interface{}, map[string]interface{})) {
+	if len(values) == len(labels) {
+		fn(values, body, line)
+		return
+	}
+	keys := make([]string, 0, len(body))
+	for key := range body {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		nested, _ := body[key].(map[string]interface{})
+		nestedLine, _ := line[key].(map[string]interface{})
+		d.labelled(nested, nestedLine, labels, append(values[:len(values):len(values)], key), fn)
+	}
+}
@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type decodeDisk struct {
+	Name string `hcl:"name,label"`
+	Size int    `hcl:"size"`
+}
+
+type decodeInstance struct {
+	Type  string            `hcl:"type,label"`
+	Name  string            `hcl:"name,label"`
+	AMI   string            `hcl:"ami"`
+	Tags  map[string]string `hcl:"tags,optional"`
+	Disks []decodeDisk      `hcl:"disk,block"`
+}
+
+func (i *decodeInstance) Validate() error {
+	if i.AMI == "" {
+		return &FieldError{Field: "ami", Err: errors.New("must not be empty")}
+	}
+	return nil
+}
+
+type decodeConfig struct {
+	Region    string            `hcl:"region"`
+	Instances []*decodeInstance `hcl:"resource,block"`
+}
+
+func TestDecode(t *testing.T) {
+	var config decodeConfig
+	err := Decode([]byte(`region = "eu-west-1"
+resource "aws_instance" "web" {
+  ami  = "ami-123"
+  tags = { team = "web" }
+  disk "root" {
+    size = 20
+  }
+}
+`), "main.tf", &config)
+	if err != nil {
+		t.Fatal("decode:", err)
+	}
+	expected := decodeConfig{
+		Region: "eu-west-1",
+		Instances: []*decodeInstance{{
+			Type:  "aws_instance",
+			Name:  "web",
+			AMI:   "ami-123",
+			Tags:  map[string]string{"team": "web"},
+			Disks: []decodeDisk{{Name: "root", Size: 20}},
+		}},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("expected %+v, got %+v", expected, config)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var config decodeConfig
+	err := Decode([]byte(`resource "aws_instance" "web" {
+  ami = ""
+  disk "root" {
+    size = "big"
+  }
+}
+`), "main.tf", &config)
+
+	var errs DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected DecodeErrors, got %v", err)
+	}
+	want := []string{
+		`main.tf:1,1: : missing required argument "region"`,
+		`main.tf:4,5: resource.aws_instance.web.disk.root.size: json: cannot unmarshal string into Go value of type int`,
+		`main.tf:2,3: resource.aws_instance.web.ami: must not be empty`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("expected %q, got %q", want[i], err.Error())
+		}
+	}
+
+	if err := Decode([]byte(`x = 1`), "main.tf", config); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+}
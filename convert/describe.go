@@ -0,0 +1,280 @@
+package convert
+
+// DescribeOutput returns JSON Schema documents describing the JSON output
+// and line info produced with the given options, keyed by "output" and
+// "lines", so clients can discover the structure they'll receive.
+func DescribeOutput(options Options) (map[string]interface{}, error) {
+	version, err := options.outputSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"output": describeJSON(options, version),
+		"lines":  describeLines(options, version),
+	}, nil
+}
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+func describeJSON(options Options, version OutputSchemaVersion) map[string]interface{} {
+	if options.CombinedOutput {
+		return map[string]interface{}{
+			"$schema":     jsonSchemaDialect,
+			"title":       "Converted HCL with positions",
+			"description": "Each value with a position is an object holding it under value, and its position under range.",
+			"$ref":        "#/$defs/node",
+			"$defs": map[string]interface{}{
+				"node": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"value", "range"},
+					"properties": map[string]interface{}{
+						"range": map[string]interface{}{
+							"type":     "object",
+							"required": []string{"line", "endLine", "startCol", "endCol"},
+						},
+					},
+				},
+			},
+		}
+	}
+	if options.Dialect.profile() != nil {
+		return map[string]interface{}{
+			"$schema":     jsonSchemaDialect,
+			"title":       "Converted HCL",
+			"description": "Configuration in the shape the " + options.Dialect.String() + " application expects.",
+			"type":        "object",
+		}
+	}
+
+	expression := map[string]interface{}{
+		"description": "An expression which couldn't be converted to a value, wrapped in ${...}.",
+		"type":        "string",
+	}
+	if options.ExpressionMode == ExpressionModeAST {
+		expression = map[string]interface{}{
+			"description": "The syntax tree of an expression which couldn't be converted to a value.",
+			"type":        "object",
+			"required":    []string{"node"},
+			"properties": map[string]interface{}{
+				"node": map[string]interface{}{"type": "string"},
+			},
+		}
+	}
+
+	block := map[string]interface{}{
+		"description": "A block, nested under an object for each of its labels.",
+		"type":        "object",
+	}
+	if version == OutputSchemaV2 {
+		block = map[string]interface{}{
+			"description": "A block with its labels.",
+			"type":        "object",
+			"required":    []string{"labels", "body"},
+			"properties": map[string]interface{}{
+				"labels": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				"body":   map[string]interface{}{"$ref": "#/$defs/body"},
+			},
+		}
+	}
+
+	values := []interface{}{
+		map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/block"}},
+		map[string]interface{}{"$ref": "#/$defs/expression"},
+	}
+	defs := map[string]interface{}{
+		"block":      block,
+		"expression": expression,
+	}
+	if options.PreserveHeredocs {
+		defs["heredoc"] = map[string]interface{}{
+			"type":     "object",
+			"required": []string{HeredocKey},
+			"properties": map[string]interface{}{
+				HeredocKey: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"delimiter", "indented", "value"},
+				},
+			},
+		}
+		values = append(values, map[string]interface{}{"$ref": "#/$defs/heredoc"})
+	}
+	if options.StructuredFor {
+		defs["for"] = map[string]interface{}{
+			"type":     "object",
+			"required": []string{ForKey},
+			"properties": map[string]interface{}{
+				ForKey: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"type", "value_var", "collection", "value"},
+					"properties": map[string]interface{}{
+						"type": map[string]interface{}{"enum": []string{"tuple", "object"}},
+					},
+				},
+			},
+		}
+		values = append(values, map[string]interface{}{"$ref": "#/$defs/for"})
+	}
+	if options.StructuredConditionals {
+		defs["conditional"] = map[string]interface{}{
+			"type":     "object",
+			"required": []string{ConditionalKey},
+			"properties": map[string]interface{}{
+				ConditionalKey: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"condition", "true", "false"},
+				},
+			},
+		}
+		values = append(values, map[string]interface{}{"$ref": "#/$defs/conditional"})
+	}
+	if options.StructuredFunctions {
+		defs["call"] = map[string]interface{}{
+			"type":     "object",
+			"required": []string{FunctionCallKey},
+			"properties": map[string]interface{}{
+				FunctionCallKey: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"name", "args"},
+				},
+			},
+		}
+		values = append(values, map[string]interface{}{"$ref": "#/$defs/call"})
+	}
+	if options.IncludeTypes == TypesInline {
+		defs["typed"] = map[string]interface{}{
+			"description": "The value of an attribute with its type, in the JSON encoding of cty types.",
+			"type":        "object",
+			"required":    []string{"value", "type"},
+		}
+		values = append(values, map[string]interface{}{"$ref": "#/$defs/typed"})
+	}
+	defs["body"] = map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"anyOf": append(values, map[string]interface{}{}),
+		},
+	}
+	schema := map[string]interface{}{
+		"$schema": jsonSchemaDialect,
+		"title":   "Converted HCL",
+		"$ref":    "#/$defs/body",
+		"$defs":   defs,
+	}
+	if options.Provenance {
+		schema["properties"] = map[string]interface{}{
+			ProvenanceKey: map[string]interface{}{
+				"type":     "object",
+				"required": []string{"tool", "version", "fingerprint", "sha256"},
+			},
+		}
+	}
+	return schema
+}
+
+func describeLines(options Options, version OutputSchemaVersion) map[string]interface{} {
+	if options.CompactLines {
+		return map[string]interface{}{
+			"$schema":  jsonSchemaDialect,
+			"title":    "Compact line info",
+			"type":     "object",
+			"required": []string{"version", "strings", "lines"},
+			"properties": map[string]interface{}{
+				"version": map[string]interface{}{"const": CompactLinesVersion},
+				"strings": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				"lines":   map[string]interface{}{"type": "array"},
+			},
+		}
+	}
+
+	integer := map[string]interface{}{"type": "integer"}
+	if options.LineFormat == LineFormatFlat {
+		position := map[string]interface{}{
+			"type":     "object",
+			"required": []string{"line", "endLine", "startCol", "endCol"},
+			"properties": map[string]interface{}{
+				"file":      map[string]interface{}{"type": "string"},
+				"line":      integer,
+				"endLine":   integer,
+				"startCol":  integer,
+				"endCol":    integer,
+				"startByte": integer,
+				"endByte":   integer,
+				"origin":    map[string]interface{}{"type": "string"},
+				"key":       map[string]interface{}{"$ref": "#/$defs/position"},
+				"labels":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/position"}},
+			},
+		}
+		return map[string]interface{}{
+			"$schema":              jsonSchemaDialect,
+			"title":                "Flat line info",
+			"description":          "The position of each value in the output, keyed by its JSON pointer.",
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"$ref": "#/$defs/position"},
+			"$defs":                map[string]interface{}{"position": position},
+		}
+	}
+
+	properties := map[string]interface{}{
+		"line":              integer,
+		"startIndex":        integer,
+		"endIndex":          integer,
+		"endLine":           integer,
+		"__key__line":       integer,
+		"__key__startIndex": integer,
+		"__key__endIndex":   integer,
+		"type":              map[string]interface{}{"enum": []string{"block", "array", "object"}},
+		"lines":             map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/entry"}},
+		"synthetic":         map[string]interface{}{"type": "boolean"},
+		"function":          map[string]interface{}{"type": "string"},
+		"arguments":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/entry"}},
+		"labelRanges":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/entry"}},
+	}
+	if options.CanonicalExpressions {
+		properties["canonical"] = map[string]interface{}{"type": "string"}
+	}
+	if options.IncludeByteOffsets {
+		for _, name := range []string{"startByte", "endByte", "__key__startByte", "__key__endByte"} {
+			properties[name] = integer
+		}
+	}
+	if options.AnnotateTypes {
+		properties["schemaType"] = map[string]interface{}{
+			"description": "The type declared by the schema, in the JSON encoding of cty types.",
+		}
+	}
+	if options.AnnotateOrigins {
+		properties["origin"] = map[string]interface{}{
+			"description": "How the value was produced.",
+			"enum":        []Origin{OriginLiteral, OriginConstant, OriginVariable, OriginComputed, OriginDefault},
+		}
+	}
+	if options.LineKeyPrefix != "" {
+		prefixed := make(map[string]interface{}, len(properties))
+		for name, property := range properties {
+			prefixed[options.LineKeyPrefix+name] = property
+		}
+		properties = prefixed
+	}
+	if version == OutputSchemaV2 {
+		properties["labels"] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+		properties["body"] = map[string]interface{}{"$ref": "#/$defs/entry"}
+	}
+
+	entry := map[string]interface{}{
+		"description": "The position of a value, and of the entries for its children.",
+		"type":        "object",
+		"properties":  properties,
+		"additionalProperties": map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"$ref": "#/$defs/entry"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/entry"}},
+			},
+		},
+	}
+	return map[string]interface{}{
+		"$schema": jsonSchemaDialect,
+		"title":   "Line info",
+		"$ref":    "#/$defs/entry",
+		"$defs":   map[string]interface{}{"entry": entry},
+	}
+}
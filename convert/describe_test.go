@@ -0,0 +1,31 @@
+package convert
+
+import "testing"
+
+func TestDescribeOutput(t *testing.T) {
+	schema, err := DescribeOutput(Options{IncludeByteOffsets: true, AnnotateTypes: true, OutputSchemaVersion: OutputSchemaV2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := schema["lines"].(map[string]interface{})
+	entry := lines["$defs"].(map[string]interface{})["entry"].(map[string]interface{})
+	properties := entry["properties"].(map[string]interface{})
+	for _, name := range []string{"line", "startByte", "__key__endByte", "schemaType", "labels"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("expected line info property %q", name)
+		}
+	}
+	if _, ok := properties["canonical"]; ok {
+		t.Error("unexpected canonical property without CanonicalExpressions")
+	}
+
+	output := schema["output"].(map[string]interface{})
+	block := output["$defs"].(map[string]interface{})["block"].(map[string]interface{})
+	if _, ok := block["required"]; !ok {
+		t.Errorf("expected v2 blocks to require labels and body, got %v", block)
+	}
+
+	if _, err := DescribeOutput(Options{OutputSchemaVersion: 9}); err == nil {
+		t.Error("expected an error for an unknown output schema version")
+	}
+}
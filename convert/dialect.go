@@ -0,0 +1,43 @@
+package convert
+
+// Dialect identifies a flavour of HCL, such as the Terraform language,
+// whose conventions the converter may take into account.
+type Dialect string
+
+const (
+	// DialectHCL is plain HCL without any application specific conventions.
+	DialectHCL Dialect = ""
+
+	// DialectTerraform is the Terraform (and OpenTofu) configuration language.
+	DialectTerraform Dialect = "terraform"
+
+	// DialectTerragrunt is Terragrunt's configuration language, which adds
+	// its own built-in functions to those of Terraform.
+	DialectTerragrunt Dialect = "terragrunt"
+
+	// DialectNomad is the Nomad job specification language. Jobs are
+	// written in the shape the Nomad HTTP API expects, as in
+	// {"Job": {"ID": "example", "TaskGroups": [...]}}, with blocks such as
+	// task groups and tasks as lists, so they can't be combined with
+	// OutputSchemaV2.
+	DialectNomad Dialect = "nomad"
+
+	// DialectVault is Vault's server configuration, written in the JSON
+	// form Vault reads, with blocks such as storage as objects keyed by
+	// their label. Like DialectNomad, it can't be combined with
+	// OutputSchemaV2.
+	DialectVault Dialect = "vault"
+
+	// DialectConsul is Consul's agent configuration, written in the JSON
+	// form Consul reads, with blocks such as ports as objects and service
+	// blocks as a list of services. Like DialectNomad, it can't be
+	// combined with OutputSchemaV2.
+	DialectConsul Dialect = "consul"
+)
+
+func (d Dialect) String() string {
+	if d == DialectHCL {
+		return "hcl"
+	}
+	return string(d)
+}
@@ -0,0 +1,208 @@
+package convert
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is an attribute or block which differs between two files, as
+// found by Diff. Path is the dot-separated path of the attribute or block,
+// with blocks named by their type and labels, and an index when a body has
+// several blocks of the same type and labels, as in ebs_block_device[1].
+// Before and After are the converted values of a changed attribute, and
+// BeforeRange and AfterRange span the attribute or block in each file, from
+// its name to the end of its value or body.
+type Change struct {
+	Kind        ChangeKind  `json:"kind"`
+	Path        string      `json:"path"`
+	Block       bool        `json:"block,omitempty"`
+	Before      interface{} `json:"before,omitempty"`
+	After       interface{} `json:"after,omitempty"`
+	BeforeRange *hcl.Range  `json:"before_range,omitempty"`
+	AfterRange  *hcl.Range  `json:"after_range,omitempty"`
+}
+
+// Diff converts two versions of a file and returns the attributes and
+// blocks added, removed or changed between them, in path order. Blocks are
+// matched by their type and labels rather than their position, so moving
+// blocks and attributes around isn't a change. A block added or removed is
+// reported once, without the attributes within it.
+func Diff(a, b []byte, options Options) ([]Change, error) {
+	options.IncludeByteOffsets = true
+	before, beforeLines, err := diffSide(a, options)
+	if err != nil {
+		return nil, fmt.Errorf("convert a: %w", err)
+	}
+	after, afterLines, err := diffSide(b, options)
+	if err != nil {
+		return nil, fmt.Errorf("convert b: %w", err)
+	}
+
+	d := differ{prefix: options.LineKeyPrefix}
+	d.body("", before, after, beforeLines, afterLines)
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return d.changes[i].Path < d.changes[j].Path
+	})
+	return d.changes, nil
+}
+
+// diffSide converts one version of a file, returning its output and line
+// info decoded from JSON.
+func diffSide(src []byte, options Options) (map[string]interface{}, map[string]interface{}, error) {
+	file, diags := parseConfig(src, "", options)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("parse config: %v", diags.Errs())
+	}
+	cfg, lines, _, err := convertFile(file, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	var out, outLines map[string]interface{}
+	if err := decodedCopy(cfg, &out); err != nil {
+		return nil, nil, err
+	}
+	if err := decodedCopy(lines, &outLines); err != nil {
+		return nil, nil, err
+	}
+	return out, outLines, nil
+}
+
+type differ struct {
+	prefix  string
+	changes []Change
+}
+
+func (d *differ) body(path string, before, after, beforeLines, afterLines map[string]interface{}) {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	for key := range keys {
+		keyPath := joinPath(path, key)
+		beforeValue, inBefore := before[key]
+		afterValue, inAfter := after[key]
+		beforeBlocks, beforeIsBlock := beforeLines[key].([]interface{})
+		afterBlocks, afterIsBlock := afterLines[key].([]interface{})
+
+		switch {
+		case beforeIsBlock || afterIsBlock:
+			d.blocks(keyPath, beforeValue, afterValue, beforeBlocks, afterBlocks)
+		case !inAfter:
+			d.changes = append(d.changes, Change{Kind: ChangeRemoved, Path: keyPath, Before: beforeValue, BeforeRange: d.rangeOf(beforeLines[key])})
+		case !inBefore:
+			d.changes = append(d.changes, Change{Kind: ChangeAdded, Path: keyPath, After: afterValue, AfterRange: d.rangeOf(afterLines[key])})
+		case !reflect.DeepEqual(beforeValue, afterValue):
+			d.changes = append(d.changes, Change{
+				Kind: ChangeChanged, Path: keyPath, Before: beforeValue, After: afterValue,
+				BeforeRange: d.rangeOf(beforeLines[key]), AfterRange: d.rangeOf(afterLines[key]),
+			})
+		}
+	}
+}
+
+// diffBlock is a block of a body, named by its type and labels.
+type diffBlock struct {
+	name        string
+	body, lines map[string]interface{}
+}
+
+// blocks compares the blocks of one type, matching them by their labels,
+// and then in order for blocks with the same labels.
+func (d *differ) blocks(path string, before, after interface{}, beforeLines, afterLines []interface{}) {
+	beforeBlocks, afterBlocks := d.named(path, before, beforeLines), d.named(path, after, afterLines)
+	names := make(map[string]bool)
+	for name := range beforeBlocks {
+		names[name] = true
+	}
+	for name := range afterBlocks {
+		names[name] = true
+	}
+	for name := range names {
+		from, to := beforeBlocks[name], afterBlocks[name]
+		indexed := len(from) > 1 || len(to) > 1
+		for i := 0; i < len(from) || i < len(to); i++ {
+			blockPath := name
+			if indexed {
+				blockPath += "[" + strconv.Itoa(i) + "]"
+			}
+			switch {
+			case i >= len(to):
+				d.changes = append(d.changes, Change{Kind: ChangeRemoved, Path: blockPath, Block: true, BeforeRange: d.rangeOf(from[i].lines)})
+			case i >= len(from):
+				d.changes = append(d.changes, Change{Kind: ChangeAdded, Path: blockPath, Block: true, AfterRange: d.rangeOf(to[i].lines)})
+			default:
+				d.body(blockPath, from[i].body, to[i].body, from[i].lines, to[i].lines)
+			}
+		}
+	}
+}
+
+// named groups the blocks of a type by their path, with their labels.
+func (d *differ) named(path string, value interface{}, lines []interface{}) map[string][]diffBlock {
+	values, _ := value.([]interface{})
+	named := make(map[string][]diffBlock)
+	for i, line := range lines {
+		var body interface{}
+		if i < len(values) {
+			body = values[i]
+		}
+		bodyLines, _ := line.(map[string]interface{})
+		var labels []string
+		for bodyLines[d.prefix+"type"] != "block" && len(bodyLines) == 1 {
+			for label, inner := range bodyLines {
+				labels = append(labels, label)
+				bodyLines, _ = inner.(map[string]interface{})
+				body, _ = body.(map[string]interface{})[label]
+			}
+		}
+		name := strings.Join(append([]string{path}, labels...), ".")
+		bodyMap, _ := body.(map[string]interface{})
+		named[name] = append(named[name], diffBlock{name: name, body: bodyMap, lines: bodyLines})
+	}
+	return named
+}
+
+// rangeOf returns the range of an attribute or block from its line info,
+// starting at its name when the line info has its position.
+func (d *differ) rangeOf(line interface{}) *hcl.Range {
+	l, ok := line.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	rng := hcl.Range{
+		Start: hcl.Pos{Line: flatInt(l[d.prefix+"line"]), Column: flatInt(l[d.prefix+"startIndex"]), Byte: flatInt(l[d.prefix+"startByte"])},
+		End:   hcl.Pos{Line: flatInt(l[d.prefix+"endLine"]), Column: flatInt(l[d.prefix+"endIndex"]), Byte: flatInt(l[d.prefix+"endByte"])},
+	}
+	if _, ok := l[d.prefix+"__key__line"]; ok {
+		rng.Start = hcl.Pos{
+			Line:   flatInt(l[d.prefix+"__key__line"]),
+			Column: flatInt(l[d.prefix+"__key__startIndex"]),
+			Byte:   flatInt(l[d.prefix+"__key__startByte"]),
+		}
+	}
+	return &rng
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := []byte(`resource "aws_instance" "web" {
+  ami           = "ami-1"
+  instance_type = "t2.micro"
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+region = "us-east-1"
+`)
+	// The blocks are reordered, which isn't a change.
+	b := []byte(`region = "us-east-1"
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  ami           = "ami-1"
+  monitoring    = true
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdc"
+  }
+}
+`)
+	changes, err := Diff(a, b, Options{})
+	if err != nil {
+		t.Fatal("diff:", err)
+	}
+
+	got, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, got, []byte(`[
+		{
+			"kind": "added", "path": "resource.aws_instance.web.ebs_block_device[1]", "block": true,
+			"after_range": {"Filename": "", "Start": {"Line": 14, "Column": 3, "Byte": 247}, "End": {"Line": 16, "Column": 4, "Byte": 298}}
+		},
+		{
+			"kind": "changed", "path": "resource.aws_instance.web.instance_type",
+			"before": "t2.micro", "after": "t3.micro",
+			"before_range": {"Filename": "", "Start": {"Line": 3, "Column": 3, "Byte": 60}, "End": {"Line": 3, "Column": 29, "Byte": 86}},
+			"after_range": {"Filename": "", "Start": {"Line": 8, "Column": 3, "Byte": 115}, "End": {"Line": 8, "Column": 29, "Byte": 141}}
+		},
+		{
+			"kind": "added", "path": "resource.aws_instance.web.monitoring", "after": true,
+			"after_range": {"Filename": "", "Start": {"Line": 10, "Column": 3, "Byte": 170}, "End": {"Line": 10, "Column": 23, "Byte": 190}}
+		},
+		{
+			"kind": "added", "path": "resource.aws_s3_bucket.assets", "block": true,
+			"after_range": {"Filename": "", "Start": {"Line": 3, "Column": 1, "Byte": 22}, "End": {"Line": 5, "Column": 2, "Byte": 79}}
+		},
+		{
+			"kind": "removed", "path": "resource.aws_s3_bucket.logs", "block": true,
+			"before_range": {"Filename": "", "Start": {"Line": 9, "Column": 1, "Byte": 144}, "End": {"Line": 11, "Column": 2, "Byte": 197}}
+		}
+	]`))
+}
+
+func TestDiffSame(t *testing.T) {
+	src := []byte(`a = 1
+b {
+  c = [1, 2]
+}
+`)
+	changes, err := Diff(src, src, Options{})
+	if err != nil {
+		t.Fatal("diff:", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("got changes %v, want none", changes)
+	}
+}
+
+func TestDiffInvalid(t *testing.T) {
+	if _, err := Diff([]byte("a = 1"), []byte("a = "), Options{}); err == nil {
+		t.Error("got no error for invalid config")
+	}
+}
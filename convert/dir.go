@@ -0,0 +1,323 @@
+package convert
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// DirResult holds the outcome of converting a directory. Its Result is
+// that of the merged document, with the diagnostics, messages and findings
+// of every file, and line info where every block and attribute records the
+// file its final value came from.
+type DirResult struct {
+	Result
+
+	// FileLines holds the line info of each file as converted on its own,
+	// keyed by file name.
+	FileLines map[string][]byte
+}
+
+// Dir converts every HCL and Terraform file directly within a directory,
+// merging them into a single document. Override files (override.tf and
+// files ending in _override.tf) are applied on top of the merged document
+// following Terraform's override semantics, and the line info of every
+// block and attribute records the file its final value came from.
+func Dir(path string, options Options) ([]byte, []byte, error) {
+	result, err := ConvertDir(path, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.JSON, result.Lines, nil
+}
+
+// ConvertDir is like Dir, but also returns the line info of each file. As
+// in Terraform, the blocks of each file are appended to those before it,
+// while an attribute outside of any block may only be defined in one file.
+// Each file is parsed and the merged document written as Convert does, but
+// the provenance and inferred types Convert gives for a single file aren't
+// supported.
+func ConvertDir(path string, options Options) (*DirResult, error) {
+	if options.Provenance || options.InferTypes {
+		return nil, fmt.Errorf("Provenance and InferTypes are not supported for directories")
+	}
+	options, err := options.lineOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	var names, overrides []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isSourceFile(name) {
+			continue
+		}
+		if isOverrideFile(name) {
+			overrides = append(overrides, name)
+		} else {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(overrides)
+
+	result := &DirResult{FileLines: make(map[string][]byte)}
+	d := dirConverter{options: options, result: result}
+	cfg, lcfg := make(jsonObj), make(lineObj)
+	for _, name := range names {
+		fileCfg, fileLines, err := d.convertPath(filepath.Join(path, name), name)
+		if err != nil {
+			return nil, err
+		}
+		if err := mergeFile(cfg, lcfg, fileCfg, fileLines, options.LineKeyPrefix); err != nil {
+			return nil, fmt.Errorf("merge %s: %w", name, err)
+		}
+	}
+	for _, name := range overrides {
+		fileCfg, fileLines, err := d.convertPath(filepath.Join(path, name), name)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyOverride(cfg, lcfg, fileCfg, fileLines, options.LineKeyPrefix); err != nil {
+			return nil, fmt.Errorf("apply override %s: %w", name, err)
+		}
+	}
+
+	merged, err := encodeResult(cfg, lcfg, d.diags, d.messages, options)
+	if err != nil {
+		return nil, err
+	}
+	sortFindings(d.findings)
+	merged.Findings = d.findings
+	result.Result = *merged
+	return result, nil
+}
+
+// dirConverter collects what's raised converting the files of a directory.
+type dirConverter struct {
+	options  Options
+	result   *DirResult
+	diags    hcl.Diagnostics
+	messages Messages
+	findings []Finding
+}
+
+func isOverrideFile(name string) bool {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return base == "override" || strings.HasSuffix(base, "_override")
+}
+
+// convertPath converts the file at path, tagging its line info with name
+// and recording it in the result before it is merged.
+func (d *dirConverter) convertPath(path, name string) (jsonObj, lineObj, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file: %w", err)
+	}
+	file, diags := parseConfig(bytes, name, d.options)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("parse %s: %v", name, diags.Errs())
+	}
+	cfg, lcfg, c, err := convertFile(file, d.options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("convert %s: %w", name, err)
+	}
+	tagFile(lcfg, name, d.options.LineKeyPrefix)
+
+	if d.messages == nil {
+		d.messages = make(Messages)
+	}
+	for _, diag := range diags {
+		d.messages[diag] = Message{ID: "syntax-error"}
+	}
+	for diag, m := range c.messages {
+		d.messages[diag] = m
+	}
+	d.diags = append(append(d.diags, diags...), c.diags...)
+	d.findings = append(d.findings, c.findings...)
+
+	// The file's own line info is written in the form selected by the
+	// options, as the merged line info is.
+	fileResult, err := encodeResult(cfg, lcfg, nil, nil, d.options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode %s: %w", name, err)
+	}
+	d.result.FileLines[name] = fileResult.Lines
+	return cfg, lcfg, nil
+}
+
+// tagFile records the file name in the line info of a body, its attributes
+// and all of its nested blocks. Without a LineKeyPrefix, the key can be
+// taken by an attribute or object key named file, whose line info is kept
+// rather than tagging that object.
+func tagFile(body lineObj, name, prefix string) {
+	tag := func(l lineObj) {
+		if _, taken := l[prefix+"file"]; !taken {
+			l[prefix+"file"] = name
+		}
+	}
+	tag(body)
+	for _, value := range body {
+		switch value := value.(type) {
+		case lineObj:
+			tag(value)
+		case []lineObj:
+			for _, block := range value {
+				_, blockBody := blockBodyLines(block, prefix)
+				tagFile(blockBody, name, prefix)
+			}
+		}
+	}
+}
+
+// definedAt returns the file and line the line info of an attribute or a
+// block records, for errors.
+func definedAt(l lineObj, prefix string) string {
+	_, body := blockBodyLines(l, prefix)
+	return fmt.Sprintf("%v:%v", body[prefix+"file"], body[prefix+"line"])
+}
+
+// blockBodyLines descends through the label objects of a converted block's
+// line info, returning the labels and the line info of the block's body.
+// prefix is Options.LineKeyPrefix.
+func blockBodyLines(l lineObj, prefix string) ([]string, lineObj) {
+	var labels []string
+	for l[prefix+"type"] != "block" && len(l) == 1 {
+		for label, inner := range l {
+			labels = append(labels, label)
+			l = inner.(lineObj)
+		}
+	}
+	return labels, l
+}
+
+// blockBody returns the body of a converted block below the given labels.
+func blockBody(cfg jsonObj, labels []string) jsonObj {
+	for _, label := range labels {
+		cfg = cfg[label].(jsonObj)
+	}
+	return cfg
+}
+
+// mergeFile appends the blocks of a converted file to those already merged.
+// Attributes outside of any block may not be redefined.
+func mergeFile(cfg jsonObj, lcfg lineObj, fileCfg jsonObj, fileLines lineObj, prefix string) error {
+	for key, value := range fileCfg {
+		blocks, isBlock := value.([]jsonObj)
+		if !isBlock {
+			switch previous := lcfg[key].(type) {
+			case lineObj:
+				return fmt.Errorf("duplicate attribute %s, already defined in %s", key, definedAt(previous, prefix))
+			case []lineObj:
+				return fmt.Errorf("attribute %s conflicts with the blocks defined in %s", key, definedAt(previous[0], prefix))
+			}
+			cfg[key] = value
+			lcfg[key] = fileLines[key]
+			continue
+		}
+		if _, exists := cfg[key]; !exists {
+			cfg[key] = value
+			lcfg[key] = fileLines[key]
+			continue
+		}
+		current, ok := cfg[key].([]jsonObj)
+		if !ok {
+			return fmt.Errorf("%s blocks conflict with the attribute defined in %s", key, definedAt(lcfg[key].(lineObj), prefix))
+		}
+		cfg[key] = append(current, blocks...)
+		lcfg[key] = append(lcfg[key].([]lineObj), fileLines[key].([]lineObj)...)
+	}
+	return nil
+}
+
+// applyOverride merges an override file into the converted configuration.
+// Each block in the override must match an existing block with the same
+// type and labels, and each of its attributes and nested block types
+// replaces the original ones. Local values override the definition of the
+// same name in any locals block.
+func applyOverride(cfg jsonObj, lcfg lineObj, overCfg jsonObj, overLines lineObj, prefix string) error {
+	for key, value := range overCfg {
+		blocks, isBlock := value.([]jsonObj)
+		if base, exists := cfg[key]; exists {
+			if _, baseIsBlock := base.([]jsonObj); baseIsBlock != isBlock {
+				return fmt.Errorf("%s is an attribute in one file and blocks in the other", key)
+			}
+		}
+		if !isBlock {
+			cfg[key] = value
+			lcfg[key] = overLines[key]
+			continue
+		}
+
+		if key == "locals" {
+			if err := overrideLocals(cfg, lcfg, blocks, overLines[key].([]lineObj)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for i, block := range blocks {
+			labels, overBody := blockBodyLines(overLines[key].([]lineObj)[i], prefix)
+			body, bodyLines, ok := findBlock(cfg, lcfg, key, labels, prefix)
+			if !ok {
+				return fmt.Errorf("missing base %s to override", strings.Join(append([]string{key}, labels...), "."))
+			}
+			overrideBody(body, bodyLines, blockBody(block, labels), overBody)
+		}
+	}
+	return nil
+}
+
+func overrideLocals(cfg jsonObj, lcfg lineObj, blocks []jsonObj, lines []lineObj) error {
+	for i, block := range blocks {
+		for name, value := range block {
+			body, bodyLines, ok := findLocal(cfg, lcfg, name)
+			if !ok {
+				return fmt.Errorf("missing base local.%s to override", name)
+			}
+			body[name] = value
+			bodyLines[name] = lines[i][name]
+		}
+	}
+	return nil
+}
+
+// findBlock returns the body of the first block of the given type and labels.
+func findBlock(cfg jsonObj, lcfg lineObj, key string, labels []string, prefix string) (jsonObj, lineObj, bool) {
+	blocks, _ := cfg[key].([]jsonObj)
+	for i, block := range blocks {
+		blockLabels, bodyLines := blockBodyLines(lcfg[key].([]lineObj)[i], prefix)
+		if strings.Join(blockLabels, "\x00") == strings.Join(labels, "\x00") {
+			return blockBody(block, labels), bodyLines, true
+		}
+	}
+	return nil, nil, false
+}
+
+func findLocal(cfg jsonObj, lcfg lineObj, name string) (jsonObj, lineObj, bool) {
+	blocks, _ := cfg["locals"].([]jsonObj)
+	for i, block := range blocks {
+		if _, ok := block[name]; ok {
+			return block, lcfg["locals"].([]lineObj)[i], true
+		}
+	}
+	return nil, nil, false
+}
+
+// overrideBody replaces every attribute and nested block type of body with
+// those from the override, leaving the position of the body itself alone.
+func overrideBody(body jsonObj, bodyLines lineObj, over jsonObj, overLines lineObj) {
+	for key, value := range over {
+		body[key] = value
+		bodyLines[key] = overLines[key]
+	}
+}
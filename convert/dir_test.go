@@ -0,0 +1,241 @@
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `
+resource "aws_instance" "web" {
+	ami           = "ami-123"
+	instance_type = "t2.micro"
+
+	ebs_block_device {
+		device_name = "sda1"
+	}
+}
+
+locals {
+	env = "dev"
+}`)
+	writeFile(t, filepath.Join(dir, "variables.tf"), `
+variable "region" {
+	default = "us-east-1"
+}`)
+	writeFile(t, filepath.Join(dir, "main_override.tf"), `
+resource "aws_instance" "web" {
+	instance_type = "m5.large"
+
+	ebs_block_device {
+		device_name = "sdb1"
+	}
+}
+
+locals {
+	env = "prod"
+}`)
+
+	expected := `{
+	"locals": [
+		{
+			"env": "prod"
+		}
+	],
+	"resource": [
+		{
+			"aws_instance": {
+				"web": {
+					"ami": "ami-123",
+					"ebs_block_device": [
+						{
+							"device_name": "sdb1"
+						}
+					],
+					"instance_type": "m5.large"
+				}
+			}
+		}
+	],
+	"variable": [
+		{
+			"region": {
+				"default": "us-east-1"
+			}
+		}
+	]
+}`
+
+	convertedBytes, lineBytes, err := Dir(dir, Options{})
+	if err != nil {
+		t.Fatal("convert dir:", err)
+	}
+
+	compareTest(t, convertedBytes, expected)
+
+	lines := decodeLines(t, lineBytes)
+	web := lines["resource"].([]interface{})[0].(map[string]interface{})["aws_instance"].(map[string]interface{})["web"].(map[string]interface{})
+	for key, file := range map[string]string{
+		"ami":           "main.tf",
+		"instance_type": "main_override.tf",
+	} {
+		if actual := web[key].(map[string]interface{})["file"]; actual != file {
+			t.Errorf("expected %s to come from %s, got %v", key, file, actual)
+		}
+	}
+	if actual := lines["locals"].([]interface{})[0].(map[string]interface{})["env"].(map[string]interface{})["file"]; actual != "main_override.tf" {
+		t.Errorf("expected local.env to come from main_override.tf, got %v", actual)
+	}
+}
+
+func TestDirOverrideMissingBase(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `resource "aws_instance" "web" {}`)
+	writeFile(t, filepath.Join(dir, "override.tf"), `resource "aws_instance" "db" {}`)
+
+	if _, _, err := Dir(dir, Options{}); err == nil {
+		t.Fatal("overriding a missing block should have returned an error")
+	}
+}
+
+func TestConvertDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.hcl"), `
+name = "app"
+service "web" {}`)
+	writeFile(t, filepath.Join(dir, "b.tf"), `service "db" {}`)
+	writeFile(t, filepath.Join(dir, "notes.txt"), `ignored`)
+
+	result, err := ConvertDir(dir, Options{})
+	if err != nil {
+		t.Fatal("convert dir:", err)
+	}
+
+	compareTest(t, result.JSON, `{
+	"name": "app",
+	"service": [
+		{
+			"web": {}
+		},
+		{
+			"db": {}
+		}
+	]
+}`)
+
+	if len(result.FileLines) != 2 {
+		t.Fatalf("expected line info for 2 files, got %d", len(result.FileLines))
+	}
+	lines := decodeLines(t, result.FileLines["b.tf"])
+	if _, ok := lines["name"]; ok {
+		t.Error("the line info of b.tf should only cover b.tf")
+	}
+	if len(lines["service"].([]interface{})) != 1 {
+		t.Errorf("expected 1 service in the line info of b.tf, got %v", lines["service"])
+	}
+}
+
+func TestConvertDirDuplicateAttribute(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.hcl"), `name = "a"`)
+	writeFile(t, filepath.Join(dir, "b.hcl"), `name = "b"`)
+
+	_, err := ConvertDir(dir, Options{})
+	if err == nil || !strings.Contains(err.Error(), "already defined in a.hcl") {
+		t.Errorf("expected a duplicate attribute error, got %v", err)
+	}
+}
+
+func TestConvertDirOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.hcl"), `nmae = "app"`)
+	writeFile(t, filepath.Join(dir, "b.hcl"), `{"service": {"web": {}}}`)
+
+	schema := &Schema{
+		Attributes: map[string]*AttributeSchema{"name": {}},
+		Blocks:     map[string]*BlockSchema{"service": {Labels: []string{"name"}}},
+	}
+	if _, err := ConvertDir(dir, Options{Schema: schema, InputSyntax: InputSyntaxJSON}); err == nil {
+		t.Fatal("expected a.hcl to fail to parse as JSON")
+	}
+
+	result, err := ConvertDir(dir, Options{Schema: schema, Indent: "  ", CompactLines: true})
+	if err != nil {
+		t.Fatal("convert dir:", err)
+	}
+	if !strings.Contains(string(result.JSON), "\n  \"nmae\": \"app\"") {
+		t.Errorf("expected indented JSON, got %s", result.JSON)
+	}
+	if _, err := ExpandLines(result.Lines); err != nil {
+		t.Errorf("expected compact line info, got %s: %v", result.Lines, err)
+	}
+	if _, err := ExpandLines(result.FileLines["a.hcl"]); err != nil {
+		t.Errorf("expected compact line info for a.hcl, got %s: %v", result.FileLines["a.hcl"], err)
+	}
+	if len(result.Diagnostics) != 1 || result.Messages[result.Diagnostics[0]].ID != "unsupported-argument" {
+		t.Errorf("expected the unsupported argument in a.hcl to be reported, got %v", result.Diagnostics)
+	}
+
+	if _, err := ConvertDir(dir, Options{Provenance: true}); err == nil {
+		t.Error("expected Provenance to be rejected")
+	}
+}
+
+func TestDirOverrideAttributeBlockCollision(t *testing.T) {
+	for name, files := range map[string][2]string{
+		"attribute over blocks": {"locals {\n\tenv = \"dev\"\n}", `locals = { env = "prod" }`},
+		"blocks over attribute": {`locals = { env = "dev" }`, "locals {\n\tenv = \"prod\"\n}"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "main.tf"), files[0])
+			writeFile(t, filepath.Join(dir, "override.tf"), files[1])
+
+			_, _, err := Dir(dir, Options{})
+			if err == nil || !strings.Contains(err.Error(), "locals is an attribute in one file and blocks in the other") {
+				t.Errorf("expected a collision error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertDirAttributeBlockCollision(t *testing.T) {
+	for name, files := range map[string][2]string{
+		"service blocks conflict with the attribute defined in a.hcl:1":  {`service = "web"`, `service "db" {}`},
+		"attribute service conflicts with the blocks defined in a.hcl:2": {"\nservice \"db\" {}", `service = "web"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "a.hcl"), files[0])
+			writeFile(t, filepath.Join(dir, "b.hcl"), files[1])
+
+			_, err := ConvertDir(dir, Options{})
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("expected a collision error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConvertDirFileAttribute(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `
+file = "a.txt"
+tags = { file = "b.txt" }`)
+
+	_, lineBytes, err := Dir(dir, Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	lines := decodeLines(t, lineBytes)
+	file, ok := lines["file"].(map[string]interface{})
+	if !ok || file["line"] != float64(2) || file["file"] != "main.tf" {
+		t.Errorf("expected the line info of the file attribute, got %v", lines["file"])
+	}
+	tags := lines["tags"].(map[string]interface{})
+	if _, ok := tags["file"].(map[string]interface{}); !ok {
+		t.Errorf("expected the line info of the file key in tags, got %v", tags["file"])
+	}
+}
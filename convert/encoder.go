@@ -0,0 +1,304 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Encoder writes the JSON output and line info of a conversion in another
+// format, such as a compact binary format for pipelines which embed
+// converted configuration.
+type Encoder interface {
+	// Name identifies the encoder in the options fingerprint.
+	Name() string
+
+	Encode(data []byte) ([]byte, error)
+}
+
+var (
+	// MsgpackEncoder writes MessagePack, as BytesToMsgpack does.
+	MsgpackEncoder Encoder = binaryEncoder{name: "msgpack", format: msgpackFormat{}}
+
+	// CBOREncoder writes CBOR, as BytesToCBOR does.
+	CBOREncoder Encoder = binaryEncoder{name: "cbor", format: cborFormat{}}
+)
+
+// BytesToMsgpack re-encodes JSON, such as the output or line info of a
+// conversion, as MessagePack. Objects keep the order of their keys, and
+// numbers are written as integers when they're whole and fit in 64 bits,
+// and otherwise as 64-bit floats.
+func BytesToMsgpack(data []byte) ([]byte, error) {
+	return MsgpackEncoder.Encode(data)
+}
+
+// BytesToCBOR re-encodes JSON as CBOR, with objects and numbers written as
+// BytesToMsgpack writes them.
+func BytesToCBOR(data []byte) ([]byte, error) {
+	return CBOREncoder.Encode(data)
+}
+
+// encode re-encodes the output, line info and type map of a result with
+// Options.Encoder, when set.
+func (o Options) encode(result *Result) error {
+	if o.Encoder == nil {
+		return nil
+	}
+	for _, data := range []*[]byte{&result.JSON, &result.Lines, &result.TypeMap} {
+		if *data == nil {
+			continue
+		}
+		encoded, err := o.Encoder.Encode(*data)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", o.Encoder.Name(), err)
+		}
+		*data = encoded
+	}
+	return nil
+}
+
+// binaryFormat writes the values of the JSON data model in a binary
+// format.
+type binaryFormat interface {
+	null(buf *bytes.Buffer)
+	boolean(buf *bytes.Buffer, b bool)
+	integer(buf *bytes.Buffer, n int64)
+	unsigned(buf *bytes.Buffer, n uint64)
+	float(buf *bytes.Buffer, f float64)
+	str(buf *bytes.Buffer, s string)
+	arrayHeader(buf *bytes.Buffer, n int)
+	mapHeader(buf *bytes.Buffer, n int)
+}
+
+type binaryEncoder struct {
+	name   string
+	format binaryFormat
+}
+
+func (e binaryEncoder) Name() string { return e.name }
+
+func (e binaryEncoder) Encode(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := e.value(&buf, dec); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("decode json: trailing data")
+	}
+	return buf.Bytes(), nil
+}
+
+// value encodes the next value read from dec. The elements of arrays and
+// objects are encoded before their header, which needs their count.
+func (e binaryEncoder) value(buf *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		var elems bytes.Buffer
+		count := 0
+		for dec.More() {
+			if tok == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return fmt.Errorf("decode json: %w", err)
+				}
+				e.format.str(&elems, key.(string))
+			}
+			if err := e.value(&elems, dec); err != nil {
+				return err
+			}
+			count++
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("decode json: %w", err)
+		}
+		if tok == '{' {
+			e.format.mapHeader(buf, count)
+		} else {
+			e.format.arrayHeader(buf, count)
+		}
+		buf.Write(elems.Bytes())
+	case string:
+		e.format.str(buf, tok)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(tok), 10, 64); err == nil {
+			e.format.integer(buf, n)
+		} else if n, err := strconv.ParseUint(string(tok), 10, 64); err == nil {
+			e.format.unsigned(buf, n)
+		} else {
+			f, err := tok.Float64()
+			if err != nil {
+				return fmt.Errorf("parse number %s: %w", tok, err)
+			}
+			e.format.float(buf, f)
+		}
+	case bool:
+		e.format.boolean(buf, tok)
+	case nil:
+		e.format.null(buf)
+	}
+	return nil
+}
+
+// msgpackFormat writes MessagePack, using the smallest encoding of each
+// value.
+type msgpackFormat struct{}
+
+func (msgpackFormat) null(buf *bytes.Buffer) { buf.WriteByte(0xc0) }
+
+func (msgpackFormat) boolean(buf *bytes.Buffer, b bool) {
+	if b {
+		buf.WriteByte(0xc3)
+	} else {
+		buf.WriteByte(0xc2)
+	}
+}
+
+func (f msgpackFormat) integer(buf *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0:
+		f.unsigned(buf, uint64(n))
+	case n >= -32:
+		buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(n)})
+	case n >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(n))
+	case n >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(n))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func (msgpackFormat) unsigned(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0x80:
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func (msgpackFormat) float(buf *bytes.Buffer, f float64) {
+	buf.WriteByte(0xcb)
+	binary.Write(buf, binary.BigEndian, f)
+}
+
+func (msgpackFormat) str(buf *bytes.Buffer, s string) {
+	msgpackHeader(buf, len(s), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	buf.WriteString(s)
+}
+
+func (msgpackFormat) arrayHeader(buf *bytes.Buffer, n int) {
+	msgpackHeader(buf, n, 0x90, 16, 0, 0xdc, 0xdd)
+}
+
+func (msgpackFormat) mapHeader(buf *bytes.Buffer, n int) {
+	msgpackHeader(buf, n, 0x80, 16, 0, 0xde, 0xdf)
+}
+
+// msgpackHeader writes the header of a string, array or map of length n:
+// in fix when n is under fixMax, or with the 8, 16 or 32-bit length of the
+// given type, where a zero type8 means there's no 8-bit form.
+func msgpackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, type8, type16, type32 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case type8 != 0 && n <= math.MaxUint8:
+		buf.Write([]byte{type8, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(type16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(type32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// cborFormat writes CBOR, as described in RFC 8949, with definite lengths.
+type cborFormat struct{}
+
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+)
+
+func (cborFormat) null(buf *bytes.Buffer) { buf.WriteByte(0xf6) }
+
+func (cborFormat) boolean(buf *bytes.Buffer, b bool) {
+	if b {
+		buf.WriteByte(0xf5)
+	} else {
+		buf.WriteByte(0xf4)
+	}
+}
+
+func (cborFormat) integer(buf *bytes.Buffer, n int64) {
+	if n >= 0 {
+		cborHead(buf, cborUnsigned, uint64(n))
+	} else {
+		cborHead(buf, cborNegative, uint64(-1-n))
+	}
+}
+
+func (cborFormat) unsigned(buf *bytes.Buffer, n uint64) { cborHead(buf, cborUnsigned, n) }
+
+func (cborFormat) float(buf *bytes.Buffer, f float64) {
+	buf.WriteByte(0xfb)
+	binary.Write(buf, binary.BigEndian, f)
+}
+
+func (cborFormat) str(buf *bytes.Buffer, s string) {
+	cborHead(buf, cborText, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func (cborFormat) arrayHeader(buf *bytes.Buffer, n int) { cborHead(buf, cborArray, uint64(n)) }
+
+func (cborFormat) mapHeader(buf *bytes.Buffer, n int) { cborHead(buf, cborMap, uint64(n)) }
+
+// cborHead writes the initial byte of a data item of the major type, with
+// its argument n.
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major | 27)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
@@ -0,0 +1,78 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestBinaryEncoders(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	for _, test := range []struct {
+		json          string
+		msgpack, cbor string
+	}{
+		{
+			`{"a":1,"b":[true,null,"x"],"c":-1.5}`,
+			"83" + "a161" + "01" + "a162" + "93c3c0a178" + "a163" + "cbbff8000000000000",
+			"a3" + "6161" + "01" + "6162" + "83f5f66178" + "6163" + "fbbff8000000000000",
+		},
+		// Keys keep their order.
+		{`{"z":0,"a":false}`, "82a17a00a161c2", "a2617a006161f4"},
+		{`[-33, 300, 18446744073709551615]`, "93d0dfcd012ccfffffffffffffffff", "833820" + "19012c" + "1bffffffffffffffff"},
+		{`"` + long + `"`, "d928" + hex.EncodeToString([]byte(long)), "7828" + hex.EncodeToString([]byte(long))},
+		{`{}`, "80", "a0"},
+	} {
+		for _, encoder := range []struct {
+			encode func([]byte) ([]byte, error)
+			want   string
+		}{
+			{BytesToMsgpack, test.msgpack},
+			{BytesToCBOR, test.cbor},
+		} {
+			got, err := encoder.encode([]byte(test.json))
+			if err != nil {
+				t.Errorf("%s: %v", test.json, err)
+				continue
+			}
+			if hex.EncodeToString(got) != encoder.want {
+				t.Errorf("%s: expected %s, got %x", test.json, encoder.want, got)
+			}
+		}
+	}
+
+	if _, err := BytesToCBOR([]byte(`{"a":`)); err == nil {
+		t.Error("expected truncated JSON to fail")
+	}
+	if _, err := BytesToMsgpack([]byte(`1 2`)); err == nil {
+		t.Error("expected trailing data to fail")
+	}
+}
+
+func TestEncoderOption(t *testing.T) {
+	src := []byte("region = \"us-east-1\"\n")
+	plain, err := Convert(src, "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	encoded, err := Convert(src, "main.tf", Options{Encoder: CBOREncoder})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	wantJSON, err := BytesToCBOR(plain.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLines, err := BytesToCBOR(plain.Lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded.JSON, wantJSON) || !bytes.Equal(encoded.Lines, wantLines) {
+		t.Errorf("expected the output and line info in CBOR, got %x and %x", encoded.JSON, encoded.Lines)
+	}
+	if (Options{}).Fingerprint() == (Options{Encoder: CBOREncoder}).Fingerprint() {
+		t.Error("expected the encoder to change the fingerprint")
+	}
+}
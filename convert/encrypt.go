@@ -0,0 +1,159 @@
+package convert
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EncryptedKey is the key of the object values encrypted by Encrypt are
+// replaced with:
+//
+//	{"__encrypted__": {"key": "...", "nonce": "...", "ciphertext": "..."}}
+//
+// with each field base64 encoded.
+const EncryptedKey = "__encrypted__"
+
+// KeyManager issues and decrypts the data keys values are encrypted with,
+// such as by wrapping a KMS client, so the key encrypting them never
+// leaves it.
+type KeyManager interface {
+	// GenerateDataKey returns a new 256-bit key, in plaintext and as
+	// encrypted by the key manager.
+	GenerateDataKey() (plaintext, encrypted []byte, err error)
+
+	// DecryptDataKey returns the plaintext of a data key it encrypted.
+	DecryptDataKey(encrypted []byte) ([]byte, error)
+}
+
+// encrypted is the object values are encrypted into, with its fields
+// base64 encoded in JSON.
+type encrypted struct {
+	Key        []byte `json:"key"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt returns a ValueTransformer replacing values matching any of the
+// patterns, as described by Redact, with objects keyed by EncryptedKey
+// holding their JSON encrypted with AES-GCM under a data key from keys.
+// Each value gets its own data key, and is bound to its dot-separated path
+// as additional data, so it can't be moved to another attribute. The
+// structure and positions of the configuration are left in the clear, but
+// canonical expressions of encrypted values are left out of the line info.
+// Decrypt reverses it.
+func Encrypt(keys KeyManager, patterns ...string) ValueTransformer {
+	return hidingTransformer{
+		name:     "encrypt " + strings.Join(patterns, ","),
+		patterns: splitPatterns(patterns),
+		replace: func(path []string, value interface{}) (interface{}, error) {
+			return encryptValue(keys, path, value)
+		},
+	}
+}
+
+func encryptValue(keys KeyManager, path []string, value interface{}) (interface{}, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal value: %w", err)
+	}
+	key, encryptedKey, err := keys.GenerateDataKey()
+	if err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return jsonObj{EncryptedKey: map[string]interface{}{
+		"key":        base64.StdEncoding.EncodeToString(encryptedKey),
+		"nonce":      base64.StdEncoding.EncodeToString(nonce),
+		"ciphertext": base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(strings.Join(path, ".")))),
+	}}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Decrypt returns converted JSON with the values encrypted by Encrypt
+// decrypted using keys. The path of each value is taken from where it is in
+// the JSON, skipping list indices, which for OutputSchemaV1 is the path it
+// was encrypted at; values moved elsewhere fail to decrypt.
+func Decrypt(keys KeyManager, jsonBytes []byte) ([]byte, error) {
+	var value interface{}
+	if err := decodeJSON(jsonBytes, &value); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+	value, err := decryptValues(keys, nil, value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+func decryptValues(keys KeyManager, path []string, value interface{}) (interface{}, error) {
+	var err error
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if fields, ok := v[EncryptedKey]; ok && len(v) == 1 {
+			return decryptValue(keys, path, fields)
+		}
+		for key, elem := range v {
+			if v[key], err = decryptValues(keys, append(path[:len(path):len(path)], key), elem); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if v[i], err = decryptValues(keys, path, elem); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+func decryptValue(keys KeyManager, path []string, fields interface{}) (interface{}, error) {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var e encrypted
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("parse %s object: %w", EncryptedKey, err)
+	}
+	key, err := keys.DecryptDataKey(e.Key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(e.Nonce))
+	}
+	plaintext, err := aead.Open(nil, e.Nonce, e.Ciphertext, []byte(strings.Join(path, ".")))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", strings.Join(path, "."), err)
+	}
+	var value interface{}
+	if err := decodeJSON(plaintext, &value); err != nil {
+		return nil, fmt.Errorf("parse decrypted value: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,106 @@
+package convert
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// xorKeys stands in for a KMS, "encrypting" data keys by XOR with a fixed
+// master key.
+type xorKeys struct{ master []byte }
+
+func (k xorKeys) xor(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ k.master[i%len(k.master)]
+	}
+	return out
+}
+
+func (k xorKeys) GenerateDataKey() ([]byte, []byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	return key, k.xor(key), nil
+}
+
+func (k xorKeys) DecryptDataKey(encrypted []byte) ([]byte, error) {
+	if len(encrypted) != 32 {
+		return nil, errors.New("bad key")
+	}
+	return k.xor(encrypted), nil
+}
+
+func TestEncrypt(t *testing.T) {
+	keys := xorKeys{master: []byte("master key")}
+	input := []byte(`
+name = "db"
+resource "aws_db_instance" "main" {
+	password = "hunter2"
+	settings = { token = 42 }
+}
+`)
+
+	result, err := Convert(input, "main.tf", Options{Transformers: []ValueTransformer{Encrypt(keys, "password", "settings.token")}})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if bytes.Contains(result.JSON, []byte("hunter2")) || bytes.Count(result.JSON, []byte(EncryptedKey)) != 2 {
+		t.Fatalf("expected password and token to be encrypted, got %s", result.JSON)
+	}
+	if !bytes.Contains(result.Lines, []byte(`"password":{`)) {
+		t.Errorf("expected line info to be kept, got %s", result.Lines)
+	}
+
+	decrypted, err := Decrypt(keys, result.JSON)
+	if err != nil {
+		t.Fatal("decrypt:", err)
+	}
+	sameJSON(t, decrypted, []byte(`{
+	"name": "db",
+	"resource": [{"aws_db_instance": {"main": {"password": "hunter2", "settings": {"token": 42}}}}]
+}`))
+
+	if _, err := Decrypt(xorKeys{master: []byte("wrong")}, result.JSON); err == nil {
+		t.Error("expected decrypting with the wrong key to fail")
+	}
+
+	// A value moved to another attribute doesn't decrypt.
+	var moved map[string]interface{}
+	if err := decodeJSON(result.JSON, &moved); err != nil {
+		t.Fatal(err)
+	}
+	main := moved["resource"].([]interface{})[0].(map[string]interface{})["aws_db_instance"].(map[string]interface{})["main"].(map[string]interface{})
+	main["username"] = main["password"]
+	delete(main, "password")
+	movedJSON, err := json.Marshal(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(keys, movedJSON); err == nil {
+		t.Error("expected decrypting a moved value to fail")
+	}
+}
+
+func TestEncryptCanonicalExpressions(t *testing.T) {
+	keys := xorKeys{master: []byte("master key")}
+	input := []byte(`password = "hunter2"
+settings = { token = "s3cr3t" }
+`)
+	result, err := Convert(input, "main.tf", Options{
+		Transformers:         []ValueTransformer{Encrypt(keys, "password", "settings.token")},
+		CanonicalExpressions: true,
+	})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	for _, secret := range []string{"hunter2", "s3cr3t"} {
+		if bytes.Contains(result.Lines, []byte(secret)) {
+			t.Errorf("line info contains %q: %s", secret, result.Lines)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Environment describes a Terraform workspace and the variable values
+// supplied for it, such as those from its tfvars file.
+type Environment struct {
+	Workspace string
+	Variables map[string]cty.Value
+}
+
+// EnvironmentView converts a Terraform file as it would be seen in the
+// given environment. References to terraform.workspace and to variables,
+// which fall back to their declared defaults, are resolved and expressions
+// using them simplified, so views of several environments can be compared
+// side by side.
+func EnvironmentView(bytes []byte, filename string, env Environment, options Options) ([]byte, []byte, error) {
+	file, diags := hclsyntax.ParseConfig(bytes, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("parse config: %v", diags.Errs())
+	}
+
+	vars := variableDefaults(file.Body.(*hclsyntax.Body))
+	for name, value := range env.Variables {
+		vars[name] = value
+	}
+
+	workspace := env.Workspace
+	if workspace == "" {
+		workspace = "default"
+	}
+
+	variables := make(map[string]cty.Value)
+	for name, value := range options.Variables {
+		variables[name] = value
+	}
+	variables["var"] = cty.ObjectVal(vars)
+	variables["terraform"] = cty.ObjectVal(map[string]cty.Value{
+		"workspace": cty.StringVal(workspace),
+	})
+
+	options.Simplify = true
+	options.Variables = variables
+	return File(file, options)
+}
+
+// variableDefaults returns the defaults of the variables declared in body
+// which can be evaluated without any context.
+func variableDefaults(body *hclsyntax.Body) map[string]cty.Value {
+	defaults := make(map[string]cty.Value)
+	for _, block := range body.Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		attr, ok := block.Body.Attributes["default"]
+		if !ok {
+			continue
+		}
+		if value, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+			defaults[block.Labels[0]] = value
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,66 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEnvironmentView(t *testing.T) {
+	input := `
+variable "instance_count" {
+	default = 1
+}
+
+variable "instance_type" {}
+
+resource "aws_instance" "web" {
+	count         = var.instance_count
+	instance_type = var.instance_type
+	tags = {
+		Name = "web-${terraform.workspace}"
+	}
+	subnet_id = aws_subnet.main.id
+}`
+
+	env := Environment{
+		Workspace: "prod",
+		Variables: map[string]cty.Value{
+			"instance_type": cty.StringVal("m5.large"),
+		},
+	}
+
+	expected := `{
+	"resource": [
+		{
+			"aws_instance": {
+				"web": {
+					"count": 1,
+					"instance_type": "m5.large",
+					"subnet_id": "${aws_subnet.main.id}",
+					"tags": {
+						"Name": "web-prod"
+					}
+				}
+			}
+		}
+	],
+	"variable": [
+		{
+			"instance_count": {
+				"default": 1
+			}
+		},
+		{
+			"instance_type": {}
+		}
+	]
+}`
+
+	convertedBytes, _, err := EnvironmentView([]byte(input), "", env, Options{})
+	if err != nil {
+		t.Fatal("environment view:", err)
+	}
+
+	compareTest(t, convertedBytes, expected)
+}
@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+func TestAllowErrors(t *testing.T) {
+	input := []byte(`
+a = 1
+b =
+resource "aws_instance" "web" {
+	ami = "ami-123"
+`)
+
+	if _, err := Convert(input, "main.tf", Options{}); err == nil {
+		t.Error("syntax errors should fail conversion by default")
+	}
+
+	result, err := Convert(input, "main.tf", Options{AllowErrors: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	compareTest(t, result.JSON, `{
+	"a": 1,
+	"b": null,
+	"resource": [
+		{
+			"aws_instance": {
+				"web": {
+					"ami": "ami-123"
+				}
+			}
+		}
+	]
+}`)
+
+	errors := 0
+	for _, diag := range result.Diagnostics {
+		if diag.Severity == hcl.DiagError {
+			errors++
+		}
+	}
+	if errors == 0 {
+		t.Error("expected the syntax errors in the diagnostics")
+	}
+}
+
+func TestRecoveryFixes(t *testing.T) {
+	input := []byte(`name = "web",
+tags = { a = 1, b = 2, }
+resource "aws_instance" "web" {
+	ami = "ami-123",
+	count = max(1,
+`)
+
+	result, err := Convert(input, "main.tf", Options{AllowErrors: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"name": "web",
+	"tags": {"a": 1, "b": 2},
+	"resource": [{"aws_instance": {"web": {"ami": "ami-123", "count": "${max(1,\n)}"}}}]
+}`))
+
+	type fix struct {
+		line, column int
+		text         string
+	}
+	var got []fix
+	for _, f := range result.Fixes {
+		got = append(got, fix{f.Range.Start.Line, f.Range.Start.Column, f.Text})
+	}
+	expected := []fix{
+		{1, 13, ""},
+		{4, 17, ""},
+		{6, 1, ")\n}\n"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected fixes %v, got %v", expected, got)
+	}
+
+	if _, err := Convert(input, "main.tf", Options{}); err == nil {
+		t.Error("fixes should only be applied with AllowErrors")
+	}
+}
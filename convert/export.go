@@ -0,0 +1,59 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PolicyInput is the input document for policy engines such as Open Policy
+// Agent, holding a converted file with its line info, so policies can
+// report where a violation is, and the provenance of the conversion.
+// Config and Lines are as written in Result.JSON and Result.Lines.
+type PolicyInput struct {
+	File     string          `json:"file"`
+	Config   json.RawMessage `json:"config"`
+	Lines    json.RawMessage `json:"lines"`
+	Metadata Provenance      `json:"metadata"`
+}
+
+// PolicyEvaluator evaluates policies over an input document, such as a
+// prepared Rego query from the OPA SDK wrapped to pass the input with
+// rego.EvalInput.
+type PolicyEvaluator interface {
+	Eval(ctx context.Context, input interface{}) (interface{}, error)
+}
+
+// ExportPolicyInput converts a file into the input document for policy
+// engines.
+func ExportPolicyInput(src []byte, filename string, options Options) (*PolicyInput, error) {
+	result, err := Convert(src, filename, options)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyInput{
+		File:     filename,
+		Config:   result.JSON,
+		Lines:    result.Lines,
+		Metadata: sourceProvenance(src, filename, options),
+	}, nil
+}
+
+// EvaluatePolicy converts a file into the input document for policy engines
+// and evaluates it with evaluator, returning its result. The input is given
+// to evaluator decoded, as a map[string]interface{}.
+func EvaluatePolicy(ctx context.Context, evaluator PolicyEvaluator, src []byte, filename string, options Options) (interface{}, error) {
+	input, err := ExportPolicyInput(src, filename, options)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := decodedCopy(input, &decoded); err != nil {
+		return nil, err
+	}
+	result, err := evaluator.Eval(ctx, decoded)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate policy: %w", err)
+	}
+	return result, nil
+}
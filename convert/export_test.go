@@ -0,0 +1,68 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExportPolicyInput(t *testing.T) {
+	clock := func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	input, err := ExportPolicyInput([]byte("region = \"us-east-1\"\n"), "main.tf", Options{Clock: clock})
+	if err != nil {
+		t.Fatal("export:", err)
+	}
+	got, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, got, []byte(`{
+		"file": "main.tf",
+		"config": {"region": "us-east-1"},
+		"lines": {
+			"line": 1, "startIndex": 1, "endLine": 2, "endIndex": 1, "type": "block",
+			"region": {"line": 1, "startIndex": 10, "endLine": 1, "endIndex": 21, "__key__line": 1, "__key__startIndex": 1, "__key__endIndex": 7}
+		},
+		"metadata": {
+			"tool": "hclparser",
+			"version": "dev",
+			"fingerprint": "`+Options{Clock: clock}.Fingerprint()+`",
+			"file": "main.tf",
+			"sha256": "2bca0a48c7f4ac2fd39a74581c611958f7720fe9ef42545dd880dafc6ebf518e",
+			"converted_at": "2024-01-02T03:04:05Z"
+		}
+	}`))
+}
+
+type evaluatorFunc func(ctx context.Context, input interface{}) (interface{}, error)
+
+func (f evaluatorFunc) Eval(ctx context.Context, input interface{}) (interface{}, error) {
+	return f(ctx, input)
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	deny := evaluatorFunc(func(ctx context.Context, input interface{}) (interface{}, error) {
+		doc := input.(map[string]interface{})
+		config := doc["config"].(map[string]interface{})
+		if config["region"] != "us-east-1" {
+			return []interface{}{"region must be us-east-1"}, nil
+		}
+		return []interface{}{}, nil
+	})
+	result, err := EvaluatePolicy(context.Background(), deny, []byte(`region = "eu-west-1"`), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("evaluate:", err)
+	}
+	if denied, _ := result.([]interface{}); len(denied) != 1 {
+		t.Errorf("expected the region to be denied, got %v", result)
+	}
+
+	failing := evaluatorFunc(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.New("undefined rule")
+	})
+	if _, err := EvaluatePolicy(context.Background(), failing, []byte(`a = 1`), "main.tf", Options{}); err == nil {
+		t.Error("expected the evaluator's error")
+	}
+}
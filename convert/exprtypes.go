@@ -0,0 +1,122 @@
+package convert
+
+import (
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// ExpressionType is the type inferred for the expression of an attribute.
+type ExpressionType struct {
+	// Attribute is the dot-separated path of the attribute.
+	Attribute string `json:"attribute"`
+
+	// Type is the type of the expression's value, which is
+	// cty.DynamicPseudoType when it can't be inferred, such as for a
+	// reference to a variable that isn't given.
+	Type cty.Type `json:"type"`
+
+	// Constraint is the type written as a type constraint, such as
+	// list(string), with tuples of a single element type generalized to
+	// lists.
+	Constraint string `json:"constraint"`
+
+	// Evaluated is whether the expression evaluated to a known value, so
+	// its type is exact rather than inferred from operations on unknown
+	// values.
+	Evaluated bool `json:"evaluated"`
+
+	Range hcl.Range `json:"range"`
+}
+
+// ExpressionTypes returns the type of the expression of every attribute of a
+// file, including those in nested blocks, in source order. Types are
+// inferred by evaluating expressions with their variables as unknown values
+// of any type, except for the variables of options when Simplify is set.
+// Calls to the functions the dialect allows give their return type.
+func ExpressionTypes(file *hcl.File, options Options) ([]ExpressionType, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := converter{bytes: file.Bytes, options: options}
+	var types []ExpressionType
+	for _, attr := range attributes(body) {
+		typ, evaluated := c.expressionType(attr.attr.Expr)
+		types = append(types, ExpressionType{
+			Attribute:  attr.pathString(),
+			Type:       typ,
+			Constraint: typeexpr.TypeString(constraintType(typ)),
+			Evaluated:  evaluated,
+			Range:      attr.attr.Expr.Range(),
+		})
+	}
+	return types, nil
+}
+
+// expressionType returns the type of an expression, and whether it
+// evaluated to a known value.
+func (c *converter) expressionType(expr hcl.Expression) (cty.Type, bool) {
+	ctx := c.evalContext()
+	if c.options.Simplify {
+		if typ, known, ok := inferType(expr, ctx.Variables, ctx.Functions); ok {
+			return typ, known
+		}
+	}
+	// A traversal into the given variables may fail, such as for an
+	// attribute they don't have, so it's retried with none.
+	typ, known, ok := inferType(expr, nil, ctx.Functions)
+	if !ok {
+		return cty.DynamicPseudoType, false
+	}
+	return typ, known
+}
+
+// inferType evaluates expr with the given variables and functions, reporting
+// whether it could. Variables that aren't given are unknown, so operations on
+// them give unknown values of the type they result in.
+func inferType(expr hcl.Expression, given map[string]cty.Value, functions map[string]function.Function) (cty.Type, bool, bool) {
+	variables := make(map[string]cty.Value, len(given))
+	for name, value := range given {
+		variables[name] = value
+	}
+	for _, traversal := range expr.Variables() {
+		if _, ok := variables[traversal.RootName()]; !ok {
+			variables[traversal.RootName()] = cty.DynamicVal
+		}
+	}
+	value, diags := expr.Value(&hcl.EvalContext{Variables: variables, Functions: functions})
+	if diags.HasErrors() {
+		return cty.NilType, false, false
+	}
+	return value.Type(), value.IsWhollyKnown(), true
+}
+
+// constraintType generalizes a type for use as a type constraint, turning
+// tuples whose elements are all of one type into lists of that type.
+func constraintType(typ cty.Type) cty.Type {
+	switch {
+	case typ.IsTupleType():
+		elems := typ.TupleElementTypes()
+		if len(elems) == 0 {
+			return cty.List(cty.DynamicPseudoType)
+		}
+		elem := constraintType(elems[0])
+		for _, other := range elems[1:] {
+			if !constraintType(other).Equals(elem) {
+				return typ
+			}
+		}
+		return cty.List(elem)
+	case typ.IsObjectType():
+		attrs := make(map[string]cty.Type, len(typ.AttributeTypes()))
+		for name, attr := range typ.AttributeTypes() {
+			attrs[name] = constraintType(attr)
+		}
+		return cty.Object(attrs)
+	default:
+		return typ
+	}
+}
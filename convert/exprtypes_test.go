@@ -0,0 +1,76 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestExpressionTypes(t *testing.T) {
+	src := []byte(`name    = "web-${var.env}"
+port    = var.port + 1
+enabled = var.enabled
+zones   = ["a", "b"]
+mixed   = ["a", 1, true]
+lengths = length(var.zones)
+region  = var.region
+
+tags {
+  prod = var.env == "prod"
+}
+`)
+	options := Options{
+		Dialect:    DialectTerraform,
+		Simplify:   true,
+		Variables:  map[string]cty.Value{"var": cty.ObjectVal(map[string]cty.Value{"region": cty.StringVal("eu-west-1")})},
+		InferTypes: true,
+	}
+	result, err := Convert(src, "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	expected := []struct {
+		attribute  string
+		constraint string
+		evaluated  bool
+	}{
+		{"name", "string", false},
+		{"port", "number", false},
+		{"enabled", "any", false},
+		{"zones", "list(string)", true},
+		{"mixed", "tuple([string,number,bool])", true},
+		{"lengths", "number", false},
+		{"region", "string", true},
+		{"tags.prod", "bool", false},
+	}
+	if len(result.Types) != len(expected) {
+		t.Fatalf("expected %d types, got %d: %v", len(expected), len(result.Types), result.Types)
+	}
+	for i, e := range expected {
+		got := result.Types[i]
+		if got.Attribute != e.attribute || got.Constraint != e.constraint || got.Evaluated != e.evaluated {
+			t.Errorf("%d: expected %s %s (evaluated %t), got %s %s (evaluated %t)",
+				i, e.attribute, e.constraint, e.evaluated, got.Attribute, got.Constraint, got.Evaluated)
+		}
+	}
+
+	// Without simplifying, the variables given aren't used.
+	options.Simplify = false
+	file, diags := parseConfig(src, "main.tf", options)
+	if diags.HasErrors() {
+		t.Fatal("parse:", diags)
+	}
+	types, err := ExpressionTypes(file, options)
+	if err != nil {
+		t.Fatal("infer:", err)
+	}
+	if region := types[6]; region.Constraint != "any" || region.Evaluated {
+		t.Errorf("expected region of any type, got %s (evaluated %t)", region.Constraint, region.Evaluated)
+	}
+
+	options.InferTypes = false
+	if result, err := Convert(src, "main.tf", options); err != nil || result.Types != nil {
+		t.Errorf("expected no types without InferTypes, got %v, %v", result.Types, err)
+	}
+}
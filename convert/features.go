@@ -0,0 +1,170 @@
+package convert
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Kinds of feature counted by FeatureUsage.
+const (
+	FeatureBlock     = "block"
+	FeatureAttribute = "attribute"
+	FeatureFunction  = "function"
+)
+
+// FeatureReport holds how often block types, attributes and functions are
+// used across a set of repositories.
+type FeatureReport struct {
+	Repos int           `json:"repos"`
+	Files int           `json:"files"`
+	Stats []FeatureStat `json:"stats"`
+	// Errors holds the error for each file which couldn't be parsed,
+	// keyed by its path.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// FeatureStat counts the uses of a single feature. Blocks are named by their
+// type, qualified by their first label for resource, data and provider
+// blocks, such as resource.aws_instance. Attributes are named by the block
+// they're in followed by their own name, such as resource.aws_instance.ami.
+type FeatureStat struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Files int    `json:"files"`
+	Repos int    `json:"repos"`
+}
+
+// FeatureUsage counts the features used by every HCL and Terraform file under
+// each of the repos, to show which must be supported by tools consuming
+// them. Stats are ordered by kind and then by how often they're used.
+func FeatureUsage(repos []string) (*FeatureReport, error) {
+	type counter struct {
+		stat  FeatureStat
+		files map[string]bool
+		repos map[string]bool
+	}
+	counters := make(map[[2]string]*counter)
+	report := &FeatureReport{Repos: len(repos)}
+
+	for _, repo := range repos {
+		files, err := sourceFiles(repo)
+		if err != nil {
+			return nil, fmt.Errorf("list source files: %w", err)
+		}
+		for _, rel := range files {
+			path := filepath.Join(repo, rel)
+			bytes, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read file: %w", err)
+			}
+			report.Files++
+
+			file, diags := hclsyntax.ParseConfig(bytes, path, hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				if report.Errors == nil {
+					report.Errors = make(map[string]string)
+				}
+				report.Errors[path] = diags.Error()
+				continue
+			}
+
+			featureBody(file.Body.(*hclsyntax.Body), "", func(kind, name string) {
+				key := [2]string{kind, name}
+				c := counters[key]
+				if c == nil {
+					c = &counter{
+						stat:  FeatureStat{Kind: kind, Name: name},
+						files: make(map[string]bool),
+						repos: make(map[string]bool),
+					}
+					counters[key] = c
+				}
+				c.stat.Count++
+				c.files[path] = true
+				c.repos[repo] = true
+			})
+		}
+	}
+
+	report.Stats = make([]FeatureStat, 0, len(counters))
+	for _, c := range counters {
+		c.stat.Files = len(c.files)
+		c.stat.Repos = len(c.repos)
+		report.Stats = append(report.Stats, c.stat)
+	}
+	sort.Slice(report.Stats, func(i, j int) bool {
+		a, b := report.Stats[i], report.Stats[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// featureBody reports the features used in body, whose block is named by
+// prefix.
+func featureBody(body *hclsyntax.Body, prefix string, use func(kind, name string)) {
+	qualify := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	for _, attr := range body.Attributes {
+		use(FeatureAttribute, qualify(attr.Name))
+		hclsyntax.VisitAll(attr.Expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+				use(FeatureFunction, call.Name)
+			}
+			return nil
+		})
+	}
+	for _, block := range body.Blocks {
+		name := block.Type
+		if prefix == "" && len(block.Labels) > 0 {
+			switch block.Type {
+			case "resource", "data", "provider":
+				name += "." + block.Labels[0]
+			}
+		}
+		name = qualify(name)
+		use(FeatureBlock, name)
+		featureBody(block.Body, name, use)
+	}
+}
+
+// WriteCSV writes the report's stats as CSV with a header row.
+func (r *FeatureReport) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"kind", "name", "count", "files", "repos"}); err != nil {
+		return err
+	}
+	for _, stat := range r.Stats {
+		record := []string{
+			stat.Kind,
+			stat.Name,
+			strconv.Itoa(stat.Count),
+			strconv.Itoa(stat.Files),
+			strconv.Itoa(stat.Repos),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFeatureUsage(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(first, "main.tf"), `
+resource "aws_instance" "web" {
+	ami  = lower(var.ami)
+	tags = merge(var.tags, { Name = upper("web") })
+}
+resource "aws_instance" "db" {
+	ami = "ami-123"
+}`)
+	writeFile(t, filepath.Join(second, "main.tf"), `
+resource "aws_instance" "app" {
+	ami = lower("AMI")
+	lifecycle {
+		create_before_destroy = true
+	}
+}`)
+	writeFile(t, filepath.Join(second, "broken.tf"), `x = `)
+
+	report, err := FeatureUsage([]string{first, second})
+	if err != nil {
+		t.Fatal("feature usage:", err)
+	}
+	if report.Repos != 2 || report.Files != 3 {
+		t.Errorf("expected 2 repos and 3 files, got %d and %d", report.Repos, report.Files)
+	}
+	if len(report.Errors) != 1 || report.Errors[filepath.Join(second, "broken.tf")] == "" {
+		t.Errorf("expected an error for broken.tf, got %v", report.Errors)
+	}
+
+	expected := []FeatureStat{
+		{Kind: FeatureAttribute, Name: "resource.aws_instance.ami", Count: 3, Files: 2, Repos: 2},
+		{Kind: FeatureAttribute, Name: "resource.aws_instance.lifecycle.create_before_destroy", Count: 1, Files: 1, Repos: 1},
+		{Kind: FeatureAttribute, Name: "resource.aws_instance.tags", Count: 1, Files: 1, Repos: 1},
+		{Kind: FeatureBlock, Name: "resource.aws_instance", Count: 3, Files: 2, Repos: 2},
+		{Kind: FeatureBlock, Name: "resource.aws_instance.lifecycle", Count: 1, Files: 1, Repos: 1},
+		{Kind: FeatureFunction, Name: "lower", Count: 2, Files: 2, Repos: 2},
+		{Kind: FeatureFunction, Name: "merge", Count: 1, Files: 1, Repos: 1},
+		{Kind: FeatureFunction, Name: "upper", Count: 1, Files: 1, Repos: 1},
+	}
+	if !reflect.DeepEqual(report.Stats, expected) {
+		t.Errorf("expected %+v, got %+v", expected, report.Stats)
+	}
+
+	var csv bytes.Buffer
+	if err := (&FeatureReport{Stats: expected[:1]}).WriteCSV(&csv); err != nil {
+		t.Fatal("write csv:", err)
+	}
+	want := "kind,name,count,files,repos\nattribute,resource.aws_instance.ami,3,2,2\n"
+	if csv.String() != want {
+		t.Errorf("expected csv %q, got %q", want, csv.String())
+	}
+}
@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"runtime"
+	"sync"
+)
+
+// FileResult is the outcome of converting one of the files given to Files,
+// holding the error it failed with or its result.
+type FileResult struct {
+	*Result
+	Err error
+}
+
+// Files converts many files at once, keyed by their names, using up to
+// Options.Workers goroutines. A file failing to convert, including when it
+// panics or takes longer than Options.FileTimeout, doesn't stop the rest.
+func Files(files map[string][]byte, options Options) map[string]FileResult {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	names := make(chan string)
+	results := make(map[string]FileResult, len(files))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				result, err := convertIsolated(files[name], name, options)
+				mu.Lock()
+				results[name] = FileResult{Result: result, Err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+	for name := range files {
+		names <- name
+	}
+	close(names)
+	wg.Wait()
+	return results
+}
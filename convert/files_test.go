@@ -0,0 +1,34 @@
+package convert
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	files := make(map[string][]byte)
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("file%d.hcl", i)] = []byte(fmt.Sprintf("x = %d", i))
+	}
+	files["broken.hcl"] = []byte("x = ")
+
+	results := Files(files, Options{Workers: 3})
+	if len(results) != len(files) {
+		t.Fatalf("expected %d results, got %d", len(files), len(results))
+	}
+	for i := 0; i < 20; i++ {
+		result := results[fmt.Sprintf("file%d.hcl", i)]
+		if result.Err != nil {
+			t.Fatalf("file%d.hcl: %v", i, result.Err)
+		}
+		if expected := fmt.Sprintf(`{"x":%d}`, i); string(result.JSON) != expected {
+			t.Errorf("expected %s, got %s", expected, result.JSON)
+		}
+	}
+	if results["broken.hcl"].Err == nil {
+		t.Error("expected broken.hcl to fail")
+	}
+	if len(Files(nil, Options{})) != 0 {
+		t.Error("expected no results for no files")
+	}
+}
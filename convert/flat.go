@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LineFormat selects the structure of the line info.
+type LineFormat string
+
+const (
+	// LineFormatNested writes line info mirroring the structure of the
+	// JSON output.
+	LineFormatNested LineFormat = ""
+
+	// LineFormatFlat writes line info as an object keyed by the JSON
+	// pointer of each value in the output, such as
+	// /resource/0/aws_instance/web/ami, as written by FlattenLines.
+	LineFormatFlat LineFormat = "flat"
+)
+
+// FlatLine is the position of a value in the flat line info. Columns are
+// 1-based and byte offsets 0-based, with the ends exclusive. Key, when
+// set, is the position of the attribute or block name the value is
+// assigned to, and Labels the position of each label of a block. Origin is
+// set with Options.AnnotateOrigins.
+type FlatLine struct {
+	File      string     `json:"file,omitempty"`
+	Line      int        `json:"line"`
+	EndLine   int        `json:"endLine"`
+	StartCol  int        `json:"startCol"`
+	EndCol    int        `json:"endCol"`
+	StartByte *int       `json:"startByte,omitempty"`
+	EndByte   *int       `json:"endByte,omitempty"`
+	Origin    Origin     `json:"origin,omitempty"`
+	Key       *FlatLine  `json:"key,omitempty"`
+	Labels    []FlatLine `json:"labels,omitempty"`
+}
+
+// FlattenLines rewrites nested line info, as produced by Bytes or File, as
+// a flat index keyed by JSON pointer, as used when Options.LineFormat is
+// LineFormatFlat, so a value's position can be looked up directly. Byte
+// offsets are included when the line info has them.
+func FlattenLines(lines []byte) ([]byte, error) {
+	return flattenLines(lines, "")
+}
+
+// flattenLines is FlattenLines for line info whose keys have prefix, from
+// Options.LineKeyPrefix.
+func flattenLines(lines []byte, prefix string) ([]byte, error) {
+	var root map[string]interface{}
+	if err := decodeJSON(lines, &root); err != nil {
+		return nil, fmt.Errorf("decode line info: %w", err)
+	}
+	flat := make(map[string]FlatLine)
+	walkLines("", root, prefix, func(pointer string, line map[string]interface{}) {
+		if _, ok := line[prefix+"line"]; ok {
+			flat[pointer] = flatLine(line, prefix)
+		}
+	})
+	return json.Marshal(flat)
+}
+
+// flatLine returns the flat line info of an entry of nested line info with
+// a position.
+func flatLine(line map[string]interface{}, prefix string) FlatLine {
+	entry := FlatLine{
+		Line:      flatInt(line[prefix+"line"]),
+		EndLine:   flatInt(line[prefix+"endLine"]),
+		StartCol:  flatInt(line[prefix+"startIndex"]),
+		EndCol:    flatInt(line[prefix+"endIndex"]),
+		StartByte: flatOffset(line[prefix+"startByte"]),
+		EndByte:   flatOffset(line[prefix+"endByte"]),
+	}
+	entry.File, _ = line[prefix+"file"].(string)
+	if origin, ok := line[prefix+"origin"].(string); ok {
+		entry.Origin = Origin(origin)
+	}
+	if _, ok := line[prefix+"__key__line"]; ok {
+		entry.Key = &FlatLine{
+			Line:      flatInt(line[prefix+"__key__line"]),
+			EndLine:   flatInt(line[prefix+"__key__line"]),
+			StartCol:  flatInt(line[prefix+"__key__startIndex"]),
+			EndCol:    flatInt(line[prefix+"__key__endIndex"]),
+			StartByte: flatOffset(line[prefix+"__key__startByte"]),
+			EndByte:   flatOffset(line[prefix+"__key__endByte"]),
+		}
+	}
+	if ranges, ok := line[prefix+"labelRanges"].([]interface{}); ok {
+		for _, rng := range ranges {
+			label, _ := rng.(map[string]interface{})
+			entry.Labels = append(entry.Labels, FlatLine{
+				Line:      flatInt(label[prefix+"line"]),
+				EndLine:   flatInt(label[prefix+"endLine"]),
+				StartCol:  flatInt(label[prefix+"startIndex"]),
+				EndCol:    flatInt(label[prefix+"endIndex"]),
+				StartByte: flatOffset(label[prefix+"startByte"]),
+				EndByte:   flatOffset(label[prefix+"endByte"]),
+			})
+		}
+	}
+	return entry
+}
+
+// walkLines calls fn with each entry of nested line info, including those
+// only grouping the entries of block labels, and the JSON pointer of the
+// value it describes in the output. The line info may be decoded or as
+// built by the converter, with keys having prefix.
+func walkLines(pointer string, line map[string]interface{}, prefix string, fn func(pointer string, line map[string]interface{})) {
+	fn(pointer, line)
+	if elems, ok := line[prefix+"lines"]; ok {
+		walkLineList(pointer, elems, prefix, fn)
+	}
+	for key, value := range line {
+		if key == prefix+"arguments" || key == prefix+"labelRanges" || key == prefix+"lines" {
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			walkLines(pointer+"/"+pointerToken(key), v, prefix, fn)
+		case jsonObj:
+			walkLines(pointer+"/"+pointerToken(key), v, prefix, fn)
+		default:
+			walkLineList(pointer+"/"+pointerToken(key), v, prefix, fn)
+		}
+	}
+}
+
+func walkLineList(pointer string, elems interface{}, prefix string, fn func(pointer string, line map[string]interface{})) {
+	switch elems := elems.(type) {
+	case []lineObj:
+		for i, line := range elems {
+			walkLines(pointer+"/"+strconv.Itoa(i), line, prefix, fn)
+		}
+	case []interface{}:
+		for i, elem := range elems {
+			switch line := elem.(type) {
+			case map[string]interface{}:
+				walkLines(pointer+"/"+strconv.Itoa(i), line, prefix, fn)
+			case jsonObj:
+				walkLines(pointer+"/"+strconv.Itoa(i), line, prefix, fn)
+			}
+		}
+	}
+}
+
+// pointerToken escapes a key for use in a JSON pointer.
+func pointerToken(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func flatInt(value interface{}) int {
+	n, _ := lineNumber(value)
+	return n
+}
+
+func flatOffset(value interface{}) *int {
+	n, ok := lineNumber(value)
+	if !ok {
+		return nil
+	}
+	return &n
+}
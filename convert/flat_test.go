@@ -0,0 +1,46 @@
+package convert
+
+import (
+	"testing"
+)
+
+func TestFlatLines(t *testing.T) {
+	input := []byte(`resource "aws_instance" "web" {
+  ami = "x"
+  zones = ["a", "b/c"]
+}
+`)
+
+	result, err := Convert(input, "main.tf", Options{LineFormat: LineFormatFlat})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.Lines, []byte(`{
+	"": {"line": 1, "endLine": 5, "startCol": 1, "endCol": 1, "startByte": 0, "endByte": 69},
+	"/resource/0/aws_instance/web": {
+		"line": 1, "endLine": 4, "startCol": 31, "endCol": 2, "startByte": 30, "endByte": 68,
+		"key": {"line": 1, "endLine": 1, "startCol": 1, "endCol": 30, "startByte": 0, "endByte": 29},
+		"labels": [
+			{"line": 1, "endLine": 1, "startCol": 10, "endCol": 24, "startByte": 9, "endByte": 23},
+			{"line": 1, "endLine": 1, "startCol": 25, "endCol": 30, "startByte": 24, "endByte": 29}
+		]
+	},
+	"/resource/0/aws_instance/web/ami": {
+		"line": 2, "endLine": 2, "startCol": 9, "endCol": 12, "startByte": 40, "endByte": 43,
+		"key": {"line": 2, "endLine": 2, "startCol": 3, "endCol": 6, "startByte": 34, "endByte": 37}
+	},
+	"/resource/0/aws_instance/web/zones": {
+		"line": 3, "endLine": 3, "startCol": 11, "endCol": 23, "startByte": 54, "endByte": 66,
+		"key": {"line": 3, "endLine": 3, "startCol": 3, "endCol": 8, "startByte": 46, "endByte": 51}
+	},
+	"/resource/0/aws_instance/web/zones/0": {"line": 3, "endLine": 3, "startCol": 12, "endCol": 15, "startByte": 55, "endByte": 58},
+	"/resource/0/aws_instance/web/zones/1": {"line": 3, "endLine": 3, "startCol": 17, "endCol": 22, "startByte": 60, "endByte": 65}
+}`))
+
+	if _, err := Convert(input, "main.tf", Options{LineFormat: LineFormatFlat, CompactLines: true}); err == nil {
+		t.Error("expected flat compact line info to be rejected")
+	}
+	if pointerToken("a/b~c") != "a~1b~0c" {
+		t.Errorf("unexpected escaping %q", pointerToken("a/b~c"))
+	}
+}
@@ -0,0 +1,45 @@
+package convert
+
+import "github.com/hashicorp/hcl/v2/hclsyntax"
+
+// ForKey is the key of the object for expressions are written as when
+// Options.StructuredFor is set and they can't be evaluated:
+//
+//	{"__for__": {"type": "tuple", "value_var": "s", "collection": "${var.list}", "value": "${upper(s)}"}}
+const ForKey = "__for__"
+
+// convertFor returns the structured form of a for expression, with its
+// expressions converted like attribute values.
+func (c *converter) convertFor(expr *hclsyntax.ForExpr) (interface{}, error) {
+	kind := "tuple"
+	if expr.KeyExpr != nil {
+		kind = "object"
+	}
+	fields := map[string]interface{}{
+		"type":      kind,
+		"value_var": expr.ValVar,
+	}
+	if expr.KeyVar != "" {
+		fields["key_var"] = expr.KeyVar
+	}
+	if expr.Group {
+		fields["group"] = true
+	}
+
+	for name, e := range map[string]hclsyntax.Expression{
+		"collection": expr.CollExpr,
+		"key":        expr.KeyExpr,
+		"value":      expr.ValExpr,
+		"condition":  expr.CondExpr,
+	} {
+		if e == nil {
+			continue
+		}
+		value, _, err := c.convertExpression(e)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+	return jsonObj{ForKey: fields}, nil
+}
@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStructuredFor(t *testing.T) {
+	input := []byte(`
+names = [for s in var.list : upper(s) if s != ""]
+ids   = {for k, v in var.map : k => v.id...}
+`)
+
+	result, err := Convert(input, "main.tf", Options{StructuredFor: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"names": {"__for__": {
+		"type": "tuple",
+		"value_var": "s",
+		"collection": "${var.list}",
+		"value": "${upper(s)}",
+		"condition": "${s != \"\"}"
+	}},
+	"ids": {"__for__": {
+		"type": "object",
+		"key_var": "k",
+		"value_var": "v",
+		"collection": "${var.map}",
+		"key": "${k}",
+		"value": "${v.id}",
+		"group": true
+	}}
+}`))
+
+	result, err = Convert([]byte(`doubled = [for n in var.numbers : n * 2]`), "main.tf", Options{
+		StructuredFor: true,
+		Simplify:      true,
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"numbers": cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{"doubled": [2, 4]}`))
+}
@@ -0,0 +1,140 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// jsonFormat describes how the JSON output and line info are written.
+type jsonFormat struct {
+	// indent indents each element of an object or array, on a line of its
+	// own, when it isn't empty.
+	indent string
+
+	// escapeHTML escapes <, > and & in strings, as json.Marshal does.
+	escapeHTML bool
+
+	// canonical writes numbers in plain decimal notation and ends the
+	// output with a newline.
+	canonical bool
+}
+
+// jsonFormat returns the format selected by the options, and whether it
+// differs from that of json.Marshal.
+func (o Options) jsonFormat() (jsonFormat, bool) {
+	format := jsonFormat{
+		indent:     o.Indent,
+		escapeHTML: !o.DisableHTMLEscaping && !o.Canonical,
+		canonical:  o.Canonical,
+	}
+	return format, format != jsonFormat{escapeHTML: true}
+}
+
+// formatJSON rewrites JSON in the given format, keeping its keys in the
+// order given.
+func formatJSON(data []byte, format jsonFormat) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	// Each open container is recorded with whether it's an object, and
+	// the number of tokens written in it.
+	type container struct {
+		object bool
+		count  int
+	}
+	var stack []container
+	newline := func(depth int) {
+		if format.indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat(format.indent, depth))
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			if stack[len(stack)-1].count > 0 {
+				newline(len(stack) - 1)
+			}
+			stack = stack[:len(stack)-1]
+			buf.WriteByte(byte(delim))
+			continue
+		}
+		if n := len(stack); n > 0 {
+			top := &stack[n-1]
+			if top.object && top.count%2 == 1 {
+				buf.WriteByte(':')
+				if format.indent != "" {
+					buf.WriteByte(' ')
+				}
+			} else {
+				if top.count > 0 {
+					buf.WriteByte(',')
+				}
+				newline(n)
+			}
+			top.count++
+		}
+
+		switch tok := tok.(type) {
+		case json.Delim:
+			stack = append(stack, container{object: tok == '{'})
+			buf.WriteByte(byte(tok))
+		case string:
+			if err := writeJSONString(&buf, tok, format.escapeHTML); err != nil {
+				return nil, err
+			}
+		case json.Number:
+			s := string(tok)
+			if format.canonical {
+				if s, err = canonicalNumber(tok); err != nil {
+					return nil, err
+				}
+			}
+			buf.WriteString(s)
+		case bool:
+			fmt.Fprint(&buf, tok)
+		case nil:
+			buf.WriteString("null")
+		}
+	}
+	if format.canonical {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string, escapeHTML bool) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("encode string: %w", err)
+	}
+	// Encode ends each value with a newline.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// canonicalNumber returns a number in plain decimal notation, without an
+// exponent, trailing zeros after its decimal point, or a negative zero.
+func canonicalNumber(n json.Number) (string, error) {
+	f, _, err := big.ParseFloat(string(n), 10, 512, big.ToNearestEven)
+	if err != nil {
+		return "", fmt.Errorf("parse number %s: %w", n, err)
+	}
+	if f.Sign() == 0 {
+		return "0", nil
+	}
+	return f.Text('f', -1), nil
+}
@@ -0,0 +1,98 @@
+package convert
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	src := []byte(`url   = "https://example.com/?a=1&b=<2>"
+size  = 1e3
+ratio = 1.50
+zero  = -0
+big   = 12345678901234567890123
+tiny  = 0.000001
+`)
+	out, lines, err := Bytes(src, "main.tf", Options{Simplify: true, Canonical: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	expected := `{"big":12345678901234567890123,"ratio":1.5,"size":1000,"tiny":0.000001,"url":"https://example.com/?a=1&b=<2>","zero":0}` + "\n"
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+	if lines[len(lines)-1] != '\n' || lines[len(lines)-2] == '\n' {
+		t.Errorf("expected line info to end with a single newline, got %q", lines)
+	}
+
+	// Converting again, or with keys in source order, gives the same
+	// bytes each time.
+	again, _, err := Bytes(src, "main.tf", Options{Simplify: true, Canonical: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if string(again) != string(out) {
+		t.Errorf("expected the same output, got %s", again)
+	}
+	ordered, _, err := Bytes(src, "main.tf", Options{Simplify: true, Canonical: true, PreserveOrder: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	expected = `{"url":"https://example.com/?a=1&b=<2>","size":1000,"ratio":1.5,"zero":0,"big":12345678901234567890123,"tiny":0.000001}` + "\n"
+	if string(ordered) != expected {
+		t.Errorf("expected %s, got %s", expected, ordered)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	src := []byte(`{ "a" : [1.0, 2E2, {"b": null, "c": [true, false]}, []], "d": {}, "e": "<\"\\" }`)
+	for _, test := range []struct {
+		format   jsonFormat
+		expected string
+	}{
+		{
+			format:   jsonFormat{canonical: true},
+			expected: `{"a":[1,200,{"b":null,"c":[true,false]},[]],"d":{},"e":"<\"\\"}` + "\n",
+		},
+		{
+			format:   jsonFormat{escapeHTML: true},
+			expected: `{"a":[1.0,2E2,{"b":null,"c":[true,false]},[]],"d":{},"e":"\u003c\"\\"}`,
+		},
+		{
+			format: jsonFormat{indent: "  "},
+			expected: `{
+  "a": [
+    1.0,
+    2E2,
+    {
+      "b": null,
+      "c": [
+        true,
+        false
+      ]
+    },
+    []
+  ],
+  "d": {},
+  "e": "<\"\\"
+}`,
+		},
+	} {
+		got, err := formatJSON(src, test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.expected {
+			t.Errorf("%+v: expected %s, got %s", test.format, test.expected, got)
+		}
+	}
+}
+
+func TestIndent(t *testing.T) {
+	src := []byte("name = \"<web>\"\n")
+	out, _, err := Bytes(src, "main.tf", Options{Indent: "\t", DisableHTMLEscaping: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	expected := "{\n\t\"name\": \"<web>\"\n}"
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+}
@@ -0,0 +1,227 @@
+package convert
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// impureFunctions returns the functions whose results depend on more than
+// their arguments. They are never known to be safe, so are only evaluated
+// when listed in Options.AllowFunctions.
+func impureFunctions(options Options) map[string]function.Function {
+	clock := options.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	random := options.RandSource
+	if random == nil {
+		random = rand.Reader
+	}
+
+	return map[string]function.Function{
+		"timestamp":  makeTimestampFunc(clock),
+		"uuid":       makeUUIDFunc(random),
+		"file":       makeFileFunc(options.FS, false),
+		"filebase64": makeFileFunc(options.FS, true),
+		"fileexists": makeFileExistsFunc(options.FS),
+	}
+}
+
+// evalContext returns the context used to evaluate expressions, built once
+// per conversion so impure functions see the configured clock, RNG and
+// filesystem. Only allowed functions are included, which keeps templates
+// rendered by templatefile under the same policy.
+func (c *converter) evalContext() *hcl.EvalContext {
+	if c.ctx != nil {
+		return c.ctx
+	}
+
+	allowed := c.allowedFunctions()
+	functions := make(map[string]function.Function)
+	for name, fn := range evalContext.Functions {
+		if allowed[name] {
+			functions[name] = fn
+		}
+	}
+	for name, fn := range dialectFunctions(c.options.Dialect) {
+		if allowed[name] {
+			functions[name] = fn
+		}
+	}
+	for name, fn := range impureFunctions(c.options) {
+		if allowed[name] {
+			functions[name] = fn
+		}
+	}
+	if c.terragrunt != nil {
+		for name, fn := range c.terragrunt.functions() {
+			if allowed[name] {
+				functions[name] = fn
+			}
+		}
+	}
+	if allowed["templatefile"] {
+		functions["templatefile"] = makeTemplateFileFunc(c.options.FS, functions)
+	}
+
+	c.ctx = &hcl.EvalContext{
+		Variables: c.options.Variables,
+		Functions: functions,
+	}
+	return c.ctx
+}
+
+// makeTimestampFunc returns a timestamp function which, like Terraform's,
+// returns the current time in RFC 3339 format.
+func makeTimestampFunc(clock func() time.Time) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(clock().UTC().Format(time.RFC3339)), nil
+		},
+	})
+}
+
+// makeUUIDFunc returns a uuid function generating version 4 UUIDs from the
+// given source of random bytes.
+func makeUUIDFunc(random io.Reader) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			var b [16]byte
+			if _, err := io.ReadFull(random, b[:]); err != nil {
+				return cty.UnknownVal(cty.String), fmt.Errorf("read random bytes: %w", err)
+			}
+			b[6] = (b[6] & 0x0f) | 0x40
+			b[8] = (b[8] & 0x3f) | 0x80
+			return cty.StringVal(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])), nil
+		},
+	})
+}
+
+// errNoFS is returned by the file functions when Options.FS isn't set.
+var errNoFS = errors.New("no filesystem is configured for file functions")
+
+// sandboxPath resolves name to a path within the root of fsys, rejecting
+// absolute paths and any that would traverse outside of it.
+func sandboxPath(fsys fs.FS, name string) (string, error) {
+	if fsys == nil {
+		return "", errNoFS
+	}
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if !fs.ValidPath(cleaned) {
+		return "", fmt.Errorf("path %q is outside of the configured filesystem root", name)
+	}
+	return cleaned, nil
+}
+
+// makeFileFunc returns a file function reading from fsys, optionally
+// encoding the contents as base64 like Terraform's filebase64.
+func makeFileFunc(fsys fs.FS, encode bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name, err := sandboxPath(fsys, args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			contents, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return cty.UnknownVal(cty.String), fmt.Errorf("read file: %w", err)
+			}
+			if encode {
+				return cty.StringVal(base64.StdEncoding.EncodeToString(contents)), nil
+			}
+			return cty.StringVal(string(contents)), nil
+		},
+	})
+}
+
+// makeFileExistsFunc returns a fileexists function checking within fsys.
+func makeFileExistsFunc(fsys fs.FS) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name, err := sandboxPath(fsys, args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.Bool), err
+			}
+			info, err := fs.Stat(fsys, name)
+			if errors.Is(err, fs.ErrNotExist) {
+				return cty.False, nil
+			}
+			if err != nil {
+				return cty.UnknownVal(cty.Bool), fmt.Errorf("stat file: %w", err)
+			}
+			return cty.BoolVal(info.Mode().IsRegular()), nil
+		},
+	})
+}
+
+// makeTemplateFileFunc returns a templatefile function rendering templates
+// read from fsys, which may call any of the given functions but templatefile
+// itself, as in Terraform, so templates can't include themselves.
+func makeTemplateFileFunc(fsys fs.FS, functions map[string]function.Function) function.Function {
+	templateFunctions := make(map[string]function.Function, len(functions))
+	for name, fn := range functions {
+		if name != "templatefile" {
+			templateFunctions[name] = fn
+		}
+	}
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+			{Name: "vars", Type: cty.DynamicPseudoType},
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name, err := sandboxPath(fsys, args[0].AsString())
+			if err != nil {
+				return cty.DynamicVal, err
+			}
+			vars := args[1]
+			if !vars.Type().IsObjectType() && !vars.Type().IsMapType() {
+				return cty.DynamicVal, fmt.Errorf("template variables must be a map or object")
+			}
+			contents, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return cty.DynamicVal, fmt.Errorf("read file: %w", err)
+			}
+
+			expr, diags := hclsyntax.ParseTemplate(contents, name, hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				return cty.DynamicVal, diags
+			}
+
+			ctx := &hcl.EvalContext{
+				Variables: vars.AsValueMap(),
+				Functions: templateFunctions,
+			}
+			value, diags := expr.Value(ctx)
+			if diags.HasErrors() {
+				return cty.DynamicVal, diags
+			}
+			return value, nil
+		},
+	})
+}
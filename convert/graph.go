@@ -0,0 +1,88 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// DependencyGraph is an adjacency list of the references between the blocks
+// of a Terraform file, from the address of each block to the sorted
+// addresses it refers to, as in
+// {"aws_instance.web": ["aws_security_group.sg", "var.ami"]}. Local values
+// are nodes of their own, such as local.name.
+type DependencyGraph map[string][]string
+
+// Graph returns the references between the blocks of a Terraform file,
+// found from the traversals made by their attributes, including depends_on.
+// Every block is a node, including those referring to nothing. References
+// to objects declared in other files, such as variables, are included, but
+// not those to things which aren't declared in configuration, such as
+// count.index.
+func Graph(file *hcl.File) (DependencyGraph, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := make(DependencyGraph)
+	for _, block := range body.Blocks {
+		if block.Type != "locals" {
+			graph.node(blockAddress(block))
+		}
+	}
+	for _, attr := range attributes(body) {
+		graph.node(attr.owner)
+		for _, addr := range references(attr.attr.Expr) {
+			if addr != attr.owner {
+				graph.edge(attr.owner, addr)
+			}
+		}
+	}
+	for addr, deps := range graph {
+		sort.Strings(deps)
+		graph[addr] = deps
+	}
+	return graph, nil
+}
+
+func (g DependencyGraph) node(addr string) {
+	if _, ok := g[addr]; !ok {
+		g[addr] = []string{}
+	}
+}
+
+func (g DependencyGraph) edge(from, to string) {
+	for _, addr := range g[from] {
+		if addr == to {
+			return
+		}
+	}
+	g[from] = append(g[from], to)
+}
+
+// DOT returns the graph in the Graphviz DOT language, with an edge from each
+// block to each block it refers to.
+func (g DependencyGraph) DOT() []byte {
+	addrs := make([]string, 0, len(g))
+	for addr := range g {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph {\n")
+	for _, addr := range addrs {
+		if len(g[addr]) == 0 {
+			fmt.Fprintf(&buf, "  %s;\n", strconv.Quote(addr))
+		}
+		for _, dep := range g[addr] {
+			fmt.Fprintf(&buf, "  %s -> %s;\n", strconv.Quote(addr), strconv.Quote(dep))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
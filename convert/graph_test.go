@@ -0,0 +1,64 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestGraph(t *testing.T) {
+	src := []byte(`resource "aws_security_group" "sg" {
+  name = local.name
+}
+
+resource "aws_instance" "web" {
+  count                  = 2
+  ami                    = var.ami
+  vpc_security_group_ids = [aws_security_group.sg.id]
+  tags = {
+    Name = "${local.name}-${count.index}"
+  }
+  depends_on = [aws_security_group.sg]
+}
+
+locals {
+  name = "web-${var.env}"
+}
+
+variable "env" {}
+`)
+	file, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse:", diags)
+	}
+	graph, err := Graph(file)
+	if err != nil {
+		t.Fatal("graph:", err)
+	}
+
+	got, err := json.Marshal(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, got, []byte(`{
+		"aws_security_group.sg": ["local.name"],
+		"aws_instance.web": ["aws_security_group.sg", "local.name", "var.ami"],
+		"local.name": ["var.env"],
+		"var.env": []
+	}`))
+
+	expected := `digraph {
+  "aws_instance.web" -> "aws_security_group.sg";
+  "aws_instance.web" -> "local.name";
+  "aws_instance.web" -> "var.ami";
+  "aws_security_group.sg" -> "local.name";
+  "local.name" -> "var.env";
+  "var.env";
+}
+`
+	if dot := string(graph.DOT()); dot != expected {
+		t.Errorf("expected %s, got %s", expected, dot)
+	}
+}
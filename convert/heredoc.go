@@ -0,0 +1,67 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// HeredocKey is the key of the object heredocs are written as when
+// Options.PreserveHeredocs is set:
+//
+//	{"__heredoc__": {"delimiter": "EOF", "indented": true, "value": "..."}}
+const HeredocKey = "__heredoc__"
+
+// heredoc reports whether a template is written as a heredoc, returning its
+// delimiter and whether it's indented, written with <<- rather than <<.
+func (c *converter) heredoc(expr hclsyntax.Expression) (delimiter string, indented, ok bool) {
+	t, isTemplate := expr.(*hclsyntax.TemplateExpr)
+	if !isTemplate || t.SrcRange.End.Byte > len(c.bytes) {
+		return "", false, false
+	}
+	src := c.bytes[t.SrcRange.Start.Byte:t.SrcRange.End.Byte]
+	if !bytes.HasPrefix(src, []byte("<<")) {
+		return "", false, false
+	}
+	src = src[2:]
+	if indented = bytes.HasPrefix(src, []byte("-")); indented {
+		src = src[1:]
+	}
+	if end := bytes.IndexByte(src, '\n'); end >= 0 {
+		src = src[:end]
+	}
+	return string(bytes.TrimSuffix(src, []byte("\r"))), indented, true
+}
+
+// heredocObject returns value in the form written for heredocs.
+func heredocObject(delimiter string, indented bool, value interface{}) jsonObj {
+	return jsonObj{HeredocKey: map[string]interface{}{
+		"delimiter": delimiter,
+		"indented":  indented,
+		"value":     value,
+	}}
+}
+
+// hclHeredoc returns the HCL for an object written by heredocObject, or
+// false if the value isn't one.
+func hclHeredoc(obj map[string]interface{}) (string, bool, error) {
+	heredoc, ok := obj[HeredocKey].(map[string]interface{})
+	if !ok || len(obj) != 1 {
+		return "", false, nil
+	}
+	delimiter, _ := heredoc["delimiter"].(string)
+	value, isString := heredoc["value"].(string)
+	if !hclsyntax.ValidIdentifier(delimiter) || !isString {
+		return "", true, fmt.Errorf("invalid %s object", HeredocKey)
+	}
+	if !strings.HasSuffix(value, "\n") {
+		value += "\n"
+	}
+	marker := "<<"
+	if indented, _ := heredoc["indented"].(bool); indented {
+		marker = "<<-"
+	}
+	return marker + delimiter + "\n" + value + delimiter, true, nil
+}
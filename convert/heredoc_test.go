@@ -0,0 +1,37 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreserveHeredocs(t *testing.T) {
+	input := []byte(`policy = <<-EOT
+	{"Version": "${var.version}"}
+	EOT
+plain = <<EOF
+hello
+EOF
+name = "web"
+`)
+
+	result, err := Convert(input, "main.tf", Options{PreserveHeredocs: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"policy": {"__heredoc__": {"delimiter": "EOT", "indented": true, "value": "{\"Version\": \"${var.version}\"}\n"}},
+	"plain": {"__heredoc__": {"delimiter": "EOF", "indented": false, "value": "hello\n"}},
+	"name": "web"
+}`))
+
+	hcl, err := JSONToHCL(result.JSON, result.Lines)
+	if err != nil {
+		t.Fatal("unconvert:", err)
+	}
+	for _, want := range []string{"policy = <<-EOT\n{\"Version\": \"${var.version}\"}\nEOT\n", "= <<EOF\nhello\nEOF\n"} {
+		if !strings.Contains(string(hcl), want) {
+			t.Errorf("expected %q in\n%s", want, hcl)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxIncludeDepth is the include depth used when
+// Options.MaxIncludeDepth is zero.
+const DefaultMaxIncludeDepth = 32
+
+// IncludeError is returned when resolving included files or module sources
+// would recurse forever or exceeds the maximum depth. Chain lists every
+// source from the root file down to the one which hit the limit.
+type IncludeError struct {
+	Chain []string
+	Cycle bool
+}
+
+// Code returns the include-cycle or include-depth-exceeded code.
+func (e *IncludeError) Code() Code {
+	id := "include-depth-exceeded"
+	if e.Cycle {
+		id = "include-cycle"
+	}
+	code, _ := CodeFor(id)
+	return code
+}
+
+func (e *IncludeError) Error() string {
+	chain := strings.Join(e.Chain, " -> ")
+	if e.Cycle {
+		return fmt.Sprintf("include cycle detected: %s", chain)
+	}
+	return fmt.Sprintf("maximum include depth of %d exceeded: %s", len(e.Chain)-2, chain)
+}
+
+// includeChain tracks the sources currently being resolved, from the root
+// file down, so that cycles and runaway nesting can be reported.
+type includeChain struct {
+	sources []string
+	max     int
+}
+
+func newIncludeChain(root string, options Options) *includeChain {
+	max := options.MaxIncludeDepth
+	if max == 0 {
+		max = DefaultMaxIncludeDepth
+	}
+	return &includeChain{sources: []string{root}, max: max}
+}
+
+// push records that source is being included by the current source.
+func (c *includeChain) push(source string) error {
+	chain := append(append([]string{}, c.sources...), source)
+	for _, s := range c.sources {
+		if s == source {
+			return &IncludeError{Chain: chain, Cycle: true}
+		}
+	}
+	if len(c.sources) > c.max {
+		return &IncludeError{Chain: chain}
+	}
+
+	c.sources = append(c.sources, source)
+	return nil
+}
+
+// pop records that the current source has been fully resolved.
+func (c *includeChain) pop() {
+	c.sources = c.sources[:len(c.sources)-1]
+}
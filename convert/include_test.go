@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIncludeChainCycle(t *testing.T) {
+	chain := newIncludeChain("root.hcl", Options{})
+	if err := chain.push("a.hcl"); err != nil {
+		t.Fatal("push:", err)
+	}
+	if err := chain.push("b.hcl"); err != nil {
+		t.Fatal("push:", err)
+	}
+
+	err := chain.push("a.hcl")
+	var includeErr *IncludeError
+	if !errors.As(err, &includeErr) || !includeErr.Cycle {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+	if expected := "include cycle detected: root.hcl -> a.hcl -> b.hcl -> a.hcl"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+
+	chain.pop()
+	if err := chain.push("a.hcl"); err == nil {
+		t.Error("a.hcl is still being resolved and should be a cycle")
+	}
+	if err := chain.push("c.hcl"); err != nil {
+		t.Error("unexpected error after pop:", err)
+	}
+}
+
+func TestIncludeChainDepth(t *testing.T) {
+	chain := newIncludeChain("root.hcl", Options{MaxIncludeDepth: 2})
+	for _, source := range []string{"a.hcl", "b.hcl"} {
+		if err := chain.push(source); err != nil {
+			t.Fatal("push:", err)
+		}
+	}
+
+	err := chain.push("c.hcl")
+	var includeErr *IncludeError
+	if !errors.As(err, &includeErr) || includeErr.Cycle {
+		t.Fatalf("expected a depth error, got %v", err)
+	}
+	if expected := "maximum include depth of 2 exceeded: root.hcl -> a.hcl -> b.hcl -> c.hcl"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,251 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// InferSchema returns a JSON Schema describing the blocks, labels and
+// attributes observed in converted files, for bootstrapping validation of
+// a dialect. Attributes present in every body of a type are required, and
+// the values seen for each label are given as examples. The results must
+// use the first output schema version, and may have compact line info.
+func InferSchema(results ...*Result) (map[string]interface{}, error) {
+	root := newInferredBody()
+	for i, result := range results {
+		var out map[string]interface{}
+		if err := decodeJSON(result.JSON, &out); err != nil {
+			return nil, fmt.Errorf("result %d: decode json: %w", i, err)
+		}
+		lines, err := inferLines(result.Lines)
+		if err != nil {
+			return nil, fmt.Errorf("result %d: %w", i, err)
+		}
+		root.add(out, lines)
+	}
+
+	schema := root.schema()
+	schema["$schema"] = jsonSchemaDialect
+	return schema, nil
+}
+
+// inferLines decodes line info, expanding it when it's compact.
+func inferLines(b []byte) (map[string]interface{}, error) {
+	var lines map[string]interface{}
+	if err := json.Unmarshal(b, &lines); err != nil {
+		return nil, fmt.Errorf("decode line info: %w", err)
+	}
+	if _, ok := lines["line"]; ok {
+		return lines, nil
+	}
+	expanded, err := ExpandLines(b)
+	if err != nil {
+		return nil, fmt.Errorf("expand line info: %w", err)
+	}
+	lines = nil
+	if err := json.Unmarshal(expanded, &lines); err != nil {
+		return nil, fmt.Errorf("decode line info: %w", err)
+	}
+	return lines, nil
+}
+
+// inferredBody collects the attributes and blocks seen in the bodies of one
+// type of block, or of files.
+type inferredBody struct {
+	count      int
+	attributes map[string]*inferredValue
+	present    map[string]int
+	blocks     map[string]*inferredBlock
+}
+
+// inferredBlock collects the labels and bodies seen for one type of block.
+type inferredBlock struct {
+	labels []map[string]bool
+	body   *inferredBody
+}
+
+// inferredValue collects the types of the values seen for an attribute, or
+// an element or property of one.
+type inferredValue struct {
+	types      map[string]bool
+	items      *inferredValue
+	properties map[string]*inferredValue
+}
+
+func newInferredBody() *inferredBody {
+	return &inferredBody{
+		attributes: make(map[string]*inferredValue),
+		present:    make(map[string]int),
+		blocks:     make(map[string]*inferredBlock),
+	}
+}
+
+func (b *inferredBody) add(out, lines map[string]interface{}) {
+	b.count++
+	for key, value := range out {
+		if key == ProvenanceKey {
+			continue
+		}
+		if _, ok := lines[key].([]interface{}); ok {
+			block, ok := b.blocks[key]
+			if !ok {
+				block = &inferredBlock{body: newInferredBody()}
+				b.blocks[key] = block
+			}
+			items, _ := value.([]interface{})
+			blockLines, _ := lines[key].([]interface{})
+			for i, item := range items {
+				var line interface{}
+				if i < len(blockLines) {
+					line = blockLines[i]
+				}
+				block.add(item, line, 0)
+			}
+			continue
+		}
+
+		attr, ok := b.attributes[key]
+		if !ok {
+			attr = &inferredValue{types: make(map[string]bool)}
+			b.attributes[key] = attr
+		}
+		attr.add(value)
+		b.present[key]++
+	}
+}
+
+// add records a block, nested under an object for each label from depth
+// onwards. Its body is told apart from the labels by its line info having
+// the block type.
+func (b *inferredBlock) add(out, line interface{}, depth int) {
+	obj, _ := out.(map[string]interface{})
+	lines, _ := line.(map[string]interface{})
+	if lines == nil || lines["type"] == "block" {
+		b.body.add(obj, lines)
+		return
+	}
+	if len(b.labels) == depth {
+		b.labels = append(b.labels, make(map[string]bool))
+	}
+	for label, value := range obj {
+		b.labels[depth][label] = true
+		labelLines, _ := lines[label].(map[string]interface{})
+		b.add(value, labelLines, depth+1)
+	}
+}
+
+func (v *inferredValue) add(value interface{}) {
+	switch value := value.(type) {
+	case nil:
+		v.types["null"] = true
+	case bool:
+		v.types["boolean"] = true
+	case string:
+		v.types["string"] = true
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			v.types["integer"] = true
+		} else {
+			v.types["number"] = true
+		}
+	case []interface{}:
+		v.types["array"] = true
+		if v.items == nil {
+			v.items = &inferredValue{types: make(map[string]bool)}
+		}
+		for _, item := range value {
+			v.items.add(item)
+		}
+	case map[string]interface{}:
+		v.types["object"] = true
+		if v.properties == nil {
+			v.properties = make(map[string]*inferredValue)
+		}
+		for key, item := range value {
+			property, ok := v.properties[key]
+			if !ok {
+				property = &inferredValue{types: make(map[string]bool)}
+				v.properties[key] = property
+			}
+			property.add(item)
+		}
+	}
+}
+
+func (b *inferredBody) schema() map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for name, attr := range b.attributes {
+		properties[name] = attr.schema()
+		if b.present[name] == b.count {
+			required = append(required, name)
+		}
+	}
+	for name, block := range b.blocks {
+		properties[name] = map[string]interface{}{
+			"type":  "array",
+			"items": block.schema(),
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// schema describes a block as an object for each label, holding the body.
+func (b *inferredBlock) schema() map[string]interface{} {
+	schema := b.body.schema()
+	for depth := len(b.labels) - 1; depth >= 0; depth-- {
+		examples := make([]string, 0, len(b.labels[depth]))
+		for label := range b.labels[depth] {
+			examples = append(examples, label)
+		}
+		sort.Strings(examples)
+		schema = map[string]interface{}{
+			"type":                 "object",
+			"propertyNames":        map[string]interface{}{"examples": examples},
+			"additionalProperties": schema,
+		}
+	}
+	return schema
+}
+
+func (v *inferredValue) schema() map[string]interface{} {
+	if v.types["integer"] && v.types["number"] {
+		delete(v.types, "integer")
+	}
+	types := make([]string, 0, len(v.types))
+	for typ := range v.types {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	schema := make(map[string]interface{})
+	switch len(types) {
+	case 0:
+	case 1:
+		schema["type"] = types[0]
+	default:
+		schema["type"] = types
+	}
+	if v.items != nil && len(v.items.types) > 0 {
+		schema["items"] = v.items.schema()
+	}
+	if v.properties != nil {
+		properties := make(map[string]interface{}, len(v.properties))
+		for key, property := range v.properties {
+			properties[key] = property.schema()
+		}
+		schema["properties"] = properties
+	}
+	return schema
+}
@@ -0,0 +1,79 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	web, err := Convert([]byte(`service "http" "web" {
+  port    = 80
+  weight  = 0.5
+  enabled = true
+  tags    = ["a", "b"]
+  health {
+    path = "/"
+  }
+}
+`), "web.hcl", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	api, err := Convert([]byte(`service "grpc" "api" {
+  port = 9000
+  weight = 1
+}
+region = "eu-west-1"
+`), "api.hcl", Options{CompactLines: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	schema, err := InferSchema(web, api)
+	if err != nil {
+		t.Fatal("infer:", err)
+	}
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, got, []byte(`{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"region": {"type": "string"},
+		"service": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"propertyNames": {"examples": ["grpc", "http"]},
+				"additionalProperties": {
+					"type": "object",
+					"propertyNames": {"examples": ["api", "web"]},
+					"additionalProperties": {
+						"type": "object",
+						"additionalProperties": false,
+						"required": ["port", "weight"],
+						"properties": {
+							"port": {"type": "integer"},
+							"weight": {"type": "number"},
+							"enabled": {"type": "boolean"},
+							"tags": {"type": "array", "items": {"type": "string"}},
+							"health": {
+								"type": "array",
+								"items": {
+									"type": "object",
+									"additionalProperties": false,
+									"required": ["path"],
+									"properties": {"path": {"type": "string"}}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`))
+}
@@ -0,0 +1,274 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// InputSyntax identifies the syntax of the source being converted.
+type InputSyntax string
+
+const (
+	// InputSyntaxAuto treats files named *.json, or whose first
+	// non-whitespace character is {, as JSON and anything else as native
+	// syntax.
+	InputSyntaxAuto InputSyntax = ""
+
+	// InputSyntaxNative is the native HCL syntax.
+	InputSyntaxNative InputSyntax = "native"
+
+	// InputSyntaxJSON is the JSON syntax of HCL, such as in .tf.json files.
+	InputSyntaxJSON InputSyntax = "json"
+)
+
+// detect returns the syntax of src when the syntax is left to be detected.
+func (s InputSyntax) detect(src []byte, filename string) InputSyntax {
+	if s != InputSyntaxAuto {
+		return s
+	}
+	if strings.HasSuffix(filename, ".json") || bytes.HasPrefix(bytes.TrimSpace(src), []byte("{")) {
+		return InputSyntaxJSON
+	}
+	return InputSyntaxNative
+}
+
+// parseConfig parses src in the syntax selected by options.
+func parseConfig(src []byte, filename string, options Options) (*hcl.File, hcl.Diagnostics) {
+	if options.InputSyntax.detect(src, filename) == InputSyntaxJSON {
+		return hcljson.Parse(src, filename)
+	}
+	return hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+}
+
+// terraformJSONSchema describes the top-level blocks of Terraform, and those
+// nested in check and removed blocks, which tells them apart from object
+// attributes in JSON files with no schema.
+var terraformJSONSchema = &Schema{
+	Blocks: map[string]*BlockSchema{
+		"terraform": {},
+		"variable":  {Labels: []string{"name"}},
+		"output":    {Labels: []string{"name"}},
+		"locals":    {},
+		"module":    {Labels: []string{"name"}},
+		"provider":  {Labels: []string{"name"}},
+		"resource":  {Labels: []string{"type", "name"}},
+		"data":      {Labels: []string{"type", "name"}},
+		"moved":     {},
+		"import":    {},
+		"removed": {Body: &Schema{Blocks: map[string]*BlockSchema{
+			"lifecycle":   {},
+			"provisioner": {Labels: []string{"type"}},
+		}}},
+		"check": {Labels: []string{"name"}, Body: &Schema{Blocks: map[string]*BlockSchema{
+			"assert": {},
+			"data":   {Labels: []string{"type", "name"}},
+		}}},
+	},
+}
+
+// jsonSchema returns the schema used to find blocks in a JSON file. JSON
+// can't tell blocks apart from object attributes by itself, so without
+// Options.Schema only the top-level blocks of Terraform files are found,
+// and every other object is converted as an attribute.
+func (c *converter) jsonSchema(file *hcl.File) *Schema {
+	if c.schema != nil {
+		return c.schema
+	}
+	if c.options.Dialect == DialectTerraform || strings.HasSuffix(file.Body.MissingItemRange().Filename, ".tf.json") {
+		return terraformJSONSchema
+	}
+	return nil
+}
+
+// convertJSONBody converts a body parsed from JSON into the same structure
+// as convertBody produces for native syntax, with line info found from the
+// positions of the JSON tokens. rng is the range of the body, from its
+// opening brace to its closing brace.
+func (c *converter) convertJSONBody(body hcl.Body, schema *Schema, rng hcl.Range) (jsonObj, lineObj, error) {
+	cfg := make(jsonObj)
+	lcfg := make(lineObj)
+
+	bodySchema := &hcl.BodySchema{}
+	if schema != nil {
+		for _, name := range sortedKeys(schema.Blocks) {
+			bodySchema.Blocks = append(bodySchema.Blocks, hcl.BlockHeaderSchema{
+				Type:       name,
+				LabelNames: schema.Blocks[name].Labels,
+			})
+		}
+	}
+	content, remain, diags := body.PartialContent(bodySchema)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("decode body: %v", diags.Errs())
+	}
+
+	for _, block := range content.Blocks {
+		outerPath := c.path
+		c.path = append(append(c.path[:len(c.path):len(c.path)], block.Type), block.Labels...)
+		bodyRange := hcl.RangeBetween(block.DefRange, block.Body.MissingItemRange())
+		value, lines, err := c.convertJSONBody(block.Body, schema.blockBody(block.Type, block.Labels), bodyRange)
+		if err == nil {
+			err = c.check("", bodyRange, value)
+		}
+		c.path = outerPath
+		if err != nil {
+			return nil, nil, fmt.Errorf("convert block: %w", err)
+		}
+		lines[c.key("__key__startIndex")] = block.TypeRange.Start.Column
+		lines[c.key("__key__endIndex")] = block.TypeRange.End.Column
+		lines[c.key("__key__line")] = block.TypeRange.Start.Line
+		c.keyByteOffsets(lines, block.TypeRange)
+		if len(block.LabelRanges) > 0 {
+			labels := make([]interface{}, len(block.LabelRanges))
+			for i, rng := range block.LabelRanges {
+				labels[i] = c.rangeLines(rng)
+			}
+			lines[c.key("labelRanges")] = labels
+		}
+
+		for i := len(block.Labels) - 1; i >= 0; i-- {
+			value = jsonObj{block.Labels[i]: value}
+			lines = lineObj{block.Labels[i]: lines}
+		}
+		blocks, _ := cfg[block.Type].([]jsonObj)
+		cfg[block.Type] = append(blocks, value)
+		blockLines, _ := lcfg[block.Type].([]lineObj)
+		lcfg[block.Type] = append(blockLines, lines)
+	}
+
+	attrs, diags := remain.JustAttributes()
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("decode attributes: %v", diags.Errs())
+	}
+	for name, attr := range attrs {
+		var line lineObj
+		if c.options.Skeleton {
+			cfg[name] = nil
+			line = c.rangeLines(attr.Expr.Range())
+			if err := c.check(name, attr.Expr.Range(), nil); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			value, err := c.convertJSONExpression(attr.Expr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("convert %s: %w", name, err)
+			}
+			_, evaluated := value.(ctyjson.SimpleJSONValue)
+			value, err = c.transform(name, attr.Expr.Range(), value)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := c.check(name, attr.Expr.Range(), value); err != nil {
+				return nil, nil, err
+			}
+			cfg[name] = value
+			line = c.jsonValueLines(attr.Expr, evaluated)
+			c.recordType(line, attr.Expr)
+		}
+		line[c.key("__key__line")] = attr.NameRange.Start.Line
+		line[c.key("__key__startIndex")] = attr.NameRange.Start.Column
+		line[c.key("__key__endIndex")] = attr.NameRange.End.Column
+		c.keyByteOffsets(line, attr.NameRange)
+		if schema != nil {
+			outer := c.schema
+			c.schema = schema
+			c.annotateType(line, name)
+			c.schema = outer
+		}
+		lcfg[name] = line
+	}
+
+	lcfg[c.key("line")] = rng.Start.Line
+	lcfg[c.key("startIndex")] = rng.Start.Column
+	lcfg[c.key("endIndex")] = rng.End.Column
+	lcfg[c.key("type")] = "block"
+	lcfg[c.key("endLine")] = rng.End.Line
+	c.byteOffsets(lcfg, rng)
+	return cfg, lcfg, nil
+}
+
+// jsonValueLines returns the line info of a JSON value, with an entry for
+// each element of an array and each attribute of an object, as convertValue
+// records for native syntax. evaluated is whether the value was evaluated
+// when simplifying.
+func (c *converter) jsonValueLines(expr hcl.Expression, evaluated bool) lineObj {
+	rng := expr.Range()
+	line := lineObj{
+		c.key("line"):       rng.Start.Line,
+		c.key("startIndex"): rng.Start.Column,
+		c.key("endIndex"):   rng.End.Column,
+		c.key("endLine"):    rng.End.Line,
+	}
+	c.byteOffsets(line, rng)
+
+	if elems, diags := hcl.ExprList(expr); !diags.HasErrors() {
+		lines := make([]interface{}, len(elems))
+		origin := OriginLiteral
+		for i, elem := range elems {
+			lines[i] = c.jsonValueLines(elem, evaluated)
+			origin = leastLiteral(origin, c.lineOrigin(lines[i]))
+		}
+		line[c.key("type")] = "array"
+		line[c.key("lines")] = lines
+		c.annotateOrigin(line, origin)
+	} else if items, diags := hcl.ExprMap(expr); !diags.HasErrors() {
+		line[c.key("type")] = "object"
+		origin := OriginLiteral
+		for _, item := range items {
+			// Decoding the name from the source keeps any ${...}
+			// sequences, as convertJSONExpression does.
+			keyRange := item.Key.Range()
+			var key string
+			if keyRange.End.Byte > len(c.bytes) || decodeJSON(c.bytes[keyRange.Start.Byte:keyRange.End.Byte], &key) != nil {
+				continue
+			}
+			line[key] = c.jsonValueLines(item.Value, evaluated)
+			origin = leastLiteral(origin, c.lineOrigin(line[key]))
+		}
+		c.annotateOrigin(line, origin)
+	} else {
+		c.annotateOrigin(line, c.jsonOrigin(expr, evaluated))
+	}
+	return line
+}
+
+// convertJSONExpression converts the value of a JSON attribute. Strings
+// keep any ${...} sequences as they are, matching how native expressions
+// are wrapped, unless they can be evaluated when simplifying.
+func (c *converter) convertJSONExpression(expr hcl.Expression) (interface{}, error) {
+	if c.options.Simplify {
+		if value, diags := expr.Value(c.evalContext()); !diags.HasErrors() {
+			return ctyjson.SimpleJSONValue{Value: value}, nil
+		}
+	}
+
+	rng := expr.Range()
+	if rng.End.Byte > len(c.bytes) {
+		return nil, fmt.Errorf("expression range is outside of the source")
+	}
+	var value interface{}
+	if err := decodeJSON(c.bytes[rng.Start.Byte:rng.End.Byte], &value); err != nil {
+		return nil, fmt.Errorf("decode value: %w", err)
+	}
+	return value, nil
+}
+
+// sourceRange returns the range covering the whole of src.
+func sourceRange(src []byte, filename string) hcl.Range {
+	end := hcl.Pos{Line: 1, Column: 1, Byte: len(src)}
+	for _, r := range string(src) {
+		if r == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+	return hcl.Range{Filename: filename, Start: hcl.Pos{Line: 1, Column: 1}, End: end}
+}
@@ -0,0 +1,173 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONInput(t *testing.T) {
+	native := `
+region = "us-east-1"
+
+resource "aws_instance" "web" {
+	ami  = "ami-${var.suffix}"
+	tags = { Name = "web" }
+}
+
+resource "aws_instance" "db" {
+	count = 2
+}
+
+variable "suffix" {
+	default = "123"
+}`
+
+	jsonInput := `{
+	"region": "us-east-1",
+	"resource": {
+		"aws_instance": {
+			"web": {
+				"ami": "ami-${var.suffix}",
+				"tags": {"Name": "web"}
+			},
+			"db": {
+				"count": 2
+			}
+		}
+	},
+	"variable": {
+		"suffix": {
+			"default": "123"
+		}
+	}
+}`
+
+	expected, _, err := Bytes([]byte(native), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert native:", err)
+	}
+	actual, lineBytes, err := Bytes([]byte(jsonInput), "main.tf.json", Options{})
+	if err != nil {
+		t.Fatal("convert json:", err)
+	}
+	sameJSON(t, actual, expected)
+
+	lines := decodeLines(t, lineBytes)
+	web := lines["resource"].([]interface{})[0].(map[string]interface{})["aws_instance"].(map[string]interface{})["web"].(map[string]interface{})
+	if line := web["ami"].(map[string]interface{})["line"]; line != float64(6) {
+		t.Errorf("expected ami on line 6 of the JSON, got %v", line)
+	}
+}
+
+func TestJSONInputTerraformBlocks(t *testing.T) {
+	native := `
+import {
+	to = aws_instance.web
+	id = "i-123"
+}
+
+removed {
+	from = aws_instance.old
+	lifecycle {
+		destroy = false
+	}
+}
+
+check "health" {
+	data "http" "site" {
+		url = "https://example.com"
+	}
+	assert {
+		condition     = data.http.site.status_code == 200
+		error_message = "down"
+	}
+}`
+	jsonInput := `{
+	"import": [{"to": "${aws_instance.web}", "id": "i-123"}],
+	"removed": [{"from": "${aws_instance.old}", "lifecycle": [{"destroy": false}]}],
+	"check": {
+		"health": {
+			"data": {"http": {"site": {"url": "https://example.com"}}},
+			"assert": [{"condition": "${data.http.site.status_code == 200}", "error_message": "down"}]
+		}
+	}
+}`
+
+	expected, _, err := Bytes([]byte(native), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert native:", err)
+	}
+	actual, _, err := Bytes([]byte(jsonInput), "main.tf.json", Options{})
+	if err != nil {
+		t.Fatal("convert json:", err)
+	}
+	sameJSON(t, actual, expected)
+}
+
+func TestInputSyntax(t *testing.T) {
+	if _, _, err := Bytes([]byte(`{"a": 1}`), "config", Options{InputSyntax: InputSyntaxNative}); err == nil {
+		t.Error("JSON should not parse as native syntax")
+	}
+	actual, _, err := Bytes([]byte(`{"a": 1}`), "config", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if string(actual) != `{"a":1}` {
+		t.Errorf("expected {\"a\":1}, got %s", actual)
+	}
+}
+
+func TestJSONInputLines(t *testing.T) {
+	native := `resource "aws_instance" "web" {
+  ami   = "ami-123"
+  tags  = { Name = "web", ports = [80, 443] }
+  zones = ["a", "b"]
+}
+`
+	jsonInput := `{
+  "resource": {
+    "aws_instance": {
+      "web": {
+        "ami": "ami-123",
+        "tags": {"Name": "web", "ports": [80, 443]},
+        "zones": ["a", "b"]
+      }
+    }
+  }
+}`
+
+	pointers := func(src, filename string) map[string]FlatLine {
+		t.Helper()
+		result, err := Convert([]byte(src), filename, Options{LineFormat: LineFormatFlat})
+		if err != nil {
+			t.Fatal("convert:", err)
+		}
+		var flat map[string]FlatLine
+		if err := json.Unmarshal(result.Lines, &flat); err != nil {
+			t.Fatal("unmarshal lines:", err)
+		}
+		return flat
+	}
+	want, got := pointers(native, "main.tf"), pointers(jsonInput, "main.tf.json")
+	for pointer := range want {
+		if _, ok := got[pointer]; !ok {
+			t.Errorf("%s: missing from the line info of the JSON", pointer)
+		}
+	}
+	for pointer := range got {
+		if _, ok := want[pointer]; !ok {
+			t.Errorf("%s: unexpected in the line info of the JSON", pointer)
+		}
+	}
+
+	for pointer, want := range map[string]FlatLine{
+		"/resource/0/aws_instance/web":              {Line: 4, EndLine: 8, StartCol: 14, EndCol: 8},
+		"/resource/0/aws_instance/web/tags/ports/1": {Line: 6, EndLine: 6, StartCol: 47, EndCol: 50},
+		"/resource/0/aws_instance/web/zones/0":      {Line: 7, EndLine: 7, StartCol: 19, EndCol: 22},
+	} {
+		line := got[pointer]
+		if line.Line != want.Line || line.EndLine != want.EndLine || line.StartCol != want.StartCol || line.EndCol != want.EndCol {
+			t.Errorf("%s: expected %+v, got %+v", pointer, want, line)
+		}
+	}
+}
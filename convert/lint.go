@@ -0,0 +1,105 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Finding is an issue found in a file by an analysis or lint rule. The
+// Message is rendered from the DefaultCatalog template for the Rule and
+// Params; use Catalog.Findings to render it with another catalog.
+// Suggestion, when set, is replacement source for the range which would
+// resolve the issue, and Owners lists who owns the file, as set by
+// Owners.Annotate.
+type Finding struct {
+	Rule       string            `json:"rule"`
+	Message    string            `json:"message"`
+	Params     map[string]string `json:"params,omitempty"`
+	Path       string            `json:"path"`
+	Range      hcl.Range         `json:"range"`
+	Suggestion string            `json:"suggestion,omitempty"`
+	Owners     []string          `json:"owners,omitempty"`
+}
+
+// Rules checks a file as it's converted, such as for custom policies.
+// Check is called with the converted value of each attribute, and the
+// converted body of each block once its contents have been checked. path
+// is dot-separated like ValueContext.Path, such as
+// resource.aws_instance.web.ami, and rng is the range of the attribute's
+// expression or of the whole block. Values are given as encoding/json
+// decodes them, with numbers as json.Number. Findings returned without a
+// Path or Range are given those of the value checked.
+type Rules interface {
+	// Name identifies the rules in the options fingerprint, so it must
+	// change whenever what they find does.
+	Name() string
+
+	Check(path string, value interface{}, rng hcl.Range) []Finding
+}
+
+type rulesFunc struct {
+	name string
+	fn   func(path string, value interface{}, rng hcl.Range) []Finding
+}
+
+func (r rulesFunc) Name() string { return r.name }
+
+func (r rulesFunc) Check(path string, value interface{}, rng hcl.Range) []Finding {
+	return r.fn(path, value, rng)
+}
+
+// RulesFunc returns Rules with the given name which call fn.
+func RulesFunc(name string, fn func(path string, value interface{}, rng hcl.Range) []Finding) Rules {
+	return rulesFunc{name: name, fn: fn}
+}
+
+// Lint converts a file, checking it with rules as well as any in
+// options.Rules, and returns their findings in source order.
+func Lint(src []byte, filename string, options Options, rules ...Rules) ([]Finding, error) {
+	options.Rules = append(options.Rules[:len(options.Rules):len(options.Rules)], rules...)
+	result, err := Convert(src, filename, options)
+	if err != nil {
+		return nil, err
+	}
+	return result.Findings, nil
+}
+
+// check passes the converted value of the attribute called name, or the
+// body of the block being converted when name is empty, to each of
+// Options.Rules, recording their findings.
+func (c *converter) check(name string, rng hcl.Range, value interface{}) error {
+	if len(c.options.Rules) == 0 {
+		return nil
+	}
+	var decoded interface{}
+	if err := decodedCopy(value, &decoded); err != nil {
+		return fmt.Errorf("decode value: %w", err)
+	}
+	path := append([]string{}, c.path...)
+	if name != "" {
+		path = append(path, name)
+	}
+	pathString := strings.Join(path, ".")
+	for _, rules := range c.options.Rules {
+		for _, finding := range rules.Check(pathString, decoded, rng) {
+			if finding.Path == "" {
+				finding.Path = pathString
+			}
+			if finding.Range == (hcl.Range{}) {
+				finding.Range = rng
+			}
+			c.findings = append(c.findings, finding)
+		}
+	}
+	return nil
+}
+
+// sortFindings sorts findings by where they start in the source.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Range.Start.Byte < findings[j].Range.Start.Byte
+	})
+}
@@ -0,0 +1,84 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// requireTags finds aws_instance resources without tags, and public ACLs.
+var requireTags = RulesFunc("require-tags", func(path string, value interface{}, rng hcl.Range) []Finding {
+	if strings.HasPrefix(path, "resource.aws_instance.") && strings.Count(path, ".") == 2 {
+		if _, ok := value.(map[string]interface{})["tags"]; !ok {
+			return []Finding{{Rule: "require-tags", Message: "instances must be tagged"}}
+		}
+	}
+	if strings.HasSuffix("."+path, ".acl") && value == "public-read" {
+		return []Finding{{Rule: "no-public-acl", Message: "buckets must not be public"}}
+	}
+	return nil
+})
+
+func TestLint(t *testing.T) {
+	src := []byte(`resource "aws_s3_bucket" "logs" {
+  acl = "public-read"
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-1"
+}
+
+resource "aws_instance" "db" {
+  ami  = "ami-2"
+  tags = { Name = "db" }
+}
+`)
+	findings, err := Lint(src, "main.tf", Options{}, requireTags)
+	if err != nil {
+		t.Fatal("lint:", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", findings)
+	}
+
+	acl := findings[0]
+	if acl.Rule != "no-public-acl" || acl.Path != "resource.aws_s3_bucket.logs.acl" {
+		t.Errorf("expected the public ACL first, got %+v", acl)
+	}
+	if rng := acl.Range; rng.Filename != "main.tf" || rng.Start.Line != 2 || rng.Start.Column != 9 || rng.End.Column != 22 {
+		t.Errorf("expected the ACL's value on line 2, got %v", rng)
+	}
+
+	tags := findings[1]
+	if tags.Rule != "require-tags" || tags.Path != "resource.aws_instance.web" {
+		t.Errorf("expected the untagged instance second, got %+v", tags)
+	}
+	if rng := tags.Range; rng.Start.Line != 5 || rng.Start.Column != 1 || rng.End.Line != 7 {
+		t.Errorf("expected the whole web block, got %v", rng)
+	}
+}
+
+func TestLintJSON(t *testing.T) {
+	src := []byte(`{"resource": {"aws_instance": {"web": {"ami": "ami-1"}}}}`)
+	findings, err := Lint(src, "main.tf.json", Options{}, requireTags)
+	if err != nil {
+		t.Fatal("lint:", err)
+	}
+	if len(findings) != 1 || findings[0].Path != "resource.aws_instance.web" || findings[0].Range.Start.Line != 1 {
+		t.Errorf("expected the untagged instance, got %v", findings)
+	}
+}
+
+func TestRulesResult(t *testing.T) {
+	result, err := Convert([]byte(`acl = "public-read"`), "main.tf", Options{Rules: []Rules{requireTags}})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Path != "acl" {
+		t.Errorf("expected a finding for acl, got %v", result.Findings)
+	}
+	if clone := result.Clone(); len(clone.Findings) != 1 {
+		t.Errorf("expected the clone to keep the finding, got %v", clone.Findings)
+	}
+}
@@ -0,0 +1,48 @@
+package convert
+
+import "strings"
+
+// LookupPosition returns the JSON pointer, as used by LineFormatFlat, of the
+// innermost attribute, block or value whose source, or whose name, covers
+// the given 1-based line and column, for features such as hover or
+// go-to-definition. lines must be nested line info, as returned by
+// ConvertFile or decoded from Bytes. It reports false when the position is
+// outside every attribute and block.
+func LookupPosition(lines lineObj, line, col int) (string, bool) {
+	best, found := "", false
+	walkLines("", lines, "", func(pointer string, entry map[string]interface{}) {
+		if pointer == "" {
+			return
+		}
+		if !entryCovers(entry, "", line, col) && !entryCovers(entry, "__key__", line, col) {
+			return
+		}
+		depth := strings.Count(pointer, "/")
+		if !found || depth > strings.Count(best, "/") || depth == strings.Count(best, "/") && pointer < best {
+			best, found = pointer, true
+		}
+	})
+	return best, found
+}
+
+// entryCovers reports whether the range of a line info entry, or of its
+// key when prefix is "__key__", includes the position. Ends are inclusive,
+// so a cursor just after a name still finds it.
+func entryCovers(entry map[string]interface{}, prefix string, line, col int) bool {
+	startLine, ok := lineNumber(entry[prefix+"line"])
+	if !ok {
+		return false
+	}
+	endLine := startLine
+	if prefix == "" {
+		if endLine, ok = lineNumber(entry["endLine"]); !ok {
+			return false
+		}
+	}
+	startCol, _ := lineNumber(entry[prefix+"startIndex"])
+	endCol, _ := lineNumber(entry[prefix+"endIndex"])
+
+	afterStart := line > startLine || line == startLine && col >= startCol
+	beforeEnd := line < endLine || line == endLine && col <= endCol
+	return afterStart && beforeEnd
+}
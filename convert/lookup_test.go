@@ -0,0 +1,48 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestLookupPosition(t *testing.T) {
+	input := []byte(`resource "aws_instance" "web" {
+  ami   = "x"
+  zones = ["a", "b"]
+  ebs {
+    size = 1
+  }
+}
+`)
+	file, diags := hclsyntax.ParseConfig(input, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	_, lines, err := ConvertFile(file, Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	for _, test := range []struct {
+		line, col int
+		want      string
+	}{
+		{1, 12, "/resource/0/aws_instance/web"},
+		{2, 4, "/resource/0/aws_instance/web/ami"},
+		{2, 12, "/resource/0/aws_instance/web/ami"},
+		{3, 13, "/resource/0/aws_instance/web/zones/0"},
+		{3, 16, "/resource/0/aws_instance/web/zones"},
+		{5, 6, "/resource/0/aws_instance/web/ebs/0/size"},
+		{4, 3, "/resource/0/aws_instance/web/ebs/0"},
+	} {
+		got, ok := LookupPosition(lines, test.line, test.col)
+		if !ok || got != test.want {
+			t.Errorf("%d:%d: expected %s, got %q (%t)", test.line, test.col, test.want, got, ok)
+		}
+	}
+	if got, ok := LookupPosition(lines, 9, 1); ok {
+		t.Errorf("expected nothing after the end of the file, got %s", got)
+	}
+}
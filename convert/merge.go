@@ -0,0 +1,263 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MergeStrategy configures how Merge combines two converted documents.
+// Objects are always merged key by key. Blocks are matched by their type
+// and labels, and then in order for blocks with the same labels, and the
+// bodies of matching blocks merged key by key; the overlay's other blocks
+// follow the base's.
+type MergeStrategy struct {
+	// AppendLists appends the elements of the overlay's lists to those of
+	// the base. By default the overlay's lists replace the base's.
+	AppendLists bool
+
+	// ErrorOnConflict fails the merge when the base and overlay have
+	// different values for the same key which can't be merged, such as two
+	// different strings or lists. By default the overlay's value is taken.
+	ErrorOnConflict bool
+}
+
+// MergeSource is the document a merged value came from.
+type MergeSource string
+
+const (
+	MergeBase    MergeSource = "base"
+	MergeOverlay MergeSource = "overlay"
+)
+
+// MergeResult is the outcome of Merge.
+type MergeResult struct {
+	JSON []byte
+
+	// Provenance gives the document each value of the merged JSON came
+	// from, keyed by its dot-separated path, with the index of list
+	// elements in brackets as in resource[0].aws_instance.web.ami. Objects
+	// and lists are given by their elements, so only strings, numbers and
+	// other values without elements are included.
+	Provenance map[string]MergeSource
+}
+
+// Merge converts two files and deep-merges the overlay over the base,
+// following strategy.
+func Merge(base, overlay []byte, strategy MergeStrategy) (*MergeResult, error) {
+	var docs, lines [2]map[string]interface{}
+	for i, src := range [][]byte{base, overlay} {
+		result, err := Convert(src, "", Options{})
+		if err != nil {
+			return nil, fmt.Errorf("convert %s: %w", []MergeSource{MergeBase, MergeOverlay}[i], err)
+		}
+		if err := decodeJSON(result.JSON, &docs[i]); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON(result.Lines, &lines[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	m := merger{strategy: strategy, provenance: make(map[string]MergeSource)}
+	merged, err := m.merge("", docs[0], docs[1], lines[0], lines[1])
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	return &MergeResult{JSON: b, Provenance: m.provenance}, nil
+}
+
+type merger struct {
+	strategy   MergeStrategy
+	provenance map[string]MergeSource
+}
+
+// merge merges the overlay's value at path over the base's, given the line
+// info of each, which tells blocks from attributes.
+func (m *merger) merge(path string, base, overlay, baseLines, overlayLines interface{}) (interface{}, error) {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		if base, ok := base.(map[string]interface{}); ok {
+			bl, _ := baseLines.(map[string]interface{})
+			ol, _ := overlayLines.(map[string]interface{})
+			merged := make(map[string]interface{}, len(base)+len(overlay))
+			for key, value := range base {
+				if _, ok := overlay[key]; !ok {
+					merged[key] = value
+					m.record(joinPath(path, key), value, MergeBase)
+				}
+			}
+			for key, value := range overlay {
+				keyPath := joinPath(path, key)
+				if baseValue, ok := base[key]; ok {
+					baseBlocks, baseIsBlock := bl[key].([]interface{})
+					overlayBlocks, overlayIsBlock := ol[key].([]interface{})
+					var v interface{}
+					var err error
+					if baseIsBlock && overlayIsBlock {
+						v, err = m.blocks(keyPath, baseValue, value, baseBlocks, overlayBlocks)
+					} else {
+						v, err = m.merge(keyPath, baseValue, value, bl[key], ol[key])
+					}
+					if err != nil {
+						return nil, err
+					}
+					merged[key] = v
+					continue
+				}
+				merged[key] = value
+				m.record(keyPath, value, MergeOverlay)
+			}
+			return merged, nil
+		}
+	case []interface{}:
+		if base, ok := base.([]interface{}); ok && m.strategy.AppendLists {
+			merged := append(append([]interface{}{}, base...), overlay...)
+			for i, elem := range merged {
+				source := MergeBase
+				if i >= len(base) {
+					source = MergeOverlay
+				}
+				m.record(path+"["+strconv.Itoa(i)+"]", elem, source)
+			}
+			return merged, nil
+		}
+		if base, ok := base.([]interface{}); ok && (!m.strategy.ErrorOnConflict || reflect.DeepEqual(base, overlay)) {
+			m.record(path, overlay, MergeOverlay)
+			return overlay, nil
+		}
+	}
+
+	if m.strategy.ErrorOnConflict && !reflect.DeepEqual(base, overlay) {
+		return nil, fmt.Errorf("%s: conflicting values %s and %s", path, jsonString(base), jsonString(overlay))
+	}
+	m.record(path, overlay, MergeOverlay)
+	return overlay, nil
+}
+
+// mergeBlock is a converted block, with its labels and the line info of
+// its body.
+type mergeBlock struct {
+	labels      []string
+	body, lines interface{}
+}
+
+// blocks merges the overlay's blocks of a type into the base's, matching
+// them by their labels, and then in order for blocks with the same labels.
+// The overlay's blocks without a match follow the base's.
+func (m *merger) blocks(path string, base, overlay interface{}, baseLines, overlayLines []interface{}) (interface{}, error) {
+	baseBlocks, overlayBlocks := m.labelled(base, baseLines), m.labelled(overlay, overlayLines)
+	byName := make(map[string][]int)
+	for i, block := range baseBlocks {
+		name := strings.Join(block.labels, "\x00")
+		byName[name] = append(byName[name], i)
+	}
+	matches := make(map[int]int)
+	var added []int
+	for i, block := range overlayBlocks {
+		name := strings.Join(block.labels, "\x00")
+		if len(byName[name]) == 0 {
+			added = append(added, i)
+			continue
+		}
+		matches[byName[name][0]] = i
+		byName[name] = byName[name][1:]
+	}
+
+	merged := make([]interface{}, 0, len(baseBlocks)+len(added))
+	for i, block := range baseBlocks {
+		blockPath := path + "[" + strconv.Itoa(len(merged)) + "]"
+		j, ok := matches[i]
+		if !ok {
+			value := labelledBody(block.labels, block.body)
+			m.record(blockPath, value, MergeBase)
+			merged = append(merged, value)
+			continue
+		}
+		over := overlayBlocks[j]
+		bodyPath := blockPath
+		if len(block.labels) > 0 {
+			bodyPath = joinPath(blockPath, strings.Join(block.labels, "."))
+		}
+		body, err := m.merge(bodyPath, block.body, over.body, block.lines, over.lines)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, labelledBody(block.labels, body))
+	}
+	for _, j := range added {
+		value := labelledBody(overlayBlocks[j].labels, overlayBlocks[j].body)
+		m.record(path+"["+strconv.Itoa(len(merged))+"]", value, MergeOverlay)
+		merged = append(merged, value)
+	}
+	return merged, nil
+}
+
+// labelled returns the blocks of a type with their labels, descending
+// through the label objects of each to its body.
+func (m *merger) labelled(value interface{}, lines []interface{}) []mergeBlock {
+	values, _ := value.([]interface{})
+	blocks := make([]mergeBlock, 0, len(values))
+	for i, body := range values {
+		var line interface{}
+		if i < len(lines) {
+			line = lines[i]
+		}
+		block := mergeBlock{body: body, lines: line}
+		bodyLines, _ := line.(map[string]interface{})
+		for bodyLines["type"] != "block" && len(bodyLines) == 1 {
+			for label, inner := range bodyLines {
+				block.labels = append(block.labels, label)
+				bodyLines, _ = inner.(map[string]interface{})
+				block.body, _ = block.body.(map[string]interface{})[label]
+			}
+		}
+		block.lines = bodyLines
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// labelledBody nests the body of a block within its labels, as converted.
+func labelledBody(labels []string, body interface{}) interface{} {
+	for i := len(labels) - 1; i >= 0; i-- {
+		body = map[string]interface{}{labels[i]: body}
+	}
+	return body
+}
+
+// record records the source of value at path, and of the values within it.
+func (m *merger) record(path string, value interface{}, source MergeSource) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) > 0 {
+			for key, elem := range value {
+				m.record(joinPath(path, key), elem, source)
+			}
+			return
+		}
+	case []interface{}:
+		if len(value) > 0 {
+			for i, elem := range value {
+				m.record(path+"["+strconv.Itoa(i)+"]", elem, source)
+			}
+			return
+		}
+	}
+	m.provenance[path] = source
+}
+
+// jsonString returns the JSON encoding of a decoded value, for messages.
+func jsonString(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
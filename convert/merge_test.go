@@ -0,0 +1,144 @@
+package convert
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var (
+	mergeBase = []byte(`region = "us-east-1"
+zones  = ["a", "b"]
+tags = {
+  team = "web"
+}
+`)
+	mergeOverlay = []byte(`zones = ["c"]
+tags = {
+  env = "prod"
+}
+`)
+)
+
+func TestMerge(t *testing.T) {
+	result, err := Merge(mergeBase, mergeOverlay, MergeStrategy{})
+	if err != nil {
+		t.Fatal("merge:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+		"region": "us-east-1",
+		"zones": ["c"],
+		"tags": {"team": "web", "env": "prod"}
+	}`))
+
+	provenance, err := json.Marshal(result.Provenance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, provenance, []byte(`{
+		"region": "base",
+		"zones[0]": "overlay",
+		"tags.team": "base",
+		"tags.env": "overlay"
+	}`))
+}
+
+func TestMergeAppendLists(t *testing.T) {
+	result, err := Merge(mergeBase, mergeOverlay, MergeStrategy{AppendLists: true})
+	if err != nil {
+		t.Fatal("merge:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+		"region": "us-east-1",
+		"zones": ["a", "b", "c"],
+		"tags": {"team": "web", "env": "prod"}
+	}`))
+	for path, want := range map[string]MergeSource{"zones[1]": MergeBase, "zones[2]": MergeOverlay} {
+		if got := result.Provenance[path]; got != want {
+			t.Errorf("%s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	overlay := []byte(`region = "eu-west-1"`)
+	result, err := Merge(mergeBase, overlay, MergeStrategy{})
+	if err != nil {
+		t.Fatal("merge:", err)
+	}
+	if result.Provenance["region"] != MergeOverlay {
+		t.Errorf("got region from %q, want the overlay", result.Provenance["region"])
+	}
+
+	_, err = Merge(mergeBase, overlay, MergeStrategy{ErrorOnConflict: true})
+	if err == nil || !strings.Contains(err.Error(), `region: conflicting values "us-east-1" and "eu-west-1"`) {
+		t.Errorf("got error %v, want a conflict on region", err)
+	}
+
+	// The same value in both isn't a conflict.
+	if _, err := Merge(mergeBase, []byte(`region = "us-east-1"`), MergeStrategy{ErrorOnConflict: true}); err != nil {
+		t.Errorf("got error %v for the same value", err)
+	}
+}
+
+func TestMergeBlocks(t *testing.T) {
+	base := []byte(`resource "aws_instance" "web" {
+  ami  = "a"
+  tags = { Name = "web" }
+}
+
+resource "aws_instance" "db" {
+  ami = "a"
+}
+`)
+	overlay := []byte(`resource "aws_instance" "web" {
+  ami = "b"
+}
+
+resource "aws_instance" "cache" {
+  ami = "c"
+}
+`)
+	want := []byte(`{"resource": [
+		{"aws_instance": {"web": {"ami": "b", "tags": {"Name": "web"}}}},
+		{"aws_instance": {"db": {"ami": "a"}}},
+		{"aws_instance": {"cache": {"ami": "c"}}}
+	]}`)
+	for name, strategy := range map[string]MergeStrategy{
+		"default":      {},
+		"append lists": {AppendLists: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := Merge(base, overlay, strategy)
+			if err != nil {
+				t.Fatal("merge:", err)
+			}
+			sameJSON(t, result.JSON, want)
+			for path, want := range map[string]MergeSource{
+				"resource[0].aws_instance.web.ami":       MergeOverlay,
+				"resource[0].aws_instance.web.tags.Name": MergeBase,
+				"resource[1].aws_instance.db.ami":        MergeBase,
+				"resource[2].aws_instance.cache.ami":     MergeOverlay,
+			} {
+				if got := result.Provenance[path]; got != want {
+					t.Errorf("%s: got %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+
+	_, err := Merge(base, overlay, MergeStrategy{ErrorOnConflict: true})
+	if err == nil || !strings.Contains(err.Error(), `resource[0].aws_instance.web.ami: conflicting values "a" and "b"`) {
+		t.Errorf("got error %v, want a conflict on the ami of web", err)
+	}
+}
+
+func TestMergeListConflict(t *testing.T) {
+	_, err := Merge(mergeBase, mergeOverlay, MergeStrategy{ErrorOnConflict: true})
+	if err == nil || !strings.Contains(err.Error(), `zones: conflicting values ["a","b"] and ["c"]`) {
+		t.Errorf("got error %v, want a conflict on zones", err)
+	}
+	if _, err := Merge(mergeBase, mergeBase, MergeStrategy{ErrorOnConflict: true}); err != nil {
+		t.Errorf("got error %v for the same lists", err)
+	}
+}
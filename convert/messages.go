@@ -0,0 +1,161 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Message identifies a diagnostic or finding by a stable ID, with the
+// parameters its text is rendered from, so tools can key off the ID while
+// users read text in their own language.
+type Message struct {
+	ID     string            `json:"id"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// MessageTemplate holds the text of a message, with parameters written as
+// {name}. Findings only use the Summary.
+type MessageTemplate struct {
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Catalog holds message templates by ID, such as translations of
+// DefaultCatalog. Messages missing from a catalog use DefaultCatalog.
+type Catalog map[string]MessageTemplate
+
+// DefaultCatalog holds the English text of every message. The did-you-mean
+// template renders the {did_you_mean} parameter of other messages when
+// there's a {suggestion}.
+var DefaultCatalog = Catalog{
+	"did-you-mean": {Summary: " Did you mean {suggestion}?"},
+
+	"function-not-allowed": {
+		Summary: "Function not allowed",
+		Detail:  "The function {function} is not allowed for the {dialect} dialect, so the expression was not evaluated.",
+	},
+	"unsupported-argument": {
+		Summary: "Unsupported argument",
+		Detail:  "An argument named {name} is not expected here.{did_you_mean}",
+	},
+	"missing-required-argument": {
+		Summary: "Missing required argument",
+		Detail:  "The argument {name} is required, but no definition was found.",
+	},
+	"unsupported-block-type": {
+		Summary: "Unsupported block type",
+		Detail:  "Blocks of type {type} are not expected here.{did_you_mean}",
+	},
+	"missing-required-block": {
+		Summary: "Missing required block",
+		Detail:  "At least one {type} block is required, but none was found.",
+	},
+	"unexpected-block-labels": {
+		Summary: "Unexpected block labels",
+		Detail:  "Blocks of type {type} have {expected} labels, but this one has {count}.",
+	},
+	"deprecated-argument": {
+		Summary: "Deprecated argument",
+		Detail:  "The argument {name} is deprecated and may be removed in a future version.",
+	},
+	"incorrect-value-type": {
+		Summary: "Incorrect attribute value type",
+		Detail:  "Inappropriate value for attribute {name}: {error}.",
+	},
+	"value-not-allowed": {
+		Summary: "Value not allowed",
+		Detail:  "The value {value} is not one of the allowed values for {name}: {allowed}.{did_you_mean}",
+	},
+	"undeclared-reference": {
+		Summary: "Reference to undeclared {kind}",
+		Detail:  "No {kind} named {address} has been declared.{did_you_mean}",
+	},
+
+	"module-ref-missing":      {Summary: "Module source {source} has no ref, so it follows the default branch."},
+	"module-ref-mutable":      {Summary: "Module source {source} uses ref {ref}, which looks like a branch."},
+	"module-version-missing":  {Summary: "Module {module} has no version, so the latest release is always used."},
+	"module-version-unpinned": {Summary: "Module {module} uses version {version}, which doesn't pin an exact release."},
+}
+
+// Render returns the summary and detail of a message.
+func (c Catalog) Render(m Message) (summary, detail string) {
+	template, ok := c[m.ID]
+	if !ok {
+		template, ok = DefaultCatalog[m.ID]
+	}
+	if !ok {
+		return m.ID, ""
+	}
+
+	params := m.Params
+	if suggestion := params["suggestion"]; suggestion != "" && m.ID != "did-you-mean" {
+		params = make(map[string]string, len(m.Params)+1)
+		for k, v := range m.Params {
+			params[k] = v
+		}
+		params["did_you_mean"], _ = c.Render(Message{ID: "did-you-mean", Params: map[string]string{"suggestion": suggestion}})
+	}
+
+	var replacements []string
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	replacements = append(replacements, "{did_you_mean}", "")
+	replacer := strings.NewReplacer(replacements...)
+	return replacer.Replace(template.Summary), replacer.Replace(template.Detail)
+}
+
+// Findings returns findings with their messages rendered by the catalog.
+func (c Catalog) Findings(findings []Finding) []Finding {
+	rendered := make([]Finding, len(findings))
+	for i, finding := range findings {
+		finding.Message, _ = c.Render(Message{ID: finding.Rule, Params: finding.Params})
+		rendered[i] = finding
+	}
+	return rendered
+}
+
+// Messages holds the message each diagnostic was rendered from, for
+// diagnostics raised through a Catalog.
+type Messages map[*hcl.Diagnostic]Message
+
+// diagnostic returns a diagnostic rendered by the catalog, recording its
+// message in messages.
+func (c Catalog) diagnostic(messages Messages, severity hcl.DiagnosticSeverity, subject hcl.Range, m Message) *hcl.Diagnostic {
+	summary, detail := c.Render(m)
+	diag := &hcl.Diagnostic{Severity: severity, Summary: summary, Detail: detail, Subject: subject.Ptr()}
+	messages[diag] = m
+	return diag
+}
+
+// diagnose records a diagnostic rendered from the message with the given ID
+// by the catalog in the options. params alternates names and values, and
+// empty values are left out.
+func (c *converter) diagnose(severity hcl.DiagnosticSeverity, subject hcl.Range, id string, params ...string) {
+	m := Message{ID: id, Params: make(map[string]string, len(params)/2)}
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			m.Params[params[i]] = params[i+1]
+		}
+	}
+	if c.messages == nil {
+		c.messages = make(Messages)
+	}
+	c.diags = append(c.diags, c.options.Catalog.diagnostic(c.messages, severity, subject, m))
+}
+
+// writeFingerprint writes the catalog's templates in a stable order for use
+// by Options.Fingerprint.
+func (c Catalog) writeFingerprint(w io.Writer) {
+	for _, id := range sortedKeys(c) {
+		fmt.Fprintf(w, "%q summary=%q detail=%q;", id, c[id].Summary, c[id].Detail)
+	}
+}
+
+// quoted returns s quoted, as names and values are in messages.
+func quoted(s string) string {
+	return fmt.Sprintf("%q", s)
+}
@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestMessageCatalog(t *testing.T) {
+	schema := &Schema{Attributes: map[string]*AttributeSchema{"name": {}}}
+	input := []byte("nmae = \"web\"\n")
+
+	result, err := Convert(input, "main.hcl", Options{Schema: schema})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", result.Diagnostics)
+	}
+	diag := result.Diagnostics[0]
+	if want := `An argument named "nmae" is not expected here. Did you mean "name"?`; diag.Detail != want {
+		t.Errorf("expected detail %q, got %q", want, diag.Detail)
+	}
+	m := result.Messages[diag]
+	if m.ID != "unsupported-argument" || m.Params["name"] != `"nmae"` || m.Params["suggestion"] != `"name"` {
+		t.Errorf("unexpected message %+v", m)
+	}
+
+	catalog := Catalog{
+		"unsupported-argument": {Summary: "Argument non pris en charge", Detail: "L'argument {name} n'est pas attendu ici.{did_you_mean}"},
+		"did-you-mean":         {Summary: " Vouliez-vous dire {suggestion} ?"},
+	}
+	result, err = Convert(input, "main.hcl", Options{Schema: schema, Catalog: catalog})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	diag = result.Diagnostics[0]
+	if diag.Summary != "Argument non pris en charge" || diag.Detail != `L'argument "nmae" n'est pas attendu ici. Vouliez-vous dire "name" ?` {
+		t.Errorf("unexpected translation %q: %q", diag.Summary, diag.Detail)
+	}
+	if result.Messages[diag].ID != "unsupported-argument" {
+		t.Errorf("expected the message to be kept, got %+v", result.Messages[diag])
+	}
+	clone := result.Clone()
+	if clone.Messages[clone.Diagnostics[0]].ID != "unsupported-argument" {
+		t.Error("expected the clone to keep the message of its diagnostic")
+	}
+
+	if (Options{}).Fingerprint() == (Options{Catalog: catalog}).Fingerprint() {
+		t.Error("expected the catalog to change the fingerprint")
+	}
+}
+
+func TestCatalogFindings(t *testing.T) {
+	file, diags := hclsyntax.ParseConfig([]byte(`module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	findings, err := ModuleSources(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Params["module"] != `"vpc"` {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	catalog := Catalog{"module-version-missing": {Summary: "Das Modul {module} hat keine Version."}}
+	translated := catalog.Findings(findings)
+	if want := `Das Modul "vpc" hat keine Version.`; translated[0].Message != want {
+		t.Errorf("expected %q, got %q", want, translated[0].Message)
+	}
+	if findings[0].Message == translated[0].Message {
+		t.Error("expected the original findings to be left alone")
+	}
+}
+
+func TestMessageDefaultDialect(t *testing.T) {
+	result, err := Convert([]byte("x = timestamp()\n"), "main.hcl", Options{Simplify: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", result.Diagnostics)
+	}
+	diag := result.Diagnostics[0]
+	if want := `The function "timestamp" is not allowed for the hcl dialect, so the expression was not evaluated.`; diag.Detail != want {
+		t.Errorf("expected detail %q, got %q", want, diag.Detail)
+	}
+	if m := result.Messages[diag]; m.Params["dialect"] != "hcl" {
+		t.Errorf("unexpected message %+v", m)
+	}
+}
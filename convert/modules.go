@@ -0,0 +1,146 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var (
+	registrySource = regexp.MustCompile(`^([a-zA-Z0-9.-]+/)?[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+(//.*)?$`)
+	versionNumber  = regexp.MustCompile(`\d+(\.\d+)*`)
+	versionRef     = regexp.MustCompile(`^v?\d+(\.\d+)*(-[0-9A-Za-z.]+)?$`)
+	commitSHA      = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+// mutableRefs are git refs which commonly name branches rather than a
+// fixed commit or release.
+var mutableRefs = map[string]bool{
+	"main": true, "master": true, "develop": true, "dev": true,
+	"trunk": true, "HEAD": true, "latest": true,
+}
+
+// ModuleSources audits the module blocks of a Terraform file, reporting
+// registry modules without an exact version, and git modules without a ref
+// or whose ref names a branch. Local modules are always considered pinned.
+func ModuleSources(file *hcl.File) ([]Finding, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, block := range body.Blocks {
+		if block.Type != "module" || len(block.Labels) != 1 {
+			continue
+		}
+		attr, ok := block.Body.Attributes["source"]
+		if !ok {
+			continue
+		}
+		source, ok := literalString(attr.Expr)
+		if !ok {
+			continue
+		}
+
+		path := "module." + block.Labels[0]
+		switch {
+		case strings.HasPrefix(source, "./"), strings.HasPrefix(source, "../"):
+		case isGitSource(source):
+			if finding, ok := auditGitSource(source, attr); ok {
+				finding.Path = path + ".source"
+				findings = append(findings, finding)
+			}
+		case registrySource.MatchString(source):
+			if finding, ok := auditRegistryVersion(block); ok {
+				finding.Path = path + ".version"
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return DefaultCatalog.Findings(findings), nil
+}
+
+func literalString(expr hclsyntax.Expression) (string, bool) {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git::") ||
+		strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "github.com/") ||
+		strings.HasPrefix(source, "bitbucket.org/")
+}
+
+func auditGitSource(source string, attr *hclsyntax.Attribute) (Finding, bool) {
+	rawQuery := ""
+	if i := strings.Index(source, "?"); i >= 0 {
+		rawQuery = source[i+1:]
+	}
+	query, _ := url.ParseQuery(rawQuery)
+	ref := query.Get("ref")
+
+	switch {
+	case ref == "":
+		separator := "?"
+		if rawQuery != "" {
+			separator = "&"
+		}
+		return Finding{
+			Rule:       "module-ref-missing",
+			Params:     map[string]string{"source": quoted(source)},
+			Range:      attr.Expr.Range(),
+			Suggestion: fmt.Sprintf("%q", source+separator+"ref=<commit-sha>"),
+		}, true
+	case mutableRefs[ref] || (!commitSHA.MatchString(ref) && !versionRef.MatchString(ref)):
+		return Finding{
+			Rule:       "module-ref-mutable",
+			Params:     map[string]string{"source": quoted(source), "ref": quoted(ref)},
+			Range:      attr.Expr.Range(),
+			Suggestion: fmt.Sprintf("%q", strings.Replace(source, "ref="+ref, "ref=<commit-sha>", 1)),
+		}, true
+	default:
+		return Finding{}, false
+	}
+}
+
+func auditRegistryVersion(block *hclsyntax.Block) (Finding, bool) {
+	attr, ok := block.Body.Attributes["version"]
+	if !ok {
+		return Finding{
+			Rule:       "module-version-missing",
+			Params:     map[string]string{"module": quoted(block.Labels[0])},
+			Range:      block.DefRange(),
+			Suggestion: `version = "<version>"`,
+		}, true
+	}
+
+	version, ok := literalString(attr.Expr)
+	if !ok {
+		return Finding{}, false
+	}
+	exact := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(version), "="))
+	if exact != "" && exact == versionNumber.FindString(exact) {
+		return Finding{}, false
+	}
+
+	suggestion := `"<version>"`
+	if number := versionNumber.FindString(version); number != "" {
+		suggestion = fmt.Sprintf("%q", number)
+	}
+	return Finding{
+		Rule:       "module-version-unpinned",
+		Params:     map[string]string{"module": quoted(block.Labels[0]), "version": quoted(version)},
+		Range:      attr.Expr.Range(),
+		Suggestion: suggestion,
+	}, true
+}
@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestModuleSources(t *testing.T) {
+	input := `
+module "local" {
+	source = "./modules/vpc"
+}
+
+module "pinned" {
+	source  = "terraform-aws-modules/vpc/aws"
+	version = "3.2.0"
+}
+
+module "unversioned" {
+	source = "terraform-aws-modules/vpc/aws"
+}
+
+module "range" {
+	source  = "terraform-aws-modules/vpc/aws"
+	version = "~> 3.2"
+}
+
+module "branch" {
+	source = "git::https://example.com/vpc.git?ref=main"
+}
+
+module "tagged" {
+	source = "git::https://example.com/vpc.git?ref=v1.2.0"
+}
+
+module "no_ref" {
+	source = "github.com/example/vpc"
+}
+
+module "prerelease" {
+	source = "git::https://example.com/vpc.git?ref=2.0.0-rc.1"
+}
+
+module "feature" {
+	source = "git::https://example.com/vpc.git?ref=feature-123"
+}
+
+module "release" {
+	source = "git::https://example.com/vpc.git?ref=release-2024"
+}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	findings, err := ModuleSources(file)
+	if err != nil {
+		t.Fatal("module sources:", err)
+	}
+
+	expected := []struct {
+		path, rule, suggestion string
+	}{
+		{"module.unversioned.version", "module-version-missing", `version = "<version>"`},
+		{"module.range.version", "module-version-unpinned", `"3.2"`},
+		{"module.branch.source", "module-ref-mutable", `"git::https://example.com/vpc.git?ref=<commit-sha>"`},
+		{"module.no_ref.source", "module-ref-missing", `"github.com/example/vpc?ref=<commit-sha>"`},
+		{"module.feature.source", "module-ref-mutable", `"git::https://example.com/vpc.git?ref=<commit-sha>"`},
+		{"module.release.source", "module-ref-mutable", `"git::https://example.com/vpc.git?ref=<commit-sha>"`},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %+v", len(expected), findings)
+	}
+	for i, e := range expected {
+		f := findings[i]
+		if f.Path != e.path || f.Rule != e.rule || f.Suggestion != e.suggestion {
+			t.Errorf("expected %+v, got %+v", e, f)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package convert
+
+// nomadProfile reshapes a Nomad job specification into the job JSON of the
+// Nomad HTTP API, as in {"Job": {"ID": "example", "TaskGroups": [...]}}.
+// Names are converted to PascalCase, except where the API uses another
+// name, and the contents of config, env and meta blocks are kept as they
+// are.
+var nomadProfile = &profile{
+	rename: pascalCase,
+	root: &shape{
+		blocks: map[string]*blockShape{
+			"job": {labels: []string{"ID"}, single: true, body: nomadJob},
+		},
+	},
+}
+
+var (
+	nomadConstraint = &shape{
+		names: map[string]string{"attribute": "LTarget", "value": "RTarget", "operator": "Operand"},
+	}
+
+	nomadSpread = &shape{
+		blocks: map[string]*blockShape{
+			"target": {labels: []string{"Value"}},
+		},
+		names: map[string]string{"target": "SpreadTarget"},
+	}
+
+	nomadUpdate = &shape{
+		durations: map[string]bool{
+			"min_healthy_time": true, "healthy_deadline": true, "progress_deadline": true, "stagger": true,
+		},
+	}
+
+	nomadCheck = &shape{
+		names:     map[string]string{"port": "PortLabel"},
+		durations: map[string]bool{"interval": true, "timeout": true},
+		blocks: map[string]*blockShape{
+			"header": {single: true},
+		},
+	}
+
+	nomadService = &shape{
+		names: map[string]string{"port": "PortLabel", "check": "Checks"},
+		blocks: map[string]*blockShape{
+			"check": {body: nomadCheck},
+			"meta":  {single: true},
+		},
+	}
+
+	// nomadPlacement are the blocks constraining where a job, group or
+	// task is placed.
+	nomadPlacement = map[string]string{
+		"constraint": "Constraints",
+		"affinity":   "Affinities",
+		"spread":     "Spreads",
+	}
+
+	nomadJob = &shape{
+		names: withNames(nomadPlacement, map[string]string{
+			"group":         "TaskGroups",
+			"parameterized": "ParameterizedJob",
+		}),
+		blocks: map[string]*blockShape{
+			"group":         {labels: []string{"Name"}, body: nomadGroup},
+			"constraint":    {body: nomadConstraint},
+			"affinity":      {body: nomadConstraint},
+			"spread":        {body: nomadSpread},
+			"update":        {single: true, body: nomadUpdate},
+			"periodic":      {single: true, body: nomadPeriodic},
+			"parameterized": {single: true, body: &shape{}},
+			"multiregion":   {single: true},
+			"meta":          {single: true},
+		},
+		post: func(body, lines map[string]interface{}) {
+			if _, ok := body["Name"]; !ok {
+				body["Name"] = body["ID"]
+			}
+		},
+	}
+
+	nomadPeriodic = &shape{
+		names: map[string]string{"cron": "Spec", "crons": "Specs"},
+		post: func(body, lines map[string]interface{}) {
+			if _, ok := body["Spec"]; ok {
+				body["SpecType"] = "cron"
+			}
+		},
+	}
+
+	nomadGroup = &shape{
+		names: withNames(nomadPlacement, map[string]string{
+			"task":       "Tasks",
+			"network":    "Networks",
+			"service":    "Services",
+			"restart":    "RestartPolicy",
+			"reschedule": "ReschedulePolicy",
+			"volume":     "Volumes",
+		}),
+		durations: map[string]bool{
+			"shutdown_delay": true, "stop_after_client_disconnect": true, "max_client_disconnect": true,
+		},
+		blocks: map[string]*blockShape{
+			"task":       {labels: []string{"Name"}, body: nomadTask},
+			"network":    {body: nomadNetwork},
+			"service":    {body: nomadService},
+			"constraint": {body: nomadConstraint},
+			"affinity":   {body: nomadConstraint},
+			"spread":     {body: nomadSpread},
+			"restart": {single: true, body: &shape{
+				durations: map[string]bool{"interval": true, "delay": true},
+			}},
+			"reschedule": {single: true, body: &shape{
+				durations: map[string]bool{"interval": true, "delay": true, "max_delay": true},
+			}},
+			"ephemeral_disk": {single: true, body: &shape{
+				names: map[string]string{"size": "SizeMB"},
+			}},
+			"update":  {single: true, body: nomadUpdate},
+			"migrate": {single: true, body: nomadUpdate},
+			"volume":  {labels: []string{"Name"}, keyed: true, body: &shape{}},
+			"meta":    {single: true},
+		},
+	}
+
+	nomadNetwork = &shape{
+		names: map[string]string{"mbits": "MBits", "port": "Ports", "dns": "DNS"},
+		blocks: map[string]*blockShape{
+			"port": {labels: []string{"Label"}, body: &shape{
+				names: map[string]string{"static": "Value"},
+			}},
+			"dns": {single: true, body: &shape{}},
+		},
+		post: func(body, lines map[string]interface{}) {
+			// Ports with a static number are reserved, and others are
+			// allocated dynamically.
+			ports, _ := body["Ports"].([]interface{})
+			portLines, _ := lines["Ports"].([]interface{})
+			for i, port := range ports {
+				key := "DynamicPorts"
+				if _, static := port.(map[string]interface{})["Value"]; static {
+					key = "ReservedPorts"
+				}
+				list, _ := body[key].([]interface{})
+				listLines, _ := lines[key].([]interface{})
+				body[key] = append(list, port)
+				if i < len(portLines) {
+					lines[key] = append(listLines, portLines[i])
+				}
+			}
+			delete(body, "Ports")
+			delete(lines, "Ports")
+		},
+	}
+
+	nomadTask = &shape{
+		names: withNames(nomadPlacement, map[string]string{
+			"service":      "Services",
+			"logs":         "LogConfig",
+			"artifact":     "Artifacts",
+			"template":     "Templates",
+			"volume_mount": "VolumeMounts",
+		}),
+		durations: map[string]bool{"kill_timeout": true, "shutdown_delay": true},
+		blocks: map[string]*blockShape{
+			"config":     {single: true},
+			"env":        {single: true},
+			"meta":       {single: true},
+			"service":    {body: nomadService},
+			"constraint": {body: nomadConstraint},
+			"affinity":   {body: nomadConstraint},
+			"resources": {single: true, body: &shape{
+				names: map[string]string{"cpu": "CPU", "memory": "MemoryMB", "memory_max": "MemoryMaxMB", "device": "Devices"},
+				blocks: map[string]*blockShape{
+					"device": {labels: []string{"Name"}, body: &shape{}},
+				},
+			}},
+			"logs": {single: true, body: &shape{
+				names: map[string]string{"max_file_size": "MaxFileSizeMB"},
+			}},
+			"artifact": {body: &shape{
+				names: map[string]string{
+					"source": "GetterSource", "destination": "RelativeDest", "mode": "GetterMode",
+					"options": "GetterOptions", "headers": "GetterHeaders",
+				},
+				blocks: map[string]*blockShape{
+					"options": {single: true},
+					"headers": {single: true},
+				},
+			}},
+			"template": {body: &shape{
+				names: map[string]string{
+					"data": "EmbeddedTmpl", "source": "SourcePath", "destination": "DestPath",
+					"left_delimiter": "LeftDelim", "right_delimiter": "RightDelim", "env": "Envvars",
+				},
+				durations: map[string]bool{"splay": true},
+			}},
+			"volume_mount": {body: &shape{}},
+			"lifecycle":    {single: true, body: &shape{}},
+			"vault":        {single: true, body: &shape{}},
+		},
+	}
+)
+
+// withNames returns the names of both maps.
+func withNames(a, b map[string]string) map[string]string {
+	names := make(map[string]string, len(a)+len(b))
+	for _, m := range []map[string]string{a, b} {
+		for key, name := range m {
+			names[key] = name
+		}
+	}
+	return names
+}
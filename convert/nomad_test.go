@@ -0,0 +1,101 @@
+package convert
+
+import (
+	"testing"
+)
+
+func TestNomadDialect(t *testing.T) {
+	src := []byte(`job "example" {
+  datacenters = ["dc1"]
+  type        = "service"
+
+  update {
+    max_parallel     = 1
+    min_healthy_time = "10s"
+  }
+
+  group "web" {
+    count = 2
+
+    network {
+      port "http" {
+        to = 8080
+      }
+      port "admin" {
+        static = 9000
+      }
+    }
+
+    volume "data" {
+      type   = "host"
+      source = "data"
+    }
+
+    task "server" {
+      driver       = "docker"
+      kill_timeout = "30s"
+
+      config {
+        image = "nginx:1.25"
+        ports = ["http"]
+      }
+
+      env {
+        LOG_LEVEL = "info"
+      }
+
+      resources {
+        cpu    = 500
+        memory = 256
+      }
+
+      template {
+        data        = "hello"
+        destination = "local/hello.txt"
+      }
+    }
+  }
+}
+`)
+	out, lines, err := Bytes(src, "example.nomad", Options{Dialect: DialectNomad})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{"Job": {
+		"ID": "example",
+		"Name": "example",
+		"Datacenters": ["dc1"],
+		"Type": "service",
+		"Update": {"MaxParallel": 1, "MinHealthyTime": 10000000000},
+		"TaskGroups": [{
+			"Name": "web",
+			"Count": 2,
+			"Networks": [{
+				"DynamicPorts": [{"Label": "http", "To": 8080}],
+				"ReservedPorts": [{"Label": "admin", "Value": 9000}]
+			}],
+			"Volumes": {"data": {"Name": "data", "Type": "host", "Source": "data"}},
+			"Tasks": [{
+				"Name": "server",
+				"Driver": "docker",
+				"KillTimeout": 30000000000,
+				"Config": {"image": "nginx:1.25", "ports": ["http"]},
+				"Env": {"LOG_LEVEL": "info"},
+				"Resources": {"CPU": 500, "MemoryMB": 256},
+				"Templates": [{"EmbeddedTmpl": "hello", "DestPath": "local/hello.txt"}]
+			}]
+		}]
+	}}`))
+
+	// The line info follows the reshaped output.
+	l := decodeLines(t, lines)
+	job := l["Job"].(map[string]interface{})
+	task := job["TaskGroups"].([]interface{})[0].(map[string]interface{})["Tasks"].([]interface{})[0].(map[string]interface{})
+	if driver := task["Driver"].(map[string]interface{}); flatInt(driver["line"]) != 28 {
+		t.Errorf("expected the driver on line 28, got %v", driver)
+	}
+
+	if _, _, err := Bytes(src, "example.nomad", Options{Dialect: DialectNomad, OutputSchemaVersion: OutputSchemaV2}); err == nil {
+		t.Error("expected the nomad dialect to fail with output schema version 2")
+	}
+}
@@ -0,0 +1,159 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// orderedObj is a JSON object which marshals its keys in the order given,
+// rather than sorted as encoding/json does for maps.
+type orderedObj []orderedEntry
+
+type orderedEntry struct {
+	key   string
+	value interface{}
+}
+
+func (o orderedObj) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(entry.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// sourceOrdered returns the converted value with the keys of every object
+// in the order they appear in the source, found from the positions in the
+// line info. Keys without a position, such as injected defaults, follow
+// the rest in name order. prefix is Options.LineKeyPrefix.
+func sourceOrdered(value interface{}, line interface{}, prefix string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return sourceOrdered(jsonObj(v), line, prefix)
+	case jsonObj:
+		lines, _ := line.(lineObj)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sortSourceOrder(keys, lines, prefix)
+
+		obj := make(orderedObj, 0, len(keys))
+		for _, key := range keys {
+			obj = append(obj, orderedEntry{key: key, value: sourceOrdered(v[key], lines[key], prefix)})
+		}
+		return obj
+	case []jsonObj:
+		lines, _ := line.([]lineObj)
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			var elemLine interface{}
+			if i < len(lines) {
+				elemLine = lines[i]
+			}
+			list[i] = sourceOrdered(elem, elemLine, prefix)
+		}
+		return list
+	case []interface{}:
+		lines, isBlocks := line.([]interface{})
+		if l, ok := line.(lineObj); ok && !isBlocks {
+			lines, _ = l[prefix+"lines"].([]interface{})
+		}
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			var elemLine interface{}
+			if i < len(lines) {
+				elemLine = lines[i]
+			}
+			list[i] = sourceOrdered(elem, elemLine, prefix)
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+// sortSourceOrder sorts the keys of an object in the order they appear in
+// the source, found from the positions in its line info, with keys without
+// a position following the rest in name order.
+func sortSourceOrder(keys []string, lines lineObj, prefix string) {
+	sort.Slice(keys, func(i, j int) bool {
+		li, ci, iok := keyPosition(lines[keys[i]], prefix)
+		lj, cj, jok := keyPosition(lines[keys[j]], prefix)
+		switch {
+		case iok != jok:
+			return iok
+		case li != lj:
+			return li < lj
+		case ci != cj:
+			return ci < cj
+		default:
+			return keys[i] < keys[j]
+		}
+	})
+}
+
+// keyPosition returns the line and column at which the key for an entry of
+// the line info starts, descending through block labels.
+func keyPosition(line interface{}, prefix string) (int, int, bool) {
+	switch l := line.(type) {
+	case []lineObj:
+		if len(l) > 0 {
+			return keyPosition(l[0], prefix)
+		}
+	case []interface{}:
+		if len(l) > 0 {
+			return keyPosition(l[0], prefix)
+		}
+	case lineObj:
+		if n, ok := lineNumber(l[prefix+"__key__line"]); ok {
+			column, _ := lineNumber(l[prefix+"__key__startIndex"])
+			return n, column, true
+		}
+		if n, ok := lineNumber(l[prefix+"line"]); ok {
+			column, _ := lineNumber(l[prefix+"startIndex"])
+			return n, column, true
+		}
+		if body, ok := l["body"]; ok {
+			return keyPosition(body, prefix)
+		}
+		if len(l) == 1 {
+			for _, inner := range l {
+				return keyPosition(inner, prefix)
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// lineNumber returns a number from line info, which holds ints when built
+// by the converter, json.Numbers when decoded by decodeJSON, and float64s
+// when decoded by callers.
+func lineNumber(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,24 @@
+package convert
+
+import "testing"
+
+func TestPreserveOrder(t *testing.T) {
+	input := `
+zone = "b"
+resource "aws_instance" "web" {
+	tags = { z = 1, a = 2 }
+	ami  = "ami-123"
+	list = [{ y = 1, x = 2 }]
+}
+after = true`
+
+	jsonBytes, _, err := Bytes([]byte(input), "main.tf", Options{PreserveOrder: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	expected := `{"zone":"b","resource":[{"aws_instance":{"web":{"tags":{"z":1,"a":2},"ami":"ami-123","list":[{"y":1,"x":2}]}}}],"after":true}`
+	if string(jsonBytes) != expected {
+		t.Errorf("expected %s, got %s", expected, jsonBytes)
+	}
+}
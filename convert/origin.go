@@ -0,0 +1,152 @@
+package convert
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Origin classifies how a converted value was produced, as recorded under
+// "origin" in its line info when Options.AnnotateOrigins is set.
+type Origin string
+
+const (
+	// OriginLiteral is a value written out in the source, such as "a", 1
+	// or ["a", { b = 2 }].
+	OriginLiteral Origin = "literal"
+
+	// OriginConstant is a value evaluated without any variables, such as
+	// 2 + 3 or upper("a").
+	OriginConstant Origin = "constant"
+
+	// OriginVariable is a value evaluated using Options.Variables or other
+	// values in the configuration, such as var.region or local.name.
+	OriginVariable Origin = "variable"
+
+	// OriginComputed is a value left as an expression, so it's only known
+	// once the configuration is planned.
+	OriginComputed Origin = "computed"
+
+	// OriginDefault is a value injected from a default in the schema, with
+	// Options.InjectDefaults.
+	OriginDefault Origin = "default"
+)
+
+// originRank orders origins from the most to the least literal, so a
+// container is classified by the least literal of its elements.
+var originRank = map[Origin]int{
+	OriginLiteral:  0,
+	OriginDefault:  1,
+	OriginConstant: 1,
+	OriginVariable: 2,
+	OriginComputed: 3,
+}
+
+// leastLiteral returns whichever of a and b is the least literal.
+func leastLiteral(a, b Origin) Origin {
+	if originRank[b] > originRank[a] {
+		return b
+	}
+	return a
+}
+
+// annotateOrigin records origin in line, when Options.AnnotateOrigins is
+// set.
+func (c *converter) annotateOrigin(line interface{}, origin Origin) {
+	if !c.options.AnnotateOrigins {
+		return
+	}
+	if l, ok := line.(lineObj); ok {
+		l[c.key("origin")] = string(origin)
+	}
+}
+
+// lineOrigin returns the origin annotateOrigin recorded in line, treating
+// anything unrecorded as computed.
+func (c *converter) lineOrigin(line interface{}) Origin {
+	l, _ := line.(lineObj)
+	origin, _ := l[c.key("origin")].(string)
+	if origin == "" {
+		return OriginComputed
+	}
+	return Origin(origin)
+}
+
+// evaluatedOrigin classifies expr once it has been evaluated to a known
+// value.
+func evaluatedOrigin(expr hclsyntax.Expression) Origin {
+	if isLiteralExpr(expr) {
+		return OriginLiteral
+	}
+	if len(expr.Variables()) > 0 {
+		return OriginVariable
+	}
+	return OriginConstant
+}
+
+// isLiteralExpr reports whether expr is written entirely as literals.
+func isLiteralExpr(expr hclsyntax.Expression) bool {
+	switch v := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		return v.Val.IsWhollyKnown()
+	case *hclsyntax.TemplateExpr:
+		return v.IsStringLiteral()
+	case *hclsyntax.TemplateWrapExpr:
+		return isLiteralExpr(v.Wrapped)
+	case *hclsyntax.UnaryOpExpr:
+		// negative numbers are parsed as the negation of a literal
+		_, ok := v.Val.(*hclsyntax.LiteralValueExpr)
+		return ok && v.Op == hclsyntax.OpNegate
+	case *hclsyntax.TupleConsExpr:
+		for _, elem := range v.Exprs {
+			if !isLiteralExpr(elem) {
+				return false
+			}
+		}
+		return true
+	case *hclsyntax.ObjectConsExpr:
+		for _, item := range v.Items {
+			if !isLiteralKey(item.KeyExpr) || !isLiteralExpr(item.ValueExpr) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// isLiteralKey reports whether the key of an object item is a bare name or
+// a literal.
+func isLiteralKey(expr hclsyntax.Expression) bool {
+	key, ok := expr.(*hclsyntax.ObjectConsKeyExpr)
+	if !ok {
+		return isLiteralExpr(expr)
+	}
+	if !key.ForceNonLiteral && hcl.ExprAsKeyword(key.Wrapped) != "" {
+		return true
+	}
+	return isLiteralExpr(key.Wrapped)
+}
+
+// jsonOrigin classifies a string, number or other scalar in JSON syntax,
+// which is literal unless it's a template. Templates are only evaluated
+// when the whole attribute was.
+func (c *converter) jsonOrigin(expr hcl.Expression, evaluated bool) Origin {
+	rng := expr.Range()
+	if rng.End.Byte > len(c.bytes) {
+		return OriginComputed
+	}
+	src := c.bytes[rng.Start.Byte:rng.End.Byte]
+	if !bytes.Contains(src, []byte("${")) && !bytes.Contains(src, []byte("%{")) {
+		return OriginLiteral
+	}
+	if !evaluated {
+		return OriginComputed
+	}
+	if len(expr.Variables()) > 0 {
+		return OriginVariable
+	}
+	return OriginConstant
+}
@@ -0,0 +1,99 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAnnotateOrigins(t *testing.T) {
+	src := []byte(`name    = "web"
+port    = 8000 + 80
+region  = var.region
+ami     = data.aws_ami.ubuntu.id
+tags    = { env = "prod", owner = var.owner }
+ports   = [80, 443]
+count   = max(1, 2)
+`)
+	options := Options{
+		Simplify:        true,
+		AnnotateOrigins: true,
+		LineFormat:      LineFormatFlat,
+		Dialect:         DialectTerraform,
+		Variables:       map[string]cty.Value{"var": cty.ObjectVal(map[string]cty.Value{"region": cty.StringVal("eu-west-1"), "owner": cty.StringVal("platform")})},
+	}
+	expected := map[string]Origin{
+		"/name":   OriginLiteral,
+		"/port":   OriginConstant,
+		"/region": OriginVariable,
+		"/ami":    OriginComputed,
+		"/tags":   OriginVariable,
+		"/ports":  OriginLiteral,
+		"/count":  OriginConstant,
+	}
+
+	_, lines, err := Bytes(src, "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	var flat map[string]FlatLine
+	if err := json.Unmarshal(lines, &flat); err != nil {
+		t.Fatal("decode line info:", err)
+	}
+	for pointer, origin := range expected {
+		if flat[pointer].Origin != origin {
+			t.Errorf("%s: expected origin %q, got %q", pointer, origin, flat[pointer].Origin)
+		}
+	}
+
+	// Without simplifying, only operations on literals are evaluated.
+	options.Simplify = false
+	_, lines, err = Bytes(src, "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	flat = nil
+	if err := json.Unmarshal(lines, &flat); err != nil {
+		t.Fatal("decode line info:", err)
+	}
+	expected = map[string]Origin{
+		"/name":       OriginLiteral,
+		"/port":       OriginConstant,
+		"/region":     OriginComputed,
+		"/tags":       OriginComputed,
+		"/tags/env":   OriginLiteral,
+		"/tags/owner": OriginComputed,
+		"/ports":      OriginLiteral,
+		"/ports/0":    OriginLiteral,
+		"/count":      OriginComputed,
+	}
+	for pointer, origin := range expected {
+		if flat[pointer].Origin != origin {
+			t.Errorf("without simplifying, %s: expected origin %q, got %q", pointer, origin, flat[pointer].Origin)
+		}
+	}
+}
+
+func TestAnnotateOriginsJSON(t *testing.T) {
+	src := []byte(`{"name": "web", "id": "${aws_instance.web.id}", "ports": [80, "${var.port}"]}`)
+	_, lines, err := Bytes(src, "main.tf.json", Options{AnnotateOrigins: true, LineFormat: LineFormatFlat})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	var flat map[string]FlatLine
+	if err := json.Unmarshal(lines, &flat); err != nil {
+		t.Fatal("decode line info:", err)
+	}
+	expected := map[string]Origin{
+		"/name":    OriginLiteral,
+		"/id":      OriginComputed,
+		"/ports":   OriginComputed,
+		"/ports/0": OriginLiteral,
+	}
+	for pointer, origin := range expected {
+		if flat[pointer].Origin != origin {
+			t.Errorf("%s: expected origin %q, got %q", pointer, origin, flat[pointer].Origin)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// OutputSchemaVersion selects the structure of the JSON output and line
+// info, so consumers can opt in to structural changes while others keep
+// the shape they were written against.
+type OutputSchemaVersion int
+
+const (
+	// OutputSchemaV1 nests the body of each block under one object per
+	// label, as in {"resource":[{"aws_instance":{"web":{...}}}]}. It is
+	// used when no version is given.
+	OutputSchemaV1 OutputSchemaVersion = 1
+
+	// OutputSchemaV2 gives the labels of each block as an array alongside
+	// its body, as in {"resource":[{"labels":["aws_instance","web"],
+	// "body":{...}}]}, so labels can be told apart from attributes without
+	// the line info.
+	OutputSchemaV2 OutputSchemaVersion = 2
+
+	// LatestOutputSchemaVersion is the newest supported version.
+	LatestOutputSchemaVersion = OutputSchemaV2
+)
+
+// outputSchemaVersion returns the version requested by options.
+func (o Options) outputSchemaVersion() (OutputSchemaVersion, error) {
+	switch o.OutputSchemaVersion {
+	case 0:
+		return OutputSchemaV1, nil
+	case OutputSchemaV1, OutputSchemaV2:
+		return o.OutputSchemaVersion, nil
+	default:
+		return 0, fmt.Errorf("unsupported output schema version %d", o.OutputSchemaVersion)
+	}
+}
+
+// MigrateOutput converts JSON output and line info from one output schema
+// version to another, such as to upgrade stored conversions or to give an
+// older consumer the shape it expects. The line info must not be compact.
+func MigrateOutput(jsonBytes, lineBytes []byte, from, to OutputSchemaVersion) ([]byte, []byte, error) {
+	var cfg, lines map[string]interface{}
+	if err := decodeJSON(jsonBytes, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("parse json: %w", err)
+	}
+	if err := decodeJSON(lineBytes, &lines); err != nil {
+		return nil, nil, fmt.Errorf("parse line info: %w", err)
+	}
+
+	if err := migrateOutput(cfg, lines, from, to, ""); err != nil {
+		return nil, nil, err
+	}
+
+	jsonBytes, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal json: %w", err)
+	}
+	lineBytes, err = json.Marshal(lines)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal json: %w", err)
+	}
+	return jsonBytes, lineBytes, nil
+}
+
+func migrateOutput(cfg, lines map[string]interface{}, from, to OutputSchemaVersion, prefix string) error {
+	for _, version := range []OutputSchemaVersion{from, to} {
+		if version < OutputSchemaV1 || version > LatestOutputSchemaVersion {
+			return fmt.Errorf("unsupported output schema version %d", version)
+		}
+	}
+
+	switch {
+	case from == OutputSchemaV1 && to == OutputSchemaV2:
+		return labelArrays(cfg, lines, prefix)
+	case from == OutputSchemaV2 && to == OutputSchemaV1:
+		return nestedLabels(cfg, lines, prefix)
+	}
+	return nil
+}
+
+// labelArrays rewrites the blocks of a body from nested labels to label
+// arrays. Blocks are found from the line info, where they are the only
+// lists directly within a body. prefix is Options.LineKeyPrefix.
+func labelArrays(cfg, lines map[string]interface{}, prefix string) error {
+	for key, line := range lines {
+		blockLines, ok := line.([]interface{})
+		if !ok || isLineMeta(key, line, prefix) {
+			continue
+		}
+		blocks, ok := cfg[key].([]interface{})
+		if !ok || len(blocks) != len(blockLines) {
+			return fmt.Errorf("%s: line info doesn't match blocks", key)
+		}
+
+		for i := range blocks {
+			labels, bodyLines := blockBodyLines(blockLines[i].(map[string]interface{}), prefix)
+			body, ok := blocks[i].(map[string]interface{})
+			for _, label := range labels {
+				if !ok {
+					break
+				}
+				body, ok = body[label].(map[string]interface{})
+			}
+			if !ok {
+				return fmt.Errorf("%s: block doesn't match its line info", key)
+			}
+			if err := labelArrays(body, bodyLines, prefix); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+
+			labelList := make([]interface{}, len(labels))
+			for j, label := range labels {
+				labelList[j] = label
+			}
+			blocks[i] = map[string]interface{}{"labels": labelList, "body": body}
+			blockLines[i] = map[string]interface{}{"labels": labelList, "body": bodyLines}
+		}
+	}
+	return nil
+}
+
+// nestedLabels reverses labelArrays.
+func nestedLabels(cfg, lines map[string]interface{}, prefix string) error {
+	for key, line := range lines {
+		blockLines, ok := line.([]interface{})
+		if !ok || isLineMeta(key, line, prefix) {
+			continue
+		}
+		blocks, ok := cfg[key].([]interface{})
+		if !ok || len(blocks) != len(blockLines) {
+			return fmt.Errorf("%s: line info doesn't match blocks", key)
+		}
+
+		for i := range blocks {
+			block, ok := blocks[i].(map[string]interface{})
+			blockLine, lok := blockLines[i].(map[string]interface{})
+			if !ok || !lok {
+				return fmt.Errorf("%s: expected a block object", key)
+			}
+			body, ok := block["body"].(map[string]interface{})
+			bodyLines, lok := blockLine["body"].(map[string]interface{})
+			labels, _ := block["labels"].([]interface{})
+			if !ok || !lok {
+				return fmt.Errorf("%s: expected a block body", key)
+			}
+			if err := nestedLabels(body, bodyLines, prefix); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+
+			value, valueLines := interface{}(body), interface{}(bodyLines)
+			for j := len(labels) - 1; j >= 0; j-- {
+				label, ok := labels[j].(string)
+				if !ok {
+					return fmt.Errorf("%s: expected string labels", key)
+				}
+				value = map[string]interface{}{label: value}
+				valueLines = map[string]interface{}{label: valueLines}
+			}
+			blocks[i], blockLines[i] = value, valueLines
+		}
+	}
+	return nil
+}
+
+// migrateConverted converts the output of the converter, which is always
+// in OutputSchemaV1, to another version.
+func migrateConverted(cfg jsonObj, lines lineObj, version OutputSchemaVersion, prefix string) (map[string]interface{}, map[string]interface{}, error) {
+	jsonBytes, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal json: %w", err)
+	}
+	lineBytes, err := json.Marshal(lines)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal json: %w", err)
+	}
+
+	var migrated, migratedLines map[string]interface{}
+	if err := decodeJSON(jsonBytes, &migrated); err != nil {
+		return nil, nil, err
+	}
+	if err := decodeJSON(lineBytes, &migratedLines); err != nil {
+		return nil, nil, err
+	}
+	if err := migrateOutput(migrated, migratedLines, OutputSchemaV1, version, prefix); err != nil {
+		return nil, nil, err
+	}
+	return migrated, migratedLines, nil
+}
@@ -0,0 +1,57 @@
+package convert
+
+import "testing"
+
+func TestOutputSchemaV2(t *testing.T) {
+	input := `
+name = "app"
+resource "aws_instance" "web" {
+	ami = "ami-123"
+	ebs_block_device {
+		device_name = "sdb"
+	}
+}`
+
+	v1JSON, v1Lines, err := Bytes([]byte(input), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	v2JSON, v2Lines, err := Bytes([]byte(input), "main.tf", Options{OutputSchemaVersion: OutputSchemaV2})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	compareTest(t, v2JSON, `{
+	"name": "app",
+	"resource": [
+		{
+			"body": {
+				"ami": "ami-123",
+				"ebs_block_device": [
+					{
+						"body": {
+							"device_name": "sdb"
+						},
+						"labels": []
+					}
+				]
+			},
+			"labels": [
+				"aws_instance",
+				"web"
+			]
+		}
+	]
+}`)
+
+	migratedJSON, migratedLines, err := MigrateOutput(v2JSON, v2Lines, OutputSchemaV2, OutputSchemaV1)
+	if err != nil {
+		t.Fatal("migrate output:", err)
+	}
+	sameJSON(t, migratedJSON, v1JSON)
+	sameJSON(t, migratedLines, v1Lines)
+
+	if _, _, err := Bytes([]byte(input), "main.tf", Options{OutputSchemaVersion: 99}); err == nil {
+		t.Error("an unsupported output schema version should return an error")
+	}
+}
@@ -0,0 +1,113 @@
+package convert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Owners maps files to their owners following the rules of a CODEOWNERS
+// file, where the last matching pattern decides the owners.
+type Owners struct {
+	rules []ownerRule
+}
+
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// ParseOwners reads a CODEOWNERS file.
+func ParseOwners(r io.Reader) (*Owners, error) {
+	owners := &Owners{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		pattern, err := ownerPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		owners.rules = append(owners.rules, ownerRule{pattern: pattern, owners: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read owners: %w", err)
+	}
+	return owners, nil
+}
+
+// ownerPattern compiles a CODEOWNERS pattern. Patterns containing a slash
+// other than at the end are relative to the root, while others match at any
+// depth, and a pattern matching a directory matches everything beneath it.
+func ownerPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("(^|/)")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("(/|$)")
+	return regexp.Compile(expr.String())
+}
+
+// Match returns the owners of a file, given relative to the root of the
+// repository, or nil if it has none.
+func (o *Owners) Match(filename string) []string {
+	filename = strings.TrimPrefix(path.Clean("/"+filename), "/")
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].pattern.MatchString(filename) {
+			return o.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// RangeOwners returns the owners of the file a range is in.
+func (o *Owners) RangeOwners(rng hcl.Range) []string {
+	return o.Match(rng.Filename)
+}
+
+// Annotate sets the owners of each finding from the file it was found in.
+func (o *Owners) Annotate(findings []Finding) {
+	for i := range findings {
+		findings[i].Owners = o.RangeOwners(findings[i].Range)
+	}
+}
+
+// AnnotateRefactors sets the owners of each refactor from the file the
+// block is now in.
+func (o *Owners) AnnotateRefactors(refactors []Refactor) {
+	for i := range refactors {
+		refactors[i].Owners = o.RangeOwners(refactors[i].ToRange)
+	}
+}
@@ -0,0 +1,44 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+func TestOwners(t *testing.T) {
+	owners, err := ParseOwners(strings.NewReader(`
+# Default owners
+*                  @platform
+
+*.tf               @infra
+/modules/network/  @network @infra # shared
+envs/**/prod.tf    @sre
+`))
+	if err != nil {
+		t.Fatal("parse owners:", err)
+	}
+
+	tests := map[string]string{
+		"README.md":                     "@platform",
+		"main.tf":                       "@infra",
+		"modules/network/main.tf":       "@network @infra",
+		"modules/network/sub/vars.hcl":  "@network @infra",
+		"modules/compute/main.tf":       "@infra",
+		"envs/us/east/prod.tf":          "@sre",
+		"envs/prod.tf":                  "@sre",
+		"other/modules/network/main.tf": "@infra",
+	}
+	for file, expected := range tests {
+		if actual := strings.Join(owners.Match(file), " "); actual != expected {
+			t.Errorf("%s: expected %q, got %q", file, expected, actual)
+		}
+	}
+
+	findings := []Finding{{Rule: "r", Range: hcl.Range{Filename: "modules/network/main.tf"}}}
+	owners.Annotate(findings)
+	if strings.Join(findings[0].Owners, " ") != "@network @infra" {
+		t.Errorf("expected the finding to be annotated with its owners, got %q", findings[0].Owners)
+	}
+}
@@ -0,0 +1,78 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// PlanLink links an entry of resource_changes in a Terraform JSON plan to
+// the resource or data block it was planned from. Index holds the count or
+// for_each key of the instance, and is omitted for resources which aren't
+// expanded.
+type PlanLink struct {
+	Address  string          `json:"address"`
+	Resource string          `json:"resource"`
+	Index    json.RawMessage `json:"index,omitempty"`
+	Range    hcl.Range       `json:"range"`
+}
+
+// planChange holds the fields of a resource_changes entry needed to find
+// its configuration.
+type planChange struct {
+	Address       string          `json:"address"`
+	ModuleAddress string          `json:"module_address"`
+	Mode          string          `json:"mode"`
+	Type          string          `json:"type"`
+	Name          string          `json:"name"`
+	Index         json.RawMessage `json:"index"`
+}
+
+// PlanLinks links every resource change in plan, the output of terraform
+// show -json, to its block in a root module file, so findings reported
+// against plan addresses such as aws_instance.web[0] can be shown at their
+// position in the configuration. Changes in child modules, or for
+// resources declared in other files, are left out.
+func PlanLinks(file *hcl.File, plan []byte) ([]PlanLink, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		ResourceChanges []planChange `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(plan, &parsed); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+
+	ranges := make(map[string]hcl.Range)
+	for _, block := range body.Blocks {
+		if (block.Type == "resource" || block.Type == "data") && len(block.Labels) == 2 {
+			ranges[blockAddress(block)] = block.DefRange()
+		}
+	}
+
+	var links []PlanLink
+	for _, change := range parsed.ResourceChanges {
+		if change.ModuleAddress != "" {
+			continue
+		}
+		resource := change.Type + "." + change.Name
+		if change.Mode == "data" {
+			resource = "data." + resource
+		}
+		rng, ok := ranges[resource]
+		if !ok {
+			continue
+		}
+		links = append(links, PlanLink{
+			Address:  change.Address,
+			Resource: resource,
+			Index:    change.Index,
+			Range:    rng,
+		})
+	}
+	return links, nil
+}
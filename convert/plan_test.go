@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestPlanLinks(t *testing.T) {
+	input := `
+resource "aws_instance" "web" {
+	count = 2
+}
+
+resource "aws_s3_bucket" "logs" {
+	for_each = toset(["a"])
+}
+
+data "aws_ami" "ubuntu" {}`
+
+	plan := `{
+	"resource_changes": [
+		{"address": "aws_instance.web[0]", "mode": "managed", "type": "aws_instance", "name": "web", "index": 0},
+		{"address": "aws_instance.web[1]", "mode": "managed", "type": "aws_instance", "name": "web", "index": 1},
+		{"address": "aws_s3_bucket.logs[\"a\"]", "mode": "managed", "type": "aws_s3_bucket", "name": "logs", "index": "a"},
+		{"address": "data.aws_ami.ubuntu", "mode": "data", "type": "aws_ami", "name": "ubuntu"},
+		{"address": "module.vpc.aws_vpc.main", "module_address": "module.vpc", "mode": "managed", "type": "aws_vpc", "name": "main"}
+	]
+}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	links, err := PlanLinks(file, []byte(plan))
+	if err != nil {
+		t.Fatal("plan links:", err)
+	}
+
+	expected := []struct {
+		address, resource, index string
+		line                     int
+	}{
+		{"aws_instance.web[0]", "aws_instance.web", "0", 2},
+		{"aws_instance.web[1]", "aws_instance.web", "1", 2},
+		{`aws_s3_bucket.logs["a"]`, "aws_s3_bucket.logs", `"a"`, 6},
+		{"data.aws_ami.ubuntu", "data.aws_ami.ubuntu", "", 10},
+	}
+	if len(links) != len(expected) {
+		t.Fatalf("expected %d links, got %+v", len(expected), links)
+	}
+	for i, e := range expected {
+		link := links[i]
+		if link.Address != e.address || link.Resource != e.resource || string(link.Index) != e.index || link.Range.Start.Line != e.line {
+			t.Errorf("expected %+v, got %s %s %s line %d", e, link.Address, link.Resource, link.Index, link.Range.Start.Line)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package convert
+
+import (
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// dialectFunctions returns the pure functions the dialect has beyond those
+// in the evaluation context. Nomad has Terraform's functions too.
+func dialectFunctions(dialect Dialect) map[string]function.Function {
+	if dialect == DialectTerraform || dialect == DialectTerragrunt || dialect == DialectNomad {
+		return terraformFunctions
+	}
+	return nil
+}
+
+// knownFunctions returns the set of functions which may be evaluated for the
+// given dialect, before any the caller explicitly allows.
+func knownFunctions(dialect Dialect) map[string]bool {
+	known := make(map[string]bool)
+	for name := range evalContext.Functions {
+		known[name] = true
+	}
+	for name := range dialectFunctions(dialect) {
+		known[name] = true
+	}
+	if dialect == DialectTerragrunt {
+		for _, name := range terragruntFunctions {
+			known[name] = true
+		}
+	}
+	return known
+}
+
+// allowedFunctions returns the dialect's known functions together with those
+// allowed through the options.
+func (c *converter) allowedFunctions() map[string]bool {
+	if c.allowed == nil {
+		c.allowed = knownFunctions(c.options.Dialect)
+		for _, name := range c.options.AllowFunctions {
+			c.allowed[name] = true
+		}
+	}
+	return c.allowed
+}
+
+// allowEvaluation reports whether every function called by expr may be
+// evaluated under the current options. Each disallowed call is recorded as
+// a warning once, and the expression is left for wrapping.
+func (c *converter) allowEvaluation(expr hclsyntax.Expression) bool {
+	functions := c.allowedFunctions()
+
+	allowed := true
+	hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+		call, ok := node.(*hclsyntax.FunctionCallExpr)
+		if !ok || functions[call.Name] {
+			return nil
+		}
+		allowed = false
+		if c.disallowed[call.NameRange] {
+			return nil
+		}
+		if c.disallowed == nil {
+			c.disallowed = make(map[hcl.Range]bool)
+		}
+		c.disallowed[call.NameRange] = true
+		c.diagnose(hcl.DiagWarning, call.NameRange, "function-not-allowed",
+			"function", quoted(call.Name), "dialect", c.options.Dialect.String())
+		return nil
+	})
+	return allowed
+}
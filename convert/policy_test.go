@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+func TestFunctionPolicy(t *testing.T) {
+	input := `locals {
+		known = upper("a")
+		lookup = lookup(var.m, "k")
+		unsafe = timestamp()
+	}`
+
+	expected := `{
+	"locals": [
+		{
+			"known": "A",
+			"lookup": "${lookup(var.m, \"k\")}",
+			"unsafe": "${timestamp()}"
+		}
+	]
+}`
+
+	result, err := Convert([]byte(input), "", Options{Simplify: true, Dialect: DialectTerraform})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	compareTest(t, result.JSON, expected)
+
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("expected a single diagnostic, got %v", result.Diagnostics)
+	}
+	diag := result.Diagnostics[0]
+	if diag.Severity != hcl.DiagWarning || !strings.Contains(diag.Detail, `"timestamp"`) {
+		t.Errorf("unexpected diagnostic: %v", diag)
+	}
+}
+
+func TestTerraformFunctions(t *testing.T) {
+	input := `
+replaced = replace("a-b-c", "/-[a-z]$/", "")
+hashed   = sha256("hello")
+encoded  = base64decode(base64encode("hello"))
+prefixed = startswith("hello", "he")
+fallback = try(tonumber("x"), 0)
+ids      = lookup({ a = 1 }, "a", 0)`
+
+	result, err := Convert([]byte(input), "", Options{Simplify: true, Dialect: DialectTerraform})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"replaced": "a-b",
+	"hashed": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	"encoded": "hello",
+	"prefixed": true,
+	"fallback": 0,
+	"ids": 1
+}`))
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", result.Diagnostics)
+	}
+
+	// they're not known to the default dialect
+	result, err = Convert([]byte(`x = upper("a")`), "", Options{Simplify: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	compareTest(t, result.JSON, `{
+	"x": "${upper(\"a\")}"
+}`)
+}
+
+func TestFunctionPolicyNested(t *testing.T) {
+	input := `x = { a = [[timestamp()]] }`
+
+	result, err := Convert([]byte(input), "", Options{Simplify: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Diagnostics) != 1 {
+		t.Fatalf("expected a single diagnostic, got %v", result.Diagnostics)
+	}
+}
+
+func TestFunctionPolicyAllow(t *testing.T) {
+	input := `x = timestamp()`
+
+	clock := func() time.Time { return time.Unix(0, 0) }
+	result, err := Convert([]byte(input), "", Options{Simplify: true, AllowFunctions: []string{"timestamp"}, Clock: clock})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	compareTest(t, result.JSON, `{
+	"x": "1970-01-01T00:00:00Z"
+}`)
+
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", result.Diagnostics)
+	}
+}
+
+func TestImpureFunctions(t *testing.T) {
+	input := `locals {
+		now = timestamp()
+		id = uuid()
+	}`
+
+	options := Options{
+		Simplify:       true,
+		AllowFunctions: []string{"timestamp", "uuid"},
+		Clock: func() time.Time {
+			return time.Date(2021, 4, 1, 12, 30, 0, 0, time.UTC)
+		},
+		RandSource: bytes.NewReader(make([]byte, 16)),
+	}
+
+	expected := `{
+	"locals": [
+		{
+			"id": "00000000-0000-4000-8000-000000000000",
+			"now": "2021-04-01T12:30:00Z"
+		}
+	]
+}`
+
+	result, err := Convert([]byte(input), "", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	compareTest(t, result.JSON, expected)
+}
+
+func TestFileFunctionsSandbox(t *testing.T) {
+	fsys := fstest.MapFS{
+		"greeting.txt":  {Data: []byte("hello")},
+		"greeting.tmpl": {Data: []byte("${strrev(name)}, ${file(\"greeting.txt\")}")},
+	}
+
+	input := `locals {
+		contents = file("./greeting.txt")
+		exists = fileexists("missing.txt")
+		rendered = templatefile("greeting.tmpl", { name = "world" })
+		escaped = file("../../etc/passwd")
+	}`
+
+	options := Options{
+		Simplify:       true,
+		AllowFunctions: []string{"file", "fileexists", "templatefile"},
+		FS:             fsys,
+	}
+
+	expected := `{
+	"locals": [
+		{
+			"contents": "hello",
+			"escaped": "${file(\"../../etc/passwd\")}",
+			"exists": false,
+			"rendered": "dlrow, hello"
+		}
+	]
+}`
+
+	result, err := Convert([]byte(input), "", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	compareTest(t, result.JSON, expected)
+}
+
+func TestTemplateFileRecursion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"self.tmpl": {Data: []byte(`${templatefile("self.tmpl", {})}`)},
+	}
+
+	input := `rendered = templatefile("self.tmpl", {})`
+	options := Options{
+		Simplify:       true,
+		AllowFunctions: []string{"templatefile"},
+		FS:             fsys,
+	}
+
+	result, err := Convert([]byte(input), "", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	compareTest(t, result.JSON, `{
+	"rendered": "${templatefile(\"self.tmpl\", {})}"
+}`)
+}
@@ -0,0 +1,42 @@
+package convert
+
+import "fmt"
+
+// OutputProcessor rewrites the JSON output of a conversion once it has been
+// marshalled, such as to embed it in an envelope document or to add a
+// checksum.
+type OutputProcessor interface {
+	// Name identifies the processor in the options fingerprint.
+	Name() string
+
+	Process(output []byte) ([]byte, error)
+}
+
+type outputProcessorFunc struct {
+	name string
+	fn   func([]byte) ([]byte, error)
+}
+
+func (p outputProcessorFunc) Name() string { return p.name }
+
+func (p outputProcessorFunc) Process(output []byte) ([]byte, error) {
+	return p.fn(output)
+}
+
+// OutputProcessorFunc returns an OutputProcessor with the given name which
+// calls fn.
+func OutputProcessorFunc(name string, fn func([]byte) ([]byte, error)) OutputProcessor {
+	return outputProcessorFunc{name: name, fn: fn}
+}
+
+// postProcess runs output through each of Options.PostProcessors in order.
+func (o Options) postProcess(output []byte) ([]byte, error) {
+	for _, processor := range o.PostProcessors {
+		var err error
+		output, err = processor.Process(output)
+		if err != nil {
+			return nil, fmt.Errorf("post-process %s: %w", processor.Name(), err)
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,44 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPostProcessors(t *testing.T) {
+	envelope := OutputProcessorFunc("envelope", func(output []byte) ([]byte, error) {
+		return []byte(fmt.Sprintf(`{"kind":"config","data":%s}`, output)), nil
+	})
+	var checksum string
+	sum := OutputProcessorFunc("checksum", func(output []byte) ([]byte, error) {
+		h := sha256.Sum256(output)
+		checksum = hex.EncodeToString(h[:])
+		return output, nil
+	})
+
+	result, err := Convert([]byte(`name = "web"`), "main.tf", Options{PostProcessors: []OutputProcessor{envelope, sum}})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	expected := `{"kind":"config","data":{"name":"web"}}`
+	if string(result.JSON) != expected {
+		t.Errorf("expected %s, got %s", expected, result.JSON)
+	}
+	if h := sha256.Sum256(result.JSON); checksum != hex.EncodeToString(h[:]) {
+		t.Errorf("expected the checksum of the enveloped output, got %s", checksum)
+	}
+
+	failing := OutputProcessorFunc("failing", func([]byte) ([]byte, error) {
+		return nil, errors.New("boom")
+	})
+	if _, err := Convert([]byte(`name = "web"`), "main.tf", Options{PostProcessors: []OutputProcessor{failing}}); err == nil {
+		t.Error("expected a failing post-processor to fail the conversion")
+	}
+
+	if (Options{PostProcessors: []OutputProcessor{envelope}}).Fingerprint() == (Options{PostProcessors: []OutputProcessor{sum}}).Fingerprint() {
+		t.Error("expected different post-processors to change the fingerprint")
+	}
+}
@@ -0,0 +1,110 @@
+package convert
+
+import (
+	"sort"
+
+	"github.com/ckndave/hclparser/addr"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Outcomes of simplifying an expression, as predicted by Preview.
+const (
+	PreviewEvaluated = "evaluated"
+	PreviewWrapped   = "wrapped"
+)
+
+// PreviewEntry predicts what simplifying would do to a single attribute's
+// expression.
+type PreviewEntry struct {
+	Attribute  string    `json:"attribute"`
+	Range      hcl.Range `json:"range"`
+	Expression string    `json:"expression"`
+	Outcome    string    `json:"outcome"`
+
+	// Functions are the functions the expression calls, and Impure those
+	// of them with side effects, such as reading files or the clock.
+	Functions []string `json:"functions,omitempty"`
+	Impure    []string `json:"impure,omitempty"`
+
+	// Disallowed are the functions which keep the expression wrapped
+	// because they aren't allowed or can't be evaluated, and Unknown the
+	// variables it refers to which aren't in Options.Variables.
+	Disallowed []string `json:"disallowed,omitempty"`
+	Unknown    []string `json:"unknown,omitempty"`
+}
+
+// Preview reports, without evaluating anything, which expressions in a file
+// would be evaluated if Simplify were turned on with the given options and
+// which functions they'd call, and which would stay wrapped and why. Only
+// expressions which call functions or refer to variables are included, as
+// others are converted the same either way. Evaluation can still fail for
+// expressions predicted to be evaluated, such as when a function is given
+// an invalid argument, in which case they're wrapped.
+func Preview(file *hcl.File, options Options) ([]PreviewEntry, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := converter{bytes: file.Bytes, options: options}
+	allowed := c.allowedFunctions()
+	impure := impureFunctions(options)
+	implemented := func(name string) bool {
+		_, pure := evalContext.Functions[name]
+		_, dialect := dialectFunctions(options.Dialect)[name]
+		_, ok := impure[name]
+		return pure || dialect || ok || name == "templatefile"
+	}
+
+	var entries []PreviewEntry
+	for _, attr := range attributes(body) {
+		expr := attr.attr.Expr
+		functions := make(map[string]bool)
+		hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+				functions[call.Name] = true
+			}
+			return nil
+		})
+		traversals := expr.Variables()
+		if len(functions) == 0 && len(traversals) == 0 {
+			continue
+		}
+
+		entry := PreviewEntry{
+			Attribute:  attr.pathString(),
+			Range:      expr.Range(),
+			Expression: c.rangeSource(expr.Range()),
+		}
+		for name := range functions {
+			entry.Functions = append(entry.Functions, name)
+			if _, ok := impure[name]; ok || name == "templatefile" {
+				entry.Impure = append(entry.Impure, name)
+			}
+			if !allowed[name] || !implemented(name) {
+				entry.Disallowed = append(entry.Disallowed, name)
+			}
+		}
+		unknown := make(map[string]bool)
+		for _, traversal := range traversals {
+			if _, ok := options.Variables[traversal.RootName()]; !ok {
+				unknown[addr.String(traversal)] = true
+			}
+		}
+		for name := range unknown {
+			entry.Unknown = append(entry.Unknown, name)
+		}
+		sort.Strings(entry.Functions)
+		sort.Strings(entry.Impure)
+		sort.Strings(entry.Disallowed)
+		sort.Strings(entry.Unknown)
+
+		entry.Outcome = PreviewEvaluated
+		if len(entry.Disallowed) > 0 || len(entry.Unknown) > 0 {
+			entry.Outcome = PreviewWrapped
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
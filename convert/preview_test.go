@@ -0,0 +1,56 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestPreview(t *testing.T) {
+	file, diags := hclsyntax.ParseConfig([]byte(`
+a = "plain"
+b = max(1, 2)
+c = join("-", [var.env, "web"])
+d = upper(var.env)
+e = lookup(local.tags, "name")
+f = file("key.pem")
+`), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse:", diags)
+	}
+
+	entries, err := Preview(file, Options{
+		Dialect:        DialectTerraform,
+		AllowFunctions: []string{"file"},
+		Variables:      map[string]cty.Value{"var": cty.ObjectVal(map[string]cty.Value{"env": cty.StringVal("prod")})},
+	})
+	if err != nil {
+		t.Fatal("preview:", err)
+	}
+
+	type entry struct {
+		attribute, outcome            string
+		functions, impure, disallowed []string
+		unknown                       []string
+	}
+	var got []entry
+	for _, e := range entries {
+		got = append(got, entry{e.Attribute, e.Outcome, e.Functions, e.Impure, e.Disallowed, e.Unknown})
+	}
+	expected := []entry{
+		{"b", PreviewEvaluated, []string{"max"}, nil, nil, nil},
+		{"c", PreviewEvaluated, []string{"join"}, nil, nil, nil},
+		{"d", PreviewEvaluated, []string{"upper"}, nil, nil, nil},
+		{"e", PreviewWrapped, []string{"lookup"}, nil, nil, []string{"local.tags"}},
+		{"f", PreviewEvaluated, []string{"file"}, []string{"file"}, nil, nil},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if entries[1].Expression != `join("-", [var.env, "web"])` {
+		t.Errorf("unexpected expression %q", entries[1].Expression)
+	}
+}
@@ -0,0 +1,258 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// profile reshapes converted output into the JSON an application expects of
+// its configuration, for dialects such as DialectNomad and DialectVault.
+// The line info is reshaped alongside, so it keeps mirroring the output.
+type profile struct {
+	// rename gives the output name of attributes and blocks a shape
+	// doesn't name. When nil, names are kept.
+	rename func(name string) string
+
+	// root describes the body of the file.
+	root *shape
+}
+
+// shape describes how a body is reshaped. Attributes keep their values,
+// and only their names change.
+type shape struct {
+	// names are the output names of attributes and blocks, overriding
+	// the profile's rename.
+	names map[string]string
+
+	// blocks describes how nested blocks are reshaped, by type. Blocks of
+	// other types are kept as they are.
+	blocks map[string]*blockShape
+
+	// durations are the attributes holding durations such as "30s", which
+	// are written as a number of nanoseconds.
+	durations map[string]bool
+
+	// post adjusts the reshaped body and its line info.
+	post func(body, lines map[string]interface{})
+}
+
+// blockShape describes how the blocks of a type are reshaped.
+type blockShape struct {
+	// labels are the names the labels of each block are written under in
+	// its body. An empty name drops the label.
+	labels []string
+
+	// single writes the first block of the type as an object, rather than
+	// all of them as a list.
+	single bool
+
+	// keyed writes the blocks as an object keyed by their first label.
+	keyed bool
+
+	// body describes the body of each block. When nil, bodies are kept
+	// as they are.
+	body *shape
+}
+
+// profile returns the profile of the dialect, or nil when its output keeps
+// the generic structure.
+func (d Dialect) profile() *profile {
+	switch d {
+	case DialectNomad:
+		return nomadProfile
+	case DialectVault:
+		return vaultProfile
+	case DialectConsul:
+		return consulProfile
+	default:
+		return nil
+	}
+}
+
+// apply reshapes converted output and its line info, whose keys have
+// prefix.
+func (p *profile) apply(cfg jsonObj, lines lineObj, prefix string) (map[string]interface{}, map[string]interface{}, error) {
+	var body, bodyLines map[string]interface{}
+	if err := decodedCopy(cfg, &body); err != nil {
+		return nil, nil, err
+	}
+	if err := decodedCopy(lines, &bodyLines); err != nil {
+		return nil, nil, err
+	}
+	out, outLines := p.reshape(p.root, body, bodyLines, prefix, nil)
+	return out, outLines, nil
+}
+
+// decodedCopy copies value into out by way of its JSON encoding, so it's
+// made up only of the types decodeJSON produces.
+func decodedCopy(value, out interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+	return decodeJSON(b, out)
+}
+
+func (p *profile) name(s *shape, key string) string {
+	if name, ok := s.names[key]; ok {
+		return name
+	}
+	if p.rename != nil {
+		return p.rename(key)
+	}
+	return key
+}
+
+// reshape reshapes a body and its line info, adding the labels of its
+// block, keyed by the names they're written under.
+func (p *profile) reshape(s *shape, body, lines map[string]interface{}, prefix string, labels map[string]string) (map[string]interface{}, map[string]interface{}) {
+	out := make(map[string]interface{}, len(body))
+	outLines := make(map[string]interface{}, len(lines))
+	for key, value := range lines {
+		if isLineMeta(key, value, prefix) {
+			outLines[key] = value
+		}
+	}
+
+	for key, value := range body {
+		name := p.name(s, key)
+		if block, ok := s.blocks[key]; ok {
+			out[name], outLines[name] = p.reshapeBlocks(block, value, lines[key], prefix)
+			if out[name] == nil {
+				delete(out, name)
+				delete(outLines, name)
+			}
+			continue
+		}
+		if s.durations[key] {
+			value = durationValue(value)
+		}
+		out[name] = value
+		if line, ok := lines[key].(map[string]interface{}); ok {
+			outLines[name] = line
+		}
+	}
+	for name, label := range labels {
+		out[name] = label
+	}
+	if s.post != nil {
+		s.post(out, outLines)
+	}
+	return out, outLines
+}
+
+// reshapeBlocks reshapes the list of blocks of a type, with its line info.
+func (p *profile) reshapeBlocks(block *blockShape, value, lines interface{}, prefix string) (interface{}, interface{}) {
+	elems, _ := value.([]interface{})
+	elemLines, _ := lines.([]interface{})
+
+	var bodies, bodyLines []interface{}
+	var keys []string
+	for i, elem := range elems {
+		var line interface{}
+		if i < len(elemLines) {
+			line = elemLines[i]
+		}
+		unlabel(len(block.labels), nil, elem, line, func(labels []string, body, lines map[string]interface{}) {
+			named := make(map[string]string)
+			for i, name := range block.labels {
+				if name != "" {
+					named[name] = labels[i]
+				}
+			}
+			if block.body != nil {
+				body, lines = p.reshape(block.body, body, lines, prefix, named)
+			} else {
+				for name, label := range named {
+					body[name] = label
+				}
+			}
+			if len(labels) > 0 {
+				keys = append(keys, labels[0])
+			}
+			bodies = append(bodies, body)
+			bodyLines = append(bodyLines, lines)
+		})
+	}
+
+	switch {
+	case len(bodies) == 0:
+		return nil, nil
+	case block.single:
+		return bodies[0], bodyLines[0]
+	case block.keyed:
+		keyed := make(map[string]interface{}, len(bodies))
+		keyedLines := make(map[string]interface{}, len(bodies))
+		for i, key := range keys {
+			keyed[key], keyedLines[key] = bodies[i], bodyLines[i]
+		}
+		return keyed, keyedLines
+	default:
+		return bodies, bodyLines
+	}
+}
+
+// unlabel calls fn with the labels and body of each block nested under
+// count levels of labels in elem, as written in OutputSchemaV1.
+func unlabel(count int, labels []string, elem, lines interface{}, fn func(labels []string, body, lines map[string]interface{})) {
+	body, ok := elem.(map[string]interface{})
+	if !ok {
+		return
+	}
+	bodyLines, _ := lines.(map[string]interface{})
+	if bodyLines == nil {
+		bodyLines = make(map[string]interface{})
+	}
+	if count == 0 {
+		fn(labels, body, bodyLines)
+		return
+	}
+	keys := make([]string, 0, len(body))
+	for key := range body {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		unlabel(count-1, append(labels[:len(labels):len(labels)], key), body[key], bodyLines[key], fn)
+	}
+}
+
+// isLineMeta reports whether the entry of line info under key describes the
+// entry itself, rather than a child.
+func isLineMeta(key string, value interface{}, prefix string) bool {
+	if !strings.HasPrefix(key, prefix) || !lineMetaKeys[strings.TrimPrefix(key, prefix)] {
+		return false
+	}
+	_, child := value.(map[string]interface{})
+	return !child
+}
+
+// durationValue returns a duration such as "30s" as a number of
+// nanoseconds, leaving anything else, such as an expression, as it is.
+func durationValue(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return value
+	}
+	return json.Number(strconv.FormatInt(int64(d), 10))
+}
+
+// pascalCase converts a snake_case name, such as max_parallel, to
+// PascalCase, such as MaxParallel.
+func pascalCase(name string) string {
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
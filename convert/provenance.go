@@ -0,0 +1,50 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Version is the converter version recorded in provenance headers. Builds
+// set it with -ldflags "-X github.com/ckndave/hclparser/convert.Version=...".
+var Version = "dev"
+
+// ProvenanceKey is the top-level key the provenance header is written
+// under when Options.Provenance is set.
+const ProvenanceKey = "__provenance__"
+
+// Provenance describes how a converted file was produced, so artifacts kept
+// long after conversion can be traced back to their input and reproduced.
+type Provenance struct {
+	Tool        string `json:"tool"`
+	Version     string `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+	SHA256      string `json:"sha256"`
+	ConvertedAt string `json:"converted_at"`
+}
+
+func newProvenance(file *hcl.File, options Options) Provenance {
+	return sourceProvenance(file.Bytes, file.Body.MissingItemRange().Filename, options)
+}
+
+// sourceProvenance returns the provenance of a file converted from src.
+func sourceProvenance(src []byte, filename string, options Options) Provenance {
+	clock := options.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	sum := sha256.Sum256(src)
+
+	return Provenance{
+		Tool:        "hclparser",
+		Version:     Version,
+		Fingerprint: options.Fingerprint(),
+		File:        filename,
+		SHA256:      hex.EncodeToString(sum[:]),
+		ConvertedAt: clock().UTC().Format(time.RFC3339),
+	}
+}
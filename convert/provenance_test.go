@@ -0,0 +1,40 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestProvenance(t *testing.T) {
+	options := Options{
+		Provenance: true,
+		Clock: func() time.Time {
+			return time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+		},
+	}
+	jsonBytes, _, err := Bytes([]byte(`x = 1`), "main.hcl", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	var out struct {
+		X          int        `json:"x"`
+		Provenance Provenance `json:"__provenance__"`
+	}
+	if err := json.Unmarshal(jsonBytes, &out); err != nil {
+		t.Fatal("unmarshal:", err)
+	}
+
+	expected := Provenance{
+		Tool:        "hclparser",
+		Version:     Version,
+		Fingerprint: options.Fingerprint(),
+		File:        "main.hcl",
+		SHA256:      "8ff436def1451285599a1b1ad70800493b8dcafde2912e1a38345633054e4c26",
+		ConvertedAt: "2021-03-04T05:06:07Z",
+	}
+	if out.X != 1 || out.Provenance != expected {
+		t.Errorf("expected %+v, got %+v", expected, out.Provenance)
+	}
+}
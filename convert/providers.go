@@ -0,0 +1,57 @@
+package convert
+
+import (
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ProviderLink links a resource or data block to the provider configuration
+// it will use. ProviderRange is nil when the provider isn't configured in
+// the file, such as a default provider configuration left implicit.
+type ProviderLink struct {
+	Address       string     `json:"address"`
+	Range         hcl.Range  `json:"range"`
+	Provider      string     `json:"provider"`
+	ProviderRange *hcl.Range `json:"provider_range,omitempty"`
+}
+
+// ProviderLinks resolves the provider configuration used by every resource
+// and data block in a Terraform file, following provider arguments such as
+// aws.west to the provider block with the matching alias.
+func ProviderLinks(file *hcl.File) ([]ProviderLink, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make(map[string]hcl.Range)
+	for _, block := range body.Blocks {
+		if block.Type != "provider" || len(block.Labels) != 1 {
+			continue
+		}
+		name := block.Labels[0]
+		if attr, ok := block.Body.Attributes["alias"]; ok {
+			if alias, diags := attr.Expr.Value(nil); !diags.HasErrors() && alias.Type() == cty.String {
+				name += "." + alias.AsString()
+			}
+		}
+		configs[name] = block.DefRange()
+	}
+
+	var links []ProviderLink
+	for _, block := range body.Blocks {
+		if (block.Type != "resource" && block.Type != "data") || len(block.Labels) != 2 {
+			continue
+		}
+		link := ProviderLink{
+			Address:  blockAddress(block),
+			Range:    block.DefRange(),
+			Provider: providerOf(block),
+		}
+		if rng, ok := configs[link.Provider]; ok {
+			link.ProviderRange = &rng
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestProviderLinks(t *testing.T) {
+	input := `
+provider "aws" {
+	region = "us-east-1"
+}
+
+provider "aws" {
+	alias  = "west"
+	region = "us-west-2"
+}
+
+resource "aws_instance" "east" {}
+
+resource "aws_instance" "west" {
+	provider = aws.west
+}
+
+data "google_project" "current" {}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	links, err := ProviderLinks(file)
+	if err != nil {
+		t.Fatal("provider links:", err)
+	}
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links, got %+v", links)
+	}
+
+	expected := []struct {
+		address, provider string
+		line              int
+	}{
+		{"aws_instance.east", "aws", 2},
+		{"aws_instance.west", "aws.west", 6},
+		{"data.google_project.current", "google", 0},
+	}
+	for i, e := range expected {
+		link := links[i]
+		if link.Address != e.address || link.Provider != e.provider {
+			t.Errorf("expected %s to use %s, got %+v", e.address, e.provider, link)
+		}
+		switch {
+		case e.line == 0 && link.ProviderRange != nil:
+			t.Errorf("expected %s to have no provider configuration, got %v", e.address, link.ProviderRange)
+		case e.line != 0 && (link.ProviderRange == nil || link.ProviderRange.Start.Line != e.line):
+			t.Errorf("expected %s to link to line %d, got %v", e.address, e.line, link.ProviderRange)
+		}
+	}
+}
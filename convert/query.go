@@ -0,0 +1,52 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ckndave/hclparser/query"
+)
+
+// QueryMatch is a value selected by Query. Pointer is its JSON pointer in
+// the output, and Range its position in the source, when the line info
+// has one.
+type QueryMatch struct {
+	Pointer string      `json:"pointer"`
+	Value   interface{} `json:"value"`
+	Range   *FlatLine   `json:"range,omitempty"`
+}
+
+// Query returns the values of converted output selected by a query such as
+// resource.aws_instance.*.ami, as described by query.Path, with their
+// positions from its nested line info. lines may be nil, leaving the
+// matches without ranges.
+func Query(output, lines []byte, q string) ([]QueryMatch, error) {
+	path, err := query.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := decodeJSON(output, &doc); err != nil {
+		return nil, fmt.Errorf("decode output: %w", err)
+	}
+	var flat map[string]FlatLine
+	if lines != nil {
+		b, err := FlattenLines(lines)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &flat); err != nil {
+			return nil, fmt.Errorf("decode flat line info: %w", err)
+		}
+	}
+
+	var matches []QueryMatch
+	for _, m := range path.Find(doc) {
+		match := QueryMatch{Pointer: m.Pointer, Value: m.Value}
+		if line, ok := flat[m.Pointer]; ok {
+			match.Range = &line
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	src := []byte(`resource "aws_instance" "web" {
+  ami = "ami-1"
+}
+
+resource "aws_instance" "db" {
+  ami = "ami-2"
+}
+`)
+	result, err := Convert(src, "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	matches, err := Query(result.JSON, result.Lines, "resource.aws_instance.*.ami")
+	if err != nil {
+		t.Fatal("query:", err)
+	}
+
+	got, err := json.Marshal(matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameJSON(t, got, []byte(`[
+		{
+			"pointer": "/resource/0/aws_instance/web/ami", "value": "ami-1",
+			"range": {"line": 2, "endLine": 2, "startCol": 9, "endCol": 16, "key": {"line": 2, "endLine": 2, "startCol": 3, "endCol": 6}}
+		},
+		{
+			"pointer": "/resource/1/aws_instance/db/ami", "value": "ami-2",
+			"range": {"line": 6, "endLine": 6, "startCol": 9, "endCol": 16, "key": {"line": 6, "endLine": 6, "startCol": 3, "endCol": 6}}
+		}
+	]`))
+
+	if matches, err := Query(result.JSON, nil, "resource.aws_instance.web.ami"); err != nil || len(matches) != 1 || matches[0].Range != nil {
+		t.Errorf("expected one match without a range, got %v, %v", matches, err)
+	}
+	if _, err := Query(result.JSON, nil, "resource..ami"); err == nil {
+		t.Error("expected an invalid query to fail")
+	}
+}
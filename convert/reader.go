@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// FromReader converts the HCL read from r, as Convert converts it from a
+// byte slice. The parser needs the whole file, so r is read to the end
+// before converting.
+func FromReader(r io.Reader, filename string, options Options) (*Result, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+	return Convert(src, filename, options)
+}
+
+// StreamEncoder converts HCL read from an io.Reader, writing the JSON and
+// line info to io.Writers. Each file is read to the end and parsed whole
+// first, so the input is buffered in full. When the options allow and the
+// file is HCL without errors, it's then converted as Stream converts it, so
+// its converted form is never held in memory at once. Otherwise it's
+// converted whole and the result written, encoded with Options.Encoder
+// when set.
+type StreamEncoder struct {
+	w, lineW io.Writer
+	options  Options
+}
+
+// NewStreamEncoder returns a StreamEncoder writing the JSON to w and the
+// line info to lineW, which may be nil to discard it.
+func NewStreamEncoder(w, lineW io.Writer, options Options) *StreamEncoder {
+	if lineW == nil {
+		lineW = ioutil.Discard
+	}
+	return &StreamEncoder{w: w, lineW: lineW, options: options}
+}
+
+// Encode converts the HCL read from r as filename and writes it,
+// returning the diagnostics, messages and findings raised.
+func (e *StreamEncoder) Encode(r io.Reader, filename string) (*StreamResult, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+	if e.options.streamable() == nil && !e.options.AllowErrors && e.options.InputSyntax.detect(src, filename) != InputSyntaxJSON {
+		return stream(src, filename, e.w, e.lineW, e.options)
+	}
+	result, err := Convert(src, filename, e.options)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.w.Write(result.JSON); err != nil {
+		return nil, fmt.Errorf("write json: %w", err)
+	}
+	if _, err := e.lineW.Write(result.Lines); err != nil {
+		return nil, fmt.Errorf("write line info: %w", err)
+	}
+	return &StreamResult{Diagnostics: result.Diagnostics, Messages: result.Messages, Findings: result.Findings}, nil
+}
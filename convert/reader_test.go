@@ -0,0 +1,66 @@
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFromReader(t *testing.T) {
+	src := "a = 1\nb {\n  c = \"d\"\n}\n"
+	result, err := FromReader(strings.NewReader(src), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	want, err := Convert([]byte(src), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, want.JSON)
+	sameJSON(t, result.Lines, want.Lines)
+
+	failed := errors.New("failed")
+	if _, err := FromReader(iotest.ErrReader(failed), "main.tf", Options{}); !errors.Is(err, failed) {
+		t.Errorf("got error %v, want %v", err, failed)
+	}
+}
+
+func TestStreamEncoder(t *testing.T) {
+	src := "b = [1, 2]\na {\n  c = \"d\"\n}\n"
+	tests := map[string]struct {
+		src, filename string
+		options       Options
+	}{
+		"streamed":   {src: src, filename: "main.tf", options: Options{Provenance: true}},
+		"whole":      {src: src, filename: "main.tf", options: Options{PreserveOrder: true}},
+		"flat lines": {src: src, filename: "main.tf", options: Options{LineFormat: LineFormatFlat}},
+		"json input": {src: `{"a": {"c": "d"}}`, filename: "main.tf.json"},
+		"errors":     {src: "a = 1\nb = \n", filename: "main.tf", options: Options{AllowErrors: true}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			want, err := Convert([]byte(test.src), test.filename, test.options)
+			if err != nil {
+				t.Fatal("convert:", err)
+			}
+			var out, lines bytes.Buffer
+			if _, err := NewStreamEncoder(&out, &lines, test.options).Encode(strings.NewReader(test.src), test.filename); err != nil {
+				t.Fatal("encode:", err)
+			}
+			sameJSON(t, out.Bytes(), want.JSON)
+			sameJSON(t, lines.Bytes(), want.Lines)
+		})
+	}
+}
+
+func TestStreamEncoderEncoder(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := NewStreamEncoder(&out, nil, Options{Encoder: MsgpackEncoder}).Encode(strings.NewReader("a = 1\n"), "main.tf"); err != nil {
+		t.Fatal("encode:", err)
+	}
+	if want := []byte{0x81, 0xa1, 'a', 0x01}; !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("got %x, want %x", out.Bytes(), want)
+	}
+}
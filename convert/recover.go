@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"github.com/ckndave/hclparser/edit"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Fix is a suggested change to the source correcting a syntax error,
+// replacing the source within Range with Text.
+type Fix struct {
+	Summary string    `json:"summary"`
+	Range   hcl.Range `json:"range"`
+	Text    string    `json:"text"`
+}
+
+// recoverFile looks for the common mistakes fixed by recoveryFixes in a
+// file with syntax errors. When fixing them leaves no errors, the fixed file
+// is returned in place of the original. Neither fix moves any source before
+// it, so ranges in the fixed file still hold for the original.
+func recoverFile(file *hcl.File, src []byte, filename string, options Options) (*hcl.File, []Fix) {
+	if options.InputSyntax.detect(src, filename) == InputSyntaxJSON {
+		return file, nil
+	}
+	fixes := recoveryFixes(src, filename)
+	if len(fixes) == 0 {
+		return file, nil
+	}
+
+	edits := make([]edit.Edit, len(fixes))
+	for i, fix := range fixes {
+		edits[i] = edit.Edit{Range: fix.Range, Text: fix.Text}
+	}
+	fixed, _, err := edit.Apply(src, edits)
+	if err != nil {
+		return file, fixes
+	}
+	fixedFile, diags := hclsyntax.ParseConfig(fixed, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return file, fixes
+	}
+	return fixedFile, fixes
+}
+
+// recoveryFixes suggests fixes for commas after attributes in a body, as
+// written by those used to JSON, and for brackets left open at the end of
+// the file.
+func recoveryFixes(src []byte, filename string) []Fix {
+	tokens, diags := hclsyntax.LexConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil
+	}
+
+	// open holds the tokens opening the brackets and templates enclosing
+	// the current token, with block braces marked as TokenOBrace and object
+	// braces as TokenCBrace.
+	var (
+		open  []hclsyntax.TokenType
+		fixes []Fix
+		prev  hclsyntax.TokenType
+	)
+	inBody := func() bool {
+		return len(open) == 0 || open[len(open)-1] == hclsyntax.TokenOBrace
+	}
+	for i, token := range tokens {
+		switch token.Type {
+		case hclsyntax.TokenOBrace:
+			if inBody() && (prev == hclsyntax.TokenIdent || prev == hclsyntax.TokenCQuote) {
+				open = append(open, hclsyntax.TokenOBrace)
+			} else {
+				open = append(open, hclsyntax.TokenCBrace)
+			}
+		case hclsyntax.TokenOBrack, hclsyntax.TokenOParen, hclsyntax.TokenOQuote, hclsyntax.TokenOHeredoc,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			open = append(open, token.Type)
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenCQuote,
+			hclsyntax.TokenCHeredoc, hclsyntax.TokenTemplateSeqEnd:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case hclsyntax.TokenComma:
+			if !inBody() || i+1 >= len(tokens) {
+				break
+			}
+			switch tokens[i+1].Type {
+			case hclsyntax.TokenNewline, hclsyntax.TokenComment, hclsyntax.TokenEOF:
+				fixes = append(fixes, Fix{
+					Summary: "Remove the comma after the attribute, as attributes are separated by newlines",
+					Range:   token.Range,
+				})
+			}
+		case hclsyntax.TokenEOF:
+			var closing []byte
+			for j := len(open) - 1; j >= 0; j-- {
+				switch open[j] {
+				case hclsyntax.TokenOBrace, hclsyntax.TokenCBrace:
+					closing = append(closing, '\n', '}')
+				case hclsyntax.TokenOBrack:
+					closing = append(closing, ']')
+				case hclsyntax.TokenOParen:
+					closing = append(closing, ')')
+				default:
+					// an unterminated string or template, which can't be
+					// closed with any confidence
+					return fixes
+				}
+			}
+			if len(closing) > 0 {
+				fixes = append(fixes, Fix{
+					Summary: "Close the brackets left open at the end of the file",
+					Range:   hcl.Range{Filename: filename, Start: token.Range.Start, End: token.Range.Start},
+					Text:    string(closing) + "\n",
+				})
+			}
+		}
+		if token.Type != hclsyntax.TokenNewline && token.Type != hclsyntax.TokenComment {
+			prev = token.Type
+		}
+	}
+	return fixes
+}
@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/agext/levenshtein"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// RefactorThreshold is the similarity above which a removed and an added
+// block are reported as the same block renamed.
+const RefactorThreshold = 0.5
+
+// Refactor is a block which was renamed between two versions of a file,
+// rather than one block being deleted and another added. StateMove is the
+// terraform state mv command which keeps the existing object, and Moved a
+// moved block doing the same from configuration. Both are empty for blocks
+// without state, such as data sources. Owners is set by
+// Owners.AnnotateRefactors.
+type Refactor struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	FromRange  hcl.Range `json:"from_range"`
+	ToRange    hcl.Range `json:"to_range"`
+	Similarity float64   `json:"similarity"`
+	StateMove  string    `json:"state_move,omitempty"`
+	Moved      string    `json:"moved,omitempty"`
+	Owners     []string  `json:"owners,omitempty"`
+}
+
+// Refactors pairs up the blocks removed from before with blocks of the same
+// kind added in after, such as a resource of the same type under a new
+// name. Pairs are scored by how similar their bodies and names are, and the
+// best scoring pairs above RefactorThreshold are returned.
+func Refactors(before, after *hcl.File) ([]Refactor, error) {
+	beforeBody, err := fileBody(before)
+	if err != nil {
+		return nil, err
+	}
+	afterBody, err := fileBody(after)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, added := changedBlocks(beforeBody, afterBody), changedBlocks(afterBody, beforeBody)
+
+	type candidate struct {
+		from, to *hclsyntax.Block
+		score    float64
+	}
+	var candidates []candidate
+	for _, from := range removed {
+		for _, to := range added {
+			if !sameKind(from, to) {
+				continue
+			}
+			score := 0.8*bodySimilarity(from, to) + 0.2*levenshtein.Similarity(blockName(from), blockName(to), nil)
+			if score > RefactorThreshold {
+				candidates = append(candidates, candidate{from: from, to: to, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var refactors []Refactor
+	used := make(map[*hclsyntax.Block]bool)
+	for _, c := range candidates {
+		if used[c.from] || used[c.to] {
+			continue
+		}
+		used[c.from], used[c.to] = true, true
+
+		refactor := Refactor{
+			From:       blockAddress(c.from),
+			To:         blockAddress(c.to),
+			FromRange:  c.from.DefRange(),
+			ToRange:    c.to.DefRange(),
+			Similarity: c.score,
+		}
+		if c.from.Type == "resource" || c.from.Type == "module" {
+			refactor.StateMove = fmt.Sprintf("terraform state mv %s %s", refactor.From, refactor.To)
+			refactor.Moved = fmt.Sprintf("moved {\n  from = %s\n  to   = %s\n}\n", refactor.From, refactor.To)
+		}
+		refactors = append(refactors, refactor)
+	}
+	sort.Slice(refactors, func(i, j int) bool {
+		return refactors[i].ToRange.Start.Byte < refactors[j].ToRange.Start.Byte
+	})
+	return refactors, nil
+}
+
+// changedBlocks returns the blocks of body whose address isn't in other.
+func changedBlocks(body, other *hclsyntax.Body) []*hclsyntax.Block {
+	addresses := make(map[string]bool)
+	for _, block := range other.Blocks {
+		addresses[blockAddress(block)] = true
+	}
+	var blocks []*hclsyntax.Block
+	for _, block := range body.Blocks {
+		if len(block.Labels) > 0 && !addresses[blockAddress(block)] {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// sameKind reports whether two blocks differ only in their name, the last
+// label.
+func sameKind(a, b *hclsyntax.Block) bool {
+	if a.Type != b.Type || len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for i := 0; i < len(a.Labels)-1; i++ {
+		if a.Labels[i] != b.Labels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func blockName(block *hclsyntax.Block) string {
+	return block.Labels[len(block.Labels)-1]
+}
+
+// bodySimilarity returns the Jaccard similarity of the attributes of two
+// blocks, comparing each attribute's path and canonical expression.
+func bodySimilarity(a, b *hclsyntax.Block) float64 {
+	aItems, bItems := bodyItems(a), bodyItems(b)
+	if len(aItems) == 0 && len(bItems) == 0 {
+		return 1
+	}
+	shared := 0
+	for item := range aItems {
+		if bItems[item] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(aItems)+len(bItems)-shared)
+}
+
+func bodyItems(block *hclsyntax.Block) map[string]bool {
+	items := make(map[string]bool)
+	for _, attr := range appendBlockAttributes(nil, block, nil, "") {
+		items[attr.pathString()+"="+SExpr(attr.attr.Expr)] = true
+	}
+	return items
+}
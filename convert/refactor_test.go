@@ -0,0 +1,71 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestRefactors(t *testing.T) {
+	before := `
+resource "aws_instance" "web" {
+	ami           = "ami-123"
+	instance_type = "t2.micro"
+	tags          = { Name = "web" }
+}
+
+resource "aws_s3_bucket" "logs" {
+	bucket = "logs"
+}
+
+data "aws_ami" "ubuntu" {
+	most_recent = true
+}`
+
+	after := `
+resource "aws_instance" "app" {
+	ami           = "ami-123"
+	instance_type = "t2.micro"
+	tags          = { Name = "web" }
+}
+
+resource "aws_s3_bucket" "assets" {
+	bucket = "assets"
+	acl    = "private"
+}
+
+data "aws_ami" "base" {
+	most_recent = true
+}`
+
+	beforeFile, diags := hclsyntax.ParseConfig([]byte(before), "before.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+	afterFile, diags := hclsyntax.ParseConfig([]byte(after), "after.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	refactors, err := Refactors(beforeFile, afterFile)
+	if err != nil {
+		t.Fatal("refactors:", err)
+	}
+	if len(refactors) != 2 {
+		t.Fatalf("expected 2 refactors, got %+v", refactors)
+	}
+
+	web := refactors[0]
+	if web.From != "aws_instance.web" || web.To != "aws_instance.app" || web.ToRange.Start.Line != 2 {
+		t.Errorf("unexpected refactor %+v", web)
+	}
+	if web.StateMove != "terraform state mv aws_instance.web aws_instance.app" {
+		t.Errorf("unexpected state move hint %q", web.StateMove)
+	}
+
+	ami := refactors[1]
+	if ami.From != "data.aws_ami.ubuntu" || ami.To != "data.aws_ami.base" || ami.StateMove != "" || ami.Moved != "" {
+		t.Errorf("unexpected refactor %+v", ami)
+	}
+}
@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"github.com/ckndave/hclparser/addr"
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Reference is a variable traversal made from within an attribute, such as
+// var.region or aws_instance.web.id.
+type Reference struct {
+	// Traversal is the traversal as written, including any index steps.
+	Traversal hcl.Traversal `json:"-"`
+
+	// Name is the traversal written out, such as aws_instance.web.id.
+	Name string `json:"name"`
+
+	// Address is the address of the object referred to, such as
+	// aws_instance.web, and is empty when it isn't declared in
+	// configuration, such as count.index.
+	Address string `json:"address,omitempty"`
+
+	// Attribute is the dot-separated path of the attribute the reference
+	// is made from.
+	Attribute string `json:"attribute"`
+
+	Range hcl.Range `json:"range"`
+}
+
+// References returns every variable traversal made by the attributes of a
+// file, including those in nested blocks, in source order. Variables local
+// to an expression, such as those declared by for expressions, aren't
+// included.
+func References(file *hcl.File) ([]Reference, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []Reference
+	for _, attr := range attributes(body) {
+		for _, traversal := range attr.attr.Expr.Variables() {
+			address, _ := referenceAddress(traversal)
+			refs = append(refs, Reference{
+				Traversal: traversal,
+				Name:      addr.String(traversal),
+				Address:   address,
+				Attribute: attr.pathString(),
+				Range:     traversal.SourceRange(),
+			})
+		}
+	}
+	return refs, nil
+}
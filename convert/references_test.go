@@ -0,0 +1,42 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestReferences(t *testing.T) {
+	file, diags := hclsyntax.ParseConfig([]byte(`resource "aws_eip" "ip" {
+  instance = aws_instance.web.id
+  tags     = { for k, v in var.tags : k => v if k != count.index }
+}
+`), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse:", diags)
+	}
+
+	refs, err := References(file)
+	if err != nil {
+		t.Fatal("references:", err)
+	}
+
+	type ref struct {
+		name, address, attribute string
+		line, column             int
+	}
+	var got []ref
+	for _, r := range refs {
+		got = append(got, ref{r.Name, r.Address, r.Attribute, r.Range.Start.Line, r.Range.Start.Column})
+	}
+	expected := []ref{
+		{"aws_instance.web.id", "aws_instance.web", "resource.aws_eip.ip.instance", 2, 14},
+		{"var.tags", "var.tags", "resource.aws_eip.ip.tags", 3, 28},
+		{"count.index", "", "resource.aws_eip.ip.tags", 3, 54},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
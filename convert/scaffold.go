@@ -0,0 +1,126 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Scaffold returns the source of a skeleton block of the given type and
+// labels, as described by schema. Required attributes are set to their
+// default, their first allowed value or a placeholder for their type, and
+// optional attributes and nested blocks are written commented out, with
+// just the required attributes of the blocks. Deprecated attributes are
+// left out.
+func Scaffold(schema *Schema, blockType string, labels ...string) ([]byte, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("no schema")
+	}
+	block, ok := schema.Blocks[blockType]
+	if !ok {
+		return nil, fmt.Errorf("the schema has no block type %s", blockType)
+	}
+	if len(labels) != len(block.Labels) {
+		return nil, fmt.Errorf("blocks of type %s have %d labels, got %d", blockType, len(block.Labels), len(labels))
+	}
+	body := schema.blockBody(blockType, labels)
+
+	var buf bytes.Buffer
+	writeScaffold(&buf, "", blockType, labels, body, false)
+	return hclwrite.Format(buf.Bytes()), nil
+}
+
+// writeScaffold writes a block with the given body, indented by indent.
+// When commented, the block is commented out and only its required
+// attributes are written.
+func writeScaffold(buf *bytes.Buffer, indent, blockType string, labels []string, body *Schema, commented bool) {
+	prefix := indent
+	if commented {
+		prefix += "# "
+	}
+	buf.WriteString(prefix + blockType)
+	for _, label := range labels {
+		buf.WriteString(" ")
+		buf.Write(hclwrite.TokensForValue(cty.StringVal(label)).Bytes())
+	}
+	if body == nil {
+		buf.WriteString(" {}\n")
+		return
+	}
+	buf.WriteString(" {\n")
+
+	var required, optional []string
+	for name, attr := range body.Attributes {
+		switch {
+		case attr.Required:
+			required = append(required, name)
+		case !attr.Deprecated && !commented:
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(required)
+	sort.Strings(optional)
+
+	for _, name := range required {
+		buf.WriteString(prefix + "  " + scaffoldAttribute(name, body.Attributes[name]) + "\n")
+	}
+	if len(optional) > 0 {
+		if len(required) > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(prefix + "  # Optional:\n")
+		for _, name := range optional {
+			buf.WriteString(prefix + "  # " + scaffoldAttribute(name, body.Attributes[name]) + "\n")
+		}
+	}
+	if !commented {
+		for _, name := range sortedKeys(body.Blocks) {
+			buf.WriteString("\n")
+			writeScaffold(buf, indent+"  ", name, nil, body.Blocks[name].Body, true)
+		}
+	}
+	buf.WriteString(prefix + "}\n")
+}
+
+// scaffoldAttribute returns the assignment of an attribute's placeholder
+// value, followed by a comment listing its allowed values.
+func scaffoldAttribute(name string, attr *AttributeSchema) string {
+	value := attr.Default
+	if value == cty.NilVal && len(attr.Allowed) > 0 {
+		value = attr.Allowed[0]
+	}
+	if value == cty.NilVal {
+		value = placeholder(attr.Type)
+	}
+	line := name + " = " + string(hclwrite.TokensForValue(value).Bytes())
+	if len(attr.Allowed) > 0 {
+		allowed := make([]string, len(attr.Allowed))
+		for i, value := range attr.Allowed {
+			allowed[i] = string(hclwrite.TokensForValue(value).Bytes())
+		}
+		line += " # one of " + strings.Join(allowed, ", ")
+	}
+	return line
+}
+
+// placeholder returns the empty value of a type, or null for any type.
+func placeholder(typ cty.Type) cty.Value {
+	switch {
+	case typ == cty.String:
+		return cty.StringVal("")
+	case typ == cty.Number:
+		return cty.Zero
+	case typ == cty.Bool:
+		return cty.False
+	case typ.IsListType(), typ.IsSetType(), typ.IsTupleType():
+		return cty.EmptyTupleVal
+	case typ.IsMapType(), typ.IsObjectType():
+		return cty.EmptyObjectVal
+	default:
+		return cty.NullVal(cty.DynamicPseudoType)
+	}
+}
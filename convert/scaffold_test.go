@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScaffold(t *testing.T) {
+	schema := &Schema{Blocks: map[string]*BlockSchema{
+		"resource": {Labels: []string{"type", "name"}, LabelBodies: map[string]*Schema{
+			"aws_s3_bucket": {
+				Attributes: map[string]*AttributeSchema{
+					"bucket":        {Type: cty.String, Required: true},
+					"acl":           {Type: cty.String, Allowed: []cty.Value{cty.StringVal("private"), cty.StringVal("public-read")}},
+					"force_destroy": {Type: cty.Bool, Default: cty.False},
+					"tags":          {Type: cty.Map(cty.String)},
+					"region":        {Type: cty.String, Deprecated: true},
+				},
+				Blocks: map[string]*BlockSchema{
+					"logging": {},
+					"versioning": {Body: &Schema{Attributes: map[string]*AttributeSchema{
+						"enabled":    {Type: cty.Bool, Required: true},
+						"mfa_delete": {Type: cty.Bool},
+					}}},
+				},
+			},
+		}},
+	}}
+
+	src, err := Scaffold(schema, "resource", "aws_s3_bucket", "example")
+	if err != nil {
+		t.Fatal("scaffold:", err)
+	}
+	expected := `resource "aws_s3_bucket" "example" {
+  bucket = ""
+
+  # Optional:
+  # acl = "private" # one of "private", "public-read"
+  # force_destroy = false
+  # tags = {}
+
+  # logging {}
+
+  # versioning {
+  #   enabled = false
+  # }
+}
+`
+	if string(src) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, src)
+	}
+
+	if _, err := Scaffold(schema, "resource", "aws_s3_bucket"); err == nil {
+		t.Error("expected a missing label to fail")
+	}
+	if _, err := Scaffold(schema, "module", "x"); err == nil {
+		t.Error("expected an undescribed block type to fail")
+	}
+}
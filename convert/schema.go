@@ -0,0 +1,257 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyconvert "github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Schema describes the attributes and blocks expected within a body.
+type Schema struct {
+	Attributes map[string]*AttributeSchema
+	Blocks     map[string]*BlockSchema
+}
+
+// AttributeSchema describes a single attribute.
+type AttributeSchema struct {
+	// Type is the type of the attribute's value, or cty.NilType when any
+	// value is accepted.
+	Type     cty.Type
+	Required bool
+
+	// Default is the value of the attribute when it is missing from the
+	// source, or cty.NilVal when there is none.
+	Default cty.Value
+
+	// Allowed lists the values the attribute may have, when it's limited
+	// to a set such as the sizes of an instance. Only literal values are
+	// checked against it.
+	Allowed []cty.Value
+
+	// Deprecated marks an attribute which is still accepted but due to be
+	// removed, and is reported with a warning when used.
+	Deprecated bool
+}
+
+// BlockSchema describes a type of block.
+type BlockSchema struct {
+	Labels []string
+	Body   *Schema
+
+	// LabelBodies describes the body of blocks by their first label, for
+	// blocks such as resource whose contents depend on it. Blocks with a
+	// label not listed here are described by Body.
+	LabelBodies map[string]*Schema
+}
+
+// block returns the schema for the body of a block, or nil if it isn't
+// described.
+func (s *Schema) block(block *hclsyntax.Block) *Schema {
+	return s.blockBody(block.Type, block.Labels)
+}
+
+// blockBody returns the schema for the body of a block of the given type
+// and labels, or nil if it isn't described.
+func (s *Schema) blockBody(typeName string, labels []string) *Schema {
+	if s == nil {
+		return nil
+	}
+	schema, ok := s.Blocks[typeName]
+	if !ok {
+		return nil
+	}
+	if len(labels) > 0 {
+		if body, ok := schema.LabelBodies[labels[0]]; ok {
+			return body
+		}
+	}
+	return schema.Body
+}
+
+// writeFingerprint writes a deterministic description of the schema for use
+// by Options.Fingerprint.
+func (s *Schema) writeFingerprint(w io.Writer) {
+	if s == nil {
+		fmt.Fprint(w, "nil")
+		return
+	}
+
+	fmt.Fprint(w, "{")
+	for _, name := range sortedKeys(s.Attributes) {
+		attr := s.Attributes[name]
+		fmt.Fprintf(w, "attr %q required=%t deprecated=%t", name, attr.Required, attr.Deprecated)
+		if attr.Type != cty.NilType {
+			typ, _ := ctyjson.MarshalType(attr.Type)
+			fmt.Fprintf(w, " type=%s", typ)
+		}
+		if attr.Default != cty.NilVal {
+			def, _ := ctyjson.Marshal(attr.Default, attr.Default.Type())
+			fmt.Fprintf(w, " default=%s", def)
+		}
+		for _, allowed := range attr.Allowed {
+			val, _ := ctyjson.Marshal(allowed, allowed.Type())
+			fmt.Fprintf(w, " allowed=%s", val)
+		}
+		fmt.Fprint(w, ";")
+	}
+	for _, name := range sortedKeys(s.Blocks) {
+		block := s.Blocks[name]
+		fmt.Fprintf(w, "block %q labels=%q ", name, block.Labels)
+		block.Body.writeFingerprint(w)
+		for _, label := range sortedKeys(block.LabelBodies) {
+			fmt.Fprintf(w, " label %q ", label)
+			block.LabelBodies[label].writeFingerprint(w)
+		}
+		fmt.Fprint(w, ";")
+	}
+	fmt.Fprint(w, "}")
+}
+
+// validateBody reports the attributes and blocks in body which the current
+// schema doesn't describe, suggesting the closest names it does.
+func (c *converter) validateBody(body *hclsyntax.Body) {
+	if c.schema == nil {
+		return
+	}
+
+	attrNames := sortedKeys(c.schema.Attributes)
+	for _, attr := range sortedAttributes(body) {
+		if schema, ok := c.schema.Attributes[attr.Name]; ok {
+			c.validateValue(attr, schema)
+			continue
+		}
+		c.diagnose(hcl.DiagError, attr.NameRange, "unsupported-argument",
+			"name", quoted(attr.Name), "suggestion", quotedSuggestion(attr.Name, attrNames))
+	}
+
+	for _, name := range attrNames {
+		if !c.schema.Attributes[name].Required || body.Attributes[name] != nil {
+			continue
+		}
+		c.diagnose(hcl.DiagError, body.MissingItemRange(), "missing-required-argument", "name", quoted(name))
+	}
+
+	blockNames := sortedKeys(c.schema.Blocks)
+	for _, block := range body.Blocks {
+		if _, ok := c.schema.Blocks[block.Type]; ok {
+			continue
+		}
+		c.diagnose(hcl.DiagError, block.TypeRange, "unsupported-block-type",
+			"type", quoted(block.Type), "suggestion", quotedSuggestion(block.Type, blockNames))
+	}
+}
+
+// validateValue reports the use of attr when it's deprecated, and a literal
+// value of it which doesn't have the type given by its schema or isn't one
+// of the values it allows.
+func (c *converter) validateValue(attr *hclsyntax.Attribute, schema *AttributeSchema) {
+	if schema.Deprecated {
+		c.diagnose(hcl.DiagWarning, attr.NameRange, "deprecated-argument", "name", quoted(attr.Name))
+	}
+	if schema.Type == cty.NilType && len(schema.Allowed) == 0 || len(attr.Expr.Variables()) > 0 {
+		return
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+		return
+	}
+
+	if schema.Type != cty.NilType {
+		if _, err := ctyconvert.Convert(val, schema.Type); err != nil {
+			c.diagnose(hcl.DiagError, attr.Expr.Range(), "incorrect-value-type", "name", quoted(attr.Name), "error", err.Error())
+			return
+		}
+	}
+	if len(schema.Allowed) == 0 {
+		return
+	}
+
+	allowed := make([]string, len(schema.Allowed))
+	var names []string
+	for i, a := range schema.Allowed {
+		if v, err := ctyconvert.Convert(val, a.Type()); err == nil && v.Equals(a).True() {
+			return
+		}
+		allowed[i] = literalSExpr(a)
+		if a.Type() == cty.String {
+			names = append(names, a.AsString())
+		}
+	}
+
+	var suggested string
+	if val.Type() == cty.String {
+		suggested = quotedSuggestion(val.AsString(), names)
+	}
+	c.diagnose(hcl.DiagError, attr.Expr.Range(), "value-not-allowed",
+		"value", literalSExpr(val), "name", quoted(attr.Name),
+		"allowed", strings.Join(allowed, ", "), "suggestion", suggested)
+}
+
+// annotateType records the type the current schema declares for the
+// attribute called name in its line info, when Options.AnnotateTypes is set.
+func (c *converter) annotateType(line lineObj, name string) {
+	if !c.options.AnnotateTypes || c.schema == nil {
+		return
+	}
+	attr, ok := c.schema.Attributes[name]
+	if !ok || attr.Type == cty.NilType {
+		return
+	}
+	typ, err := ctyjson.MarshalType(attr.Type)
+	if err != nil {
+		return
+	}
+	line[c.key("schemaType")] = json.RawMessage(typ)
+}
+
+// injectDefaults adds the default value of every attribute in the current
+// schema missing from the body, marking its line info as synthetic.
+func (c *converter) injectDefaults(cfg jsonObj, lcfg lineObj) {
+	if !c.options.InjectDefaults || c.options.Skeleton || c.schema == nil {
+		return
+	}
+	for name, attr := range c.schema.Attributes {
+		if attr.Default == cty.NilVal {
+			continue
+		}
+		if _, exists := cfg[name]; exists {
+			continue
+		}
+		cfg[name] = ctyjson.SimpleJSONValue{Value: attr.Default}
+		lcfg[name] = lineObj{c.key("synthetic"): true}
+		c.annotateOrigin(lcfg[name], OriginDefault)
+		c.recordValueType(lcfg[name], attr.Default.Type())
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]*AttributeSchema:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*BlockSchema:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*Schema:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case Catalog:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestInjectDefaults(t *testing.T) {
+	input := `
+resource "aws_instance" "web" {
+	ami = "ami-123"
+}`
+
+	schema := &Schema{
+		Blocks: map[string]*BlockSchema{
+			"resource": {
+				Labels: []string{"type", "name"},
+				Body: &Schema{
+					Attributes: map[string]*AttributeSchema{
+						"ami":           {Type: cty.String, Required: true},
+						"instance_type": {Type: cty.String, Default: cty.StringVal("t2.micro")},
+						"monitoring":    {Type: cty.Bool},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `{
+	"resource": [
+		{
+			"aws_instance": {
+				"web": {
+					"ami": "ami-123",
+					"instance_type": "t2.micro"
+				}
+			}
+		}
+	]
+}`
+
+	convertedBytes, lineBytes, err := Bytes([]byte(input), "", Options{Schema: schema, InjectDefaults: true})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+
+	compareTest(t, convertedBytes, expected)
+
+	lines := decodeLines(t, lineBytes)
+	web := lines["resource"].([]interface{})[0].(map[string]interface{})["aws_instance"].(map[string]interface{})["web"].(map[string]interface{})
+	if synthetic, _ := web["instance_type"].(map[string]interface{})["synthetic"].(bool); !synthetic {
+		t.Errorf("expected instance_type to be marked synthetic, got %v", web["instance_type"])
+	}
+	if _, synthetic := web["ami"].(map[string]interface{})["synthetic"]; synthetic {
+		t.Errorf("expected ami not to be marked synthetic, got %v", web["ami"])
+	}
+
+	if (Options{Schema: schema}).Fingerprint() == (Options{}).Fingerprint() {
+		t.Error("the schema should change the fingerprint")
+	}
+}
+
+func TestSchemaSuggestions(t *testing.T) {
+	input := `
+resorce "aws_instance" "web" {}
+
+resource "aws_instance" "db" {
+	instnce_type = "t2.micro"
+}`
+
+	schema := &Schema{
+		Blocks: map[string]*BlockSchema{
+			"resource": {
+				Labels: []string{"type", "name"},
+				Body: &Schema{
+					Attributes: map[string]*AttributeSchema{
+						"instance_type": {Type: cty.String},
+					},
+				},
+			},
+			"data": {Labels: []string{"type", "name"}},
+		},
+	}
+
+	result, err := Convert([]byte(input), "", Options{Schema: schema})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	expected := []string{
+		`Blocks of type "resorce" are not expected here. Did you mean "resource"?`,
+		`An argument named "instnce_type" is not expected here. Did you mean "instance_type"?`,
+	}
+	if len(result.Diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), result.Diagnostics)
+	}
+	for i, detail := range expected {
+		if result.Diagnostics[i].Detail != detail {
+			t.Errorf("expected %q, got %q", detail, result.Diagnostics[i].Detail)
+		}
+	}
+}
+
+func TestSchemaAllowedValues(t *testing.T) {
+	input := `
+resource "aws_instance" "web" {
+	instance_type = "t2.mircro"
+	volumes       = 3
+}
+
+resource "aws_instance" "db" {
+	instance_type = "t3.micro"
+	volumes       = 1
+}
+
+resource "aws_instance" "app" {
+	instance_type = var.type
+}`
+
+	schema := &Schema{
+		Blocks: map[string]*BlockSchema{
+			"resource": {
+				Labels: []string{"type", "name"},
+				Body: &Schema{
+					Attributes: map[string]*AttributeSchema{
+						"instance_type": {
+							Type:    cty.String,
+							Allowed: []cty.Value{cty.StringVal("t2.micro"), cty.StringVal("t3.micro")},
+						},
+						"volumes": {
+							Type:    cty.Number,
+							Allowed: []cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := Convert([]byte(input), "", Options{Schema: schema})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	expected := []string{
+		`The value "t2.mircro" is not one of the allowed values for "instance_type": "t2.micro", "t3.micro". Did you mean "t2.micro"?`,
+		`The value 3 is not one of the allowed values for "volumes": 1, 2.`,
+	}
+	if len(result.Diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), result.Diagnostics)
+	}
+	for i, detail := range expected {
+		if result.Diagnostics[i].Detail != detail {
+			t.Errorf("expected %q, got %q", detail, result.Diagnostics[i].Detail)
+		}
+	}
+	if subject := result.Diagnostics[0].Subject; subject.Start.Line != 3 || subject.Start.Column != 18 {
+		t.Errorf("expected the diagnostic on the value, got %v", subject)
+	}
+}
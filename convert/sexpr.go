@@ -0,0 +1,172 @@
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ckndave/hclparser/addr"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// operatorSymbols names each operation in canonical expressions.
+var operatorSymbols = map[*hclsyntax.Operation]string{
+	hclsyntax.OpLogicalOr:          "||",
+	hclsyntax.OpLogicalAnd:         "&&",
+	hclsyntax.OpLogicalNot:         "!",
+	hclsyntax.OpEqual:              "==",
+	hclsyntax.OpNotEqual:           "!=",
+	hclsyntax.OpGreaterThan:        ">",
+	hclsyntax.OpGreaterThanOrEqual: ">=",
+	hclsyntax.OpLessThan:           "<",
+	hclsyntax.OpLessThanOrEqual:    "<=",
+	hclsyntax.OpAdd:                "+",
+	hclsyntax.OpSubtract:           "-",
+	hclsyntax.OpMultiply:           "*",
+	hclsyntax.OpDivide:             "/",
+	hclsyntax.OpModulo:             "%",
+	hclsyntax.OpNegate:             "neg",
+}
+
+// SExpr returns the canonical form of an expression: its operator tree
+// serialized as an S-expression. Formatting, comments and redundant
+// parentheses are dropped and object items are sorted, so expressions with
+// the same structure always have the same canonical form.
+func SExpr(expr hclsyntax.Expression) string {
+	var b strings.Builder
+	writeSExpr(&b, expr)
+	return b.String()
+}
+
+// ExpressionHash returns a hash of the canonical form of an expression, for
+// finding structurally identical expressions.
+func ExpressionHash(expr hclsyntax.Expression) string {
+	sum := sha256.Sum256([]byte(SExpr(expr)))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeSExpr(b *strings.Builder, expr hclsyntax.Expression) {
+	list := func(head string, exprs ...hclsyntax.Expression) {
+		b.WriteString("(" + head)
+		for _, e := range exprs {
+			b.WriteString(" ")
+			writeSExpr(b, e)
+		}
+		b.WriteString(")")
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		b.WriteString(literalSExpr(e.Val))
+	case *hclsyntax.ParenthesesExpr:
+		writeSExpr(b, e.Expression)
+	case *hclsyntax.TemplateWrapExpr:
+		writeSExpr(b, e.Wrapped)
+	case *hclsyntax.ScopeTraversalExpr:
+		b.WriteString(addr.String(e.Traversal))
+	case *hclsyntax.RelativeTraversalExpr:
+		b.WriteString("(get ")
+		writeSExpr(b, e.Source)
+		b.WriteString(" " + addr.String(e.Traversal) + ")")
+	case *hclsyntax.FunctionCallExpr:
+		if e.ExpandFinal && len(e.Args) > 0 {
+			last := len(e.Args) - 1
+			b.WriteString("(call " + e.Name)
+			for _, arg := range e.Args[:last] {
+				b.WriteString(" ")
+				writeSExpr(b, arg)
+			}
+			b.WriteString(" ")
+			list("expand", e.Args[last])
+			b.WriteString(")")
+			return
+		}
+		list("call "+e.Name, e.Args...)
+	case *hclsyntax.BinaryOpExpr:
+		list(operatorSymbols[e.Op], e.LHS, e.RHS)
+	case *hclsyntax.UnaryOpExpr:
+		list(operatorSymbols[e.Op], e.Val)
+	case *hclsyntax.ConditionalExpr:
+		list("if", e.Condition, e.TrueResult, e.FalseResult)
+	case *hclsyntax.TemplateExpr:
+		if e.IsStringLiteral() {
+			v, _ := e.Value(nil)
+			b.WriteString(literalSExpr(v))
+			return
+		}
+		list("template", e.Parts...)
+	case *hclsyntax.TemplateJoinExpr:
+		list("join", e.Tuple)
+	case *hclsyntax.TupleConsExpr:
+		list("tuple", e.Exprs...)
+	case *hclsyntax.ObjectConsExpr:
+		items := make([]string, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = "(" + SExpr(item.KeyExpr) + " " + SExpr(item.ValueExpr) + ")"
+		}
+		sort.Strings(items)
+		b.WriteString("(object")
+		for _, item := range items {
+			b.WriteString(" " + item)
+		}
+		b.WriteString(")")
+	case *hclsyntax.ObjectConsKeyExpr:
+		if name := hcl.ExprAsKeyword(e.Wrapped); name != "" && !e.ForceNonLiteral {
+			b.WriteString(strconv.Quote(name))
+			return
+		}
+		writeSExpr(b, e.Wrapped)
+	case *hclsyntax.ForExpr:
+		b.WriteString("(for " + symbolOrNil(e.KeyVar) + " " + symbolOrNil(e.ValVar) + " ")
+		writeSExpr(b, e.CollExpr)
+		for _, part := range []hclsyntax.Expression{e.CondExpr, e.KeyExpr, e.ValExpr} {
+			b.WriteString(" ")
+			if part == nil {
+				b.WriteString("nil")
+			} else {
+				writeSExpr(b, part)
+			}
+		}
+		if e.Group {
+			b.WriteString(" group")
+		}
+		b.WriteString(")")
+	case *hclsyntax.IndexExpr:
+		list("index", e.Collection, e.Key)
+	case *hclsyntax.SplatExpr:
+		list("splat", e.Source, e.Each)
+	case *hclsyntax.AnonSymbolExpr:
+		b.WriteString("_")
+	default:
+		b.WriteString(fmt.Sprintf("(unknown %T)", expr))
+	}
+}
+
+func symbolOrNil(name string) string {
+	if name == "" {
+		return "nil"
+	}
+	return name
+}
+
+func literalSExpr(val cty.Value) string {
+	switch {
+	case val.IsNull():
+		return "null"
+	case !val.IsKnown():
+		return "unknown"
+	case val.Type() == cty.String:
+		return strconv.Quote(val.AsString())
+	case val.Type() == cty.Number:
+		return val.AsBigFloat().Text('g', -1)
+	case val.Type() == cty.Bool:
+		return strconv.FormatBool(val.True())
+	default:
+		return "(unknown " + val.Type().FriendlyName() + ")"
+	}
+}
@@ -0,0 +1,52 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestSExpr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 + (2 * var.x)`, `(+ 1 (* 2 var.x))`},
+		{`merge(local.a, { b = 1, a = "x" })`, `(call merge local.a (object ("a" "x") ("b" 1)))`},
+		{`var.on ? aws_instance.web[0].id : null`, `(if var.on aws_instance.web[0].id null)`},
+		{`"${var.name}-web"`, `(template var.name "-web")`},
+		{`[for k, v in var.m : upper(v) if v != ""]`, `(for k v var.m (!= v "") nil (call upper v))`},
+		{`concat(var.a...)`, `(call concat (expand var.a))`},
+	}
+
+	for _, test := range tests {
+		expr, diags := hclsyntax.ParseExpression([]byte(test.input), "", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			t.Fatalf("parse %q: %v", test.input, diags)
+		}
+		if actual := SExpr(expr); actual != test.expected {
+			t.Errorf("expected %q to be %s, got %s", test.input, test.expected, actual)
+		}
+	}
+}
+
+func TestExpressionHash(t *testing.T) {
+	a, _ := hclsyntax.ParseExpression([]byte(`{ a = 1, b = (var.x) }`), "", hcl.Pos{Line: 1, Column: 1})
+	b, _ := hclsyntax.ParseExpression([]byte("{\n  b = var.x\n  a = 1\n}"), "", hcl.Pos{Line: 1, Column: 1})
+	if ExpressionHash(a) != ExpressionHash(b) {
+		t.Errorf("expected %s and %s to have the same hash", SExpr(a), SExpr(b))
+	}
+}
+
+func TestCanonicalExpressions(t *testing.T) {
+	_, lineBytes, err := Bytes([]byte(`x = 1 + var.y`), "", Options{CanonicalExpressions: true})
+	if err != nil {
+		t.Fatal("parse bytes:", err)
+	}
+
+	lines := decodeLines(t, lineBytes)
+	if canonical := lines["x"].(map[string]interface{})["canonical"]; canonical != "(+ 1 var.y)" {
+		t.Errorf("unexpected canonical form %v", canonical)
+	}
+}
@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SignatureSuffix is appended to the names of the artifacts and manifest
+// written by Batch for their signatures, when Options.SigningKey is set.
+const SignatureSuffix = ".sig"
+
+// Statement is what a Signature attests: that the artifact with the given
+// hash was produced by this converter version and options from the input
+// with the given hash, which is empty for manifests.
+type Statement struct {
+	Tool           string `json:"tool"`
+	Version        string `json:"version"`
+	Fingerprint    string `json:"fingerprint"`
+	InputSHA256    string `json:"input_sha256,omitempty"`
+	ArtifactSHA256 string `json:"artifact_sha256"`
+}
+
+// Signature is an ed25519 signature of a Statement, as JSON, by the key
+// identified by KeyID, the hex SHA-256 of its public key.
+type Signature struct {
+	Statement Statement `json:"statement"`
+	KeyID     string    `json:"key_id"`
+	Signature []byte    `json:"signature"`
+}
+
+// Sign signs a statement that artifact was converted from the input with
+// the given hash with options.
+func Sign(key ed25519.PrivateKey, artifact []byte, inputSHA256 string, options Options) (*Signature, error) {
+	statement := Statement{
+		Tool:           "hclparser",
+		Version:        Version,
+		Fingerprint:    options.Fingerprint(),
+		InputSHA256:    inputSHA256,
+		ArtifactSHA256: sha256Hex(artifact),
+	}
+	message, err := json.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("marshal statement: %w", err)
+	}
+	return &Signature{
+		Statement: statement,
+		KeyID:     keyID(key.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(key, message),
+	}, nil
+}
+
+// Verify checks that sig was made by key for artifact. Callers should
+// then check the statement's version and input hash are those they trust.
+func Verify(key ed25519.PublicKey, artifact []byte, sig *Signature) error {
+	if sig.KeyID != keyID(key) {
+		return fmt.Errorf("signed by key %s, not %s", sig.KeyID, keyID(key))
+	}
+	if sum := sha256Hex(artifact); sum != sig.Statement.ArtifactSHA256 {
+		return fmt.Errorf("artifact hash %s doesn't match signed hash %s", sum, sig.Statement.ArtifactSHA256)
+	}
+	message, err := json.Marshal(sig.Statement)
+	if err != nil {
+		return fmt.Errorf("marshal statement: %w", err)
+	}
+	if !ed25519.Verify(key, message, sig.Signature) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func keyID(key ed25519.PublicKey) string {
+	return sha256Hex(key)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeSignature signs an artifact written by Batch, writing the signature
+// alongside it.
+func writeSignature(dst, rel string, artifact []byte, inputSHA256 string, options Options) error {
+	sig, err := Sign(options.SigningKey, artifact, inputSHA256, options)
+	if err != nil {
+		return fmt.Errorf("sign %s: %w", rel, err)
+	}
+	sigBytes, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal signature: %w", err)
+	}
+	return writeArtifact(dst, rel+SignatureSuffix, sigBytes)
+}
@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignedBatch(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, dst := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(src, "main.tf"), `x = 1`)
+
+	manifest, err := Batch(src, dst, Options{SigningKey: private})
+	if err != nil {
+		t.Fatal("batch:", err)
+	}
+
+	for _, rel := range []string{"main.tf.json", "main.tf.lines.json", ManifestName} {
+		artifact, err := ioutil.ReadFile(filepath.Join(dst, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigBytes, err := ioutil.ReadFile(filepath.Join(dst, rel+SignatureSuffix))
+		if err != nil {
+			t.Fatal("read signature:", err)
+		}
+		var sig Signature
+		if err := json.Unmarshal(sigBytes, &sig); err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(public, artifact, &sig); err != nil {
+			t.Errorf("verify %s: %v", rel, err)
+		}
+		if rel != ManifestName && sig.Statement.InputSHA256 != manifest.Files[0].SHA256 {
+			t.Errorf("expected %s to be signed with the input hash, got %+v", rel, sig.Statement)
+		}
+		if err := Verify(public, append(artifact, ' '), &sig); err == nil {
+			t.Errorf("expected a modified %s to fail verification", rel)
+		}
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	sig, err := Sign(private, []byte("{}"), "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(other, []byte("{}"), sig); err == nil {
+		t.Error("expected verification with another key to fail")
+	}
+	sig.Statement.Version = "forged"
+	if err := Verify(public, []byte("{}"), sig); err == nil {
+		t.Error("expected a modified statement to fail verification")
+	}
+}
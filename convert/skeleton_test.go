@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSkeleton(t *testing.T) {
+	src := []byte(`resource "aws_instance" "web" {
+  ami   = data.aws_ami.ubuntu.id
+  count = length(var.zones)
+
+  ebs_block_device {
+    size = 8 * 1024
+  }
+}
+
+locals {
+  name = "web-${var.env}"
+}
+`)
+	out, lines, err := Bytes(src, "main.tf", Options{Skeleton: true, LineFormat: LineFormatFlat})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{
+		"resource": [{"aws_instance": {"web": {
+			"ami": null,
+			"count": null,
+			"ebs_block_device": [{"size": null}]
+		}}}],
+		"locals": [{"name": null}]
+	}`))
+
+	var flat map[string]FlatLine
+	if err := json.Unmarshal(lines, &flat); err != nil {
+		t.Fatal("decode line info:", err)
+	}
+	if line := flat["/resource/0/aws_instance/web/ebs_block_device/0/size"]; line.Line != 6 {
+		t.Errorf("expected size on line 6, got %d", line.Line)
+	}
+}
+
+func TestSkeletonJSON(t *testing.T) {
+	src := []byte(`{"variable": {"region": {"default": "eu-west-1"}}, "locals": {"zones": ["a", "b"]}}`)
+	out, _, err := Bytes(src, "main.tf.json", Options{Skeleton: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{
+		"variable": [{"region": {"default": null}}],
+		"locals": [{"zones": null}]
+	}`))
+}
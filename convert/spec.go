@@ -0,0 +1,123 @@
+package convert
+
+import (
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+)
+
+// WithSchema returns options which validate files against spec while
+// converting them, where spec is an hcldec.Spec or an *hcl.BodySchema.
+// Unknown blocks and attributes, missing required attributes and literal
+// values of the wrong type are reported as diagnostics, while the rest of
+// the file is still converted.
+func WithSchema(options Options, spec interface{}) (Options, error) {
+	var schema *Schema
+	switch spec := spec.(type) {
+	case *hcl.BodySchema:
+		schema = bodySchemaSchema(spec)
+	case hcldec.Spec:
+		schema = &Schema{}
+		if err := addSpec(schema, spec); err != nil {
+			return options, err
+		}
+	default:
+		return options, fmt.Errorf("unsupported schema %T", spec)
+	}
+	options.Schema = schema
+	return options, nil
+}
+
+// bodySchemaSchema converts a body schema. The bodies of its blocks aren't
+// described, so aren't validated.
+func bodySchemaSchema(spec *hcl.BodySchema) *Schema {
+	schema := &Schema{
+		Attributes: make(map[string]*AttributeSchema),
+		Blocks:     make(map[string]*BlockSchema),
+	}
+	for _, attr := range spec.Attributes {
+		schema.Attributes[attr.Name] = &AttributeSchema{Required: attr.Required}
+	}
+	for _, block := range spec.Blocks {
+		schema.Blocks[block.Type] = &BlockSchema{Labels: block.LabelNames}
+	}
+	return schema
+}
+
+// addSpec adds the attributes and blocks decoded by spec to schema.
+func addSpec(schema *Schema, spec hcldec.Spec) error {
+	addAttr := func(spec *hcldec.AttrSpec) *AttributeSchema {
+		if schema.Attributes == nil {
+			schema.Attributes = make(map[string]*AttributeSchema)
+		}
+		attr := &AttributeSchema{Type: spec.Type, Required: spec.Required}
+		schema.Attributes[spec.Name] = attr
+		return attr
+	}
+	addBlock := func(typeName string, labels []string, nested hcldec.Spec) error {
+		if schema.Blocks == nil {
+			schema.Blocks = make(map[string]*BlockSchema)
+		}
+		block := &BlockSchema{Labels: labels}
+		if nested != nil {
+			block.Body = &Schema{}
+			if err := addSpec(block.Body, nested); err != nil {
+				return fmt.Errorf("block %s: %w", typeName, err)
+			}
+		}
+		schema.Blocks[typeName] = block
+		return nil
+	}
+
+	switch spec := spec.(type) {
+	case hcldec.ObjectSpec:
+		for _, child := range spec {
+			if err := addSpec(schema, child); err != nil {
+				return err
+			}
+		}
+	case hcldec.TupleSpec:
+		for _, child := range spec {
+			if err := addSpec(schema, child); err != nil {
+				return err
+			}
+		}
+	case *hcldec.AttrSpec:
+		addAttr(spec)
+	case *hcldec.DefaultSpec:
+		if attr, ok := spec.Primary.(*hcldec.AttrSpec); ok {
+			if literal, ok := spec.Default.(*hcldec.LiteralSpec); ok {
+				addAttr(attr).Default = literal.Value
+				return nil
+			}
+		}
+		return addSpec(schema, spec.Primary)
+	case *hcldec.BlockSpec:
+		return addBlock(spec.TypeName, nil, spec.Nested)
+	case *hcldec.BlockListSpec:
+		return addBlock(spec.TypeName, nil, spec.Nested)
+	case *hcldec.BlockTupleSpec:
+		return addBlock(spec.TypeName, nil, spec.Nested)
+	case *hcldec.BlockSetSpec:
+		return addBlock(spec.TypeName, nil, spec.Nested)
+	case *hcldec.BlockMapSpec:
+		return addBlock(spec.TypeName, spec.LabelNames, spec.Nested)
+	case *hcldec.BlockObjectSpec:
+		return addBlock(spec.TypeName, spec.LabelNames, spec.Nested)
+	case *hcldec.BlockAttrsSpec:
+		// the attributes of the block can have any name
+		return addBlock(spec.TypeName, nil, nil)
+	case *hcldec.TransformExprSpec:
+		return addSpec(schema, spec.Wrapped)
+	case *hcldec.TransformFuncSpec:
+		return addSpec(schema, spec.Wrapped)
+	case *hcldec.ValidateSpec:
+		return addSpec(schema, spec.Wrapped)
+	case *hcldec.LiteralSpec, *hcldec.ExprSpec, *hcldec.BlockLabelSpec:
+		// these don't read from the body
+	default:
+		return fmt.Errorf("unsupported spec %T", spec)
+	}
+	return nil
+}
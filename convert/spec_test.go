@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestWithSchemaSpec(t *testing.T) {
+	spec := hcldec.ObjectSpec{
+		"name": &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: true},
+		"port": &hcldec.DefaultSpec{
+			Primary: &hcldec.AttrSpec{Name: "port", Type: cty.Number},
+			Default: &hcldec.LiteralSpec{Value: cty.NumberIntVal(80)},
+		},
+		"listener": &hcldec.BlockListSpec{
+			TypeName: "listener",
+			Nested: hcldec.ObjectSpec{
+				"protocol": &hcldec.AttrSpec{Name: "protocol", Type: cty.String, Required: true},
+			},
+		},
+	}
+	options, err := WithSchema(Options{InjectDefaults: true}, spec)
+	if err != nil {
+		t.Fatal("with schema:", err)
+	}
+
+	input := []byte(`port = "http"
+listener {
+	protocl = "tcp"
+}
+backend {}
+`)
+	result, err := Convert(input, "lb.hcl", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"port": "http",
+	"listener": [{"protocl": "tcp"}],
+	"backend": [{}]
+}`))
+
+	expected := []string{
+		`Inappropriate value for attribute "port": a number is required.`,
+		`The argument "name" is required, but no definition was found.`,
+		`Blocks of type "backend" are not expected here.`,
+		`An argument named "protocl" is not expected here. Did you mean "protocol"?`,
+		`The argument "protocol" is required, but no definition was found.`,
+	}
+	if len(result.Diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), result.Diagnostics)
+	}
+	for i, detail := range expected {
+		if result.Diagnostics[i].Detail != detail {
+			t.Errorf("expected %q, got %q", detail, result.Diagnostics[i].Detail)
+		}
+	}
+}
+
+func TestWithSchemaBodySchema(t *testing.T) {
+	options, err := WithSchema(Options{}, &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "name", Required: true}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "rule", LabelNames: []string{"name"}}},
+	})
+	if err != nil {
+		t.Fatal("with schema:", err)
+	}
+
+	result, err := Convert([]byte(`rule "a" { anything = 1 }`), "", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Summary != "Missing required argument" {
+		t.Errorf("expected only the missing name to be reported, got %v", result.Diagnostics)
+	}
+
+	if _, err := WithSchema(Options{}, "schema"); err == nil {
+		t.Error("expected an unsupported schema to fail")
+	}
+}
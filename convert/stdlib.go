@@ -1,7 +1,21 @@
 package convert
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
 	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
@@ -54,3 +68,130 @@ var evalContext = hcl.EvalContext{
 		"timeadd":    stdlib.TimeAddFunc,
 	},
 }
+
+// terraformFunctions are the pure Terraform functions, beyond those in the
+// evaluation context, which are evaluated for the dialects that have them.
+// Those from the cty standard library behave the same as in Terraform.
+var terraformFunctions = map[string]function.Function{
+	// string
+	"endswith":    makeStringPredicateFunc(strings.HasSuffix),
+	"lower":       stdlib.LowerFunc,
+	"regex":       stdlib.RegexFunc,
+	"regexall":    stdlib.RegexAllFunc,
+	"replace":     replaceFunc,
+	"startswith":  makeStringPredicateFunc(strings.HasPrefix),
+	"strcontains": makeStringPredicateFunc(strings.Contains),
+	"substr":      stdlib.SubstrFunc,
+	"title":       stdlib.TitleFunc,
+	"upper":       stdlib.UpperFunc,
+
+	// collections
+	"coalesce":        stdlib.CoalesceFunc,
+	"coalescelist":    stdlib.CoalesceListFunc,
+	"compact":         stdlib.CompactFunc,
+	"contains":        stdlib.ContainsFunc,
+	"element":         stdlib.ElementFunc,
+	"index":           stdlib.IndexFunc,
+	"keys":            stdlib.KeysFunc,
+	"lookup":          stdlib.LookupFunc,
+	"range":           stdlib.RangeFunc,
+	"setintersection": stdlib.SetIntersectionFunc,
+	"setproduct":      stdlib.SetProductFunc,
+	"setsubtract":     stdlib.SetSubtractFunc,
+	"setunion":        stdlib.SetUnionFunc,
+	"slice":           stdlib.SliceFunc,
+	"values":          stdlib.ValuesFunc,
+	"zipmap":          stdlib.ZipmapFunc,
+
+	// encoding and hashing
+	"base64decode": makeStringFunc(func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode base64 data %q", s)
+		}
+		if !utf8.Valid(b) {
+			return "", fmt.Errorf("the result of decoding %q is not valid UTF-8", s)
+		}
+		return string(b), nil
+	}),
+	"base64encode": makeStringFunc(func(s string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s)), nil
+	}),
+	"base64sha256": makeHashFunc(sha256.New, base64.StdEncoding.EncodeToString),
+	"base64sha512": makeHashFunc(sha512.New, base64.StdEncoding.EncodeToString),
+	"md5":          makeHashFunc(md5.New, hex.EncodeToString),
+	"sha1":         makeHashFunc(sha1.New, hex.EncodeToString),
+	"sha256":       makeHashFunc(sha256.New, hex.EncodeToString),
+	"sha512":       makeHashFunc(sha512.New, hex.EncodeToString),
+	"urlencode": makeStringFunc(func(s string) (string, error) {
+		return url.QueryEscape(s), nil
+	}),
+
+	// type conversion
+	"can":      tryfunc.CanFunc,
+	"tobool":   stdlib.MakeToFunc(cty.Bool),
+	"tolist":   stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+	"tomap":    stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+	"tonumber": stdlib.MakeToFunc(cty.Number),
+	"toset":    stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"tostring": stdlib.MakeToFunc(cty.String),
+	"try":      tryfunc.TryFunc,
+}
+
+// replaceFunc is Terraform's replace, which takes the substring to replace
+// as a regular expression when it's wrapped in slashes.
+var replaceFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "str", Type: cty.String},
+		{Name: "substr", Type: cty.String},
+		{Name: "replace", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		substr := args[1].AsString()
+		if len(substr) > 1 && strings.HasPrefix(substr, "/") && strings.HasSuffix(substr, "/") {
+			return stdlib.RegexReplace(args[0], cty.StringVal(substr[1:len(substr)-1]), args[2])
+		}
+		return stdlib.Replace(args[0], args[1], args[2])
+	},
+})
+
+// makeStringFunc returns a function of a single string argument.
+func makeStringFunc(fn func(s string) (string, error)) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "str", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			result, err := fn(args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(result), nil
+		},
+	})
+}
+
+// makeStringPredicateFunc returns a function reporting whether its first
+// string argument relates to its second as pred says.
+func makeStringPredicateFunc(pred func(s, substr string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: "substr", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.BoolVal(pred(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+// makeHashFunc returns a function hashing its string argument, encoding
+// the digest with encode.
+func makeHashFunc(h func() hash.Hash, encode func([]byte) string) function.Function {
+	return makeStringFunc(func(s string) (string, error) {
+		hash := h()
+		hash.Write([]byte(s))
+		return encode(hash.Sum(nil)), nil
+	})
+}
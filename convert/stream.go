@@ -0,0 +1,255 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// StreamResult holds what Stream raises while converting a file, besides
+// the output it writes.
+type StreamResult struct {
+	Diagnostics hcl.Diagnostics
+	Messages    Messages
+	Findings    []Finding
+}
+
+// Stream converts the HCL read from r, writing the JSON to w and the line
+// info to lineW. The output is the same as from Bytes, but each top-level
+// attribute and block is encoded and written as soon as it is converted,
+// so the converted form of the whole file is never held in memory at once.
+// Parsing is still whole-file: r is read to the end and parsed before
+// anything is written, so the source and its syntax tree are held.
+// PreserveOrder, CompactLines, LineFormatFlat, IncludeTypes,
+// PostProcessors, the formatting options such as Canonical and Indent,
+// output schema versions other than OutputSchemaV1 and dialects with their
+// own output shape, such as DialectNomad, need the whole output, so are not
+// supported.
+func Stream(r io.Reader, w io.Writer, lineW io.Writer, options Options) (*StreamResult, error) {
+	if err := options.streamable(); err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+	return stream(src, "", w, lineW, options)
+}
+
+// streamable returns why the options can't be used with Stream, or nil when
+// they can.
+func (o Options) streamable() error {
+	if o.PreserveOrder || o.CompactLines || o.IncludeTypes != "" || len(o.PostProcessors) > 0 || o.Encoder != nil || o.CombinedOutput {
+		return fmt.Errorf("PreserveOrder, CompactLines, IncludeTypes, PostProcessors, Encoder and CombinedOutput are not supported when streaming")
+	}
+	if o.LineFormat != LineFormatNested {
+		return fmt.Errorf("only nested line info is supported when streaming")
+	}
+	if _, reformat := o.jsonFormat(); reformat {
+		return fmt.Errorf("Canonical, Indent and DisableHTMLEscaping are not supported when streaming")
+	}
+	if version, err := o.outputSchemaVersion(); err != nil || version != OutputSchemaV1 {
+		return fmt.Errorf("only OutputSchemaV1 is supported when streaming")
+	}
+	if o.Dialect.profile() != nil {
+		return fmt.Errorf("the %s dialect is not supported when streaming", o.Dialect)
+	}
+	return nil
+}
+
+// stream is Stream, converting src as filename.
+func stream(src []byte, filename string, w io.Writer, lineW io.Writer, options Options) (*StreamResult, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parse config: %v", diags.Errs())
+	}
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := converter{
+		bytes:   file.Bytes,
+		options: options,
+		schema:  options.Schema,
+	}
+	c.validateBody(body)
+
+	// Placeholders for everything in the body let injectDefaults find the
+	// attributes which are missing without converting the rest.
+	root, rootLines := make(jsonObj), make(lineObj)
+	blocks := make(map[string][]*hclsyntax.Block)
+	for name := range body.Attributes {
+		root[name] = nil
+	}
+	for _, block := range body.Blocks {
+		root[block.Type] = nil
+		blocks[block.Type] = append(blocks[block.Type], block)
+	}
+	c.injectDefaults(root, rootLines)
+	if options.Provenance {
+		root[ProvenanceKey] = newProvenance(file, options)
+	}
+
+	rootLines[c.key("line")] = body.SrcRange.Start.Line
+	rootLines[c.key("startIndex")] = body.SrcRange.Start.Column
+	rootLines[c.key("endIndex")] = body.SrcRange.End.Column
+	rootLines[c.key("type")] = "block"
+	rootLines[c.key("endLine")] = body.SrcRange.End.Line
+	c.byteOffsets(rootLines, body.SrcRange)
+
+	keys := make([]string, 0, len(root)+len(rootLines))
+	for key := range root {
+		keys = append(keys, key)
+	}
+	for key := range rootLines {
+		if _, exists := root[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	out, lines := newObjectWriter(w), newObjectWriter(lineW)
+	for _, key := range keys {
+		var value, line interface{}
+		switch {
+		case body.Attributes[key] != nil:
+			value, line, err = c.convertAttribute(body.Attributes[key])
+			if err != nil {
+				return nil, err
+			}
+		case blocks[key] != nil:
+			if err := c.streamBlocks(key, blocks[key], out, lines); err != nil {
+				return nil, err
+			}
+			continue
+		default:
+			value, line = root[key], rootLines[key]
+		}
+
+		if _, isValue := root[key]; isValue {
+			out.field(key, value)
+		}
+		// Values with no line info, such as the provenance, are left out
+		// of it as they are by Bytes.
+		if line != nil {
+			lines.field(key, line)
+		}
+	}
+
+	if err := out.close(); err != nil {
+		return nil, fmt.Errorf("write json: %w", err)
+	}
+	if err := lines.close(); err != nil {
+		return nil, fmt.Errorf("write line info: %w", err)
+	}
+
+	result := &StreamResult{Diagnostics: append(diags, c.diags...), Messages: c.messages, Findings: c.findings}
+	for _, diag := range diags {
+		if result.Messages == nil {
+			result.Messages = make(Messages)
+		}
+		result.Messages[diag] = Message{ID: "syntax-error"}
+	}
+	sortFindings(result.Findings)
+	return result, nil
+}
+
+// streamBlocks converts the top-level blocks of one type, writing each as
+// an element of the array of them as soon as it is converted.
+func (c *converter) streamBlocks(key string, blocks []*hclsyntax.Block, out, lines *objectWriter) error {
+	out.beginArray(key)
+	lines.beginArray(key)
+	for _, block := range blocks {
+		bcfg, blcfg := make(jsonObj), make(lineObj)
+		if err := c.convertBlock(block, bcfg, blcfg); err != nil {
+			return fmt.Errorf("convert block: %w", err)
+		}
+		out.element(bcfg[block.Type])
+		lines.element(blcfg[block.Type])
+	}
+	out.endArray()
+	lines.endArray()
+	return nil
+}
+
+// objectWriter writes a JSON object one field at a time, or a field
+// holding an array one element at a time, keeping the first error so
+// callers only need to check once.
+type objectWriter struct {
+	w        io.Writer
+	fields   int
+	elements int
+	err      error
+}
+
+func newObjectWriter(w io.Writer) *objectWriter {
+	o := &objectWriter{w: w}
+	o.write([]byte("{"))
+	return o
+}
+
+func (o *objectWriter) write(b []byte) {
+	if o.err == nil {
+		_, o.err = o.w.Write(b)
+	}
+}
+
+// marshal returns the JSON encoding of value, or nil after an error.
+func (o *objectWriter) marshal(value interface{}) []byte {
+	if o.err != nil {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		o.err = err
+		return nil
+	}
+	return b
+}
+
+// key writes the key of the next field.
+func (o *objectWriter) key(key string) {
+	keyBytes := o.marshal(key)
+	if o.fields > 0 {
+		o.write([]byte(","))
+	}
+	o.write(keyBytes)
+	o.write([]byte(":"))
+	o.fields++
+}
+
+func (o *objectWriter) field(key string, value interface{}) {
+	valueBytes := o.marshal(value)
+	o.key(key)
+	o.write(valueBytes)
+}
+
+func (o *objectWriter) beginArray(key string) {
+	o.key(key)
+	o.write([]byte("["))
+	o.elements = 0
+}
+
+func (o *objectWriter) element(value interface{}) {
+	valueBytes := o.marshal(value)
+	if o.elements > 0 {
+		o.write([]byte(","))
+	}
+	o.write(valueBytes)
+	o.elements++
+}
+
+func (o *objectWriter) endArray() {
+	o.write([]byte("]"))
+}
+
+func (o *objectWriter) close() error {
+	o.write([]byte("}"))
+	return o.err
+}
@@ -0,0 +1,106 @@
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStream(t *testing.T) {
+	input := `
+region = "us-east-1"
+
+resource "aws_instance" "web" {
+	ami   = "ami-123"
+	count = length(var.zones)
+}
+
+variable "zones" {}
+
+resource "aws_instance" "db" {
+	tags = { Name = "db" }
+}`
+
+	options := Options{
+		Schema: &Schema{
+			Attributes: map[string]*AttributeSchema{
+				"region":  {Type: cty.String},
+				"profile": {Type: cty.String, Default: cty.StringVal("default")},
+			},
+			Blocks: map[string]*BlockSchema{
+				"resource": {Labels: []string{"type", "name"}},
+				"variable": {Labels: []string{"name"}},
+			},
+		},
+		InjectDefaults: true,
+	}
+
+	expectedJSON, expectedLines, err := Bytes([]byte(input), "", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	var out, lines bytes.Buffer
+	if _, err := Stream(strings.NewReader(input), &out, &lines, options); err != nil {
+		t.Fatal("stream:", err)
+	}
+	if out.String() != string(expectedJSON) {
+		t.Errorf("expected JSON %s, got %s", expectedJSON, out.String())
+	}
+	if lines.String() != string(expectedLines) {
+		t.Errorf("expected line info %s, got %s", expectedLines, lines.String())
+	}
+
+	if _, err := Stream(strings.NewReader(input), &out, &lines, Options{LineFormat: LineFormatFlat}); err == nil {
+		t.Error("expected flat line info to be rejected")
+	}
+}
+
+func TestStreamDiagnostics(t *testing.T) {
+	input := `nmae = "web"
+resource "a" "x" {
+	acl = "public"
+}
+resource "a" "y" {
+	acl = "private"
+}
+`
+	public := RulesFunc("public-acl", func(path string, value interface{}, rng hcl.Range) []Finding {
+		if value == "public" {
+			return []Finding{{Rule: "public-acl"}}
+		}
+		return nil
+	})
+	options := Options{
+		Schema: &Schema{
+			Attributes: map[string]*AttributeSchema{"name": {}},
+			Blocks:     map[string]*BlockSchema{"resource": {Labels: []string{"type", "name"}}},
+		},
+		Rules: []Rules{public},
+	}
+
+	want, err := Convert([]byte(input), "", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	var out, lines bytes.Buffer
+	result, err := Stream(strings.NewReader(input), &out, &lines, options)
+	if err != nil {
+		t.Fatal("stream:", err)
+	}
+	if out.String() != string(want.JSON) {
+		t.Errorf("expected JSON %s, got %s", want.JSON, out.String())
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Detail != want.Diagnostics[0].Detail {
+		t.Errorf("expected diagnostics %v, got %v", want.Diagnostics, result.Diagnostics)
+	}
+	if result.Messages[result.Diagnostics[0]].ID != "unsupported-argument" {
+		t.Errorf("expected the message of the diagnostic, got %v", result.Messages)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Rule != "public-acl" {
+		t.Errorf("expected a public-acl finding, got %v", result.Findings)
+	}
+}
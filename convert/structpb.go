@@ -0,0 +1,173 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Struct returns the converted output as a google.protobuf.Struct, for
+// services passing converted configuration over gRPC. It is built from the
+// converted values rather than the JSON, so works whatever Encoder the
+// result was written with, but doesn't reflect Options.PostProcessors.
+// Numbers become doubles, as the Struct type has no other numbers.
+func (r *Result) Struct() (*structpb.Struct, error) {
+	if r.output == nil {
+		// a result not returned by the converter
+		return jsonStruct(r.JSON)
+	}
+	value, err := structValue(r.output)
+	if err != nil {
+		return nil, fmt.Errorf("build struct: %w", err)
+	}
+	s := value.GetStructValue()
+	if s == nil {
+		return nil, fmt.Errorf("build struct: the output is not an object")
+	}
+	return s, nil
+}
+
+// LinesStruct returns the line info as a google.protobuf.Struct, in the
+// form selected by the options it was converted with.
+func (r *Result) LinesStruct() (*structpb.Struct, error) {
+	if r.lineJSON == nil {
+		return jsonStruct(r.Lines)
+	}
+	return jsonStruct(r.lineJSON)
+}
+
+func jsonStruct(data []byte) (*structpb.Struct, error) {
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	s, err := structpb.NewStruct(value)
+	if err != nil {
+		return nil, fmt.Errorf("build struct: %w", err)
+	}
+	return s, nil
+}
+
+// structValue converts a value as produced by the converter, after any
+// reshaping of the output, to a google.protobuf.Value.
+func structValue(value interface{}) (*structpb.Value, error) {
+	switch value := value.(type) {
+	case ctyjson.SimpleJSONValue:
+		return ctyStructValue(value.Value)
+	case json.Number:
+		f, err := value.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewNumberValue(f), nil
+	case jsonObj:
+		return structObject(value)
+	case map[string]interface{}:
+		return structObject(value)
+	case orderedObj:
+		fields := make(map[string]*structpb.Value, len(value))
+		for _, entry := range value {
+			v, err := structValue(entry.value)
+			if err != nil {
+				return nil, fmt.Errorf("convert %s: %w", entry.key, err)
+			}
+			fields[entry.key] = v
+		}
+		return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+	case []jsonObj:
+		elems := make([]interface{}, len(value))
+		for i, elem := range value {
+			elems[i] = elem
+		}
+		return structValue(elems)
+	case []lineObj:
+		elems := make([]interface{}, len(value))
+		for i, elem := range value {
+			elems[i] = elem
+		}
+		return structValue(elems)
+	case []interface{}:
+		values := make([]*structpb.Value, len(value))
+		for i, elem := range value {
+			var err error
+			if values[i], err = structValue(elem); err != nil {
+				return nil, err
+			}
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case json.Marshaler:
+		// leaves marshalled by their own type, such as json.RawMessage
+		bytes, err := value.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshal value: %w", err)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(bytes, &decoded); err != nil {
+			return nil, fmt.Errorf("decode value: %w", err)
+		}
+		return structpb.NewValue(decoded)
+	default:
+		// nil, strings, bools and numbers
+		return structpb.NewValue(value)
+	}
+}
+
+func structObject(obj map[string]interface{}) (*structpb.Value, error) {
+	fields := make(map[string]*structpb.Value, len(obj))
+	for key, elem := range obj {
+		v, err := structValue(elem)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s: %w", key, err)
+		}
+		fields[key] = v
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+}
+
+// ctyStructValue converts an evaluated value, as ctyjson.SimpleJSONValue
+// would write it.
+func ctyStructValue(value cty.Value) (*structpb.Value, error) {
+	if !value.IsWhollyKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+	value, _ = value.Unmark()
+	if value.IsNull() {
+		return structpb.NewNullValue(), nil
+	}
+	ty := value.Type()
+	switch {
+	case ty == cty.String:
+		return structpb.NewStringValue(value.AsString()), nil
+	case ty == cty.Bool:
+		return structpb.NewBoolValue(value.True()), nil
+	case ty == cty.Number:
+		f, _ := value.AsBigFloat().Float64()
+		return structpb.NewNumberValue(f), nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		values := make([]*structpb.Value, 0, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			v, err := ctyStructValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case ty.IsMapType() || ty.IsObjectType():
+		fields := make(map[string]*structpb.Value, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			v, err := ctyStructValue(elem)
+			if err != nil {
+				return nil, fmt.Errorf("convert %s: %w", key.AsString(), err)
+			}
+			fields[key.AsString()] = v
+		}
+		return structpb.NewStructValue(&structpb.Struct{Fields: fields}), nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", ty.FriendlyName())
+}
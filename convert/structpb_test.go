@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStruct(t *testing.T) {
+	result, err := Convert([]byte(`resource "aws_instance" "web" {
+  ami   = "ami-1"
+  count = 2
+  tags  = { Name = "web" }
+}
+`), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	s, err := result.Struct()
+	if err != nil {
+		t.Fatal("struct:", err)
+	}
+	web := s.Fields["resource"].GetListValue().Values[0].GetStructValue().
+		Fields["aws_instance"].GetStructValue().Fields["web"].GetStructValue()
+	if got := web.Fields["ami"].GetStringValue(); got != "ami-1" {
+		t.Errorf("expected ami-1, got %q", got)
+	}
+	if got := web.Fields["count"].GetNumberValue(); got != 2 {
+		t.Errorf("expected a count of 2, got %v", got)
+	}
+	if got := web.Fields["tags"].GetStructValue().Fields["Name"].GetStringValue(); got != "web" {
+		t.Errorf("expected the Name tag web, got %q", got)
+	}
+
+	lines, err := result.LinesStruct()
+	if err != nil {
+		t.Fatal("lines struct:", err)
+	}
+	if got := lines.Fields["line"].GetNumberValue(); got != 1 {
+		t.Errorf("expected the file to start on line 1, got %v", got)
+	}
+}
+
+func TestStructEncoder(t *testing.T) {
+	src := []byte(`name = "app"
+ports = [80, 443]
+service "web" {
+  enabled = true
+  tags    = { tier = "front" }
+}
+`)
+	want, err := Convert(src, "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	wantStruct, err := jsonStruct(want.JSON)
+	if err != nil {
+		t.Fatal("struct:", err)
+	}
+	for name, options := range map[string]Options{
+		"encoder":        {Encoder: MsgpackEncoder},
+		"preserve order": {Encoder: CBOREncoder, PreserveOrder: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := Convert(src, "main.tf", options)
+			if err != nil {
+				t.Fatal("convert:", err)
+			}
+			s, err := result.Struct()
+			if err != nil {
+				t.Fatal("struct:", err)
+			}
+			if !proto.Equal(s, wantStruct) {
+				t.Errorf("expected %v, got %v", wantStruct, s)
+			}
+			lines, err := result.LinesStruct()
+			if err != nil {
+				t.Fatal("lines struct:", err)
+			}
+			if got := lines.Fields["line"].GetNumberValue(); got != 1 {
+				t.Errorf("expected the file to start on line 1, got %v", got)
+			}
+		})
+	}
+}
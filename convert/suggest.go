@@ -0,0 +1,26 @@
+package convert
+
+import (
+	"github.com/agext/levenshtein"
+)
+
+// suggestion returns the candidate closest to given, or an empty string if
+// none are close enough to suggest a typo.
+func suggestion(given string, candidates []string) string {
+	best, bestDist := "", 3 // the same threshold HCL uses
+	for _, candidate := range candidates {
+		if dist := levenshtein.Distance(given, candidate, nil); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// quotedSuggestion returns the quoted candidate closest to given, for the
+// suggestion parameter of a message, or an empty string if none are close.
+func quotedSuggestion(given string, candidates []string) string {
+	if s := suggestion(given, candidates); s != "" {
+		return quoted(s)
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// declarations returns the addresses of everything declared in a Terraform
+// body which can be referenced, such as var.region or aws_instance.web.
+func declarations(body *hclsyntax.Body) map[string]bool {
+	declared := make(map[string]bool)
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "resource", "data", "module", "variable":
+			declared[blockAddress(block)] = true
+		case "locals":
+			for name := range block.Body.Attributes {
+				declared["local."+name] = true
+			}
+		}
+	}
+	return declared
+}
+
+// Symbol is something declared in a Terraform file which can be referenced,
+// such as var.region or aws_instance.web, with the range of its name.
+type Symbol struct {
+	Address string    `json:"address"`
+	Kind    string    `json:"kind"`
+	Range   hcl.Range `json:"range"`
+}
+
+// Symbols returns everything declared in a Terraform file which can be
+// referenced, in source order.
+func Symbols(file *hcl.File) ([]Symbol, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "resource", "data", "module", "variable":
+			addr := blockAddress(block)
+			symbols = append(symbols, Symbol{Address: addr, Kind: referenceKind(addr), Range: block.DefRange()})
+		case "locals":
+			for _, attr := range sortedAttributes(block.Body) {
+				symbols = append(symbols, Symbol{Address: "local." + attr.Name, Kind: "local value", Range: attr.NameRange})
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// UndefinedReferences reports references to variables, local values,
+// resources, data sources and modules which aren't declared in a Terraform
+// file, suggesting the closest declared name where one looks like a typo.
+// Configuration split across several files should be checked together.
+func UndefinedReferences(file *hcl.File) (hcl.Diagnostics, error) {
+	diags, _, err := DefaultCatalog.UndefinedReferences(file)
+	return diags, err
+}
+
+// UndefinedReferences is like the function of the same name, but renders
+// the diagnostics with the catalog and returns the messages they were
+// rendered from.
+func (c Catalog) UndefinedReferences(file *hcl.File) (hcl.Diagnostics, Messages, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	declared := declarations(body)
+	byKind := make(map[string][]string)
+	for addr := range declared {
+		kind := referenceKind(addr)
+		byKind[kind] = append(byKind[kind], addr)
+	}
+	for _, addrs := range byKind {
+		sort.Strings(addrs)
+	}
+
+	var diags hcl.Diagnostics
+	messages := make(Messages)
+	for _, attr := range attributes(body) {
+		for _, traversal := range attr.attr.Expr.Variables() {
+			addr, ok := referenceAddress(traversal)
+			if !ok || declared[addr] {
+				continue
+			}
+			kind := referenceKind(addr)
+			m := Message{ID: "undeclared-reference", Params: map[string]string{"kind": kind, "address": quoted(addr)}}
+			if s := quotedSuggestion(addr, byKind[kind]); s != "" {
+				m.Params["suggestion"] = s
+			}
+			diags = append(diags, c.diagnostic(messages, hcl.DiagError, traversal.SourceRange(), m))
+		}
+	}
+	return diags, messages, nil
+}
+
+// referenceKind describes the kind of object an address refers to.
+func referenceKind(addr string) string {
+	switch strings.SplitN(addr, ".", 2)[0] {
+	case "var":
+		return "input variable"
+	case "local":
+		return "local value"
+	case "data":
+		return "data source"
+	case "module":
+		return "module"
+	default:
+		return "resource"
+	}
+}
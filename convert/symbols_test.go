@@ -0,0 +1,48 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestUndefinedReferences(t *testing.T) {
+	input := `
+variable "region" {}
+
+locals {
+	name = "web"
+}
+
+resource "aws_instance" "web" {
+	tags = {
+		Region = var.regoin
+		Name   = local.name
+	}
+	subnet_id = aws_subnet.main.id
+}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	diags, err := UndefinedReferences(file)
+	if err != nil {
+		t.Fatal("undefined references:", err)
+	}
+
+	expected := []string{
+		`No input variable named "var.regoin" has been declared. Did you mean "var.region"?`,
+		`No resource named "aws_subnet.main" has been declared.`,
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), diags)
+	}
+	for i, detail := range expected {
+		if diags[i].Detail != detail {
+			t.Errorf("expected %q, got %q", detail, diags[i].Detail)
+		}
+	}
+}
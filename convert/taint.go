@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"sort"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// TaintedAttribute is an attribute whose value derives from one or more of
+// the variables given to Taint.
+type TaintedAttribute struct {
+	Path      string    `json:"path"`
+	Range     hcl.Range `json:"range"`
+	Variables []string  `json:"variables"`
+}
+
+// Taint reports the attributes whose values derive from the given variables,
+// either directly or transitively through local values and references to
+// other blocks, such as an instance referring to a tainted security group.
+func Taint(file *hcl.File, variables []string) ([]TaintedAttribute, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+	attrs := attributes(body)
+
+	// sources maps each address to the set of variables flowing into it.
+	sources := make(map[string]map[string]bool)
+	for _, name := range variables {
+		sources["var."+name] = map[string]bool{name: true}
+	}
+
+	taint := func(addr string, from map[string]bool) bool {
+		changed := false
+		if sources[addr] == nil {
+			sources[addr] = make(map[string]bool)
+		}
+		for name := range from {
+			if !sources[addr][name] {
+				sources[addr][name] = true
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	tainted := make([]map[string]bool, len(attrs))
+	for changed := true; changed; {
+		changed = false
+		for i, attr := range attrs {
+			for _, ref := range references(attr.attr.Expr) {
+				if len(sources[ref]) == 0 {
+					continue
+				}
+				if tainted[i] == nil {
+					tainted[i] = make(map[string]bool)
+				}
+				for name := range sources[ref] {
+					tainted[i][name] = true
+				}
+				if taint(attr.owner, sources[ref]) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	var result []TaintedAttribute
+	for i, attr := range attrs {
+		if len(tainted[i]) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(tainted[i]))
+		for name := range tainted[i] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		result = append(result, TaintedAttribute{
+			Path:      attr.pathString(),
+			Range:     attr.attr.SrcRange,
+			Variables: names,
+		})
+	}
+	return result, nil
+}
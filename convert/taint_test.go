@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestTaint(t *testing.T) {
+	input := `
+variable "cidr" {}
+variable "name" {}
+
+locals {
+	prefix = "${var.name}-app"
+	unrelated = "x"
+}
+
+resource "aws_security_group" "sg" {
+	ingress {
+		cidr_blocks = [var.cidr]
+	}
+}
+
+resource "aws_instance" "web" {
+	ami = "ami-123"
+	vpc_security_group_ids = [aws_security_group.sg.id]
+	tags = {
+		Name = local.prefix
+	}
+}`
+
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	tainted, err := Taint(file, []string{"cidr", "name"})
+	if err != nil {
+		t.Fatal("taint:", err)
+	}
+
+	actual := make(map[string][]string)
+	for _, attr := range tainted {
+		actual[attr.Path] = attr.Variables
+	}
+	expected := map[string][]string{
+		"locals.prefix": {"name"},
+		"resource.aws_security_group.sg.ingress.cidr_blocks": {"cidr"},
+		"resource.aws_instance.web.vpc_security_group_ids":   {"cidr"},
+		"resource.aws_instance.web.tags":                     {"name"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	if tainted[0].Range.Start.Line != 6 {
+		t.Errorf("expected the first tainted attribute on line 6, got %v", tainted[0].Range)
+	}
+}
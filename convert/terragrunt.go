@@ -0,0 +1,519 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// terragruntFunctions are Terragrunt's built-in functions which only depend
+// on where the configuration is, and so are known to be safe to evaluate
+// with DialectTerragrunt.
+var terragruntFunctions = []string{
+	"find_in_parent_folders", "get_original_terragrunt_dir",
+	"get_parent_terragrunt_dir", "get_terragrunt_dir",
+	"path_relative_from_include", "path_relative_to_include",
+}
+
+// terragruntFile is a Terragrunt configuration being converted, whose place
+// within Options.FS the built-in functions are evaluated for. Paths are
+// relative to the root of the filesystem, which stands in for the absolute
+// paths Terragrunt would use.
+type terragruntFile struct {
+	fsys fs.FS
+
+	// path is the file being converted, and original the terragrunt.hcl
+	// being resolved, which is path unless path was included by it.
+	path, original string
+
+	// includes holds the paths of the files included by original, keyed
+	// by the labels of their include blocks.
+	includes map[string]string
+}
+
+// functions returns the implementations of terragruntFunctions.
+func (f *terragruntFile) functions() map[string]function.Function {
+	dir := function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(path.Dir(f.original)), nil
+		},
+	})
+	return map[string]function.Function{
+		"find_in_parent_folders": function.New(&function.Spec{
+			VarParam: &function.Parameter{Name: "args", Type: cty.String},
+			Type:     function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				name := "terragrunt.hcl"
+				if len(args) > 0 {
+					name = args[0].AsString()
+				}
+				found, err := f.findInParentFolders(name)
+				if err != nil && len(args) > 1 {
+					return args[1], nil
+				}
+				if err != nil {
+					return cty.UnknownVal(cty.String), err
+				}
+				return cty.StringVal(found), nil
+			},
+		}),
+		"get_terragrunt_dir":          dir,
+		"get_original_terragrunt_dir": dir,
+		"get_parent_terragrunt_dir": f.includeFunc(func(include string) string {
+			return path.Dir(include)
+		}),
+		"path_relative_to_include": f.includeFunc(func(include string) string {
+			return relativePath(path.Dir(include), path.Dir(f.original))
+		}),
+		"path_relative_from_include": f.includeFunc(func(include string) string {
+			return relativePath(path.Dir(f.original), path.Dir(include))
+		}),
+	}
+}
+
+// includeFunc returns a function of the path of an included file, taking
+// the label of the include block when there's more than one.
+func (f *terragruntFile) includeFunc(fn func(include string) string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "name", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			var label *string
+			if len(args) > 0 {
+				name := args[0].AsString()
+				label = &name
+			}
+			include, err := f.include(label)
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(fn(include)), nil
+		},
+	})
+}
+
+// include returns the path of the file included under label, or of the
+// only included file when label is nil. Within an included file, it's the
+// file itself, and without includes it's the original file.
+func (f *terragruntFile) include(label *string) (string, error) {
+	if f.path != f.original {
+		return f.path, nil
+	}
+	if label != nil {
+		include, ok := f.includes[*label]
+		if !ok {
+			return "", fmt.Errorf("no include named %q", *label)
+		}
+		return include, nil
+	}
+	switch len(f.includes) {
+	case 0:
+		return f.original, nil
+	case 1:
+		for _, include := range f.includes {
+			return include, nil
+		}
+	}
+	return "", fmt.Errorf("the include must be named when there's more than one")
+}
+
+// findInParentFolders returns the path of the nearest file called name in
+// the folders above the original configuration, relative to the folder of
+// the file being converted, as include paths are.
+func (f *terragruntFile) findInParentFolders(name string) (string, error) {
+	start := path.Dir(f.original)
+	for dir := start; dir != "."; {
+		dir = path.Dir(dir)
+		candidate := path.Join(dir, name)
+		if info, err := fs.Stat(f.fsys, candidate); err == nil && !info.IsDir() {
+			return relativePath(path.Dir(f.path), candidate), nil
+		}
+	}
+	return "", fmt.Errorf("no %s found in the folders above %s", name, start)
+}
+
+func relativePath(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(from), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Terragrunt converts the Terragrunt configuration at name within
+// Options.FS, such as live/prod/app/terragrunt.hcl, to the configuration
+// Terragrunt would run with. It's simplified with DialectTerragrunt, whose
+// built-in functions, such as find_in_parent_folders, are evaluated for the
+// file's place in Options.FS, and:
+//
+//   - the locals of each file are evaluated and available as local.<name>
+//   - dependency blocks make their mock_outputs available as
+//     dependency.<name>.outputs, as the dependencies aren't applied
+//   - the file named by each include block is converted in turn, and the
+//     configuration merged over it following the block's merge_strategy,
+//     with shallow and deep merges of inputs and labelled blocks. When the
+//     block sets expose, the included file's attributes and locals are
+//     available as include.<name>.
+//
+// The include blocks themselves are left out of the output, and the line
+// info records the file each value came from. Nested includes are limited
+// by Options.MaxIncludeDepth.
+func Terragrunt(name string, options Options) (*Result, error) {
+	if options.FS == nil {
+		return nil, errNoFS
+	}
+	options, err := options.lineOptions()
+	if err != nil {
+		return nil, err
+	}
+	options.Dialect = DialectTerragrunt
+	options.Simplify = true
+
+	r := &terragruntResolver{options: options, chain: newIncludeChain(name, options)}
+	config, err := r.resolve(name, name)
+	if err != nil {
+		return nil, err
+	}
+	return encodeResult(config.out, config.lines, r.diags, r.messages, options)
+}
+
+// terragruntResolver converts a Terragrunt configuration and the files it
+// includes.
+type terragruntResolver struct {
+	options  Options
+	chain    *includeChain
+	diags    hcl.Diagnostics
+	messages Messages
+}
+
+// terragruntConfig is a converted Terragrunt configuration, merged over
+// those it includes, with the values its expressions were evaluated with.
+type terragruntConfig struct {
+	out          jsonObj
+	lines        lineObj
+	locals       map[string]cty.Value
+	dependencies map[string]cty.Value
+}
+
+// resolve converts the file at name, which is original or included by it.
+func (r *terragruntResolver) resolve(name, original string) (*terragruntConfig, error) {
+	src, err := fs.ReadFile(r.options.FS, name)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parse %s: %v", name, diags.Errs())
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	tg := &terragruntFile{fsys: r.options.FS, path: name, original: original, includes: make(map[string]string)}
+	c := &converter{bytes: file.Bytes, options: r.options, schema: r.options.Schema, terragrunt: tg}
+	ctx := c.evalContext()
+	variables := make(map[string]cty.Value)
+	for key, value := range r.options.Variables {
+		variables[key] = value
+	}
+	ctx.Variables = variables
+
+	config := &terragruntConfig{locals: make(map[string]cty.Value), dependencies: make(map[string]cty.Value)}
+	var bases []*terragruntConfig
+	var strategies []string
+	exposed := make(map[string]cty.Value)
+	for _, block := range body.Blocks {
+		if block.Type != "include" {
+			continue
+		}
+		var label string
+		if len(block.Labels) > 0 {
+			label = block.Labels[0]
+		}
+		include, err := includeString(block, "path", "", ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		strategy, err := includeString(block, "merge_strategy", "shallow", ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if strategy != "shallow" && strategy != "deep" && strategy != "no_merge" {
+			return nil, fmt.Errorf("%s: unsupported merge strategy %q", name, strategy)
+		}
+		include = path.Join(path.Dir(name), include)
+		if !fs.ValidPath(include) {
+			return nil, fmt.Errorf("%s: include %q is outside of the configured filesystem root", name, include)
+		}
+		tg.includes[label] = include
+
+		if err := r.chain.push(include); err != nil {
+			return nil, err
+		}
+		base, err := r.resolve(include, original)
+		r.chain.pop()
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range base.dependencies {
+			config.dependencies[key] = value
+		}
+		if expose, ok := block.Body.Attributes["expose"]; ok && label != "" {
+			if value, diags := expose.Expr.Value(ctx); !diags.HasErrors() && value.Type() == cty.Bool && value.True() {
+				exposed[label] = base.value()
+			}
+		}
+		bases = append(bases, base)
+		strategies = append(strategies, strategy)
+	}
+	if len(exposed) > 0 {
+		variables["include"] = cty.ObjectVal(exposed)
+	}
+
+	config.evalLocals(body, variables, ctx)
+	config.evalDependencies(body, variables, ctx)
+
+	out, lines, err := c.convertBody(body)
+	if err != nil {
+		return nil, fmt.Errorf("convert %s: %w", name, err)
+	}
+	delete(out, "include")
+	delete(lines, "include")
+	tagFile(lines, name, r.options.LineKeyPrefix)
+	if name == original && r.options.Provenance {
+		out[ProvenanceKey] = newProvenance(file, r.options)
+	}
+	r.diags = append(r.diags, c.diags...)
+	for diag, m := range c.messages {
+		if r.messages == nil {
+			r.messages = make(Messages)
+		}
+		r.messages[diag] = m
+	}
+
+	config.out, config.lines = make(jsonObj), make(lineObj)
+	deep := false
+	for i, base := range bases {
+		if strategies[i] != "no_merge" {
+			mergeTerragrunt(config.out, config.lines, base.out, base.lines, strategies[i] == "deep", r.options.LineKeyPrefix)
+		}
+		deep = deep || strategies[i] == "deep"
+	}
+	mergeTerragrunt(config.out, config.lines, out, lines, deep, r.options.LineKeyPrefix)
+	for _, key := range []string{"line", "startIndex", "endIndex", "endLine", "type", "startByte", "endByte", "file"} {
+		if value, ok := lines[r.options.LineKeyPrefix+key]; ok {
+			config.lines[r.options.LineKeyPrefix+key] = value
+		}
+	}
+	return config, nil
+}
+
+// includeString evaluates a string attribute of an include block, which is
+// required when there's no default.
+func includeString(block *hclsyntax.Block, name, def string, ctx *hcl.EvalContext) (string, error) {
+	attr, ok := block.Body.Attributes[name]
+	if !ok {
+		if def == "" {
+			return "", fmt.Errorf("include has no %s", name)
+		}
+		return def, nil
+	}
+	value, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("evaluate include %s: %v", name, diags.Errs())
+	}
+	if !value.IsWhollyKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", fmt.Errorf("include %s must be a known string", name)
+	}
+	return value.AsString(), nil
+}
+
+// evalLocals evaluates the locals of body, setting local in variables.
+// Locals may refer to each other, so they're evaluated in rounds until no
+// more can be, leaving out any which couldn't.
+func (config *terragruntConfig) evalLocals(body *hclsyntax.Body, variables map[string]cty.Value, ctx *hcl.EvalContext) {
+	var pending []*hclsyntax.Attribute
+	for _, block := range body.Blocks {
+		if block.Type == "locals" {
+			for _, attr := range block.Body.Attributes {
+				pending = append(pending, attr)
+			}
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Name < pending[j].Name })
+
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		variables["local"] = cty.ObjectVal(config.locals)
+		remaining := pending[:0]
+		for _, attr := range pending {
+			value, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() || !value.IsWhollyKnown() {
+				remaining = append(remaining, attr)
+				continue
+			}
+			config.locals[attr.Name] = value
+			progress = true
+		}
+		pending = remaining
+	}
+	variables["local"] = cty.ObjectVal(config.locals)
+}
+
+// evalDependencies records the dependency blocks of body, setting
+// dependency in variables along with those of the included files.
+func (config *terragruntConfig) evalDependencies(body *hclsyntax.Body, variables map[string]cty.Value, ctx *hcl.EvalContext) {
+	for _, block := range body.Blocks {
+		if block.Type != "dependency" || len(block.Labels) != 1 {
+			continue
+		}
+		dependency := make(map[string]cty.Value)
+		for _, name := range []string{"config_path", "mock_outputs"} {
+			attr, ok := block.Body.Attributes[name]
+			if !ok {
+				continue
+			}
+			if value, diags := attr.Expr.Value(ctx); !diags.HasErrors() && value.IsWhollyKnown() {
+				dependency[name] = value
+			}
+		}
+		if outputs, ok := dependency["mock_outputs"]; ok {
+			dependency["outputs"] = outputs
+		}
+		config.dependencies[block.Labels[0]] = cty.ObjectVal(dependency)
+	}
+	if len(config.dependencies) > 0 {
+		variables["dependency"] = cty.ObjectVal(config.dependencies)
+	}
+}
+
+// value returns what an include exposes of the configuration: its
+// attributes, such as inputs, and its locals.
+func (config *terragruntConfig) value() cty.Value {
+	attrs := make(map[string]interface{})
+	for key, value := range config.out {
+		if _, isBlock := value.([]jsonObj); !isBlock {
+			attrs[key] = value
+		}
+	}
+	exposed := map[string]cty.Value{"locals": cty.ObjectVal(config.locals)}
+	if b, err := json.Marshal(attrs); err == nil {
+		if typ, err := ctyjson.ImpliedType(b); err == nil {
+			if value, err := ctyjson.Unmarshal(b, typ); err == nil {
+				for key, attr := range value.AsValueMap() {
+					exposed[key] = attr
+				}
+			}
+		}
+	}
+	return cty.ObjectVal(exposed)
+}
+
+// mergeTerragrunt merges a configuration over the one it includes, as
+// Terragrunt does. Attributes replace those of the base, except inputs,
+// whose keys are merged. Blocks with the same labels replace those of the
+// base, and others are added. A deep merge instead merges the attributes of
+// objects and blocks recursively.
+func mergeTerragrunt(cfg jsonObj, lcfg lineObj, over jsonObj, overLines lineObj, deep bool, prefix string) {
+	for key, value := range over {
+		base, exists := cfg[key]
+		blocks, isBlock := value.([]jsonObj)
+		baseBlocks, baseIsBlock := base.([]jsonObj)
+		switch {
+		case !exists:
+			cfg[key], lcfg[key] = value, overLines[key]
+		case isBlock && baseIsBlock:
+			baseLines := lcfg[key].([]lineObj)
+			for i, block := range blocks {
+				blockLines := overLines[key].([]lineObj)[i]
+				labels, _ := blockBodyLines(blockLines, prefix)
+				j := matchingBlock(baseLines, labels, prefix)
+				switch {
+				case j < 0:
+					baseBlocks = append(baseBlocks, block)
+					baseLines = append(baseLines, blockLines)
+				case deep:
+					_, bodyLines := blockBodyLines(baseLines[j], prefix)
+					_, overBody := blockBodyLines(blockLines, prefix)
+					mergeTerragrunt(blockBody(baseBlocks[j], labels), bodyLines, blockBody(block, labels), overBody, deep, prefix)
+				default:
+					baseBlocks[j], baseLines[j] = block, blockLines
+				}
+			}
+			cfg[key], lcfg[key] = baseBlocks, baseLines
+		case key == "inputs" || deep:
+			baseObj, baseOK := objectEntries(base)
+			overObj, overOK := objectEntries(value)
+			if !baseOK || !overOK {
+				cfg[key], lcfg[key] = value, overLines[key]
+				continue
+			}
+			merged := make(jsonObj, len(baseObj)+len(overObj))
+			for k, v := range baseObj {
+				merged[k] = v
+			}
+			mergedLines := make(lineObj)
+			if l, ok := lcfg[key].(lineObj); ok {
+				for k, v := range l {
+					mergedLines[k] = v
+				}
+			}
+			overObjLines, _ := overLines[key].(lineObj)
+			for k, v := range overObjLines {
+				if _, isEntry := overObj[k]; !isEntry || !deep {
+					mergedLines[k] = v
+				}
+			}
+			if deep {
+				mergeTerragrunt(merged, mergedLines, overObj, overObjLines, deep, prefix)
+			} else {
+				for k, v := range overObj {
+					merged[k] = v
+				}
+			}
+			cfg[key], lcfg[key] = merged, mergedLines
+		default:
+			cfg[key], lcfg[key] = value, overLines[key]
+		}
+	}
+}
+
+// matchingBlock returns the index of the block with the given labels, or
+// -1 when there's none.
+func matchingBlock(lines []lineObj, labels []string, prefix string) int {
+	for i, l := range lines {
+		blockLabels, _ := blockBodyLines(l, prefix)
+		if strings.Join(blockLabels, "\x00") == strings.Join(labels, "\x00") {
+			return i
+		}
+	}
+	return -1
+}
+
+// objectEntries returns the attributes of a converted object, which is
+// either converted attribute by attribute or evaluated as a whole.
+func objectEntries(value interface{}) (jsonObj, bool) {
+	switch v := value.(type) {
+	case jsonObj:
+		return v, true
+	case ctyjson.SimpleJSONValue:
+		if v.IsNull() || !v.IsKnown() || !(v.Type().IsObjectType() || v.Type().IsMapType()) {
+			return nil, false
+		}
+		entries := make(jsonObj)
+		for key, attr := range v.AsValueMap() {
+			entries[key] = ctyjson.SimpleJSONValue{Value: attr}
+		}
+		return entries, true
+	}
+	return nil, false
+}
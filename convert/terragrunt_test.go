@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTerragrunt(t *testing.T) {
+	fsys := fstest.MapFS{
+		"live/terragrunt.hcl": {Data: []byte(`locals {
+  region = "eu-west-1"
+}
+
+remote_state {
+  backend = "s3"
+  config = {
+    key = "${path_relative_to_include()}/terraform.tfstate"
+  }
+}
+
+inputs = {
+  region = local.region
+  owner  = "platform"
+}
+`)},
+		"live/prod/app/terragrunt.hcl": {Data: []byte(`include "root" {
+  path   = find_in_parent_folders()
+  expose = true
+}
+
+locals {
+  name = "app-${local.env}"
+  env  = "prod"
+}
+
+dependency "vpc" {
+  config_path  = "../vpc"
+  mock_outputs = { vpc_id = "vpc-123" }
+}
+
+terraform {
+  source = "${get_terragrunt_dir()}/../../../modules/app"
+}
+
+inputs = {
+  name   = local.name
+  vpc_id = dependency.vpc.outputs.vpc_id
+  region = include.root.locals.region
+  owner  = "app"
+}
+`)},
+	}
+
+	result, err := Terragrunt("live/prod/app/terragrunt.hcl", Options{FS: fsys})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+		"dependency": [{"vpc": {"config_path": "../vpc", "mock_outputs": {"vpc_id": "vpc-123"}}}],
+		"inputs": {"name": "app-prod", "owner": "app", "region": "eu-west-1", "vpc_id": "vpc-123"},
+		"locals": [{"env": "prod", "name": "app-prod"}],
+		"remote_state": [{"backend": "s3", "config": {"key": "prod/app/terraform.tfstate"}}],
+		"terraform": [{"source": "live/prod/app/../../../modules/app"}]
+	}`))
+
+	lines := decodeLines(t, result.Lines)
+	state := lines["remote_state"].([]interface{})[0].(map[string]interface{})
+	if state["file"] != "live/terragrunt.hcl" {
+		t.Errorf("expected remote_state to come from the included file, got %v", state["file"])
+	}
+	if inputs := lines["inputs"].(map[string]interface{}); inputs["file"] != "live/prod/app/terragrunt.hcl" {
+		t.Errorf("expected inputs to come from the configuration, got %v", inputs["file"])
+	}
+}
+
+func TestTerragruntIncludeCycle(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/terragrunt.hcl": {Data: []byte(`include { path = "../b/terragrunt.hcl" }`)},
+		"b/terragrunt.hcl": {Data: []byte(`include { path = "../a/terragrunt.hcl" }`)},
+	}
+	_, err := Terragrunt("a/terragrunt.hcl", Options{FS: fsys})
+	var includeErr *IncludeError
+	if !errors.As(err, &includeErr) || !includeErr.Cycle {
+		t.Fatalf("expected an include cycle, got %v", err)
+	}
+}
+
+func TestTerragruntDialect(t *testing.T) {
+	fsys := fstest.MapFS{"live/app/terragrunt.hcl": {Data: []byte(`dir = get_terragrunt_dir()`)}}
+	src, _ := fsys.ReadFile("live/app/terragrunt.hcl")
+	out, _, err := Bytes(src, "live/app/terragrunt.hcl", Options{Simplify: true, Dialect: DialectTerragrunt, FS: fsys})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{"dir": "live/app"}`))
+
+	out, _, err = Bytes(src, "live/app/terragrunt.hcl", Options{Simplify: true, Dialect: DialectTerraform, FS: fsys})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{"dir": "${get_terragrunt_dir()}"}`))
+}
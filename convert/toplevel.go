@@ -0,0 +1,279 @@
+package convert
+
+import (
+	"strconv"
+
+	"github.com/ckndave/hclparser/addr"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Moved is a moved block, recording that the object at the address From is
+// now at To.
+type Moved struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Range hcl.Range `json:"range"`
+}
+
+// Import is an import block, bringing the existing object with the given ID
+// under management at the address To. ID is the import ID when it's a
+// literal string, and otherwise empty with the expression in IDExpression,
+// wrapped in ${...}, as is ForEach.
+type Import struct {
+	To           string    `json:"to"`
+	ID           string    `json:"id,omitempty"`
+	IDExpression string    `json:"id_expression,omitempty"`
+	Provider     string    `json:"provider,omitempty"`
+	ForEach      string    `json:"for_each,omitempty"`
+	Range        hcl.Range `json:"range"`
+}
+
+// Check is a check block, with the addresses of the data sources scoped to
+// it.
+type Check struct {
+	Name        string      `json:"name"`
+	DataSources []string    `json:"data_sources,omitempty"`
+	Assertions  []Assertion `json:"assertions"`
+	Range       hcl.Range   `json:"range"`
+}
+
+// Assertion is an assert block of a check, with its condition wrapped in
+// ${...}. ErrorMessage is wrapped too when it isn't a literal string.
+type Assertion struct {
+	Condition    string    `json:"condition"`
+	ErrorMessage string    `json:"error_message"`
+	Range        hcl.Range `json:"range"`
+}
+
+// Removed is a removed block, recording that the object at the address From
+// is no longer managed. Destroy is whether the object is destroyed rather
+// than forgotten, as set in its lifecycle block.
+type Removed struct {
+	From    string    `json:"from"`
+	Destroy bool      `json:"destroy"`
+	Range   hcl.Range `json:"range"`
+}
+
+// TopLevel holds the moved, import, check and removed blocks of a file.
+// Findings reports any of them missing an argument they require, or
+// having labels or arguments they don't support. Blocks are included even when
+// they have findings, with whatever could be read from them.
+type TopLevel struct {
+	Moved    []Moved   `json:"moved"`
+	Imports  []Import  `json:"imports"`
+	Checks   []Check   `json:"checks"`
+	Removed  []Removed `json:"removed"`
+	Findings []Finding `json:"findings"`
+}
+
+// TopLevelBlocks extracts the moved, import, check and removed blocks of a
+// Terraform file in source order, validating their arguments.
+func TopLevelBlocks(file *hcl.File) (*TopLevel, error) {
+	body, err := fileBody(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := converter{bytes: file.Bytes}
+	blocks := topLevelReader{c: &c}
+	top := &TopLevel{Moved: []Moved{}, Imports: []Import{}, Checks: []Check{}, Removed: []Removed{}}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "moved":
+			if blocks.labels(block, 0) {
+				top.Moved = append(top.Moved, blocks.moved(block))
+			}
+		case "import":
+			if blocks.labels(block, 0) {
+				top.Imports = append(top.Imports, blocks.importBlock(block))
+			}
+		case "check":
+			if blocks.labels(block, 1) {
+				top.Checks = append(top.Checks, blocks.check(block))
+			}
+		case "removed":
+			if blocks.labels(block, 0) {
+				top.Removed = append(top.Removed, blocks.removed(block))
+			}
+		}
+	}
+	top.Findings = DefaultCatalog.Findings(blocks.findings)
+	return top, nil
+}
+
+// topLevelReader reads top level blocks, collecting findings about them.
+type topLevelReader struct {
+	c        *converter
+	findings []Finding
+}
+
+func (r *topLevelReader) find(rule, path string, rng hcl.Range, params ...string) {
+	finding := Finding{Rule: rule, Path: path, Range: rng, Params: make(map[string]string)}
+	for i := 0; i+1 < len(params); i += 2 {
+		finding.Params[params[i]] = params[i+1]
+	}
+	r.findings = append(r.findings, finding)
+}
+
+// labels reports whether block has the expected number of labels.
+func (r *topLevelReader) labels(block *hclsyntax.Block, expected int) bool {
+	if len(block.Labels) == expected {
+		return true
+	}
+	r.find("unexpected-block-labels", blockAddress(block), block.DefRange(),
+		"type", block.Type, "expected", strconv.Itoa(expected), "count", strconv.Itoa(len(block.Labels)))
+	return false
+}
+
+// arguments checks body has every required attribute and no attributes or
+// blocks other than those allowed.
+func (r *topLevelReader) arguments(path string, body *hclsyntax.Body, required, optional, blocks []string) {
+	allowed := make(map[string]bool)
+	for _, name := range append(required, optional...) {
+		allowed[name] = true
+	}
+	for _, name := range required {
+		if _, ok := body.Attributes[name]; !ok {
+			r.find("missing-required-argument", path+"."+name, body.SrcRange, "name", name)
+		}
+	}
+	for _, attr := range sortedAttributes(body) {
+		if !allowed[attr.Name] {
+			r.find("unsupported-argument", path+"."+attr.Name, attr.NameRange, "name", attr.Name)
+		}
+	}
+	nested := make(map[string]bool)
+	for _, name := range blocks {
+		nested[name] = true
+	}
+	for _, block := range body.Blocks {
+		if !nested[block.Type] {
+			r.find("unsupported-block-type", path+"."+block.Type, block.TypeRange, "type", block.Type)
+		}
+	}
+}
+
+// address returns the address an attribute refers to, such as
+// aws_instance.web[0], reporting a finding when it isn't a reference. With
+// dynamic, the last index may be an expression, as in the to of an import
+// using for_each, and the address is returned as written.
+func (r *topLevelReader) address(path string, body *hclsyntax.Body, name string, dynamic bool) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	traversal, diags := hcl.AbsTraversalForExpr(attr.Expr)
+	if diags.HasErrors() {
+		if index, ok := attr.Expr.(*hclsyntax.IndexExpr); ok && dynamic {
+			if _, diags := hcl.AbsTraversalForExpr(index.Collection); !diags.HasErrors() {
+				return r.c.rangeSource(attr.Expr.Range())
+			}
+		}
+		r.find("incorrect-value-type", path+"."+name, attr.Expr.Range(),
+			"name", name, "error", "an address is required")
+		return ""
+	}
+	return addr.String(traversal)
+}
+
+// expression returns the value of an attribute when it's a literal string,
+// and otherwise its expression wrapped in ${...}.
+func (r *topLevelReader) expression(body *hclsyntax.Body, name string) (value string, literal bool) {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return "", false
+	}
+	if s, ok := literalString(attr.Expr); ok {
+		return s, true
+	}
+	return r.c.wrapExpr(attr.Expr), false
+}
+
+func (r *topLevelReader) moved(block *hclsyntax.Block) Moved {
+	r.arguments("moved", block.Body, []string{"from", "to"}, nil, nil)
+	return Moved{
+		From:  r.address("moved", block.Body, "from", false),
+		To:    r.address("moved", block.Body, "to", false),
+		Range: block.DefRange(),
+	}
+}
+
+func (r *topLevelReader) importBlock(block *hclsyntax.Block) Import {
+	r.arguments("import", block.Body, []string{"to", "id"}, []string{"provider", "for_each"}, nil)
+	imp := Import{
+		To:       r.address("import", block.Body, "to", block.Body.Attributes["for_each"] != nil),
+		Provider: r.address("import", block.Body, "provider", false),
+		Range:    block.DefRange(),
+	}
+	if id, literal := r.expression(block.Body, "id"); literal {
+		imp.ID = id
+	} else {
+		imp.IDExpression = id
+	}
+	if attr, ok := block.Body.Attributes["for_each"]; ok {
+		imp.ForEach = r.c.wrapExpr(attr.Expr)
+	}
+	return imp
+}
+
+func (r *topLevelReader) check(block *hclsyntax.Block) Check {
+	path := "check." + block.Labels[0]
+	r.arguments(path, block.Body, nil, nil, []string{"assert", "data"})
+	check := Check{Name: block.Labels[0], Assertions: []Assertion{}, Range: block.DefRange()}
+	for _, nested := range block.Body.Blocks {
+		switch nested.Type {
+		case "data":
+			if len(nested.Labels) != 2 {
+				r.find("unexpected-block-labels", path+".data", nested.DefRange(),
+					"type", nested.Type, "expected", "2", "count", strconv.Itoa(len(nested.Labels)))
+				continue
+			}
+			check.DataSources = append(check.DataSources, blockAddress(nested))
+		case "assert":
+			r.arguments(path+".assert", nested.Body, []string{"condition", "error_message"}, nil, nil)
+			var assertion Assertion
+			if attr, ok := nested.Body.Attributes["condition"]; ok {
+				assertion.Condition = r.c.wrapExpr(attr.Expr)
+			}
+			assertion.ErrorMessage, _ = r.expression(nested.Body, "error_message")
+			assertion.Range = nested.DefRange()
+			check.Assertions = append(check.Assertions, assertion)
+		}
+	}
+	if len(check.Assertions) == 0 {
+		r.find("missing-required-block", path+".assert", block.DefRange(), "type", "assert")
+	}
+	if len(check.DataSources) > 1 {
+		r.find("unsupported-block-type", path+".data", block.DefRange(), "type", "data")
+	}
+	return check
+}
+
+func (r *topLevelReader) removed(block *hclsyntax.Block) Removed {
+	r.arguments("removed", block.Body, []string{"from"}, nil, []string{"lifecycle", "provisioner", "connection"})
+	removed := Removed{
+		From:    r.address("removed", block.Body, "from", false),
+		Destroy: true,
+		Range:   block.DefRange(),
+	}
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "lifecycle" {
+			continue
+		}
+		r.arguments("removed.lifecycle", nested.Body, nil, []string{"destroy"}, nil)
+		attr, ok := nested.Body.Attributes["destroy"]
+		if !ok {
+			continue
+		}
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || value.Type() != cty.Bool || value.IsNull() {
+			r.find("incorrect-value-type", "removed.lifecycle.destroy", attr.Expr.Range(),
+				"name", "destroy", "error", "a bool is required")
+			continue
+		}
+		removed.Destroy = value.True()
+	}
+	return removed
+}
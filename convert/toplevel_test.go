@@ -0,0 +1,133 @@
+package convert
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestTopLevelBlocks(t *testing.T) {
+	input := `
+moved {
+  from = aws_instance.web
+  to   = aws_instance.app["web"]
+}
+
+import {
+  to = aws_s3_bucket.logs
+  id = "company-logs"
+}
+
+import {
+  for_each = var.buckets
+  to       = aws_s3_bucket.this[each.key]
+  id       = each.value
+  provider = aws.west
+}
+
+check "health" {
+  data "http" "site" {
+    url = "https://example.com"
+  }
+
+  assert {
+    condition     = data.http.site.status_code == 200
+    error_message = "The site is down."
+  }
+}
+
+removed {
+  from = module.legacy
+
+  lifecycle {
+    destroy = false
+  }
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	top, err := TopLevelBlocks(file)
+	if err != nil {
+		t.Fatal("top level blocks:", err)
+	}
+	if len(top.Findings) != 0 {
+		t.Errorf("expected no findings, got %+v", top.Findings)
+	}
+
+	if len(top.Moved) != 1 || top.Moved[0].From != "aws_instance.web" || top.Moved[0].To != `aws_instance.app["web"]` {
+		t.Errorf("unexpected moved blocks %+v", top.Moved)
+	}
+	if len(top.Imports) != 2 {
+		t.Fatalf("expected 2 imports, got %+v", top.Imports)
+	}
+	if imp := top.Imports[0]; imp.To != "aws_s3_bucket.logs" || imp.ID != "company-logs" || imp.IDExpression != "" {
+		t.Errorf("unexpected import %+v", imp)
+	}
+	if imp := top.Imports[1]; imp.To != "aws_s3_bucket.this[each.key]" || imp.IDExpression != "${each.value}" || imp.Provider != "aws.west" || imp.ForEach != "${var.buckets}" {
+		t.Errorf("unexpected import %+v", imp)
+	}
+	if len(top.Checks) != 1 {
+		t.Fatalf("expected 1 check, got %+v", top.Checks)
+	}
+	check := top.Checks[0]
+	if check.Name != "health" || len(check.DataSources) != 1 || check.DataSources[0] != "data.http.site" {
+		t.Errorf("unexpected check %+v", check)
+	}
+	if len(check.Assertions) != 1 || check.Assertions[0].Condition != "${data.http.site.status_code == 200}" || check.Assertions[0].ErrorMessage != "The site is down." {
+		t.Errorf("unexpected assertions %+v", check.Assertions)
+	}
+	if len(top.Removed) != 1 || top.Removed[0].From != "module.legacy" || top.Removed[0].Destroy {
+		t.Errorf("unexpected removed blocks %+v", top.Removed)
+	}
+}
+
+func TestTopLevelBlocksFindings(t *testing.T) {
+	input := `
+moved {
+  from = aws_instance.web
+  into = aws_instance.app
+}
+
+import "labelled" {
+  to = aws_s3_bucket.logs
+  id = "logs"
+}
+
+check "empty" {}
+
+removed {
+  from = "module.legacy"
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+
+	top, err := TopLevelBlocks(file)
+	if err != nil {
+		t.Fatal("top level blocks:", err)
+	}
+	expected := []struct{ rule, path string }{
+		{"missing-required-argument", "moved.to"},
+		{"unsupported-argument", "moved.into"},
+		{"unexpected-block-labels", "import.labelled"},
+		{"missing-required-block", "check.empty.assert"},
+		{"incorrect-value-type", "removed.from"},
+	}
+	if len(top.Findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %+v", len(expected), top.Findings)
+	}
+	for i, e := range expected {
+		if f := top.Findings[i]; f.Rule != e.rule || f.Path != e.path || f.Message == "" {
+			t.Errorf("finding %d: expected %s at %s, got %+v", i, e.rule, e.path, f)
+		}
+	}
+	if len(top.Imports) != 0 || len(top.Moved) != 1 || len(top.Checks) != 1 {
+		t.Errorf("expected blocks with the wrong labels to be left out, got %+v", top)
+	}
+}
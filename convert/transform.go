@@ -0,0 +1,225 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// RedactedValue replaces the values removed by Redact.
+const RedactedValue = "(redacted)"
+
+// ValueContext describes the attribute whose value is being transformed.
+type ValueContext struct {
+	// Path is the dot-separated path of the attribute, made up of the
+	// types and labels of its enclosing blocks followed by its name, such
+	// as resource.aws_instance.web.ami.
+	Path string
+
+	// Range is the range of the attribute's expression.
+	Range hcl.Range
+}
+
+// ValueTransformer rewrites the converted values of attributes, such as to
+// redact secrets or parse units. Values are given as encoding/json decodes
+// them, with numbers as json.Number, and include expressions which couldn't
+// be converted in the form chosen by Options.ExpressionMode.
+type ValueTransformer interface {
+	// Name identifies the transformer in the options fingerprint.
+	Name() string
+
+	Transform(ctx ValueContext, value interface{}) (interface{}, error)
+}
+
+type transformerFunc struct {
+	name string
+	fn   func(ValueContext, interface{}) (interface{}, error)
+}
+
+func (t transformerFunc) Name() string { return t.name }
+
+func (t transformerFunc) Transform(ctx ValueContext, value interface{}) (interface{}, error) {
+	return t.fn(ctx, value)
+}
+
+// TransformerFunc returns a ValueTransformer with the given name which
+// calls fn.
+func TransformerFunc(name string, fn func(ValueContext, interface{}) (interface{}, error)) ValueTransformer {
+	return transformerFunc{name: name, fn: fn}
+}
+
+// Redact returns a ValueTransformer replacing values matching any of the
+// given patterns by RedactedValue. Patterns are dot-separated like
+// ValueContext.Path, with * matching any one segment, and match the end of
+// a path, so "password" redacts every attribute called password while
+// "provider.*.secret_key" only redacts secret_key in provider blocks. The
+// keys of object values extend their attribute's path, so "tags.owner"
+// redacts the owner key of tags attributes.
+func Redact(patterns ...string) ValueTransformer {
+	return hidingTransformer{
+		name:     "redact " + strings.Join(patterns, ","),
+		patterns: splitPatterns(patterns),
+		replace: func([]string, interface{}) (interface{}, error) {
+			return RedactedValue, nil
+		},
+	}
+}
+
+// hidingTransformer replaces the values matching its patterns, such as to
+// redact them. What's derived from the source of an attribute with hidden
+// values, such as its canonical expression, is left out of the line info.
+type hidingTransformer struct {
+	name     string
+	patterns [][]string
+	replace  func(path []string, value interface{}) (interface{}, error)
+}
+
+func (t hidingTransformer) Name() string { return t.name }
+
+func (t hidingTransformer) Transform(ctx ValueContext, value interface{}) (interface{}, error) {
+	return replaceMatching(t.patterns, strings.Split(ctx.Path, "."), value, t.replace)
+}
+
+// hides reports whether the value at path, or any value nested within it,
+// matches the patterns.
+func (t hidingTransformer) hides(path []string, value interface{}) bool {
+	for _, pattern := range t.patterns {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, v := range value {
+			if t.hides(append(path[:len(path):len(path)], key), v) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range value {
+			if t.hides(path, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitPatterns(patterns []string) [][]string {
+	split := make([][]string, len(patterns))
+	for i, pattern := range patterns {
+		split[i] = strings.Split(pattern, ".")
+	}
+	return split
+}
+
+// replaceMatching replaces the value at path, or the values nested within
+// it, which match any of the patterns with the result of replace, given
+// the path of the value it replaces.
+func replaceMatching(patterns [][]string, path []string, value interface{}, replace func([]string, interface{}) (interface{}, error)) (interface{}, error) {
+	for _, pattern := range patterns {
+		if matchPath(pattern, path) {
+			return replace(path, value)
+		}
+	}
+	var err error
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, v := range value {
+			if value[key], err = replaceMatching(patterns, append(path[:len(path):len(path)], key), v, replace); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, v := range value {
+			if value[i], err = replaceMatching(patterns, path, v, replace); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// matchPath reports whether pattern matches the end of path.
+func matchPath(pattern, path []string) bool {
+	if len(pattern) > len(path) {
+		return false
+	}
+	path = path[len(path)-len(pattern):]
+	for i, segment := range pattern {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// transformers returns Options.Transformers, followed by a transformer for
+// Options.Redact so redaction applies to their output.
+func (o Options) transformers() []ValueTransformer {
+	if len(o.Redact) == 0 {
+		return o.Transformers
+	}
+	return append(o.Transformers[:len(o.Transformers):len(o.Transformers)], Redact(o.Redact...))
+}
+
+// transform passes the value of the attribute called name through each of
+// Options.Transformers in turn.
+func (c *converter) transform(name string, rng hcl.Range, value interface{}) (interface{}, error) {
+	transformers := c.options.transformers()
+	if len(transformers) == 0 {
+		return value, nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("marshal value: %w", err)
+	}
+	if err := decodeJSON(bytes, &value); err != nil {
+		return nil, fmt.Errorf("decode value: %w", err)
+	}
+
+	ctx := ValueContext{
+		Path:  strings.Join(append(append([]string{}, c.path...), name), "."),
+		Range: rng,
+	}
+	for _, transformer := range transformers {
+		value, err = transformer.Transform(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s with %s: %w", ctx.Path, transformer.Name(), err)
+		}
+	}
+	return value, nil
+}
+
+// hidden reports whether any of Options.Transformers or Options.Redact hide
+// the value of the attribute called name, or values within it.
+// The value is given as converted, before it's transformed.
+func (c *converter) hidden(name string, value interface{}) (bool, error) {
+	var hiding []hidingTransformer
+	for _, transformer := range c.options.transformers() {
+		if t, ok := transformer.(hidingTransformer); ok {
+			hiding = append(hiding, t)
+		}
+	}
+	if len(hiding) == 0 {
+		return false, nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("marshal value: %w", err)
+	}
+	if err := decodeJSON(bytes, &value); err != nil {
+		return false, fmt.Errorf("decode value: %w", err)
+	}
+	path := append(append([]string{}, c.path...), name)
+	for _, t := range hiding {
+		if t.hides(path, value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
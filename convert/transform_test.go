@@ -0,0 +1,128 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	input := []byte(`
+name = "db"
+resource "aws_db_instance" "main" {
+	password = "hunter2"
+	size     = "20GB"
+	port     = var.port
+}
+`)
+
+	var paths []string
+	record := TransformerFunc("record", func(ctx ValueContext, value interface{}) (interface{}, error) {
+		paths = append(paths, ctx.Path)
+		return value, nil
+	})
+	gigabytes := TransformerFunc("gigabytes", func(ctx ValueContext, value interface{}) (interface{}, error) {
+		if s, ok := value.(string); ok && strings.HasSuffix(s, "GB") {
+			return json.Number(strings.TrimSuffix(s, "GB")), nil
+		}
+		return value, nil
+	})
+
+	options := Options{Transformers: []ValueTransformer{Redact("password"), gigabytes, record}}
+	result, err := Convert(input, "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"name": "db",
+	"resource": [{"aws_db_instance": {"main": {
+		"password": "(redacted)",
+		"size": 20,
+		"port": "${var.port}"
+	}}}]
+}`))
+
+	want := map[string]bool{
+		"name":                                   true,
+		"resource.aws_db_instance.main.password": true,
+		"resource.aws_db_instance.main.size":     true,
+		"resource.aws_db_instance.main.port":     true,
+	}
+	if len(paths) != len(want) {
+		t.Errorf("transformed paths %q, want %d", paths, len(want))
+	}
+	for _, path := range paths {
+		if !want[path] {
+			t.Errorf("unexpected path %q", path)
+		}
+	}
+
+	if (Options{}).Fingerprint() == options.Fingerprint() {
+		t.Error("transformers should change the fingerprint")
+	}
+
+	failing := TransformerFunc("fail", func(ValueContext, interface{}) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	if _, err := Convert(input, "main.tf", Options{Transformers: []ValueTransformer{failing}}); err == nil {
+		t.Error("transformer errors should fail conversion")
+	}
+}
+
+func TestRedactPatterns(t *testing.T) {
+	input := []byte(`
+password = "top"
+provider "aws" {
+	secret_key = "s3cr3t"
+	region     = "eu-west-1"
+}
+resource "aws_db_instance" "main" {
+	password   = "hunter2"
+	secret_key = "kept"
+	tags = {
+		owner = "alice"
+		team  = "data"
+	}
+}
+`)
+
+	options := Options{Redact: []string{"*.password", "provider.*.secret_key", "tags.owner"}}
+	result, err := Convert(input, "main.tf", options)
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+	"password": "top",
+	"provider": [{"aws": {"secret_key": "(redacted)", "region": "eu-west-1"}}],
+	"resource": [{"aws_db_instance": {"main": {
+		"password": "(redacted)",
+		"secret_key": "kept",
+		"tags": {"owner": "(redacted)", "team": "data"}
+	}}}]
+}`))
+
+	if (Options{}).Fingerprint() == options.Fingerprint() {
+		t.Error("redact patterns should change the fingerprint")
+	}
+}
+
+func TestRedactCanonicalExpressions(t *testing.T) {
+	input := []byte(`
+password = "hunter2"
+tags = { owner = "alice-secret", team = "db" }
+name = "db"
+`)
+	result, err := Convert(input, "main.tf", Options{Redact: []string{"password", "tags.owner"}, CanonicalExpressions: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	for _, secret := range []string{"hunter2", "alice-secret"} {
+		if strings.Contains(string(result.Lines), secret) {
+			t.Errorf("line info contains %q: %s", secret, result.Lines)
+		}
+	}
+	if !strings.Contains(string(result.Lines), `"canonical"`) {
+		t.Errorf("expected the canonical expression of name in the line info: %s", result.Lines)
+	}
+}
@@ -0,0 +1,258 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// JSONToHCL converts the JSON and line map produced by Bytes or File back
+// into HCL source, so tools can modify the JSON and write the configuration
+// back out. The line map tells blocks apart from object attributes and
+// labels apart from block bodies, and keeps attributes and blocks in their
+// original order. The output must be in OutputSchemaV1, which
+// MigrateOutput can convert other versions to. Any provenance header is
+// dropped. Strings consisting of a single ${...} expression are
+// written back as native expressions.
+func JSONToHCL(jsonBytes, lineBytes []byte) ([]byte, error) {
+	var cfg map[string]interface{}
+	if err := decodeJSON(jsonBytes, &cfg); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+	var lines map[string]interface{}
+	if err := decodeJSON(lineBytes, &lines); err != nil {
+		return nil, fmt.Errorf("parse line map: %w", err)
+	}
+
+	delete(cfg, ProvenanceKey)
+
+	var buf bytes.Buffer
+	if err := writeBody(&buf, cfg, lines); err != nil {
+		return nil, err
+	}
+
+	src := hclwrite.Format(buf.Bytes())
+	if _, diags := hclsyntax.ParseConfig(src, "", hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		return nil, fmt.Errorf("generated invalid HCL: %v", diags.Errs())
+	}
+	return src, nil
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// bodyItem is an attribute, or the blocks of one type, within a body.
+type bodyItem struct {
+	key  string
+	line int
+}
+
+func writeBody(buf *bytes.Buffer, cfg, lines map[string]interface{}) error {
+	items := make([]bodyItem, 0, len(cfg))
+	for key := range cfg {
+		items = append(items, bodyItem{key: key, line: itemLine(lines[key])})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].line != items[j].line {
+			return items[i].line < items[j].line
+		}
+		return items[i].key < items[j].key
+	})
+
+	for _, item := range items {
+		value := cfg[item.key]
+		blockLines, isBlock := lines[item.key].([]interface{})
+		if !isBlock {
+			expr, err := hclValue(value)
+			if err != nil {
+				return fmt.Errorf("convert %s: %w", item.key, err)
+			}
+			fmt.Fprintf(buf, "%s = %s\n", objectKey(item.key), expr)
+			continue
+		}
+
+		blocks, ok := value.([]interface{})
+		if !ok || len(blocks) != len(blockLines) {
+			return fmt.Errorf("convert %s: line map doesn't match blocks", item.key)
+		}
+		for i, block := range blocks {
+			if err := writeBlock(buf, item.key, nil, block, blockLines[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeBlock writes a block, descending through its labels until the line
+// map marks the block body.
+func writeBlock(buf *bytes.Buffer, typeName string, labels []string, value, line interface{}) error {
+	cfg, ok := value.(map[string]interface{})
+	lines, lok := line.(map[string]interface{})
+	if !ok || !lok {
+		return fmt.Errorf("convert block %s: expected an object", typeName)
+	}
+
+	if lines["type"] != "block" {
+		if len(cfg) != 1 {
+			return fmt.Errorf("convert block %s: expected a single label", typeName)
+		}
+		for label, inner := range cfg {
+			return writeBlock(buf, typeName, append(labels, label), inner, lines[label])
+		}
+	}
+
+	buf.WriteString(typeName)
+	for _, label := range labels {
+		fmt.Fprintf(buf, " %q", label)
+	}
+	buf.WriteString(" {\n")
+	if err := writeBody(buf, cfg, lines); err != nil {
+		return err
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// itemLine returns the line an attribute or block starts on, so items can
+// be written in their original order.
+func itemLine(line interface{}) int {
+	if list, ok := line.([]interface{}); ok && len(list) > 0 {
+		line = list[0]
+	}
+	lines, ok := line.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	for lines["type"] != "block" && lines["line"] == nil && len(lines) == 1 {
+		for _, inner := range lines {
+			lines, _ = inner.(map[string]interface{})
+		}
+	}
+	for _, key := range []string{"__key__line", "line"} {
+		if n, ok := lines[key].(json.Number); ok {
+			i, _ := n.Int64()
+			return int(i)
+		}
+	}
+	return 0
+}
+
+// hclValue returns the HCL expression for a JSON value.
+func hclValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return hclString(v)
+	case []interface{}:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			s, err := hclValue(elem)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, s)
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case map[string]interface{}:
+		if heredoc, ok, err := hclHeredoc(v); ok {
+			return heredoc, err
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var builder strings.Builder
+		builder.WriteString("{\n")
+		for _, key := range keys {
+			s, err := hclValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&builder, "%s = %s\n", objectKey(key), s)
+		}
+		builder.WriteString("}")
+		return builder.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported value %T", value)
+	}
+}
+
+// hclString returns a string as a quoted template, or as a native
+// expression when it is a single ${...} wrapped expression.
+func hclString(s string) (string, error) {
+	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
+		expr, diags := hclsyntax.ParseTemplate([]byte(s), "", hcl.Pos{Line: 1, Column: 1})
+		if !diags.HasErrors() {
+			if _, isWrap := expr.(*hclsyntax.TemplateWrapExpr); isWrap {
+				return s[2 : len(s)-1], nil
+			}
+		}
+	}
+	return quoteTemplate(s), nil
+}
+
+// quoteTemplate quotes a string, escaping only the literal parts so
+// ${...} and %{...} sequences stay as they are.
+func quoteTemplate(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if depth > 0 {
+			switch ch {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			builder.WriteByte(ch)
+			continue
+		}
+		switch {
+		case (ch == '$' || ch == '%') && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			builder.WriteString(s[i : i+2])
+			i++
+		case ch == '"':
+			builder.WriteString(`\"`)
+		case ch == '\\':
+			builder.WriteString(`\\`)
+		case ch == '\n':
+			builder.WriteString(`\n`)
+		case ch == '\r':
+			builder.WriteString(`\r`)
+		case ch == '\t':
+			builder.WriteString(`\t`)
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// objectKey returns an attribute or object key, quoted unless it is a
+// valid identifier.
+func objectKey(key string) string {
+	if hclsyntax.ValidIdentifier(key) {
+		return key
+	}
+	return quoteTemplate(key)
+}
@@ -0,0 +1,71 @@
+package convert
+
+import (
+	"testing"
+)
+
+func TestJSONToHCL(t *testing.T) {
+	input := `
+variable "region" {
+	default = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+	ami   = "ami-123"
+	count = length(var.zones)
+	tags = {
+		Name = "web-${var.region}"
+		"kubernetes.io/role" = "node"
+	}
+
+	ebs_block_device {
+		device_name = "/dev/sdb"
+	}
+}
+
+locals {
+	zones = ["a", "b"]
+	quoted = "say \"hi\""
+}`
+
+	jsonBytes, lineBytes, err := Bytes([]byte(input), "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	src, err := JSONToHCL(jsonBytes, lineBytes)
+	if err != nil {
+		t.Fatal("json to hcl:", err)
+	}
+
+	expected := `variable "region" {
+  default = "us-east-1"
+}
+resource "aws_instance" "web" {
+  ami   = "ami-123"
+  count = length(var.zones)
+  tags = {
+    Name                 = "web-${var.region}"
+    "kubernetes.io/role" = "node"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+}
+locals {
+  zones  = ["a", "b"]
+  quoted = "say \"hi\""
+}
+`
+	if string(src) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, src)
+	}
+
+	roundTrip, _, err := Bytes(src, "main.tf", Options{})
+	if err != nil {
+		t.Fatal("convert generated HCL:", err)
+	}
+	if string(roundTrip) != string(jsonBytes) {
+		t.Errorf("round trip changed the JSON:\n%s\n%s", jsonBytes, roundTrip)
+	}
+}
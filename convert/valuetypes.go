@@ -0,0 +1,124 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// TypeLayout selects how Options.IncludeTypes writes the type of each
+// attribute's value.
+type TypeLayout string
+
+const (
+	// TypesInline replaces the value of each attribute with an object
+	// holding the value and its type, as in
+	// {"value": "web", "type": "string"}.
+	TypesInline TypeLayout = "inline"
+
+	// TypesMap leaves the output as it is, and sets Result.TypeMap to a
+	// document with the structure of the line info, holding the type of
+	// each attribute in place of its position.
+	TypesMap TypeLayout = "map"
+)
+
+// valueTypeKey is the line info key the type of an attribute's value is
+// recorded under while converting, before it's moved to where
+// Options.IncludeTypes writes it.
+const valueTypeKey = "valueType"
+
+// recordType records the type of an attribute's value in its line info,
+// when Options.IncludeTypes is set. Types are written in the JSON encoding
+// of cty types, such as "string" or ["list","number"], and are those
+// inferred by ExpressionTypes for expressions that aren't evaluated.
+func (c *converter) recordType(line interface{}, expr hcl.Expression) {
+	if c.options.IncludeTypes == "" || c.options.Skeleton {
+		return
+	}
+	typ, _ := c.expressionType(expr)
+	c.recordValueType(line, typ)
+}
+
+func (c *converter) recordValueType(line interface{}, typ cty.Type) {
+	l, ok := line.(lineObj)
+	if !ok || c.options.IncludeTypes == "" {
+		return
+	}
+	b, err := ctyjson.MarshalType(typ)
+	if err != nil {
+		return
+	}
+	l[c.key(valueTypeKey)] = json.RawMessage(b)
+}
+
+// includeTypes moves the types recorded in line info to where layout
+// writes them, returning the type map for TypesMap.
+func includeTypes(output, lines interface{}, layout TypeLayout, prefix string) (interface{}, interface{}, interface{}, error) {
+	switch layout {
+	case TypesInline, TypesMap:
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported type layout %q", layout)
+	}
+	var out, outLines interface{}
+	if err := decodedCopy(output, &out); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := decodedCopy(lines, &outLines); err != nil {
+		return nil, nil, nil, err
+	}
+	types := typesOf(out, outLines, layout, prefix+valueTypeKey)
+	if layout == TypesInline {
+		types = nil
+	}
+	return out, outLines, types, nil
+}
+
+// typesOf returns the types recorded under key in lines, with the
+// structure of value, removing them from lines. With TypesInline, each
+// attribute of value is replaced by its value and type.
+func typesOf(value, lines interface{}, layout TypeLayout, key string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		lines, _ := lines.(map[string]interface{})
+		types := make(map[string]interface{})
+		for name, v := range value {
+			line, _ := lines[name].(map[string]interface{})
+			if typ, ok := line[key]; ok {
+				delete(line, key)
+				types[name] = typ
+				if layout == TypesInline {
+					value[name] = map[string]interface{}{"value": v, "type": typ}
+				}
+				continue
+			}
+			if nested := typesOf(v, lines[name], layout, key); nested != nil {
+				types[name] = nested
+			}
+		}
+		if len(types) == 0 {
+			return nil
+		}
+		return types
+	case []interface{}:
+		lines, _ := lines.([]interface{})
+		types := make([]interface{}, len(value))
+		found := false
+		for i, v := range value {
+			var line interface{}
+			if i < len(lines) {
+				line = lines[i]
+			}
+			types[i] = typesOf(v, line, layout, key)
+			found = found || types[i] != nil
+		}
+		if !found {
+			return nil
+		}
+		return types
+	default:
+		return nil
+	}
+}
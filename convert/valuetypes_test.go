@@ -0,0 +1,53 @@
+package convert
+
+import "testing"
+
+func TestIncludeTypes(t *testing.T) {
+	src := []byte(`name  = "web"
+ports = [80, 443]
+zone  = var.zone
+
+service "http" {
+  enabled = true
+  weight  = var.weight * 2
+}
+`)
+	out, _, err := Bytes(src, "main.hcl", Options{IncludeTypes: TypesInline})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{
+		"name": {"value": "web", "type": "string"},
+		"ports": {"value": [80, 443], "type": ["tuple", ["number", "number"]]},
+		"zone": {"value": "${var.zone}", "type": "dynamic"},
+		"service": [{"http": {
+			"enabled": {"value": true, "type": "bool"},
+			"weight": {"value": "${var.weight * 2}", "type": "number"}
+		}}]
+	}`))
+
+	result, err := Convert(src, "main.hcl", Options{IncludeTypes: TypesMap, Simplify: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, result.JSON, []byte(`{
+		"name": "web",
+		"ports": [80, 443],
+		"zone": "${var.zone}",
+		"service": [{"http": {"enabled": true, "weight": "${var.weight * 2}"}}]
+	}`))
+	sameJSON(t, result.TypeMap, []byte(`{
+		"name": "string",
+		"ports": ["tuple", ["number", "number"]],
+		"zone": "dynamic",
+		"service": [{"http": {"enabled": "bool", "weight": "number"}}]
+	}`))
+	lines := decodeLines(t, result.Lines)
+	if _, ok := lines["name"].(map[string]interface{})[valueTypeKey]; ok {
+		t.Errorf("expected no types in the line info, got %v", lines["name"])
+	}
+
+	if _, err := Convert(src, "main.hcl", Options{IncludeTypes: "sideways"}); err == nil {
+		t.Error("expected an unsupported type layout to fail")
+	}
+}
@@ -0,0 +1,28 @@
+package convert
+
+// vaultProfile reshapes a Vault server configuration into the JSON form of
+// the configuration, as in {"storage": {"raft": {...}}, "listener":
+// [{"tcp": {...}}]}. Blocks Vault only takes one of are written as an
+// object, keyed by their label when they have one.
+var vaultProfile = &profile{
+	root: &shape{
+		blocks: map[string]*blockShape{
+			"storage":              {labels: []string{""}, keyed: true, body: vaultStorage},
+			"ha_storage":           {labels: []string{""}, keyed: true, body: vaultStorage},
+			"seal":                 {labels: []string{""}, keyed: true},
+			"service_registration": {labels: []string{""}, keyed: true},
+			"entropy":              {labels: []string{""}, keyed: true},
+			"user_lockout":         {labels: []string{""}, keyed: true},
+			"telemetry":            {single: true},
+			"replication":          {single: true},
+		},
+	},
+}
+
+// vaultStorage keeps the retry_join blocks of raft storage as a list, and
+// writes its single blocks as objects.
+var vaultStorage = &shape{
+	blocks: map[string]*blockShape{
+		"autopilot": {single: true},
+	},
+}
@@ -0,0 +1,54 @@
+package convert
+
+import (
+	"testing"
+)
+
+func TestVaultDialect(t *testing.T) {
+	src := []byte(`ui = true
+
+storage "raft" {
+  path    = "/vault/data"
+  node_id = "node1"
+
+  retry_join {
+    leader_api_addr = "https://vault-0:8200"
+  }
+}
+
+listener "tcp" {
+  address     = "0.0.0.0:8200"
+  tls_disable = true
+}
+
+listener "unix" {
+  address = "/run/vault.sock"
+}
+
+seal "awskms" {
+  kms_key_id = "alias/vault"
+}
+
+telemetry {
+  prometheus_retention_time = "30s"
+}
+`)
+	out, _, err := Bytes(src, "vault.hcl", Options{Dialect: DialectVault})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	sameJSON(t, out, []byte(`{
+		"ui": true,
+		"storage": {"raft": {
+			"path": "/vault/data",
+			"node_id": "node1",
+			"retry_join": [{"leader_api_addr": "https://vault-0:8200"}]
+		}},
+		"listener": [
+			{"tcp": {"address": "0.0.0.0:8200", "tls_disable": true}},
+			{"unix": {"address": "/run/vault.sock"}}
+		],
+		"seal": {"awskms": {"kms_key_id": "alias/vault"}},
+		"telemetry": {"prometheus_retention_time": "30s"}
+	}`))
+}
@@ -0,0 +1,117 @@
+// Package edit applies text edits to HCL source and remaps positions
+// computed against the original source, such as those in the line info
+// from the convert package, so they stay valid without converting again.
+package edit
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Edit replaces the source within Range with Text. Only the byte offsets of
+// the range are used.
+type Edit struct {
+	Range hcl.Range
+	Text  string
+}
+
+// Apply applies edits to src, returning the edited source and a Remap from
+// positions in src to positions in the edited source. Edits may be given in
+// any order but must not overlap.
+func Apply(src []byte, edits []Edit) ([]byte, *Remap, error) {
+	sorted := append([]Edit{}, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Range.Start.Byte < sorted[j].Range.Start.Byte
+	})
+
+	var out []byte
+	shifts := make([]shift, 0, len(sorted))
+	last := 0
+	for _, e := range sorted {
+		start, end := e.Range.Start.Byte, e.Range.End.Byte
+		if start < last || end < start || end > len(src) {
+			return nil, nil, fmt.Errorf("invalid or overlapping edit at bytes %d-%d", start, end)
+		}
+		out = append(out, src[last:start]...)
+		newStart := len(out)
+		out = append(out, e.Text...)
+		shifts = append(shifts, shift{start: start, end: end, newStart: newStart, newEnd: len(out)})
+		last = end
+	}
+	out = append(out, src[last:]...)
+
+	return out, &Remap{shifts: shifts, src: out}, nil
+}
+
+// shift records where an edited region of the original source ended up.
+type shift struct {
+	start, end       int
+	newStart, newEnd int
+}
+
+// Remap translates positions in the original source into the edited
+// source.
+type Remap struct {
+	shifts []shift
+	src    []byte
+}
+
+// Byte returns the offset in the edited source of an offset in the original
+// source. Offsets strictly within a replaced region have no equivalent, so
+// false is returned for them.
+func (m *Remap) Byte(offset int) (int, bool) {
+	delta := 0
+	for _, s := range m.shifts {
+		switch {
+		case offset < s.start:
+			return offset + delta, true
+		case offset == s.start:
+			return s.newStart, true
+		case offset < s.end:
+			return 0, false
+		case offset == s.end:
+			return s.newEnd, true
+		}
+		delta = s.newEnd - s.end
+	}
+	return offset + delta, true
+}
+
+// Pos returns the position in the edited source of a position in the
+// original source, recomputing its line and column.
+func (m *Remap) Pos(pos hcl.Pos) (hcl.Pos, bool) {
+	offset, ok := m.Byte(pos.Byte)
+	if !ok || offset > len(m.src) {
+		return hcl.Pos{}, false
+	}
+
+	line, column := 1, 1
+	for i := 0; i < offset; {
+		r, size := utf8.DecodeRune(m.src[i:])
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+		i += size
+	}
+	return hcl.Pos{Line: line, Column: column, Byte: offset}, true
+}
+
+// Range returns the range in the edited source of a range in the original
+// source, or false if either end fell within a replaced region.
+func (m *Remap) Range(rng hcl.Range) (hcl.Range, bool) {
+	start, ok := m.Pos(rng.Start)
+	if !ok {
+		return hcl.Range{}, false
+	}
+	end, ok := m.Pos(rng.End)
+	if !ok {
+		return hcl.Range{}, false
+	}
+	return hcl.Range{Filename: rng.Filename, Start: start, End: end}, true
+}
@@ -0,0 +1,53 @@
+package edit
+
+import (
+	"testing"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestApplyRemap(t *testing.T) {
+	src := []byte("a = 1\nb = \"two\"\nc = 3\n")
+	file, diags := hclsyntax.ParseConfig(src, "main.hcl", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+	attrs := file.Body.(*hclsyntax.Body).Attributes
+
+	edits := []Edit{
+		{Range: attrs["b"].Expr.Range(), Text: "\"a much longer value\"\n# comment"},
+		{Range: attrs["a"].Expr.Range(), Text: "100"},
+	}
+	out, remap, err := Apply(src, edits)
+	if err != nil {
+		t.Fatal("apply:", err)
+	}
+	if expected := "a = 100\nb = \"a much longer value\"\n# comment\nc = 3\n"; string(out) != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+
+	moved, ok := remap.Range(attrs["c"].SrcRange)
+	if !ok {
+		t.Fatal("expected c to be remapped")
+	}
+	if moved.Start.Line != 4 || moved.Start.Column != 1 || string(out[moved.Start.Byte:moved.End.Byte]) != "c = 3" {
+		t.Errorf("unexpected range for c: %+v", moved)
+	}
+
+	inside := attrs["b"].Expr.Range().Start
+	inside.Byte++
+	if _, ok := remap.Pos(inside); ok {
+		t.Error("a position within a replaced region should not be remapped")
+	}
+}
+
+func TestApplyOverlapping(t *testing.T) {
+	edits := []Edit{
+		{Range: hcl.Range{Start: hcl.Pos{Byte: 0}, End: hcl.Pos{Byte: 4}}},
+		{Range: hcl.Range{Start: hcl.Pos{Byte: 2}, End: hcl.Pos{Byte: 6}}},
+	}
+	if _, _, err := Apply([]byte("abcdefgh"), edits); err == nil {
+		t.Error("overlapping edits should return an error")
+	}
+}
@@ -1,9 +1,9 @@
 module github.com/ckndave/hclparser
 
-go 1.14
+go 1.16
 
 require (
-	github.com/agext/levenshtein v1.2.3 // indirect
+	github.com/agext/levenshtein v1.2.3
 	github.com/go-test/deep v1.0.7 // indirect
 	github.com/google/go-cmp v0.5.5 // indirect
 	github.com/hashicorp/hcl/v2 v2.9.1
@@ -13,4 +13,5 @@ require (
 	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
 	github.com/zclconf/go-cty v1.8.1
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	google.golang.org/protobuf v1.28.1
 )
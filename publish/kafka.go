@@ -0,0 +1,22 @@
+package publish
+
+import "context"
+
+// KafkaProducer produces records to Kafka. This package bundles no Kafka
+// client: callers implement it by wrapping the producer of the client
+// they use, such as the Writer of segmentio/kafka-go or the Producer of
+// confluent-kafka-go, which also own the brokers, authentication and
+// delivery guarantees.
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// Kafka returns a Publisher producing messages to a topic through
+// producer, keyed by their Key so the results of each file land in one
+// partition, in order. Produce should return once the record has been
+// acknowledged, so that ordering and Retry hold.
+func Kafka(producer KafkaProducer, topic string) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		return producer.Produce(ctx, topic, []byte(msg.Key), msg.Value)
+	})
+}
@@ -0,0 +1,178 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSOptions configures the connection to a NATS server.
+type NATSOptions struct {
+	// User and Password authenticate with a username and password, and
+	// Token with an authorization token, as the server is configured.
+	User     string
+	Password string
+	Token    string
+
+	// TLS, if set, upgrades the connection to TLS after the server sends
+	// its INFO. Servers requiring TLS are upgraded to with the default
+	// configuration when it's nil.
+	TLS *tls.Config
+}
+
+// NATSPublisher publishes messages to a NATS subject using the core NATS
+// protocol, waiting for the server to acknowledge each one with a PONG so
+// errors are seen before the next message is sent.
+type NATSPublisher struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	subject string
+}
+
+// natsInfo is the part of the server's INFO the client acts on.
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// NATS connects to the NATS server at addr, such as localhost:4222, to
+// publish messages to subject.
+func NATS(ctx context.Context, addr, subject string, options NATSOptions) (*NATSPublisher, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	p := &NATSPublisher{conn: conn, reader: bufio.NewReader(conn), subject: subject}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := p.connect(addr, options); err != nil {
+		p.conn.Close()
+		return nil, err
+	}
+	p.conn.SetDeadline(time.Time{})
+	return p, nil
+}
+
+func (p *NATSPublisher) connect(addr string, options NATSOptions) error {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("read server info: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-ERR") {
+		return natsError(line)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("read server info: unexpected %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("parse server info: %w", err)
+	}
+
+	if options.TLS != nil || info.TLSRequired {
+		config := &tls.Config{}
+		if options.TLS != nil {
+			config = options.TLS.Clone()
+		}
+		if config.ServerName == "" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				config.ServerName = host
+			}
+		}
+		conn := tls.Client(p.conn, config)
+		if err := conn.Handshake(); err != nil {
+			return fmt.Errorf("tls handshake: %w", err)
+		}
+		p.conn, p.reader = conn, bufio.NewReader(conn)
+	}
+
+	connect := map[string]interface{}{
+		"verbose":      false,
+		"pedantic":     false,
+		"name":         "hclparser",
+		"tls_required": options.TLS != nil || info.TLSRequired,
+	}
+	if options.User != "" {
+		connect["user"], connect["pass"] = options.User, options.Password
+	}
+	if options.Token != "" {
+		connect["auth_token"] = options.Token
+	}
+	b, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(p.conn, "CONNECT %s\r\n", b); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	if err := p.flush(); err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	return nil
+}
+
+// Publish sends a message to the subject. NATS has no message keys, so
+// the key is dropped; it's part of the FileEvent or BlockEvent published.
+// Errors the server reports for the message, such as a permissions
+// violation, are returned.
+func (p *NATSPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if deadline, ok := ctx.Deadline(); ok {
+		p.conn.SetDeadline(deadline)
+		defer p.conn.SetDeadline(time.Time{})
+	}
+	if _, err := fmt.Fprintf(p.conn, "PUB %s %d\r\n%s\r\n", p.subject, len(msg.Value), msg.Value); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	if err := p.flush(); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	return nil
+}
+
+// flush sends a PING and waits for its PONG, answering PINGs from the
+// server and failing on the first -ERR it reports before the PONG.
+func (p *NATSPublisher) flush() error {
+	if _, err := p.conn.Write([]byte("PING\r\n")); err != nil {
+		return err
+	}
+	for {
+		line, err := p.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return natsError(line)
+		}
+	}
+}
+
+// natsError returns the error reported by a -ERR line, such as
+// -ERR 'Authorization Violation'.
+func natsError(line string) error {
+	message := strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))
+	return fmt.Errorf("server error: %s", strings.Trim(message, "'"))
+}
+
+// Close closes the connection to the server.
+func (p *NATSPublisher) Close() error {
+	return p.conn.Close()
+}
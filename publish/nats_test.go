@@ -0,0 +1,171 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATSServer configures fakeNATS.
+type fakeNATSServer struct {
+	info string
+	// tls, if set, upgrades the connection after sending the INFO.
+	tls *tls.Config
+	// token, if set, is required in the CONNECT.
+	token string
+	// deny rejects publishes to the subject with a permissions violation.
+	deny string
+}
+
+// fakeNATS accepts one connection, replying to PINGs and sending each
+// published payload to pubs.
+func fakeNATS(t *testing.T, server fakeNATSServer, pubs chan<- string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	if server.info == "" {
+		server.info = `{"server_id":"test"}`
+	}
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(conn, "INFO %s\r\n", server.info)
+		if server.tls != nil {
+			conn = tls.Server(conn, server.tls)
+		}
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch fields := strings.Fields(line); fields[0] {
+			case "CONNECT":
+				var connect struct {
+					AuthToken string `json:"auth_token"`
+				}
+				json.Unmarshal([]byte(fields[1]), &connect)
+				if connect.AuthToken != server.token {
+					fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+					return
+				}
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB":
+				var size int
+				fmt.Sscan(fields[2], &size)
+				payload := make([]byte, size+2)
+				if _, err := reader.Read(payload); err != nil {
+					return
+				}
+				if fields[1] == server.deny {
+					fmt.Fprintf(conn, "-ERR 'Permissions Violation for Publish to \"%s\"'\r\n", fields[1])
+					continue
+				}
+				pubs <- fields[1] + " " + string(payload[:size])
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestNATS(t *testing.T) {
+	pubs := make(chan string, 1)
+	p, err := NATS(context.Background(), fakeNATS(t, fakeNATSServer{}, pubs), "hcl.converted", NATSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.Publish(context.Background(), Message{Key: "main.tf", Value: []byte(`{"a":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-pubs; got != `hcl.converted {"a":1}` {
+		t.Errorf("unexpected publish %q", got)
+	}
+}
+
+func TestNATSErrors(t *testing.T) {
+	server := fakeNATSServer{info: `{"auth_required":true}`, token: "secret", deny: "hcl.denied"}
+	if _, err := NATS(context.Background(), fakeNATS(t, server, nil), "hcl.converted", NATSOptions{Token: "wrong"}); err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("expected an authorization violation, got %v", err)
+	}
+
+	pubs := make(chan string, 1)
+	p, err := NATS(context.Background(), fakeNATS(t, server, pubs), "hcl.denied", NATSOptions{Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Publish(context.Background(), Message{Value: []byte(`{}`)}); err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("expected a permissions violation, got %v", err)
+	}
+}
+
+func TestNATSTLS(t *testing.T) {
+	serverTLS, clientTLS := tlsConfigs(t)
+	pubs := make(chan string, 1)
+	addr := fakeNATS(t, fakeNATSServer{info: `{"tls_required":true}`, tls: serverTLS}, pubs)
+	p, err := NATS(context.Background(), addr, "hcl.converted", NATSOptions{TLS: clientTLS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.Publish(context.Background(), Message{Value: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-pubs; got != `hcl.converted {}` {
+		t.Errorf("unexpected publish %q", got)
+	}
+}
+
+// tlsConfigs returns a server configuration with a self-signed certificate
+// for 127.0.0.1 and a client configuration trusting it.
+func tlsConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		&tls.Config{RootCAs: roots}
+}
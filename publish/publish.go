@@ -0,0 +1,209 @@
+// Package publish streams conversion results into event pipelines, one
+// message per file or per block. It includes a client for core NATS, and
+// publishes to Kafka through a producer from the Kafka client of your
+// choice; see KafkaProducer.
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+// Message is a conversion result to publish.
+type Message struct {
+	// Key identifies what the message describes, such as main.tf or
+	// main.tf#resource.2, and is used to partition messages, so those for
+	// a file stay in order.
+	Key   string
+	Value []byte
+}
+
+// Publisher sends messages to an event pipeline. Publish returns once the
+// message has been accepted, so messages published in turn arrive in order.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error { return f(ctx, msg) }
+
+// Retry returns a Publisher retrying each message with p up to attempts
+// times in all, waiting backoff after the first failure and doubling the
+// wait after each one since. Messages are retried before the next is
+// published, so their order is kept.
+func Retry(p Publisher, attempts int, backoff time.Duration) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		wait := backoff
+		for attempt := 1; ; attempt++ {
+			err := p.Publish(ctx, msg)
+			if err == nil || attempt >= attempts {
+				return err
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return fmt.Errorf("%v, then %w", err, ctx.Err())
+			}
+			wait *= 2
+		}
+	})
+}
+
+// Granularity selects whether a message is published for each file or for
+// each top-level block.
+type Granularity int
+
+const (
+	PerFile Granularity = iota
+	PerBlock
+)
+
+// FileEvent is the value of messages published per file.
+type FileEvent struct {
+	File  string          `json:"file"`
+	JSON  json.RawMessage `json:"json"`
+	Lines json.RawMessage `json:"lines"`
+}
+
+// BlockEvent is the value of messages published per block, with Index
+// counting the blocks of the same type in the file.
+type BlockEvent struct {
+	File  string          `json:"file"`
+	Type  string          `json:"type"`
+	Index int             `json:"index"`
+	JSON  json.RawMessage `json:"json"`
+	Lines json.RawMessage `json:"lines"`
+}
+
+// reshapingDialects write their own output shape, whose blocks can't be
+// told apart to publish them one by one.
+var reshapingDialects = map[convert.Dialect]bool{
+	convert.DialectNomad:  true,
+	convert.DialectVault:  true,
+	convert.DialectConsul: true,
+}
+
+// Dir converts the HCL and Terraform files in a directory, in lexical
+// order, publishing the results with p at the given granularity. It stops
+// at the first file which fails to convert or to publish. PerBlock needs
+// the nested line info and the JSON output of Convert, so doesn't support
+// LineFormatFlat, CombinedOutput, an Encoder or dialects such as
+// DialectNomad which write their own output shape.
+func Dir(ctx context.Context, p Publisher, path string, granularity Granularity, options convert.Options) error {
+	if granularity == PerBlock {
+		if options.LineFormat != convert.LineFormatNested || options.CombinedOutput || options.Encoder != nil {
+			return fmt.Errorf("LineFormatFlat, CombinedOutput and Encoder are not supported when publishing per block")
+		}
+		if reshapingDialects[options.Dialect] {
+			return fmt.Errorf("the %s dialect is not supported when publishing per block", options.Dialect)
+		}
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("read directory: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".hcl", ".tf":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		bytes, err := ioutil.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+		result, err := convert.Convert(bytes, name, options)
+		if err != nil {
+			return fmt.Errorf("convert %s: %w", name, err)
+		}
+		if granularity == PerBlock && options.CompactLines {
+			// split the expanded line info, so each block's stands alone
+			if result.Lines, err = convert.ExpandLines(result.Lines); err != nil {
+				return fmt.Errorf("expand line info for %s: %w", name, err)
+			}
+		}
+		messages, err := messages(name, result, granularity)
+		if err != nil {
+			return fmt.Errorf("split %s: %w", name, err)
+		}
+		for _, msg := range messages {
+			if err := p.Publish(ctx, msg); err != nil {
+				return fmt.Errorf("publish %s: %w", msg.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// messages returns the messages for a converted file.
+func messages(name string, result *convert.Result, granularity Granularity) ([]Message, error) {
+	if granularity == PerFile {
+		value, err := json.Marshal(FileEvent{File: name, JSON: result.JSON, Lines: result.Lines})
+		if err != nil {
+			return nil, err
+		}
+		return []Message{{Key: name, Value: value}}, nil
+	}
+
+	var (
+		body  map[string]json.RawMessage
+		lines map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(result.JSON, &body); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	if err := json.Unmarshal(result.Lines, &lines); err != nil {
+		return nil, fmt.Errorf("decode line info: %w", err)
+	}
+	types := make([]string, 0, len(body))
+	for key := range body {
+		types = append(types, key)
+	}
+	sort.Strings(types)
+
+	var list []Message
+	for _, blockType := range types {
+		line, ok := lines[blockType]
+		if !ok {
+			return nil, fmt.Errorf("no line info for %s", blockType)
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("{")) {
+			continue // attributes have an object of line info, blocks a list
+		}
+		var blockLines []json.RawMessage
+		if err := json.Unmarshal(line, &blockLines); err != nil {
+			return nil, fmt.Errorf("decode %s line info: %w", blockType, err)
+		}
+		var blocks []json.RawMessage
+		if err := json.Unmarshal(body[blockType], &blocks); err != nil {
+			return nil, fmt.Errorf("decode %s blocks: %w", blockType, err)
+		}
+		if len(blocks) != len(blockLines) {
+			return nil, fmt.Errorf("%d %s blocks with line info for %d", len(blocks), blockType, len(blockLines))
+		}
+		for i, block := range blocks {
+			value, err := json.Marshal(BlockEvent{File: name, Type: blockType, Index: i, JSON: block, Lines: blockLines[i]})
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, Message{Key: fmt.Sprintf("%s#%s.%d", name, blockType, i), Value: value})
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,117 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func record(messages *[]Message) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		*messages = append(*messages, msg)
+		return nil
+	})
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `
+name = "web"
+resource "aws_instance" "a" {}
+resource "aws_instance" "b" {}
+`)
+	writeFile(t, filepath.Join(dir, "outputs.tf"), `output "id" { value = 1 }`)
+	writeFile(t, filepath.Join(dir, "README.md"), `not hcl`)
+
+	var messages []Message
+	if err := Dir(context.Background(), record(&messages), dir, PerFile, convert.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].Key != "main.tf" || messages[1].Key != "outputs.tf" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+	var file FileEvent
+	if err := json.Unmarshal(messages[1].Value, &file); err != nil {
+		t.Fatal(err)
+	}
+	if string(file.JSON) != `{"output":[{"id":{"value":1}}]}` {
+		t.Errorf("unexpected json %s", file.JSON)
+	}
+
+	messages = nil
+	if err := Dir(context.Background(), record(&messages), dir, PerBlock, convert.Options{CompactLines: true}); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, msg := range messages {
+		keys = append(keys, msg.Key)
+	}
+	if len(keys) != 3 || keys[0] != "main.tf#resource.0" || keys[1] != "main.tf#resource.1" || keys[2] != "outputs.tf#output.0" {
+		t.Fatalf("unexpected keys %q", keys)
+	}
+	var block BlockEvent
+	if err := json.Unmarshal(messages[1].Value, &block); err != nil {
+		t.Fatal(err)
+	}
+	if block.Type != "resource" || block.Index != 1 || string(block.JSON) != `{"aws_instance":{"b":{}}}` {
+		t.Errorf("unexpected block event %+v", block)
+	}
+}
+
+func TestDirPerBlockOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `resource "aws_instance" "a" {}`)
+
+	for name, options := range map[string]convert.Options{
+		"flat":     {LineFormat: convert.LineFormatFlat},
+		"combined": {CombinedOutput: true},
+		"nomad":    {Dialect: convert.DialectNomad},
+	} {
+		var messages []Message
+		if err := Dir(context.Background(), record(&messages), dir, PerBlock, options); err == nil {
+			t.Errorf("%s: expected an error, got %d messages", name, len(messages))
+		}
+	}
+
+	result := &convert.Result{JSON: []byte(`{"resource":[{}]}`), Lines: []byte(`{"/resource/0":{}}`)}
+	if _, err := messages("main.tf", result, PerBlock); err == nil {
+		t.Error("expected missing line info to be an error")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	failures := 2
+	var messages []Message
+	flaky := PublisherFunc(func(ctx context.Context, msg Message) error {
+		if failures > 0 {
+			failures--
+			return errors.New("unavailable")
+		}
+		messages = append(messages, msg)
+		return nil
+	})
+
+	if err := Retry(flaky, 3, 0).Publish(context.Background(), Message{Key: "a"}); err != nil {
+		t.Fatal("expected the third attempt to succeed:", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected one message, got %d", len(messages))
+	}
+
+	failures = 2
+	if err := Retry(flaky, 2, 0).Publish(context.Background(), Message{Key: "b"}); err == nil {
+		t.Error("expected an error after two attempts")
+	}
+}
@@ -0,0 +1,98 @@
+// Package query selects values from converted JSON by a dot-separated path,
+// such as resource.aws_instance.*.ami, so checks can be written without
+// walking the nesting of blocks and labels by hand.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed query. Each step selects the key of an object, or with
+// * every key. Lists, such as those holding the blocks of a type, are
+// stepped through: a number selects an element, and other steps apply to
+// every element, with * selecting the elements which aren't objects or
+// lists.
+type Path []string
+
+// Parse parses a dot-separated query.
+func Parse(s string) (Path, error) {
+	if s == "" {
+		return nil, errors.New("empty query")
+	}
+	path := Path(strings.Split(s, "."))
+	for i, step := range path {
+		if step == "" {
+			return nil, fmt.Errorf("query %q: empty step %d", s, i+1)
+		}
+	}
+	return path, nil
+}
+
+// Match is a value selected by a query, with its JSON pointer in the
+// document.
+type Match struct {
+	Pointer string
+	Value   interface{}
+}
+
+// Find returns the values of a decoded JSON document selected by the
+// path, in document order, with the keys of objects sorted.
+func (p Path) Find(doc interface{}) []Match {
+	var matches []Match
+	find(p, "", doc, func(pointer string, value interface{}) {
+		matches = append(matches, Match{Pointer: pointer, Value: value})
+	})
+	return matches
+}
+
+func find(path Path, pointer string, value interface{}, fn func(pointer string, value interface{})) {
+	if len(path) == 0 {
+		fn(pointer, value)
+		return
+	}
+	step := path[0]
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if step != "*" {
+			if elem, ok := value[step]; ok {
+				find(path[1:], pointer+"/"+token(step), elem, fn)
+			}
+			return
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			find(path[1:], pointer+"/"+token(key), value[key], fn)
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(step); err == nil {
+			if i >= 0 && i < len(value) {
+				find(path[1:], pointer+"/"+step, value[i], fn)
+			}
+			return
+		}
+		for i, elem := range value {
+			elemPointer := pointer + "/" + strconv.Itoa(i)
+			switch elem.(type) {
+			case map[string]interface{}, []interface{}:
+				find(path, elemPointer, elem, fn)
+			default:
+				if step == "*" {
+					find(path[1:], elemPointer, elem, fn)
+				}
+			}
+		}
+	}
+}
+
+// token escapes a key for use in a JSON pointer.
+func token(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
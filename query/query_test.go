@@ -0,0 +1,66 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{
+		"resource": [
+			{"aws_instance": {"web": {"ami": "ami-1", "ebs": [{"size": 8}, {"size": 16}]}}},
+			{"aws_instance": {"db": {"ami": "ami-2"}}},
+			{"aws_s3_bucket": {"logs": {"acl": "private"}}}
+		],
+		"tags": ["a", "b"],
+		"a/b": {"c": true}
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		query string
+		want  []Match
+	}{
+		{"resource.aws_instance.*.ami", []Match{
+			{"/resource/0/aws_instance/web/ami", "ami-1"},
+			{"/resource/1/aws_instance/db/ami", "ami-2"},
+		}},
+		{"resource.*.*.acl", []Match{
+			{"/resource/2/aws_s3_bucket/logs/acl", "private"},
+		}},
+		{"resource.aws_instance.web.ebs.size", []Match{
+			{"/resource/0/aws_instance/web/ebs/0/size", float64(8)},
+			{"/resource/0/aws_instance/web/ebs/1/size", float64(16)},
+		}},
+		{"resource.aws_instance.web.ebs.1.size", []Match{
+			{"/resource/0/aws_instance/web/ebs/1/size", float64(16)},
+		}},
+		{"resource.aws_instance.web.ebs.*", []Match{
+			{"/resource/0/aws_instance/web/ebs/0/size", float64(8)},
+			{"/resource/0/aws_instance/web/ebs/1/size", float64(16)},
+		}},
+		{"tags.*", []Match{{"/tags/0", "a"}, {"/tags/1", "b"}}},
+		{"a/b.c", []Match{{"/a~1b/c", true}}},
+		{"resource.aws_instance.web.missing", nil},
+	} {
+		path, err := Parse(test.query)
+		if err != nil {
+			t.Errorf("parse %s: %v", test.query, err)
+			continue
+		}
+		if got := path.Find(doc); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, query := range []string{"", "resource..ami", "resource."} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("expected %q to be invalid", query)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+name    = "web"
+count   = 3
+ratio   = 0.5
+enabled = true
+nothing = null
+zones   = ["a", "b"]
+tags = {
+  team = "platform"
+  tier = 1
+}
@@ -0,0 +1,19 @@
+service "http" {
+  port = 80
+
+  listener "public" {
+    address = "0.0.0.0"
+  }
+
+  listener "private" {
+    address = "127.0.0.1"
+  }
+}
+
+service "grpc" {
+  port = 9090
+}
+
+settings {
+  debug = false
+}
@@ -0,0 +1,6 @@
+greeting = "hello, ${name}!"
+script = <<-EOT
+  #!/bin/sh
+  echo "${message}"
+EOT
+directive = "%{if enabled}on%{else}off%{endif}"
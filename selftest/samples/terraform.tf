@@ -0,0 +1,25 @@
+variable "region" {
+  type    = string
+  default = "eu-west-1"
+}
+
+locals {
+  name   = "app-${var.region}"
+  ports  = [for p in var.ports : p + 1]
+  prod   = var.env == "prod" ? 3 : 1
+  upper  = upper(var.name)
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = "t3.micro"
+  count         = local.prod
+
+  tags = {
+    Name = local.name
+  }
+}
+
+output "ids" {
+  value = aws_instance.web[*].id
+}
@@ -0,0 +1,167 @@
+// Package selftest converts an embedded corpus of representative HCL and
+// Terraform files and checks invariants of the output, to validate a build
+// of the converter, with any custom options or transformers, before it's
+// rolled out.
+package selftest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sort"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+//go:embed samples
+var samples embed.FS
+
+// Samples returns the embedded samples, keyed by file name.
+func Samples() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := fs.WalkDir(samples, "samples", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := samples.ReadFile(path)
+		files[d.Name()] = b
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read samples: %w", err)
+	}
+	return files, nil
+}
+
+// Result holds the invariants a sample failed to uphold.
+type Result struct {
+	Sample   string   `json:"sample"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Report holds the results for every sample, in order of name.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Failed returns the results of the samples which failed an invariant.
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if len(result.Failures) > 0 {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Run converts each sample with options, checking that:
+//
+//   - it converts without error
+//   - converting it again gives the same output
+//   - its line info has an entry for every top-level key of its JSON
+//   - its line info survives compaction
+//   - converting its JSON back to HCL and converting that gives the same
+//     JSON
+func Run(options convert.Options) (*Report, error) {
+	files, err := Samples()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &Report{Results: make([]Result, 0, len(names))}
+	for _, name := range names {
+		report.Results = append(report.Results, Result{
+			Sample:   name,
+			Failures: check(name, files[name], options),
+		})
+	}
+	return report, nil
+}
+
+func check(name string, src []byte, options convert.Options) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	result, err := convert.Convert(src, name, options)
+	if err != nil {
+		fail("convert: %v", err)
+		return failures
+	}
+	lines, err := expanded(result.Lines, options)
+	if err != nil {
+		fail("expand line info: %v", err)
+		return failures
+	}
+
+	again, err := convert.Convert(src, name, options)
+	if err != nil || !bytes.Equal(again.JSON, result.JSON) || !bytes.Equal(again.Lines, result.Lines) {
+		fail("converting again gave different output")
+	}
+
+	var (
+		body    map[string]json.RawMessage
+		lineMap map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(result.JSON, &body); err != nil {
+		fail("decode json: %v", err)
+	}
+	if err := json.Unmarshal(lines, &lineMap); err != nil {
+		fail("decode line info: %v", err)
+	}
+	for key := range body {
+		if _, ok := lineMap[key]; !ok && key != convert.ProvenanceKey {
+			fail("no line info for %s", key)
+		}
+	}
+
+	compact, err := convert.CompactLines(lines)
+	if err == nil {
+		var roundTripped []byte
+		if roundTripped, err = convert.ExpandLines(compact); err == nil && !sameJSON(roundTripped, lines) {
+			fail("compacting line info changed it")
+		}
+	}
+	if err != nil {
+		fail("compact line info: %v", err)
+	}
+
+	hcl, err := convert.JSONToHCL(result.JSON, lines)
+	if err != nil {
+		fail("convert back to HCL: %v", err)
+		return failures
+	}
+	reconverted, err := convert.Convert(hcl, name, options)
+	if err != nil {
+		fail("convert the HCL converted back: %v", err)
+	} else if !sameJSON(reconverted.JSON, result.JSON) {
+		fail("converting back to HCL and again changed the JSON")
+	}
+	return failures
+}
+
+// expanded returns line info in its full form.
+func expanded(lines []byte, options convert.Options) ([]byte, error) {
+	if options.CompactLines {
+		return convert.ExpandLines(lines)
+	}
+	return lines, nil
+}
+
+func sameJSON(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
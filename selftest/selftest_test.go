@@ -0,0 +1,22 @@
+package selftest
+
+import (
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func TestRun(t *testing.T) {
+	for _, options := range []convert.Options{{}, {Simplify: true}, {CompactLines: true}} {
+		report, err := Run(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Results) == 0 {
+			t.Fatal("expected samples to be embedded")
+		}
+		for _, result := range report.Failed() {
+			t.Errorf("%s with %+v: %q", result.Sample, options, result.Failures)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+// Status is the body of responses from /healthz and /readyz.
+type Status struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Status{Status: "ok"})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.config.Ready != nil {
+		if err := s.config.Ready(); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, Status{Status: "unavailable", Error: err.Error()})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, Status{Status: "ok"})
+}
+
+// handleSchema describes the documents returned by /convert with the
+// configured options.
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := convert.DescribeOutput(s.config.Options)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, schema)
+}
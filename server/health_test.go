@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestProbes(t *testing.T) {
+	var notReady error = errors.New("loading schemas")
+	s := New(Config{Ready: func() error { return notReady }})
+
+	if rec := get(t, s, "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("expected /healthz to be 200, got %d", rec.Code)
+	}
+	rec := get(t, s, "/readyz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz to be 503 before ready, got %d", rec.Code)
+	}
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil || status.Error != "loading schemas" {
+		t.Errorf("unexpected status %s", rec.Body)
+	}
+
+	notReady = nil
+	if rec := get(t, s, "/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("expected /readyz to be 200 once ready, got %d", rec.Code)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s := New(Config{Options: convert.Options{CompactLines: true}})
+	rec := get(t, s, "/schema")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var schema struct {
+		Output map[string]interface{} `json:"output"`
+		Lines  map[string]interface{} `json:"lines"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &schema); err != nil {
+		t.Fatal("unmarshal schema:", err)
+	}
+	if schema.Output["$ref"] != "#/$defs/body" || schema.Lines["title"] != "Compact line info" {
+		t.Errorf("unexpected schema %s", rec.Body)
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// Error is a request the server couldn't serve, with the HTTP status it's
+// reported with, and the ID of its code when it has one.
+type Error struct {
+	Status  int    `json:"status"`
+	Message string `json:"error"`
+	Code    string `json:"code,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Invoke serves a Request given as JSON, returning its Response as JSON, so
+// the server can run outside HTTP wherever a func(context.Context, []byte)
+// ([]byte, error) handler is taken. In AWS Lambda it implements the
+// lambda.Handler interface:
+//
+//	lambda.StartHandler(server.New(config))
+//
+// The same limits apply as to /convert, except for Config.Limiter, which
+// needs an HTTP request. Failures are returned as an *Error, and the
+// conversion is abandoned with a 504 Error if ctx is done first.
+func (s *Server) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	release, rejected := s.acquire(nil)
+	if rejected != nil {
+		return nil, rejected
+	}
+	if int64(len(payload)) > s.maxRequestBytes() {
+		release()
+		atomic.AddInt64(&s.metrics.TooLarge, 1)
+		return nil, &Error{Status: http.StatusRequestEntityTooLarge, Message: errTooLarge.Error(), Code: "request-too-large"}
+	}
+
+	type outcome struct {
+		resp *Response
+		err  *Error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		var o outcome
+		o.resp, o.err = s.convert(payload)
+		done <- o
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil {
+			return nil, o.err
+		}
+		resp, err := json.Marshal(o.resp)
+		if err != nil {
+			return nil, &Error{Status: http.StatusInternalServerError, Message: fmt.Sprintf("encode response: %v", err)}
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, &Error{Status: http.StatusGatewayTimeout, Message: fmt.Sprintf("conversion abandoned: %v", ctx.Err()), Code: "timeout"}
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func TestInvoke(t *testing.T) {
+	s := New(Config{Options: convert.Options{Simplify: true}, MaxRequestBytes: 64})
+
+	resp, err := s.Invoke(context.Background(), []byte(`{"source": "a = max(1, 2)"}`))
+	if err != nil {
+		t.Fatal("invoke:", err)
+	}
+	if expected := `{"json":{"a":2},"lines":`; !strings.HasPrefix(string(resp), expected) {
+		t.Errorf("expected response starting %s, got %s", expected, resp)
+	}
+
+	for payload, status := range map[string]int{
+		`{"source": "a = "}`: http.StatusUnprocessableEntity,
+		`{"source": "a = 1", "options": {"simplify": true}}`: http.StatusBadRequest,
+		`{"source": "` + strings.Repeat("#", 64) + `"}`:      http.StatusRequestEntityTooLarge,
+	} {
+		_, err := s.Invoke(context.Background(), []byte(payload))
+		if e, ok := err.(*Error); !ok || e.Status != status {
+			t.Errorf("expected a %d error for %s, got %v", status, payload, err)
+		}
+	}
+}
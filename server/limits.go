@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+)
+
+// DefaultMaxRequestBytes is the limit on the size of request bodies when
+// Config.MaxRequestBytes is zero.
+const DefaultMaxRequestBytes = 10 << 20
+
+// Limiter decides whether to serve a request, such as by tracking the rate
+// of requests from each client. It must be safe for concurrent use.
+type Limiter interface {
+	Allow(r *http.Request) bool
+}
+
+// LimiterFunc adapts a function to a Limiter.
+type LimiterFunc func(r *http.Request) bool
+
+func (f LimiterFunc) Allow(r *http.Request) bool { return f(r) }
+
+// Metrics counts the requests a server has handled.
+type Metrics struct {
+	Requests    int64 `json:"requests"`
+	Converted   int64 `json:"converted"`
+	Failed      int64 `json:"failed"`
+	RateLimited int64 `json:"rate_limited"`
+	Overloaded  int64 `json:"overloaded"`
+	TooLarge    int64 `json:"too_large"`
+	InFlight    int64 `json:"in_flight"`
+
+	// WebhookFailures counts events which couldn't be sent to a webhook,
+	// including those dropped when the queue of events is full.
+	WebhookFailures int64 `json:"webhook_failures"`
+
+	// ArtifactFailures counts converted files Config.Artifacts couldn't
+	// store.
+	ArtifactFailures int64 `json:"artifact_failures"`
+}
+
+// Metrics returns a snapshot of the server's metrics.
+func (s *Server) Metrics() Metrics {
+	return Metrics{
+		Requests:    atomic.LoadInt64(&s.metrics.Requests),
+		Converted:   atomic.LoadInt64(&s.metrics.Converted),
+		Failed:      atomic.LoadInt64(&s.metrics.Failed),
+		RateLimited: atomic.LoadInt64(&s.metrics.RateLimited),
+		Overloaded:  atomic.LoadInt64(&s.metrics.Overloaded),
+		TooLarge:    atomic.LoadInt64(&s.metrics.TooLarge),
+		InFlight:    atomic.LoadInt64(&s.metrics.InFlight),
+
+		WebhookFailures:  atomic.LoadInt64(&s.metrics.WebhookFailures),
+		ArtifactFailures: atomic.LoadInt64(&s.metrics.ArtifactFailures),
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Metrics())
+}
+
+// errTooLarge is returned by readBody for bodies over the limit.
+var errTooLarge = errors.New("request body too large")
+
+// admit applies the server's limits to a request, writing the response and
+// returning a nil release function when it's rejected. Otherwise release
+// must be called once the request is served.
+func (s *Server) admit(w http.ResponseWriter, r *http.Request) (release func()) {
+	release, err := s.acquire(r)
+	if err != nil {
+		writeError(w, err.Status, err)
+		return nil
+	}
+	return release
+}
+
+// acquire applies the server's limits to a request, which is nil for
+// invocations through Invoke, returning the error to respond with when
+// it's rejected. Otherwise release must be called once it's served.
+func (s *Server) acquire(r *http.Request) (release func(), err *Error) {
+	atomic.AddInt64(&s.metrics.Requests, 1)
+	if r != nil && s.config.Limiter != nil && !s.config.Limiter.Allow(r) {
+		atomic.AddInt64(&s.metrics.RateLimited, 1)
+		return nil, &Error{Status: http.StatusTooManyRequests, Message: "rate limit exceeded", Code: "too-many-requests"}
+	}
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			atomic.AddInt64(&s.metrics.Overloaded, 1)
+			return nil, &Error{Status: http.StatusTooManyRequests, Message: "too many concurrent requests", Code: "too-many-requests"}
+		}
+	}
+	atomic.AddInt64(&s.metrics.InFlight, 1)
+	return func() {
+		atomic.AddInt64(&s.metrics.InFlight, -1)
+		if s.slots != nil {
+			<-s.slots
+		}
+	}, nil
+}
+
+// maxRequestBytes returns the configured limit on the size of requests.
+func (s *Server) maxRequestBytes() int64 {
+	if s.config.MaxRequestBytes == 0 {
+		return DefaultMaxRequestBytes
+	}
+	return s.config.MaxRequestBytes
+}
+
+// readBody reads the request body, failing with errTooLarge when it's over
+// the configured limit.
+func (s *Server) readBody(r *http.Request) ([]byte, error) {
+	limit := s.maxRequestBytes()
+	if r.ContentLength > limit {
+		return nil, errTooLarge
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, errTooLarge
+	}
+	return body, nil
+}
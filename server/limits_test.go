@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	allow := true
+	s := New(Config{
+		MaxConcurrent:   1,
+		MaxRequestBytes: 64,
+		Limiter:         LimiterFunc(func(*http.Request) bool { return allow }),
+	})
+
+	if rec := post(t, s, "/convert", `{"source": "a = 1"}`); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := post(t, s, "/convert", `{"source": "`+strings.Repeat("#", 64)+`"}`); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d: %s", rec.Code, rec.Body)
+	}
+
+	allow = false
+	if rec := post(t, s, "/convert", `{"source": "a = 1"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 from the limiter, got %d", rec.Code)
+	}
+	allow = true
+
+	release := s.admit(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/convert", nil))
+	if rec := post(t, s, "/convert", `{"source": "a = 1"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 when at the concurrency limit, got %d", rec.Code)
+	}
+	release()
+
+	expected := Metrics{Requests: 5, Converted: 1, RateLimited: 1, Overloaded: 1, TooLarge: 1}
+	if got := s.Metrics(); got != expected {
+		t.Errorf("expected metrics %+v, got %+v", expected, got)
+	}
+}
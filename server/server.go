@@ -0,0 +1,395 @@
+// Package server serves conversions over HTTP, so a single deployment can
+// convert files for many clients.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/ckndave/hclparser/convert"
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Config configures a Server.
+type Config struct {
+	// Options are the options every conversion starts from.
+	Options convert.Options
+
+	// Overrides lists the request options, by their JSON names such as
+	// "dialect", which requests may set. Requests setting any other
+	// option are rejected.
+	Overrides []string
+
+	// MaxConcurrent limits how many conversions run at once, with requests
+	// over the limit rejected with 429 Too Many Requests. When zero, there
+	// is no limit.
+	MaxConcurrent int
+
+	// MaxRequestBytes limits the size of request bodies, with larger
+	// requests rejected with 413 Request Entity Too Large. When zero,
+	// DefaultMaxRequestBytes is used.
+	MaxRequestBytes int64
+
+	// Limiter, when set, is asked whether to serve each request, with
+	// those it refuses rejected with 429 Too Many Requests.
+	Limiter Limiter
+
+	// Ready, when set, reports whether the server is ready to serve
+	// conversions, such as once provider schemas have been loaded, with
+	// its error reported by /readyz.
+	Ready func() error
+
+	// Webhooks lists URLs each sent an Event when a conversion completes.
+	// Events are sent in the background, so responses don't wait on them.
+	Webhooks []string
+
+	// WebhookClient sends events to Webhooks. When nil, a client giving up
+	// after DefaultWebhookTimeout is used.
+	WebhookClient *http.Client
+
+	// WebhookQueue limits how many events may wait to be sent, with events
+	// over the limit dropped and counted as failures. When zero,
+	// DefaultWebhookQueue is used.
+	WebhookQueue int
+
+	// Artifacts, when set, stores the JSON of each successful conversion,
+	// with where it was stored given in webhook events.
+	Artifacts ArtifactStore
+
+	// Catalogs holds message catalogs by language, such as "de", which
+	// requests may select with the language option to have diagnostics
+	// rendered in it.
+	Catalogs map[string]convert.Catalog
+}
+
+// Server converts files posted to /convert, and reports its Metrics as JSON
+// at /metrics. /healthz and /readyz serve liveness and readiness probes, and
+// /schema describes the documents it returns.
+type Server struct {
+	metrics   Metrics // first, for 64-bit alignment of its counters
+	config    Config
+	overrides map[string]bool
+	mux       *http.ServeMux
+	slots     chan struct{}
+
+	// deliveries queues events for the webhook workers.
+	deliveries chan delivery
+}
+
+// New returns a server with the given configuration.
+func New(config Config) *Server {
+	s := &Server{
+		config:    config,
+		overrides: make(map[string]bool, len(config.Overrides)),
+		mux:       http.NewServeMux(),
+	}
+	for _, name := range config.Overrides {
+		s.overrides[name] = true
+	}
+	if config.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+	if len(config.Webhooks) > 0 {
+		s.startWebhooks()
+	}
+	s.mux.HandleFunc("/convert", s.handleConvert)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	s.mux.HandleFunc("/schema", s.handleSchema)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Request is the body of a request to /convert.
+type Request struct {
+	Filename string         `json:"filename"`
+	Source   string         `json:"source"`
+	Options  RequestOptions `json:"options"`
+}
+
+// RequestOptions are the options a request may override, when allowed by
+// Config.Overrides. Options left unset keep their configured values.
+type RequestOptions struct {
+	Dialect                *string  `json:"dialect,omitempty"`
+	Simplify               *bool    `json:"simplify,omitempty"`
+	AllowFunctions         []string `json:"allow_functions,omitempty"`
+	InjectDefaults         *bool    `json:"inject_defaults,omitempty"`
+	CanonicalExpressions   *bool    `json:"canonical_expressions,omitempty"`
+	PreserveOrder          *bool    `json:"preserve_order,omitempty"`
+	Canonical              *bool    `json:"canonical,omitempty"`
+	Indent                 *string  `json:"indent,omitempty"`
+	DisableHTMLEscaping    *bool    `json:"disable_html_escaping,omitempty"`
+	CombinedOutput         *bool    `json:"combined_output,omitempty"`
+	CompactLines           *bool    `json:"compact_lines,omitempty"`
+	LineFormat             *string  `json:"line_format,omitempty"`
+	IncludeByteOffsets     *bool    `json:"include_byte_offsets,omitempty"`
+	LineKeyPrefix          *string  `json:"line_key_prefix,omitempty"`
+	AnnotateTypes          *bool    `json:"annotate_types,omitempty"`
+	AnnotateOrigins        *bool    `json:"annotate_origins,omitempty"`
+	OutputSchemaVersion    *int     `json:"output_schema_version,omitempty"`
+	ExpressionMode         *string  `json:"expression_mode,omitempty"`
+	PreserveHeredocs       *bool    `json:"preserve_heredocs,omitempty"`
+	StructuredFor          *bool    `json:"structured_for,omitempty"`
+	StructuredConditionals *bool    `json:"structured_conditionals,omitempty"`
+	StructuredFunctions    *bool    `json:"structured_functions,omitempty"`
+	Language               *string  `json:"language,omitempty"`
+	Redact                 []string `json:"redact,omitempty"`
+	Skeleton               *bool    `json:"skeleton,omitempty"`
+	InferTypes             *bool    `json:"infer_types,omitempty"`
+	IncludeTypes           *string  `json:"include_types,omitempty"`
+	AllowErrors            *bool    `json:"allow_errors,omitempty"`
+	InputSyntax            *string  `json:"input_syntax,omitempty"`
+	Provenance             *bool    `json:"provenance,omitempty"`
+}
+
+// apply returns the server's options with the request's overrides, or an
+// error naming the first override which isn't allowed.
+func (s *Server) apply(o RequestOptions) (convert.Options, error) {
+	options := s.config.Options
+	var err error
+	allow := func(name string, set bool) bool {
+		if !set || err != nil {
+			return false
+		}
+		if !s.overrides[name] {
+			err = fmt.Errorf("option %q may not be set per request", name)
+			return false
+		}
+		return true
+	}
+
+	if allow("dialect", o.Dialect != nil) {
+		options.Dialect = convert.Dialect(*o.Dialect)
+	}
+	if allow("simplify", o.Simplify != nil) {
+		options.Simplify = *o.Simplify
+	}
+	if allow("allow_functions", o.AllowFunctions != nil) {
+		options.AllowFunctions = append(append([]string{}, options.AllowFunctions...), o.AllowFunctions...)
+	}
+	if allow("inject_defaults", o.InjectDefaults != nil) {
+		options.InjectDefaults = *o.InjectDefaults
+	}
+	if allow("canonical_expressions", o.CanonicalExpressions != nil) {
+		options.CanonicalExpressions = *o.CanonicalExpressions
+	}
+	if allow("preserve_order", o.PreserveOrder != nil) {
+		options.PreserveOrder = *o.PreserveOrder
+	}
+	if allow("canonical", o.Canonical != nil) {
+		options.Canonical = *o.Canonical
+	}
+	if allow("indent", o.Indent != nil) {
+		options.Indent = *o.Indent
+	}
+	if allow("disable_html_escaping", o.DisableHTMLEscaping != nil) {
+		options.DisableHTMLEscaping = *o.DisableHTMLEscaping
+	}
+	if allow("combined_output", o.CombinedOutput != nil) {
+		options.CombinedOutput = *o.CombinedOutput
+	}
+	if allow("compact_lines", o.CompactLines != nil) {
+		options.CompactLines = *o.CompactLines
+	}
+	if allow("line_format", o.LineFormat != nil) {
+		options.LineFormat = convert.LineFormat(*o.LineFormat)
+	}
+	if allow("include_byte_offsets", o.IncludeByteOffsets != nil) {
+		options.IncludeByteOffsets = *o.IncludeByteOffsets
+	}
+	if allow("line_key_prefix", o.LineKeyPrefix != nil) {
+		options.LineKeyPrefix = *o.LineKeyPrefix
+	}
+	if allow("annotate_types", o.AnnotateTypes != nil) {
+		options.AnnotateTypes = *o.AnnotateTypes
+	}
+	if allow("annotate_origins", o.AnnotateOrigins != nil) {
+		options.AnnotateOrigins = *o.AnnotateOrigins
+	}
+	if allow("output_schema_version", o.OutputSchemaVersion != nil) {
+		options.OutputSchemaVersion = convert.OutputSchemaVersion(*o.OutputSchemaVersion)
+	}
+	if allow("expression_mode", o.ExpressionMode != nil) {
+		options.ExpressionMode = convert.ExpressionMode(*o.ExpressionMode)
+	}
+	if allow("preserve_heredocs", o.PreserveHeredocs != nil) {
+		options.PreserveHeredocs = *o.PreserveHeredocs
+	}
+	if allow("structured_for", o.StructuredFor != nil) {
+		options.StructuredFor = *o.StructuredFor
+	}
+	if allow("structured_conditionals", o.StructuredConditionals != nil) {
+		options.StructuredConditionals = *o.StructuredConditionals
+	}
+	if allow("structured_functions", o.StructuredFunctions != nil) {
+		options.StructuredFunctions = *o.StructuredFunctions
+	}
+	if allow("language", o.Language != nil) {
+		catalog, ok := s.config.Catalogs[*o.Language]
+		if !ok {
+			return options, fmt.Errorf("no message catalog for language %q", *o.Language)
+		}
+		options.Catalog = catalog
+	}
+	if allow("redact", o.Redact != nil) {
+		options.Redact = append(append([]string{}, options.Redact...), o.Redact...)
+	}
+	if allow("infer_types", o.InferTypes != nil) {
+		options.InferTypes = *o.InferTypes
+	}
+	if allow("include_types", o.IncludeTypes != nil) {
+		options.IncludeTypes = convert.TypeLayout(*o.IncludeTypes)
+	}
+	if allow("skeleton", o.Skeleton != nil) {
+		options.Skeleton = *o.Skeleton
+	}
+	if allow("allow_errors", o.AllowErrors != nil) {
+		options.AllowErrors = *o.AllowErrors
+	}
+	if allow("input_syntax", o.InputSyntax != nil) {
+		options.InputSyntax = convert.InputSyntax(*o.InputSyntax)
+	}
+	if allow("provenance", o.Provenance != nil) {
+		options.Provenance = *o.Provenance
+	}
+	return options, err
+}
+
+// Response is the body of a successful response from /convert.
+type Response struct {
+	JSON        json.RawMessage `json:"json"`
+	Lines       json.RawMessage `json:"lines"`
+	Diagnostics []Diagnostic    `json:"diagnostics"`
+
+	// Types holds the type of every attribute's expression, when the
+	// request sets infer_types.
+	Types []convert.ExpressionType `json:"types,omitempty"`
+
+	// TypeMap holds the type of every attribute's value, when the request
+	// sets include_types to map.
+	TypeMap json.RawMessage `json:"type_map,omitempty"`
+}
+
+// Diagnostic is a diagnostic raised while converting. ID and Params are
+// set for diagnostics rendered from the message catalog, and Category and
+// URL for those with a code.
+type Diagnostic struct {
+	Severity string            `json:"severity"`
+	ID       string            `json:"id,omitempty"`
+	Category convert.Category  `json:"category,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+	Summary  string            `json:"summary"`
+	Detail   string            `json:"detail,omitempty"`
+	Range    *hcl.Range        `json:"range,omitempty"`
+}
+
+func diagnostics(result *convert.Result) []Diagnostic {
+	list := make([]Diagnostic, len(result.Diagnostics))
+	for i, diag := range result.Diagnostics {
+		severity := "warning"
+		if diag.Severity == hcl.DiagError {
+			severity = "error"
+		}
+		m := result.Messages[diag]
+		code, _ := result.Code(diag)
+		list[i] = Diagnostic{
+			Severity: severity,
+			ID:       m.ID,
+			Category: code.Category,
+			URL:      code.URL,
+			Params:   m.Params,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+			Range:    diag.Subject,
+		}
+	}
+	return list
+}
+
+func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	release := s.admit(w, r)
+	if release == nil {
+		return
+	}
+	defer release()
+
+	body, err := s.readBody(r)
+	if err == errTooLarge {
+		atomic.AddInt64(&s.metrics.TooLarge, 1)
+		writeError(w, http.StatusRequestEntityTooLarge, &Error{Status: http.StatusRequestEntityTooLarge, Message: err.Error(), Code: "request-too-large"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, failed := s.convert(body)
+	if failed != nil {
+		writeError(w, failed.Status, failed)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// convert serves a request to /convert, given its body, returning the
+// error to respond with when it fails.
+func (s *Server) convert(body []byte) (*Response, *Error) {
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Message: fmt.Sprintf("decode request: %v", err)}
+	}
+	options, err := s.apply(req.Options)
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	result, err := convert.Convert([]byte(req.Source), req.Filename, options)
+	if err != nil {
+		s.notify(newEvent(req, nil, nil, err))
+		atomic.AddInt64(&s.metrics.Failed, 1)
+		failed := &Error{Status: http.StatusUnprocessableEntity, Message: err.Error()}
+		if code, ok := convert.ErrorCode(err); ok {
+			failed.Code = code.ID
+		}
+		return nil, failed
+	}
+	atomic.AddInt64(&s.metrics.Converted, 1)
+	event := newEvent(req, result.JSON, result.Diagnostics, nil)
+	event.ArtifactLocation = s.storeArtifact(event.JSONSHA256, result.JSON)
+	s.notify(event)
+	return &Response{
+		JSON:        result.JSON,
+		Lines:       result.Lines,
+		Diagnostics: diagnostics(result),
+		Types:       result.Types,
+		TypeMap:     result.TypeMap,
+	}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := map[string]string{"error": err.Error()}
+	if e, ok := err.(*Error); ok && e.Code != "" {
+		body["code"] = e.Code
+	}
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func post(t *testing.T, handler http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return rec
+}
+
+func TestConvertOverrides(t *testing.T) {
+	s := New(Config{
+		Options:   convert.Options{Dialect: convert.DialectTerraform},
+		Overrides: []string{"simplify", "redact"},
+	})
+
+	rec := post(t, s, "/convert", `{
+		"filename": "main.tf",
+		"source": "a = max(1, 2)\npassword = \"x\"",
+		"options": {"simplify": true, "redact": ["password"]}
+	}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal("unmarshal response:", err)
+	}
+	if string(resp.JSON) != `{"a":2,"password":"(redacted)"}` {
+		t.Errorf("unexpected json %s", resp.JSON)
+	}
+
+	rec = post(t, s, "/convert", `{"source": "a = 1", "options": {"dialect": "hcl"}}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `option \"dialect\" may not be set per request`) {
+		t.Errorf("expected the dialect override to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = post(t, s, "/convert", `{"source": "a = "}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a syntax error, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/convert", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestConvertLanguage(t *testing.T) {
+	s := New(Config{
+		Options:   convert.Options{Dialect: convert.DialectTerraform, Simplify: true},
+		Overrides: []string{"language"},
+		Catalogs: map[string]convert.Catalog{
+			"de": {"function-not-allowed": {Summary: "Funktion nicht erlaubt", Detail: "{function} ist nicht erlaubt."}},
+		},
+	})
+
+	rec := post(t, s, "/convert", `{"source": "a = file(\"x\")", "options": {"language": "de"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal("unmarshal response:", err)
+	}
+	if len(resp.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", resp.Diagnostics)
+	}
+	diag := resp.Diagnostics[0]
+	if diag.ID != "function-not-allowed" || diag.Summary != "Funktion nicht erlaubt" || diag.Detail != `"file" ist nicht erlaubt.` {
+		t.Errorf("unexpected diagnostic %+v", diag)
+	}
+
+	rec = post(t, s, "/convert", `{"source": "a = 1", "options": {"language": "fr"}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a language without a catalog, got %d: %s", rec.Code, rec.Body)
+	}
+}
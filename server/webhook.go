@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Event is posted as JSON to each of Config.Webhooks when a conversion
+// completes, whether or not it succeeded.
+type Event struct {
+	Filename string `json:"filename"`
+	SHA256   string `json:"sha256"`
+
+	// JSONSHA256 is the hash of the converted JSON, when conversion
+	// succeeded, and Error why it failed otherwise.
+	JSONSHA256 string `json:"json_sha256,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	// ArtifactLocation is where Config.Artifacts stored the converted JSON,
+	// when set and conversion succeeded.
+	ArtifactLocation string `json:"artifact_location,omitempty"`
+
+	Errors      int       `json:"errors"`
+	Warnings    int       `json:"warnings"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+const (
+	// DefaultWebhookTimeout is how long events are given to be sent when
+	// Config.WebhookClient is nil.
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// DefaultWebhookQueue is how many events may wait to be sent when
+	// Config.WebhookQueue is zero.
+	DefaultWebhookQueue = 1000
+
+	// webhookWorkers is how many events are sent at once.
+	webhookWorkers = 4
+)
+
+// ArtifactStore stores converted JSON, such as in object storage, so
+// webhook events can say where to fetch it.
+type ArtifactStore interface {
+	// Put stores the converted JSON under name, returning where it was
+	// stored, such as its URL.
+	Put(name string, data []byte) (location string, err error)
+}
+
+// DirStore is an ArtifactStore writing files to a directory, with their
+// path as their location.
+type DirStore string
+
+// Put writes data to the named file in the directory.
+func (d DirStore) Put(name string, data []byte) (string, error) {
+	path := filepath.Join(string(d), filepath.Base(name))
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write artifact: %w", err)
+	}
+	return path, nil
+}
+
+// storeArtifact stores the JSON of a conversion, named by its hash, in
+// Config.Artifacts, returning where it was stored, or "" when there's no
+// store or it failed.
+func (s *Server) storeArtifact(jsonSHA256 string, data []byte) string {
+	if s.config.Artifacts == nil {
+		return ""
+	}
+	location, err := s.config.Artifacts.Put(jsonSHA256+".json", data)
+	if err != nil {
+		atomic.AddInt64(&s.metrics.ArtifactFailures, 1)
+		return ""
+	}
+	return location
+}
+
+func newEvent(req Request, result []byte, diags hcl.Diagnostics, err error) Event {
+	event := Event{
+		Filename:    req.Filename,
+		SHA256:      sha256Hex([]byte(req.Source)),
+		CompletedAt: time.Now().UTC(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	} else {
+		event.JSONSHA256 = sha256Hex(result)
+	}
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagError {
+			event.Errors++
+		} else {
+			event.Warnings++
+		}
+	}
+	return event
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// delivery is an event waiting to be sent to a webhook.
+type delivery struct {
+	url  string
+	body []byte
+}
+
+// startWebhooks starts the workers sending queued events to webhooks.
+func (s *Server) startWebhooks() {
+	queue := s.config.WebhookQueue
+	if queue <= 0 {
+		queue = DefaultWebhookQueue
+	}
+	s.deliveries = make(chan delivery, queue)
+	client := s.config.WebhookClient
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for d := range s.deliveries {
+				if err := deliver(client, d.url, d.body); err != nil {
+					atomic.AddInt64(&s.metrics.WebhookFailures, 1)
+				}
+			}
+		}()
+	}
+}
+
+// notify queues the event for each webhook, counting failed deliveries,
+// and those dropped when the queue is full, in the server's metrics.
+func (s *Server) notify(event Event) {
+	if len(s.config.Webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		atomic.AddInt64(&s.metrics.WebhookFailures, int64(len(s.config.Webhooks)))
+		return
+	}
+	for _, url := range s.config.Webhooks {
+		select {
+		case s.deliveries <- delivery{url: url, body: body}:
+		default:
+			atomic.AddInt64(&s.metrics.WebhookFailures, 1)
+		}
+	}
+}
+
+func deliver(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post event: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post event: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhooks(t *testing.T) {
+	events := make(chan Event, 2)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error("decode event:", err)
+		}
+		events <- event
+	}))
+	defer hook.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	s := New(Config{Webhooks: []string{hook.URL, broken.URL}})
+	post(t, s, "/convert", `{"filename": "main.tf", "source": "a = 1"}`)
+	post(t, s, "/convert", `{"filename": "bad.tf", "source": "a = "}`)
+
+	received := map[string]Event{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			received[event.Filename] = event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for events")
+		}
+	}
+	if event := received["main.tf"]; event.JSONSHA256 != sha256Hex([]byte(`{"a":1}`)) || event.SHA256 != sha256Hex([]byte("a = 1")) || event.Error != "" {
+		t.Errorf("unexpected event for main.tf %+v", event)
+	}
+	if event := received["bad.tf"]; event.Error == "" || event.JSONSHA256 != "" {
+		t.Errorf("expected a failure event for bad.tf, got %+v", event)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt64(&s.metrics.WebhookFailures) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 webhook failures, got %d", s.Metrics().WebhookFailures)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookArtifacts(t *testing.T) {
+	events := make(chan Event, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error("decode event:", err)
+		}
+		events <- event
+	}))
+	defer hook.Close()
+
+	dir := t.TempDir()
+	s := New(Config{Webhooks: []string{hook.URL}, Artifacts: DirStore(dir)})
+	post(t, s, "/convert", `{"filename": "main.tf", "source": "a = 1"}`)
+
+	select {
+	case event := <-events:
+		want := filepath.Join(dir, sha256Hex([]byte(`{"a":1}`))+".json")
+		if event.ArtifactLocation != want {
+			t.Fatalf("expected the artifact at %s, got %+v", want, event)
+		}
+		if b, err := ioutil.ReadFile(want); err != nil || string(b) != `{"a":1}` {
+			t.Errorf("expected the converted JSON in the artifact, got %s: %v", b, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hook.Close()
+	defer close(release)
+
+	s := New(Config{Webhooks: []string{hook.URL}, WebhookQueue: 1})
+	for i := 0; i < webhookWorkers+3; i++ {
+		post(t, s, "/convert", `{"filename": "main.tf", "source": "a = 1"}`)
+	}
+	if failures := s.Metrics().WebhookFailures; failures == 0 {
+		t.Error("expected events over the queue limit to be dropped and counted")
+	}
+}
@@ -0,0 +1,94 @@
+// Package terraform adds Terraform specific behaviour on top of the convert
+// package, such as validating configuration against provider schemas.
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultRegistryHost is the registry providers come from when their source
+// doesn't name one.
+const DefaultRegistryHost = "registry.terraform.io"
+
+// Provider is an entry of a required_providers block.
+type Provider struct {
+	// Name is the local name the configuration uses for the provider.
+	Name string
+
+	// Source is the fully qualified source address of the provider, such
+	// as registry.terraform.io/hashicorp/aws.
+	Source string
+
+	// Version is the version constraint, or empty when there is none.
+	Version string
+}
+
+// RequiredProviders returns the providers listed in the required_providers
+// blocks of a file, sorted by name. Both the object form and the legacy
+// form giving only a version constraint are understood.
+func RequiredProviders(file *hcl.File) ([]Provider, error) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("convert file body to body type")
+	}
+
+	var providers []Provider
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		for _, nested := range block.Body.Blocks {
+			if nested.Type != "required_providers" {
+				continue
+			}
+			for name, attr := range nested.Body.Attributes {
+				provider, err := requiredProvider(name, attr)
+				if err != nil {
+					return nil, err
+				}
+				providers = append(providers, provider)
+			}
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+	return providers, nil
+}
+
+func requiredProvider(name string, attr *hclsyntax.Attribute) (Provider, error) {
+	provider := Provider{Name: name, Source: "hashicorp/" + name}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return provider, fmt.Errorf("evaluate required provider %s: %v", name, diags.Errs())
+	}
+
+	switch {
+	case value.Type() == cty.String:
+		provider.Version = value.AsString()
+	case value.Type().IsObjectType():
+		if value.Type().HasAttribute("source") {
+			if source := value.GetAttr("source"); source.Type() == cty.String {
+				provider.Source = source.AsString()
+			}
+		}
+		if value.Type().HasAttribute("version") {
+			if version := value.GetAttr("version"); version.Type() == cty.String {
+				provider.Version = version.AsString()
+			}
+		}
+	default:
+		return provider, fmt.Errorf("required provider %s must be a string or an object", name)
+	}
+
+	if strings.Count(provider.Source, "/") == 1 {
+		provider.Source = DefaultRegistryHost + "/" + provider.Source
+	}
+	return provider, nil
+}
@@ -0,0 +1,211 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ckndave/hclparser/convert"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ProviderSchema is the schema of a single provider, in the format written
+// by terraform providers schema -json.
+type ProviderSchema struct {
+	Provider          *ResourceSchema            `json:"provider,omitempty"`
+	ResourceSchemas   map[string]*ResourceSchema `json:"resource_schemas,omitempty"`
+	DataSourceSchemas map[string]*ResourceSchema `json:"data_source_schemas,omitempty"`
+}
+
+// ResourceSchema is the schema of a resource, data source or provider
+// configuration.
+type ResourceSchema struct {
+	Version int    `json:"version"`
+	Block   *Block `json:"block"`
+}
+
+// Block describes the contents of a block.
+type Block struct {
+	Attributes map[string]*Attribute   `json:"attributes,omitempty"`
+	BlockTypes map[string]*NestedBlock `json:"block_types,omitempty"`
+}
+
+// Attribute describes an attribute. Type is the JSON encoding of its cty
+// type.
+type Attribute struct {
+	Type       json.RawMessage `json:"type"`
+	Required   bool            `json:"required,omitempty"`
+	Optional   bool            `json:"optional,omitempty"`
+	Computed   bool            `json:"computed,omitempty"`
+	Deprecated bool            `json:"deprecated,omitempty"`
+}
+
+// NestedBlock describes a type of block nested within another.
+type NestedBlock struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       *Block `json:"block"`
+	MinItems    int    `json:"min_items,omitempty"`
+	MaxItems    int    `json:"max_items,omitempty"`
+}
+
+// Schema returns the schema of a Terraform file, describing the resources,
+// data sources and provider configurations of every provider in its
+// required_providers blocks with the schemas fetched from source.
+func Schema(file *hcl.File, source SchemaSource) (*convert.Schema, error) {
+	providers, err := RequiredProviders(file)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make(map[string]*convert.Schema)
+	dataSources := make(map[string]*convert.Schema)
+	configs := make(map[string]*convert.Schema)
+	for _, provider := range providers {
+		schema, err := source.ProviderSchema(provider)
+		if err != nil {
+			return nil, fmt.Errorf("fetch schema for %s: %w", provider.Source, err)
+		}
+		for name, resource := range schema.ResourceSchemas {
+			if resources[name], err = bodySchema(resource.Block, resourceMetaArguments); err != nil {
+				return nil, fmt.Errorf("resource %s: %w", name, err)
+			}
+		}
+		for name, dataSource := range schema.DataSourceSchemas {
+			if dataSources[name], err = bodySchema(dataSource.Block, dataMetaArguments); err != nil {
+				return nil, fmt.Errorf("data source %s: %w", name, err)
+			}
+		}
+		if schema.Provider != nil {
+			if configs[provider.Name], err = bodySchema(schema.Provider.Block, providerMetaArguments); err != nil {
+				return nil, fmt.Errorf("provider %s: %w", provider.Name, err)
+			}
+		}
+	}
+
+	return &convert.Schema{
+		Blocks: map[string]*convert.BlockSchema{
+			"terraform": {},
+			"variable":  {Labels: []string{"name"}},
+			"output":    {Labels: []string{"name"}},
+			"locals":    {},
+			"module":    {Labels: []string{"name"}},
+			"moved":     {},
+			"import":    {},
+			"removed":   {},
+			"check":     {Labels: []string{"name"}},
+			"provider":  {Labels: []string{"name"}, LabelBodies: configs},
+			"resource":  {Labels: []string{"type", "name"}, LabelBodies: resources},
+			"data":      {Labels: []string{"type", "name"}, LabelBodies: dataSources},
+		},
+	}, nil
+}
+
+// metaArguments are the attributes and blocks Terraform accepts in a body
+// on top of those from the provider schema.
+type metaArguments struct {
+	attributes []string
+	blocks     []string
+}
+
+var (
+	resourceMetaArguments = metaArguments{
+		attributes: []string{"count", "for_each", "provider", "depends_on"},
+		blocks:     []string{"lifecycle", "provisioner", "connection", "dynamic"},
+	}
+	dataMetaArguments = metaArguments{
+		attributes: []string{"count", "for_each", "provider", "depends_on"},
+		blocks:     []string{"lifecycle", "dynamic"},
+	}
+	providerMetaArguments = metaArguments{
+		attributes: []string{"alias", "version"},
+		blocks:     []string{"dynamic"},
+	}
+	nestedMetaArguments = metaArguments{
+		blocks: []string{"dynamic"},
+	}
+)
+
+func bodySchema(block *Block, meta metaArguments) (*convert.Schema, error) {
+	schema := &convert.Schema{
+		Attributes: make(map[string]*convert.AttributeSchema),
+		Blocks:     make(map[string]*convert.BlockSchema),
+	}
+	for _, name := range meta.attributes {
+		schema.Attributes[name] = &convert.AttributeSchema{}
+	}
+	for _, name := range meta.blocks {
+		schema.Blocks[name] = &convert.BlockSchema{}
+	}
+	if block == nil {
+		return schema, nil
+	}
+
+	for name, attr := range block.Attributes {
+		typ, err := ctyjson.UnmarshalType(attr.Type)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		schema.Attributes[name] = &convert.AttributeSchema{
+			Type:       typ,
+			Required:   attr.Required,
+			Deprecated: attr.Deprecated,
+		}
+	}
+	for name, nested := range block.BlockTypes {
+		body, err := bodySchema(nested.Block, nestedMetaArguments)
+		if err != nil {
+			return nil, fmt.Errorf("block %s: %w", name, err)
+		}
+		schema.Blocks[name] = &convert.BlockSchema{Body: body}
+	}
+	return schema, nil
+}
+
+// Convert converts a Terraform file like convert.Convert, validating it
+// against the schemas of its required providers unless options already has
+// a schema.
+func Convert(bytes []byte, filename string, source SchemaSource, options convert.Options) (*convert.Result, error) {
+	if options.Schema == nil {
+		file, diags := hclsyntax.ParseConfig(bytes, filename, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parse config: %v", diags.Errs())
+		}
+		schema, err := Schema(file, source)
+		if err != nil {
+			return nil, err
+		}
+		options.Schema = schema
+	}
+	if options.Dialect == convert.DialectHCL {
+		options.Dialect = convert.DialectTerraform
+	}
+	return convert.Convert(bytes, filename, options)
+}
+
+// Scaffold returns the source of a skeleton resource block of the given
+// type and name, or data block when data is set, using the schema of the
+// provider fetched from source. Meta-arguments such as count are left out.
+func Scaffold(provider Provider, source SchemaSource, typeName, name string, data bool) ([]byte, error) {
+	schema, err := source.ProviderSchema(provider)
+	if err != nil {
+		return nil, fmt.Errorf("fetch schema for %s: %w", provider.Source, err)
+	}
+	blockType, schemas := "resource", schema.ResourceSchemas
+	if data {
+		blockType, schemas = "data", schema.DataSourceSchemas
+	}
+	resource, ok := schemas[typeName]
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s type %s", provider.Source, blockType, typeName)
+	}
+	body, err := bodySchema(resource.Block, metaArguments{})
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", blockType, typeName, err)
+	}
+	return convert.Scaffold(&convert.Schema{
+		Blocks: map[string]*convert.BlockSchema{
+			blockType: {Labels: []string{"type", "name"}, LabelBodies: map[string]*convert.Schema{typeName: body}},
+		},
+	}, blockType, typeName, name)
+}
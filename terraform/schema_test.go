@@ -0,0 +1,305 @@
+package terraform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+const awsSchema = `{
+	"provider": {
+		"version": 0,
+		"block": {
+			"attributes": {
+				"region": {"type": "string", "optional": true}
+			}
+		}
+	},
+	"resource_schemas": {
+		"aws_instance": {
+			"version": 1,
+			"block": {
+				"attributes": {
+					"ami": {"type": "string", "required": true},
+					"tags": {"type": ["map", "string"], "optional": true}
+				},
+				"block_types": {
+					"ebs_block_device": {
+						"nesting_mode": "set",
+						"block": {
+							"attributes": {
+								"device_name": {"type": "string", "required": true}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+const input = `
+terraform {
+	required_providers {
+		aws = {
+			source  = "hashicorp/aws"
+			version = "3.74.0"
+		}
+	}
+}
+
+provider "aws" {
+	region = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+	ami   = "ami-123"
+	count = 2
+	tgas  = {}
+
+	ebs_block_device {
+		device_nmae = "sdb"
+	}
+}
+
+resource "random_id" "suffix" {
+	byte_length = 4
+}`
+
+func TestConvertWithDumpSource(t *testing.T) {
+	source, err := NewDumpSource(strings.NewReader(`{
+		"format_version": "0.2",
+		"provider_schemas": {"registry.terraform.io/hashicorp/aws": ` + awsSchema + `}
+	}`))
+	if err != nil {
+		t.Fatal("load dump:", err)
+	}
+
+	result, err := Convert([]byte(input), "main.tf", source, convert.Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	var details []string
+	for _, diag := range result.Diagnostics {
+		details = append(details, diag.Detail)
+	}
+	expected := []string{
+		`An argument named "tgas" is not expected here. Did you mean "tags"?`,
+		`An argument named "device_nmae" is not expected here. Did you mean "device_name"?`,
+		`The argument "device_name" is required, but no definition was found.`,
+	}
+	if strings.Join(details, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected diagnostics:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(details, "\n"))
+	}
+}
+
+func TestRegistrySourceDiskCache(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if r.URL.Path != "/registry.terraform.io/hashicorp/aws/3.74.0.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(awsSchema))
+	}))
+	defer server.Close()
+
+	cache := &DiskCache{Dir: t.TempDir(), Source: &RegistrySource{BaseURL: server.URL}}
+	provider := Provider{Name: "aws", Source: "registry.terraform.io/hashicorp/aws", Version: "= 3.74.0"}
+	for i := 0; i < 2; i++ {
+		schema, err := cache.ProviderSchema(provider)
+		if err != nil {
+			t.Fatal("fetch schema:", err)
+		}
+		if schema.ResourceSchemas["aws_instance"] == nil {
+			t.Error("expected the aws_instance schema")
+		}
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected the schema to be fetched once, got requests %q", requests)
+	}
+}
+
+func TestDiskCacheInvalidSource(t *testing.T) {
+	dir := t.TempDir()
+	cache := &DiskCache{Dir: filepath.Join(dir, "cache"), Source: &RegistrySource{BaseURL: "http://127.0.0.1:0"}}
+	for _, source := range []string{"../../etc/x", "hashicorp/../../x", "a/b/c/d", "/hashicorp/aws", "hashicorp/aws/", "..:1/hashicorp/aws"} {
+		if _, err := cache.ProviderSchema(Provider{Source: source}); err == nil || !strings.Contains(err.Error(), "invalid provider source") {
+			t.Errorf("source %q: got error %v, want an invalid provider source", source, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cache")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written, got %v", err)
+	}
+}
+
+func TestSchemaTerraformBlocks(t *testing.T) {
+	source, err := NewDumpSource(strings.NewReader(`{
+		"provider_schemas": {"registry.terraform.io/hashicorp/aws": ` + awsSchema + `}
+	}`))
+	if err != nil {
+		t.Fatal("load dump:", err)
+	}
+
+	result, err := Convert([]byte(`
+terraform {
+	required_providers {
+		aws = { source = "hashicorp/aws" }
+	}
+}
+
+import {
+	to = aws_instance.web
+	id = "i-123"
+}
+
+removed {
+	from = aws_instance.old
+	lifecycle {
+		destroy = false
+	}
+}
+
+check "health" {
+	assert {
+		condition     = true
+		error_message = "down"
+	}
+}`), "main.tf", source, convert.Options{})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", result.Diagnostics)
+	}
+}
+
+func TestRequiredProviders(t *testing.T) {
+	file := parse(t, `
+terraform {
+	required_providers {
+		aws    = { source = "hashicorp/aws" }
+		google = "~> 3.0"
+		corp   = { source = "example.com/corp/corp", version = "1.0.0" }
+	}
+}`)
+
+	providers, err := RequiredProviders(file)
+	if err != nil {
+		t.Fatal("required providers:", err)
+	}
+	expected := []Provider{
+		{Name: "aws", Source: "registry.terraform.io/hashicorp/aws"},
+		{Name: "corp", Source: "example.com/corp/corp", Version: "1.0.0"},
+		{Name: "google", Source: "registry.terraform.io/hashicorp/google", Version: "~> 3.0"},
+	}
+	if len(providers) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, providers)
+	}
+	for i := range expected {
+		if providers[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], providers[i])
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *hcl.File {
+	t.Helper()
+	file, diags := hclsyntax.ParseConfig([]byte(input), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatal("parse config:", diags)
+	}
+	return file
+}
+
+func TestConvertTypesAndDeprecations(t *testing.T) {
+	source, err := NewDumpSource(strings.NewReader(`{
+		"format_version": "0.2",
+		"provider_schemas": {"registry.terraform.io/hashicorp/aws": {
+			"resource_schemas": {"aws_instance": {"block": {"attributes": {
+				"ami": {"type": "string", "required": true},
+				"cpu_core_count": {"type": "number", "optional": true, "deprecated": true},
+				"tags": {"type": ["map", "string"], "optional": true}
+			}}}}
+		}}
+	}`))
+	if err != nil {
+		t.Fatal("load dump:", err)
+	}
+
+	result, err := Convert([]byte(`
+terraform {
+	required_providers {
+		aws = { source = "hashicorp/aws" }
+	}
+}
+
+resource "aws_instance" "web" {
+	ami            = ["ami-123"]
+	cpu_core_count = 2
+	tags           = { Name = "web" }
+}`), "main.tf", source, convert.Options{AnnotateTypes: true})
+	if err != nil {
+		t.Fatal("convert:", err)
+	}
+
+	var details []string
+	for _, diag := range result.Diagnostics {
+		details = append(details, diag.Summary+": "+diag.Detail)
+	}
+	expected := []string{
+		`Incorrect attribute value type: Inappropriate value for attribute "ami": string required.`,
+		`Deprecated argument: The argument "cpu_core_count" is deprecated and may be removed in a future version.`,
+	}
+	if strings.Join(details, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected diagnostics:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(details, "\n"))
+	}
+
+	for _, want := range []string{`"schemaType":"string"`, `"schemaType":"number"`, `"schemaType":["map","string"]`} {
+		if !strings.Contains(string(result.Lines), want) {
+			t.Errorf("expected %s in line info %s", want, result.Lines)
+		}
+	}
+}
+
+func TestScaffold(t *testing.T) {
+	source, err := NewDumpSource(strings.NewReader(`{
+		"provider_schemas": {"registry.terraform.io/hashicorp/aws": ` + awsSchema + `}
+	}`))
+	if err != nil {
+		t.Fatal("load dump:", err)
+	}
+	provider := Provider{Source: "registry.terraform.io/hashicorp/aws"}
+
+	src, err := Scaffold(provider, source, "aws_instance", "web", false)
+	if err != nil {
+		t.Fatal("scaffold:", err)
+	}
+	expected := `resource "aws_instance" "web" {
+  ami = ""
+
+  # Optional:
+  # tags = {}
+
+  # ebs_block_device {
+  #   device_name = ""
+  # }
+}
+`
+	if string(src) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, src)
+	}
+
+	if _, err := Scaffold(provider, source, "aws_instance", "web", true); err == nil {
+		t.Error("expected a missing data source type to fail")
+	}
+}
@@ -0,0 +1,172 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SchemaSource fetches the schema of a provider.
+type SchemaSource interface {
+	ProviderSchema(provider Provider) (*ProviderSchema, error)
+}
+
+// ErrSchemaNotFound is returned by a SchemaSource which has no schema for a
+// provider.
+var ErrSchemaNotFound = errors.New("provider schema not found")
+
+// DumpSource serves the provider schemas from the output of terraform
+// providers schema -json. The schemas are those of the installed versions,
+// so version constraints are ignored.
+type DumpSource struct {
+	schemas map[string]*ProviderSchema
+}
+
+// NewDumpSource reads a schema dump.
+func NewDumpSource(r io.Reader) (*DumpSource, error) {
+	var dump struct {
+		ProviderSchemas map[string]*ProviderSchema `json:"provider_schemas"`
+	}
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, fmt.Errorf("parse schema dump: %w", err)
+	}
+	return &DumpSource{schemas: dump.ProviderSchemas}, nil
+}
+
+// ProviderSchema returns the schema of the provider from the dump.
+func (s *DumpSource) ProviderSchema(provider Provider) (*ProviderSchema, error) {
+	schema, ok := s.schemas[provider.Source]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, provider.Source)
+	}
+	return schema, nil
+}
+
+// RegistrySource fetches provider schemas over HTTP from a registry or
+// mirror serving them as <BaseURL>/<source>/<version>.json, where version
+// is the exact version the constraint pins, or "latest" when it doesn't
+// pin one.
+type RegistrySource struct {
+	BaseURL string
+
+	// Client is used to make requests. When nil, http.DefaultClient is
+	// used.
+	Client *http.Client
+}
+
+// ProviderSchema fetches the schema of the provider.
+func (s *RegistrySource) ProviderSchema(provider Provider) (*ProviderSchema, error) {
+	if err := checkSource(provider.Source); err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := strings.TrimSuffix(s.BaseURL, "/") + "/" + provider.Source + "/" + pinnedVersion(provider.Version) + ".json"
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, url)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetch schema: %s returned %s", url, resp.Status)
+	}
+
+	var schema ProviderSchema
+	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return &schema, nil
+}
+
+var exactVersion = regexp.MustCompile(`^=?\s*v?(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)$`)
+
+// pinnedVersion returns the version a constraint pins, or "latest".
+func pinnedVersion(constraint string) string {
+	if match := exactVersion.FindStringSubmatch(strings.TrimSpace(constraint)); match != nil {
+		return match[1]
+	}
+	return "latest"
+}
+
+var (
+	sourceHost    = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.-]*(:[0-9]+)?$`)
+	sourceSegment = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z_-]*$`)
+)
+
+// checkSource checks that a provider source is [host/]namespace/type, as
+// sources from the configuration are used in URLs and cache paths.
+func checkSource(source string) error {
+	segments := strings.Split(source, "/")
+	if len(segments) == 3 {
+		if !sourceHost.MatchString(segments[0]) {
+			return fmt.Errorf("invalid provider source %q: invalid host", source)
+		}
+		segments = segments[1:]
+	}
+	if len(segments) != 2 {
+		return fmt.Errorf("invalid provider source %q: want [host/]namespace/type", source)
+	}
+	for _, segment := range segments {
+		if !sourceSegment.MatchString(segment) {
+			return fmt.Errorf("invalid provider source %q: invalid name %q", source, segment)
+		}
+	}
+	return nil
+}
+
+// DiskCache caches the schemas fetched from Source as files under Dir, so
+// each version of a provider is only fetched once. Schemas for
+// constraints which don't pin a version are cached as "latest" until the
+// cache is cleared.
+type DiskCache struct {
+	Dir    string
+	Source SchemaSource
+}
+
+// ProviderSchema returns the cached schema of the provider, fetching and
+// caching it first if needed.
+func (c *DiskCache) ProviderSchema(provider Provider) (*ProviderSchema, error) {
+	if err := checkSource(provider.Source); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(c.Dir, filepath.FromSlash(provider.Source), pinnedVersion(provider.Version)+".json")
+	if cached, err := ioutil.ReadFile(path); err == nil {
+		var schema ProviderSchema
+		if err := json.Unmarshal(cached, &schema); err != nil {
+			return nil, fmt.Errorf("parse cached schema %s: %w", path, err)
+		}
+		return &schema, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read cached schema: %w", err)
+	}
+
+	schema, err := c.Source.ProviderSchema(provider)
+	if err != nil {
+		return nil, err
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	if err := ioutil.WriteFile(path, schemaBytes, 0644); err != nil {
+		return nil, fmt.Errorf("write cached schema: %w", err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,250 @@
+// Package workspace keeps an index of the HCL and Terraform files under a
+// directory, with their symbols, references and conversions, which
+// `hclparser daemon` keeps up to date and serves over a local socket so
+// tools can share one warm index instead of each parsing the files again.
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ckndave/hclparser/convert"
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Document is the indexed form of a file in the workspace. When the file
+// couldn't be parsed or converted, Error is set and the rest is empty.
+type Document struct {
+	// Path is the file's path relative to the workspace, with forward
+	// slashes, as used in the ranges of its symbols and references.
+	Path       string              `json:"path"`
+	Symbols    []convert.Symbol    `json:"symbols,omitempty"`
+	References []convert.Reference `json:"references,omitempty"`
+	JSON       json.RawMessage     `json:"json,omitempty"`
+	Lines      json.RawMessage     `json:"lines,omitempty"`
+	Error      string              `json:"error,omitempty"`
+
+	modTime time.Time
+	size    int64
+
+	// positions holds the line info flattened, for searching values.
+	positions map[string]convert.FlatLine
+}
+
+// Index holds a Document for each file in a workspace. It's safe for
+// concurrent use, with queries answered while it's refreshed.
+type Index struct {
+	root    string
+	options convert.Options
+
+	mu   sync.RWMutex
+	docs map[string]*Document
+}
+
+// New returns an empty index of the workspace at root, converting files
+// with the given options. Output is always JSON, and line info always
+// nested, with byte offsets and no key prefix, so it can be searched. Call
+// Refresh or Watch to fill the index.
+func New(root string, options convert.Options) *Index {
+	options.CompactLines = false
+	options.LineFormat = convert.LineFormatNested
+	options.IncludeByteOffsets = true
+	options.LineKeyPrefix = ""
+	options.Encoder = nil
+	return &Index{root: root, options: options, docs: make(map[string]*Document)}
+}
+
+// Refresh brings the index up to date with the workspace, indexing files
+// which are new or whose size or modification time changed, and dropping
+// those which were removed. It returns the paths of the documents which
+// changed, in order.
+func (ix *Index) Refresh() ([]string, error) {
+	seen := make(map[string]bool)
+	var updated []*Document
+	err := filepath.Walk(ix.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != ix.root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".hcl" && ext != ".tf" {
+			return nil
+		}
+		rel, err := filepath.Rel(ix.root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+
+		ix.mu.RLock()
+		doc, ok := ix.docs[rel]
+		ix.mu.RUnlock()
+		if ok && doc.modTime.Equal(info.ModTime()) && doc.size == info.Size() {
+			return nil
+		}
+		updated = append(updated, ix.index(path, rel, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk workspace: %w", err)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	var changed []string
+	for _, doc := range updated {
+		ix.docs[doc.Path] = doc
+		changed = append(changed, doc.Path)
+	}
+	for path := range ix.docs {
+		if !seen[path] {
+			delete(ix.docs, path)
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// index reads and indexes a single file.
+func (ix *Index) index(path, rel string, info os.FileInfo) *Document {
+	doc := &Document{Path: rel, modTime: info.ModTime(), size: info.Size()}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+
+	result, err := convert.Convert(src, rel, ix.options)
+	if err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	flat, err := convert.FlattenLines(result.Lines)
+	if err == nil {
+		err = json.Unmarshal(flat, &doc.positions)
+	}
+	if err != nil {
+		doc.Error = fmt.Sprintf("flatten line info: %v", err)
+		return doc
+	}
+	doc.JSON, doc.Lines = result.JSON, result.Lines
+
+	file, diags := hclsyntax.ParseConfig(src, rel, hcl.InitialPos)
+	if diags.HasErrors() {
+		doc.Error = diags.Error()
+		return doc
+	}
+	if doc.Symbols, err = convert.Symbols(file); err != nil {
+		doc.Error = err.Error()
+		return doc
+	}
+	if doc.References, err = convert.References(file); err != nil {
+		doc.Error = err.Error()
+	}
+	return doc
+}
+
+// Watch refreshes the index every interval until ctx is done, calling
+// changed, when it's not nil, with the paths of the documents which changed
+// by each refresh. It returns the first error refreshing the index.
+func (ix *Index) Watch(ctx context.Context, interval time.Duration, changed func(paths []string)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		paths, err := ix.Refresh()
+		if err != nil {
+			return err
+		}
+		if changed != nil && len(paths) > 0 {
+			changed(paths)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Paths returns the paths of the indexed documents, in order.
+func (ix *Index) Paths() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	paths := make([]string, 0, len(ix.docs))
+	for path := range ix.docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Document returns the document for the file at path, relative to the
+// workspace, reporting whether it's indexed.
+func (ix *Index) Document(path string) (*Document, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	doc, ok := ix.docs[path]
+	return doc, ok
+}
+
+// Symbols returns the symbols declared in the workspace whose address is
+// address or starts with it followed by a dot, such as every resource of a
+// type, or every symbol when address is empty. They're ordered by file and
+// then position.
+func (ix *Index) Symbols(address string) []convert.Symbol {
+	var symbols []convert.Symbol
+	for _, doc := range ix.documents() {
+		for _, symbol := range doc.Symbols {
+			if matchAddress(symbol.Address, address) {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// References returns the references in the workspace to the object at
+// address, or to any object under it as for Symbols, ordered by file and
+// then position.
+func (ix *Index) References(address string) []convert.Reference {
+	var refs []convert.Reference
+	for _, doc := range ix.documents() {
+		for _, ref := range doc.References {
+			if ref.Address != "" && matchAddress(ref.Address, address) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// documents returns the indexed documents ordered by path.
+func (ix *Index) documents() []*Document {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	docs := make([]*Document, 0, len(ix.docs))
+	for _, doc := range ix.docs {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Path < docs[j].Path })
+	return docs
+}
+
+func matchAddress(addr, query string) bool {
+	return query == "" || addr == query || strings.HasPrefix(addr, query+".")
+}
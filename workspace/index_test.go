@@ -0,0 +1,126 @@
+package workspace
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal("mkdir:", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal("write file:", err)
+	}
+}
+
+func addresses(symbols []convert.Symbol) []string {
+	var addrs []string
+	for _, symbol := range symbols {
+		addrs = append(addrs, symbol.Address)
+	}
+	return addrs
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "variables.tf"), "variable \"region\" {}\nlocals {\n  name = \"web\"\n}\n")
+	writeFile(t, filepath.Join(dir, "modules", "main.tf"), "resource \"aws_instance\" \"web\" {\n  tags = { Name = local.name }\n  az = var.region\n}\n")
+	writeFile(t, filepath.Join(dir, ".terraform", "cached.tf"), "variable \"ignored\" {}\n")
+
+	ix := New(dir, convert.Options{})
+	changed, err := ix.Refresh()
+	if err != nil {
+		t.Fatal("refresh:", err)
+	}
+	if want := []string{"modules/main.tf", "variables.tf"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("expected %v to change, got %v", want, changed)
+	}
+
+	if got, want := addresses(ix.Symbols("")), []string{"aws_instance.web", "var.region", "local.name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected symbols %v, got %v", want, got)
+	}
+	if got := addresses(ix.Symbols("aws_instance")); !reflect.DeepEqual(got, []string{"aws_instance.web"}) {
+		t.Errorf("unexpected symbols of type aws_instance: %v", got)
+	}
+	refs := ix.References("var.region")
+	if len(refs) != 1 || refs[0].Range.Filename != "modules/main.tf" || refs[0].Range.Start.Line != 3 {
+		t.Errorf("unexpected references %+v", refs)
+	}
+	if doc, ok := ix.Document("variables.tf"); !ok || string(doc.JSON) != `{"locals":[{"name":"web"}],"variable":[{"region":{}}]}` {
+		t.Errorf("unexpected document %+v", doc)
+	}
+
+	if changed, _ := ix.Refresh(); len(changed) != 0 {
+		t.Errorf("expected nothing to change, got %v", changed)
+	}
+	writeFile(t, filepath.Join(dir, "variables.tf"), "variable \"zone\" {}\n")
+	os.Remove(filepath.Join(dir, "modules", "main.tf"))
+	changed, err = ix.Refresh()
+	if err != nil {
+		t.Fatal("refresh:", err)
+	}
+	if want := []string{"modules/main.tf", "variables.tf"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("expected %v to change, got %v", want, changed)
+	}
+	if got := addresses(ix.Symbols("")); !reflect.DeepEqual(got, []string{"var.zone"}) {
+		t.Errorf("unexpected symbols after refreshing: %v", got)
+	}
+}
+
+func TestSocket(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), "variable \"region\" {}\noutput \"region\" {\n  value = var.region\n}\n")
+	ix := New(dir, convert.Options{})
+	if _, err := ix.Refresh(); err != nil {
+		t.Fatal("refresh:", err)
+	}
+
+	socket := filepath.Join(dir, "daemon.sock")
+	listener, err := Listen(socket)
+	if err != nil {
+		t.Fatal("listen:", err)
+	}
+	go http.Serve(listener, Handler(ix))
+	defer listener.Close()
+	if _, err := Listen(socket); err == nil {
+		t.Error("expected listening twice to fail")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := Dial(socket)
+	symbols, err := client.Symbols(ctx, "var")
+	if err != nil {
+		t.Fatal("symbols:", err)
+	}
+	if got := addresses(symbols); !reflect.DeepEqual(got, []string{"var.region"}) {
+		t.Errorf("unexpected symbols %v", got)
+	}
+	refs, err := client.References(ctx, "var.region")
+	if err != nil || len(refs) != 1 || refs[0].Attribute != "output.region.value" {
+		t.Errorf("unexpected references %+v: %v", refs, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "extra.hcl"), "a = 1\n")
+	changed, err := client.Refresh(ctx)
+	if err != nil || !reflect.DeepEqual(changed, []string{"extra.hcl"}) {
+		t.Errorf("unexpected refresh %v: %v", changed, err)
+	}
+	doc, err := client.Document(ctx, "extra.hcl")
+	if err != nil || string(doc.JSON) != `{"a":1}` {
+		t.Errorf("unexpected document %+v: %v", doc, err)
+	}
+	if _, err := client.Document(ctx, "missing.tf"); err == nil {
+		t.Error("expected an error for a document which isn't indexed")
+	}
+}
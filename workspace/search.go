@@ -0,0 +1,171 @@
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// Query selects what to search the index for. Each field set adds its
+// matches to the results, and at least one must be set.
+type Query struct {
+	// Type finds resources of a type, such as aws_instance, or data
+	// sources, given as data.aws_ami.
+	Type string `json:"type,omitempty"`
+
+	// Reference finds references whose name or address matches a pattern
+	// in the syntax of path.Match, such as var.db_*.
+	Reference string `json:"reference,omitempty"`
+
+	// Value finds literal values, after conversion, matching a regular
+	// expression.
+	Value string `json:"value,omitempty"`
+}
+
+// Match is something found by a search. Path is the address of a resource,
+// the dot-separated path of the attribute making a reference, or the JSON
+// pointer of a value in the document's output, and Value is the name of
+// the reference or the value found.
+type Match struct {
+	Kind  string    `json:"kind"`
+	Path  string    `json:"path"`
+	Value string    `json:"value,omitempty"`
+	Range hcl.Range `json:"range"`
+}
+
+// Search returns what the query finds in the index: resources, then
+// references, then values, each ordered by file and position.
+func (ix *Index) Search(q Query) ([]Match, error) {
+	if q.Type == "" && q.Reference == "" && q.Value == "" {
+		return nil, errors.New("empty query")
+	}
+	if _, err := path.Match(q.Reference, ""); err != nil {
+		return nil, fmt.Errorf("reference pattern: %w", err)
+	}
+	var value *regexp.Regexp
+	if q.Value != "" {
+		var err error
+		if value, err = regexp.Compile(q.Value); err != nil {
+			return nil, fmt.Errorf("value pattern: %w", err)
+		}
+	}
+
+	var matches []Match
+	docs := ix.documents()
+	if q.Type != "" {
+		for _, doc := range docs {
+			for _, symbol := range doc.Symbols {
+				if (symbol.Kind == "resource" || symbol.Kind == "data source") && strings.HasPrefix(symbol.Address, q.Type+".") {
+					matches = append(matches, Match{Kind: "resource", Path: symbol.Address, Range: symbol.Range})
+				}
+			}
+		}
+	}
+	if q.Reference != "" {
+		for _, doc := range docs {
+			for _, ref := range doc.References {
+				if globMatch(q.Reference, ref.Name) || globMatch(q.Reference, ref.Address) {
+					matches = append(matches, Match{Kind: "reference", Path: ref.Attribute, Value: ref.Name, Range: ref.Range})
+				}
+			}
+		}
+	}
+	if value != nil {
+		for _, doc := range docs {
+			found, err := doc.searchValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", doc.Path, err)
+			}
+			matches = append(matches, found...)
+		}
+	}
+	return matches, nil
+}
+
+func globMatch(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return name != "" && ok
+}
+
+// searchValues returns the scalar values in the document's output matching
+// re, ordered by position.
+func (doc *Document) searchValues(re *regexp.Regexp) ([]Match, error) {
+	if doc.JSON == nil {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(doc.JSON))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	var matches []Match
+	var walk func(pointer string, value interface{})
+	walk = func(pointer string, value interface{}) {
+		var text string
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, elem := range v {
+				walk(pointer+"/"+strings.NewReplacer("~", "~0", "/", "~1").Replace(key), elem)
+			}
+			return
+		case []interface{}:
+			for i, elem := range v {
+				walk(pointer+"/"+strconv.Itoa(i), elem)
+			}
+			return
+		case string:
+			text = v
+		case json.Number:
+			text = v.String()
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			return
+		}
+		if !re.MatchString(text) {
+			return
+		}
+		matches = append(matches, Match{Kind: "value", Path: pointer, Value: text, Range: doc.rangeOf(pointer)})
+	}
+	walk("", out)
+
+	sortMatches(matches)
+	return matches, nil
+}
+
+// rangeOf returns the source range of the value at pointer in the output.
+func (doc *Document) rangeOf(pointer string) hcl.Range {
+	pos := doc.positions[pointer]
+	rng := hcl.Range{
+		Filename: doc.Path,
+		Start:    hcl.Pos{Line: pos.Line, Column: pos.StartCol},
+		End:      hcl.Pos{Line: pos.EndLine, Column: pos.EndCol},
+	}
+	if pos.StartByte != nil && pos.EndByte != nil {
+		rng.Start.Byte, rng.End.Byte = *pos.StartByte, *pos.EndByte
+	}
+	return rng
+}
+
+func sortMatches(matches []Match) {
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i].Range.Start, matches[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return matches[i].Path < matches[j].Path
+	})
+}
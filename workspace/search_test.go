@@ -0,0 +1,67 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `resource "aws_instance" "web" {
+  ami  = "ami-123"
+  name = var.db_name
+}
+data "aws_ami" "ubuntu" {
+  owners = ["ami-owner"]
+}
+output "host" {
+  value = var.db_host
+}
+`)
+	writeFile(t, filepath.Join(dir, "other.tf"), "resource \"aws_instance\" \"api\" {\n  region = var.region\n}\n")
+	ix := New(dir, convert.Options{})
+	if _, err := ix.Refresh(); err != nil {
+		t.Fatal("refresh:", err)
+	}
+
+	matches, err := ix.Search(Query{Type: "aws_instance"})
+	if err != nil {
+		t.Fatal("search:", err)
+	}
+	if len(matches) != 2 || matches[0].Path != "aws_instance.web" || matches[1].Path != "aws_instance.api" {
+		t.Errorf("unexpected resources %+v", matches)
+	}
+	if matches, _ := ix.Search(Query{Type: "data.aws_ami"}); len(matches) != 1 || matches[0].Path != "data.aws_ami.ubuntu" {
+		t.Errorf("unexpected data sources %+v", matches)
+	}
+
+	matches, err = ix.Search(Query{Reference: "var.db_*"})
+	if err != nil {
+		t.Fatal("search:", err)
+	}
+	if len(matches) != 2 || matches[0].Value != "var.db_name" || matches[1].Path != "output.host.value" {
+		t.Errorf("unexpected references %+v", matches)
+	}
+
+	matches, err = ix.Search(Query{Value: "^ami-"})
+	if err != nil {
+		t.Fatal("search:", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 values, got %+v", matches)
+	}
+	if m := matches[0]; m.Path != "/resource/0/aws_instance/web/ami" || m.Range.String() != "main.tf:2,10-19" || m.Range.Start.Byte != 41 {
+		t.Errorf("unexpected match %+v", m)
+	}
+	if m := matches[1]; m.Path != "/data/0/aws_ami/ubuntu/owners/0" || m.Range.Start.Line != 6 {
+		t.Errorf("unexpected match %+v", m)
+	}
+
+	for _, q := range []Query{{}, {Value: "("}, {Reference: "["}} {
+		if _, err := ix.Search(q); err == nil {
+			t.Errorf("expected %+v to fail", q)
+		}
+	}
+}
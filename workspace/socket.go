@@ -0,0 +1,169 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/ckndave/hclparser/convert"
+)
+
+// Handler serves queries of the index as JSON:
+//
+//	GET /documents                        the paths of the indexed documents
+//	GET /document?path=main.tf            a document
+//	GET /symbols?address=var              symbols, as for Index.Symbols
+//	GET /references?address=var.x         references, as for Index.References
+//	GET /search?type=&reference=&value=   matches, as for Index.Search
+//	POST /refresh                         refresh the index now
+func Handler(ix *Index) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/documents", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, ix.Paths())
+	})
+	mux.HandleFunc("/document", func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := ix.Document(r.URL.Query().Get("path"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "document not indexed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, doc)
+	})
+	mux.HandleFunc("/symbols", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, ix.Symbols(r.URL.Query().Get("address")))
+	})
+	mux.HandleFunc("/references", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, ix.References(r.URL.Query().Get("address")))
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		matches, err := ix.Search(Query{Type: query.Get("type"), Reference: query.Get("reference"), Value: query.Get("value")})
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, matches)
+	})
+	mux.HandleFunc("/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		changed, err := ix.Refresh()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, changed)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Listen listens on a unix socket at path, removing a socket left behind by
+// a daemon which is no longer running. It fails if a daemon is listening.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("remove stale socket: %w", err)
+	}
+	return net.Listen("unix", path)
+}
+
+// Client queries a daemon's index over its socket.
+type Client struct {
+	http *http.Client
+}
+
+// Dial returns a client of the daemon listening on the unix socket at path.
+// Connections are made as queries are sent.
+func Dial(path string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{http: &http.Client{Transport: transport}}
+}
+
+// Paths returns the paths of the indexed documents.
+func (c *Client) Paths(ctx context.Context) ([]string, error) {
+	var paths []string
+	return paths, c.do(ctx, http.MethodGet, "/documents", nil, &paths)
+}
+
+// Document returns the document for the file at path.
+func (c *Client) Document(ctx context.Context, path string) (*Document, error) {
+	var doc Document
+	if err := c.do(ctx, http.MethodGet, "/document", url.Values{"path": {path}}, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Symbols returns the symbols at or under address, as for Index.Symbols.
+func (c *Client) Symbols(ctx context.Context, address string) ([]convert.Symbol, error) {
+	var symbols []convert.Symbol
+	return symbols, c.do(ctx, http.MethodGet, "/symbols", url.Values{"address": {address}}, &symbols)
+}
+
+// References returns the references to address, as for Index.References.
+func (c *Client) References(ctx context.Context, address string) ([]convert.Reference, error) {
+	var refs []convert.Reference
+	return refs, c.do(ctx, http.MethodGet, "/references", url.Values{"address": {address}}, &refs)
+}
+
+// Search returns what the query finds, as for Index.Search.
+func (c *Client) Search(ctx context.Context, q Query) ([]Match, error) {
+	var matches []Match
+	query := url.Values{"type": {q.Type}, "reference": {q.Reference}, "value": {q.Value}}
+	return matches, c.do(ctx, http.MethodGet, "/search", query, &matches)
+}
+
+// Refresh asks the daemon to refresh its index now, returning the paths
+// of the documents which changed.
+func (c *Client) Refresh(ctx context.Context) ([]string, error) {
+	var changed []string
+	return changed, c.do(ctx, http.MethodPost, "/refresh", nil, &changed)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, v interface{}) error {
+	u := "http://daemon" + path
+	if query != nil {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("query daemon: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var failed struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failed)
+		return fmt.Errorf("query daemon: %s: %s", resp.Status, failed.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}